@@ -0,0 +1,66 @@
+// Command admin promotes an existing user to admin, the first admin can not
+// be created through the api.
+//
+//	go run ./cmd/admin -email admin@greenbasket.com
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func main() {
+	email := flag.String("email", "", "email of the user to promote")
+	demote := flag.Bool("demote", false, "turn the admin back into a user")
+	flag.Parse()
+
+	if *email == "" {
+		log.Fatal("-email is required")
+	}
+
+	cnf := config.Get()
+	client := db.DBInstance(cnf)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	userRepo := repository.NewUserRepository(client)
+	user, err := userRepo.FindUserByEmail(ctx, *email)
+	if err != nil {
+		log.Fatal("failed to find user: ", err)
+	}
+
+	role, action := domain.RoleAdmin, domain.AuditPromoteAdmin
+	if *demote {
+		role, action = domain.RoleUser, domain.AuditDemoteAdmin
+	}
+
+	_, err = userRepo.UpdateUser(ctx, user.Email, bson.D{{Key: "role", Value: role}, {Key: "updated_at", Value: time.Now()}})
+	if err != nil {
+		log.Fatal("failed to update role: ", err)
+	}
+
+	_, err = repository.NewAuditLogRepository(client).Insert(ctx, domain.AuditLog{
+		ID:          primitive.NewObjectID(),
+		Admin_Email: "console",
+		Action:      action,
+		Target_Type: domain.AuditTargetUser,
+		Target_Id:   user.User_Id,
+		Reason:      "role set to " + role + " from the console",
+		Created_At:  time.Now(),
+	})
+	if err != nil {
+		log.Fatal("failed to write audit log: ", err)
+	}
+
+	log.Println(user.Email, "is now", role+", the role applies from the next token refresh")
+}
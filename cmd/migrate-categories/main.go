@@ -0,0 +1,104 @@
+// Command migrate-categories seeds the Categories collection with the
+// categories that used to be hardcoded, and rewrites the category of every
+// product from its display name to its slug. It can be run more than once.
+//
+//	go run ./cmd/migrate-categories
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// defaultCategories are the flat categories products were created with so far.
+var defaultCategories = []map[string]string{
+	{"en": "Vegetables", "id": "Sayuran"},
+	{"en": "Fruits", "id": "Buah"},
+	{"en": "Protein", "id": "Protein"},
+	{"en": "Ready to Eat", "id": "Siap Saji"},
+	{"en": "Staples", "id": "Bahan Pokok"},
+	{"en": "Snacks", "id": "Camilan"},
+	{"en": "Mother & Baby", "id": "Ibu & Bayi"},
+	{"en": "Spices", "id": "Bumbu"},
+	{"en": "Milk & Dairy", "id": "Susu & Olahan"},
+	{"en": "Breakfast", "id": "Sarapan"},
+}
+
+func main() {
+	cnf := config.Get()
+	client := db.DBInstance(cnf)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	categoryRepo := repository.NewCategoryRepository(client)
+	known := map[string]bool{}
+
+	for i, names := range defaultCategories {
+		slug := util.Slugify(names[domain.DefaultLocale])
+		known[slug] = true
+
+		if existing, _ := categoryRepo.FindBySlug(ctx, slug); existing != nil {
+			continue
+		}
+
+		_, err := categoryRepo.Insert(ctx, domain.Category{
+			ID:         primitive.NewObjectID(),
+			Slug:       slug,
+			Names:      names,
+			Sort_Order: i,
+			Created_At: time.Now(),
+			Updated_At: time.Now(),
+		})
+		if err != nil {
+			log.Fatal("failed to create category ", slug, ": ", err)
+		}
+		log.Println("created category", slug)
+	}
+
+	categories, err := categoryRepo.FindAll(ctx)
+	if err != nil {
+		log.Fatal("failed to get categories: ", err)
+	}
+	for _, category := range *categories {
+		known[category.Slug] = true
+	}
+
+	products := db.OpenCollection(client, "Products")
+	values, err := products.Distinct(ctx, "category", bson.M{})
+	if err != nil {
+		log.Fatal("failed to get product categories: ", err)
+	}
+
+	for _, value := range values {
+		name, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		slug := util.Slugify(name)
+		if !known[slug] {
+			log.Println("skipped products of unknown category", name)
+			continue
+		}
+
+		if slug == name {
+			continue
+		}
+
+		result, err := products.UpdateMany(ctx, bson.M{"category": name}, bson.D{{Key: "$set", Value: bson.D{{Key: "category", Value: slug}}}})
+		if err != nil {
+			log.Fatal("failed to migrate products of ", name, ": ", err)
+		}
+		log.Println("moved", result.ModifiedCount, "products from", name, "to", slug)
+	}
+}
@@ -0,0 +1,51 @@
+// Command reconcile-stock sums the StockMovements ledger of every product and
+// reports the products whose stock differs from it. Products created before
+// the ledger have no movements, -opening records their current stock as the
+// opening balance. -fix sets the stock of the products that differ to the ledger.
+//
+//	go run ./cmd/reconcile-stock -opening
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+)
+
+func main() {
+	opening := flag.Bool("opening", false, "record the stock of products without movements as their opening balance")
+	fix := flag.Bool("fix", false, "set the stock of products that differ to their ledger")
+	flag.Parse()
+
+	cnf := config.Get()
+	client := db.DBInstance(cnf)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	productRepo := repository.NewProductRepository(client)
+	ledgerSvc := service.NewStockLedgerService(repository.NewStockMovementRepository(client), productRepo,
+		repository.NewStoreRepository(client), repository.NewTransactor(client, cnf.MongoDB.TxMode))
+
+	drifts, err := ledgerSvc.Reconcile(ctx, *opening, *fix)
+	if err != nil {
+		log.Fatal("failed to reconcile stock: ", err)
+	}
+
+	for _, drift := range *drifts {
+		log.Printf("store %s product %s variant %q: stock %g, ledger %g", drift.Store_Id, drift.Product_Id,
+			drift.Variant_Id, drift.Stock, drift.Ledger)
+	}
+
+	if *fix {
+		log.Println("fixed", len(*drifts), "stocks")
+	} else {
+		log.Println(len(*drifts), "stocks differ from the ledger")
+	}
+}
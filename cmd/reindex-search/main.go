@@ -0,0 +1,40 @@
+// Command reindex-search builds the MongoDB search index of the products from
+// scratch. Run it once before using SEARCH_ENGINE=mongo and after changing
+// products outside of the API, like with migrate-categories.
+//
+//	go run ./cmd/reindex-search
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+)
+
+func main() {
+	cnf := config.Get()
+	client := db.DBInstance(cnf)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	searchIndex, err := repository.NewMongoSearchIndex(ctx, client)
+	if err != nil {
+		log.Fatal("failed to setup search index: ", err)
+	}
+
+	searchSvc := service.NewProductSearchService(searchIndex, repository.NewProductRepository(client),
+		repository.NewStoreRepository(client))
+
+	indexed, err := searchSvc.Reindex(ctx)
+	if err != nil {
+		log.Fatal("failed to reindex products: ", err)
+	}
+
+	log.Println("indexed", indexed, "products")
+}
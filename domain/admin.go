@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Audit log actions
+const (
+	AuditSuspendUser      = "SUSPEND_USER"
+	AuditUnsuspendUser    = "UNSUSPEND_USER"
+	AuditSuspendSeller    = "SUSPEND_SELLER"
+	AuditUnsuspendSeller  = "UNSUSPEND_SELLER"
+	AuditSuspendStore     = "SUSPEND_STORE"
+	AuditUnsuspendStore   = "UNSUSPEND_STORE"
+	AuditUnpublishProduct = "UNPUBLISH_PRODUCT"
+	AuditRepublishProduct = "REPUBLISH_PRODUCT"
+	AuditRemoveReview     = "REMOVE_REVIEW"
+	AuditPromoteAdmin     = "PROMOTE_ADMIN"
+	AuditDemoteAdmin      = "DEMOTE_ADMIN"
+	AuditCreateCategory   = "CREATE_CATEGORY"
+	AuditUpdateCategory   = "UPDATE_CATEGORY"
+	AuditDeleteCategory   = "DELETE_CATEGORY"
+)
+
+// Audit log target types
+const (
+	AuditTargetUser     = "USER"
+	AuditTargetSeller   = "SELLER"
+	AuditTargetStore    = "STORE"
+	AuditTargetProduct  = "PRODUCT"
+	AuditTargetReview   = "REVIEW"
+	AuditTargetCategory = "CATEGORY"
+)
+
+// AdminPages is the paging of the users, sellers, stores and audit logs
+// served to admins.
+var AdminPages = pagination.Spec{
+	Keys:        map[string]string{"created_at": "created_at"},
+	Default:     "-created_at",
+	Tiebreaker:  "_id",
+	Types:       map[string]bsontype.Type{"created_at": bsontype.DateTime, "_id": bsontype.ObjectID},
+	DefaultSize: 20,
+	MaxSize:     100,
+}
+
+// OrderPages is the paging of the orders served to admins and to their
+// buyers.
+var OrderPages = pagination.Spec{
+	Keys:        map[string]string{"order_date": "order_date", "total_price": "total_price"},
+	Default:     "-order_date",
+	Tiebreaker:  "_id",
+	Types:       map[string]bsontype.Type{"order_date": bsontype.DateTime, "total_price": bsontype.Double, "_id": bsontype.ObjectID},
+	DefaultSize: 20,
+	MaxSize:     100,
+}
+
+// AuditLog records one moderation action of an admin, it is never updated.
+type AuditLog struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	Admin_Email string             `json:"admin_email" bson:"admin_email"`
+	Action      string             `json:"action" bson:"action"`
+	Target_Type string             `json:"target_type" bson:"target_type"`
+	Target_Id   string             `json:"target_id" bson:"target_id"`
+	Reason      string             `json:"reason" bson:"reason"`
+	Created_At  time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type AuditLogRepository interface {
+	Insert(ctx context.Context, log AuditLog) (primitive.ObjectID, error)
+	// FindAll returns the latest entries first, targetID narrows them down when given.
+	FindAll(ctx context.Context, page pagination.Request, targetID ...string) (*pagination.Page[AuditLog], error)
+}
+
+// AdminService moderates the platform, every change is written to the audit log
+// under adminEmail.
+type AdminService interface {
+	SearchUsers(ctx context.Context, query string, page pagination.Request) (*pagination.Page[dto.AdminUserRes], error)
+	SuspendUser(ctx context.Context, adminEmail, userID string, req *dto.SuspendReq) error
+	SearchSellers(ctx context.Context, query string, page pagination.Request) (*pagination.Page[dto.AdminSellerRes], error)
+	// SuspendSeller hides the products of every store of the seller too.
+	SuspendSeller(ctx context.Context, adminEmail, sellerID string, req *dto.SuspendReq) error
+	SearchStores(ctx context.Context, query string, page pagination.Request) (*pagination.Page[Store], error)
+	SuspendStore(ctx context.Context, adminEmail, storeID string, req *dto.SuspendReq) error
+	UnpublishProduct(ctx context.Context, adminEmail, productID string, req *dto.UnpublishReq) error
+	RemoveReview(ctx context.Context, adminEmail, reviewID, reason string) error
+	GetAllOrders(ctx context.Context, page pagination.Request) (*pagination.Page[Orders], error)
+	GetAuditLogs(ctx context.Context, page pagination.Request, targetID string) (*pagination.Page[AuditLog], error)
+}
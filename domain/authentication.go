@@ -2,12 +2,27 @@ package domain
 
 import (
 	"context"
+	"errors"
 
 	"github.com/IndraSty/GreenBasket/dto"
 )
 
+var (
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid")
+	// ErrRefreshTokenReused is returned when a refresh token that was already
+	// exchanged comes back, the whole family is revoked and the account must log in again.
+	ErrRefreshTokenReused = errors.New("refresh token has already been used, please log in again")
+)
+
 type AuthService interface {
 	ValidateOTP(ctx context.Context, req dto.ValidateOtpReq) error
 	AuthenticateUser(ctx context.Context, req *dto.UserAuthReq) (*dto.UserAuthRes, error)
 	RequestEmail(ctx context.Context, req dto.UserReqEmail, action string) error
+	// RefreshToken exchanges the refresh token of a user or a seller for a new
+	// access token and a new refresh token.
+	RefreshToken(ctx context.Context, refreshToken string) (*dto.UserAuthRes, error)
+	// Logout revokes the access token of claims and the refresh token of its account.
+	Logout(ctx context.Context, claims *SignedDetails) error
+	// LogoutAllSessions revokes every token issued to the account of claims.
+	LogoutAllSessions(ctx context.Context, claims *SignedDetails) error
 }
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/dto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	BatchActive = "ACTIVE"
+	// BatchWithdrawn is a batch taken off sale because it is about to expire,
+	// its remaining quantity is no longer part of the product stock.
+	BatchWithdrawn = "WITHDRAWN"
+)
+
+// Batch is one lot of a product received by the seller. The product stock is
+// the sum of its active batches plus any stock that is not tracked in a batch,
+// like the stock of products created before batches or restocked by a refund.
+type Batch struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Batch_Id   string             `json:"batch_id" bson:"batch_id"`
+	Product_Id string             `json:"product_id" bson:"product_id"`
+	Variant_Id string             `json:"variant_id" bson:"variant_id"`
+	Store_Id   string             `json:"store_id" bson:"store_id"`
+	// Quantity is what is left of the batch for sale.
+	Quantity    float64   `json:"quantity" bson:"quantity"`
+	Received_At time.Time `json:"received_at" bson:"received_at"`
+	Expires_At  time.Time `json:"expires_at" bson:"expires_at"`
+	Status      string    `json:"status" bson:"status"`
+	// Alerted is set once the seller has been told the batch is about to expire.
+	Alerted    bool      `json:"alerted" bson:"alerted"`
+	Created_At time.Time `json:"created_at" bson:"created_at"`
+	Updated_At time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// BatchAllocation is the part of a reservation taken from one batch.
+type BatchAllocation struct {
+	Batch_Id string  `json:"batch_id" bson:"batch_id"`
+	Quantity float64 `json:"quantity" bson:"quantity"`
+}
+
+type BatchRepository interface {
+	Insert(ctx context.Context, batch Batch) (primitive.ObjectID, error)
+	FindByProductId(ctx context.Context, storeID, productID string) (*[]Batch, error)
+	// FindAvailable returns the active batches of a product or variant that
+	// still hold stock, the first to expire first.
+	FindAvailable(ctx context.Context, productID, variantID string) (*[]Batch, error)
+	// FindActiveExpiringBefore returns the active batches holding stock that expire before t.
+	FindActiveExpiringBefore(ctx context.Context, t time.Time, storeID ...string) (*[]Batch, error)
+	// Take removes quantity from an active batch that still holds at least quantity.
+	Take(ctx context.Context, batchID string, quantity float64, updateAt time.Time) (*mongo.UpdateResult, error)
+	// Restore gives quantity back to a batch that is still active.
+	Restore(ctx context.Context, batchID string, quantity float64, updateAt time.Time) (*mongo.UpdateResult, error)
+	// Withdraw takes an active batch off sale and returns it as it was before.
+	Withdraw(ctx context.Context, batchID string, updateAt time.Time) (*Batch, error)
+	MarkAlerted(ctx context.Context, batchID string) (*mongo.UpdateResult, error)
+}
+
+type BatchService interface {
+	// AddBatch receives a batch of a product and adds it to the product stock.
+	AddBatch(ctx context.Context, email, storeID, productID string, req *dto.BatchReq) (*dto.BatchRes, error)
+	GetBatches(ctx context.Context, email, storeID, productID string) (*[]Batch, error)
+	// GetExpiringStock is the stock of the store that expires in the next days.
+	GetExpiringStock(ctx context.Context, email, storeID string, days int) (*[]dto.ExpiringStockRes, error)
+	// Allocate takes quantity from the batches of a product first expiry first
+	// out, as far as the batches go. The rest of the quantity is taken from the
+	// stock that is not tracked in a batch.
+	Allocate(ctx context.Context, productID, variantID string, quantity float64) ([]BatchAllocation, error)
+	// Restore gives allocations back to their batches and returns the quantity
+	// of batches withdrawn in the meantime, that quantity is not for sale anymore.
+	Restore(ctx context.Context, allocations []BatchAllocation) (float64, error)
+	// ApplyExpiryPolicy withdraws and discounts the batches near expiry and
+	// alerts the sellers, it returns how many batches were withdrawn.
+	ApplyExpiryPolicy(ctx context.Context, now time.Time) (int, error)
+}
@@ -1,9 +1,25 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrCacheMiss is returned by Get for a key that is not in the cache, any
+// other error means the cache could not be read.
+var ErrCacheMiss = errors.New("key not found in cache")
 
 type CacheRepository interface {
 	Get(key string) ([]byte, error)
 	Del(key string) error
 	Set(key string, entry []byte, expiration time.Duration) error
+	// SetNX only sets key when it does not exist yet and reports whether it did.
+	SetNX(key string, entry []byte, expiration time.Duration) (bool, error)
+	// ExpireIfEqual only touches key while it holds entry and reports whether
+	// it did. It sets the expiration of key, or deletes key for an expiration
+	// that is not positive.
+	ExpireIfEqual(key string, entry []byte, expiration time.Duration) (bool, error)
+	// Incr atomically adds one to the number at key, a missing key is 0, and
+	// returns the new number.
+	Incr(key string) (int64, error)
 }
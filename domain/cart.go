@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -17,33 +19,55 @@ type Cart struct {
 	Items      []CartItem         `json:"items" bson:"items"`
 }
 
+// CartItem is keyed by product id and variant id, Variant_Id is empty for a
+// product without variants.
 type CartItem struct {
 	Product_Id    string    `json:"product_id" bson:"product_id"`
+	Variant_Id    string    `json:"variant_id" bson:"variant_id"`
+	Variant_Name  string    `json:"variant_name" bson:"variant_name"`
 	Product_Name  string    `json:"product_name" bson:"product_name"`
 	Product_Image []string  `json:"product_image" bson:"product_image"`
 	StoreID       string    `json:"store_id" bson:"store_id"`
-	Quantity      int       `json:"quantity" bson:"quantity"`
+	Quantity      float64   `json:"quantity" bson:"quantity"`
+	Unit          string    `json:"unit" bson:"unit"`
 	AddedAt       time.Time `json:"added_at" bson:"added_at"`
 	Selected      bool      `json:"selected" bson:"selected"`
 	Price         float64   `json:"price" bson:"price"`
 }
 
+// CartItemPages is the paging of the items of a cart. The items are keyed by
+// item_key, the product id and the variant id joined by the repository.
+var CartItemPages = pagination.Spec{
+	Keys:        map[string]string{"added_at": "added_at", "price": "price"},
+	Default:     "-added_at",
+	Tiebreaker:  "item_key",
+	Types:       map[string]bsontype.Type{"added_at": bsontype.DateTime, "price": bsontype.Double, "item_key": bsontype.String},
+	DefaultSize: 20,
+	MaxSize:     100,
+}
+
 type CartRepository interface {
 	CreateCart(ctx context.Context, cart *Cart) error
 	GetUserCart(ctx context.Context, email string) (*Cart, error)
 	CheckUserCart(ctx context.Context, email string) (bool, error)
 	AddToCart(ctx context.Context, email string, item *CartItem) (*mongo.UpdateResult, error)
 	GetAllCartItem(ctx context.Context, email string) (*[]CartItem, error)
-	UpdateCartItemById(ctx context.Context, email, productID string, value *dto.CartItemEditRepo, updateAt time.Time) (*mongo.UpdateResult, error)
+	// FindItems returns the items of the cart of the user email, see
+	// CartItemPages.
+	FindItems(ctx context.Context, email string, page pagination.Request) (*pagination.Page[CartItem], error)
+	UpdateCartItemById(ctx context.Context, email, productID, variantID string, value *dto.CartItemEditRepo, updateAt time.Time) (*mongo.UpdateResult, error)
 	UpdateTotalPrice(ctx context.Context, email string, value float64) error
-	RemoveCartItemById(ctx context.Context, email, productID string, updateAt time.Time) (*mongo.UpdateResult, error)
+	RemoveCartItemById(ctx context.Context, email, productID, variantID string, updateAt time.Time) (*mongo.UpdateResult, error)
+	RemoveSelectedItems(ctx context.Context, email string, totalPrice float64, updateAt time.Time) (*mongo.UpdateResult, error)
+	// ReplaceItems sets the items and the total price of the cart at once.
+	ReplaceItems(ctx context.Context, email string, items []CartItem, totalPrice float64, updateAt time.Time) (*mongo.UpdateResult, error)
 }
 
 type CartService interface {
 	CreateCart(ctx context.Context, email string) error
 	GetUserCart(ctx context.Context, email string) (*Cart, error)
 	AddToCart(ctx context.Context, email, productID string, req *dto.AddCartReq) error
-	GetAllCartItem(ctx context.Context, email string) (*[]dto.GetCartItemRes, error)
-	UpdateCartItemById(ctx context.Context, email, productID string, input *dto.CartItemEditReq) error
-	RemoveCartItemById(ctx context.Context, email, productID string) error
+	GetAllCartItem(ctx context.Context, email string, page pagination.Request) (*pagination.Page[dto.GetCartItemRes], error)
+	UpdateCartItemById(ctx context.Context, email, productID, variantID string, input *dto.CartItemEditReq) error
+	RemoveCartItemById(ctx context.Context, email, productID, variantID string) error
 }
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/dto"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DefaultLocale names every category, other locales fall back to it.
+const DefaultLocale = "en"
+
+var ErrCategoryNotFound = errors.New("invalid category")
+
+// Category is a node of the category tree, products keep the slug of their category.
+type Category struct {
+	ID   primitive.ObjectID `bson:"_id"`
+	Slug string             `json:"slug" bson:"slug"`
+	// Parent is the slug of the parent category, empty for a root category.
+	Parent string `json:"parent" bson:"parent"`
+	// Names maps a locale like "en" or "id" to the display name.
+	Names      map[string]string `json:"names" bson:"names"`
+	Icon       string            `json:"icon" bson:"icon"`
+	Sort_Order int               `json:"sort_order" bson:"sort_order"`
+	Created_At time.Time         `json:"created_at" bson:"created_at"`
+	Updated_At time.Time         `json:"updated_at" bson:"updated_at"`
+}
+
+type CategoryRepository interface {
+	Insert(ctx context.Context, category Category) (primitive.ObjectID, error)
+	Update(ctx context.Context, slug string, update bson.D) (*mongo.UpdateResult, error)
+	Delete(ctx context.Context, slug string) (*mongo.DeleteResult, error)
+	FindBySlug(ctx context.Context, slug string) (*Category, error)
+	// FindAll returns every category ordered by sort order.
+	FindAll(ctx context.Context) (*[]Category, error)
+}
+
+type CategoryService interface {
+	// GetTree returns the root categories with their children, named in locale.
+	GetTree(ctx context.Context, locale string) ([]dto.CategoryRes, error)
+	CreateCategory(ctx context.Context, adminEmail string, req *dto.CategoryReq) (*Category, error)
+	// UpdateCategory can move a category but never renames its slug.
+	UpdateCategory(ctx context.Context, adminEmail, slug string, req *dto.CategoryReq) error
+	// DeleteCategory refuses categories that still have children or products.
+	DeleteCategory(ctx context.Context, adminEmail, slug string) error
+	// Resolve returns the slug of category, which may be a slug or a display
+	// name, followed by the slugs of its descendants when asked. An unknown
+	// category returns ErrCategoryNotFound.
+	Resolve(ctx context.Context, category string, descendants bool) ([]string, error)
+}
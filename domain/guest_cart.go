@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/dto"
+)
+
+var (
+	ErrInvalidCartToken  = errors.New("cart token is invalid")
+	ErrGuestCartNotFound = errors.New("guest cart not found or expired")
+	ErrCartItemNotFound  = errors.New("item is not in the cart")
+)
+
+// What became of an item of a guest cart merged into the cart of a user.
+const (
+	// MergeMoved is an item the user did not have yet.
+	MergeMoved = "moved"
+	// MergeCombined is an item the user had, the quantities are added up.
+	MergeCombined = "combined"
+	// MergeCapped is a moved or combined item lowered to the stock of the
+	// product, or to its quantity rule.
+	MergeCapped = "capped"
+	// MergeUnavailable is an item dropped because its product or variant was
+	// removed or hidden.
+	MergeUnavailable = "unavailable"
+	// MergeOutOfStock is an item dropped because not even the minimum
+	// quantity is in stock.
+	MergeOutOfStock = "out_of_stock"
+)
+
+// GuestCart is the cart of a visitor, its id is signed into the cart token
+// cookie. Every change moves Expires_At forward, a cart left alone past it is
+// removed by mongo.
+type GuestCart struct {
+	ID         string     `json:"id" bson:"_id"`
+	Items      []CartItem `json:"items" bson:"items"`
+	UpdatedAt  time.Time  `json:"updated_at" bson:"updated_at"`
+	Expires_At time.Time  `json:"expires_at" bson:"expires_at"`
+}
+
+type GuestCartRepository interface {
+	// FindById returns ErrGuestCartNotFound for a missing or expired cart.
+	FindById(ctx context.Context, cartID string) (*GuestCart, error)
+	// Save creates or replaces the cart.
+	Save(ctx context.Context, cart *GuestCart) error
+	Delete(ctx context.Context, cartID string) error
+}
+
+type GuestCartService interface {
+	GetAllCartItem(ctx context.Context, token string) (*[]dto.GetCartItemRes, error)
+	// AddToCart starts a new cart when token is empty, invalid or expired,
+	// and returns the token of the cart the item went to.
+	AddToCart(ctx context.Context, token, productID string, req *dto.AddCartReq) (string, error)
+	// UpdateCartItemById removes the item when the quantity is 0.
+	UpdateCartItemById(ctx context.Context, token, productID, variantID string, input *dto.CartItemEditReq) error
+	RemoveCartItemById(ctx context.Context, token, productID, variantID string) error
+	// Merge moves the items of the guest cart into the cart of the user,
+	// creating it when the user has none, and deletes the guest cart.
+	Merge(ctx context.Context, token, email string) (*dto.CartMergeRes, error)
+}
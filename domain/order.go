@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -16,6 +17,7 @@ type Orders struct {
 	Order_Date       time.Time          `json:"order_date" bson:"order_date"`
 	Updated_At       time.Time          `json:"updated_at" bson:"updated_at"`
 	Total_Price      float64            `json:"total_price" bson:"total_price"`
+	Shipping_Fee     float64            `json:"shipping_fee" bson:"shipping_fee"`
 	Address_Shipping Address            `json:"address_shipping" bson:"address_shipping"`
 	Payment          *PaymentOrder      `json:"payment" bson:"payment"`
 	Items            []OrderItem        `json:"items" bson:"items"`
@@ -27,29 +29,62 @@ type PaymentOrder struct {
 	Payment_Method string `json:"payment_method" bson:"payment_method"`
 }
 
+// OrderItem is keyed by product id and variant id through the order
+// lifecycle, an order can hold several variants of a product. The Quantity of
+// a weighed item is replaced by the packed quantity before it ships,
+// Ordered_Quantity then keeps the quantity the buyer paid for at checkout.
 type OrderItem struct {
 	Product_Id    string   `json:"product_id" bson:"product_id"`
+	Variant_Id    string   `json:"variant_id" bson:"variant_id,omitempty"`
+	Variant_Name  string   `json:"variant_name" bson:"variant_name,omitempty"`
 	Product_Name  string   `json:"product_name" bson:"product_name"`
 	Product_Image []string `json:"product_image" bson:"product_image"`
 	StoreID       string   `json:"store_id" bson:"store_id"`
 	Order_Status  string   `json:"order_status" bson:"order_status"`
-	Quantity      int      `json:"quantity" bson:"quantity"`
+	Quantity      float64  `json:"quantity" bson:"quantity"`
+	Unit          string   `json:"unit" bson:"unit"`
 	Price         float64  `json:"price" bson:"price"`
+	// Ordered_Quantity is only set once the packed quantity has been entered.
+	Ordered_Quantity float64 `json:"ordered_quantity" bson:"ordered_quantity,omitempty"`
+	// Status_History is appended by OrderStatusService on every status change.
+	Status_History []OrderStatusHistory `json:"status_history" bson:"status_history,omitempty"`
 }
 
+// Is reports whether the item is the variant variantID of the product
+// productID, variantID is empty for a product without variants.
+func (item OrderItem) Is(productID, variantID string) bool {
+	return item.Product_Id == productID && item.Variant_Id == variantID
+}
+
+// OrderRepository methods accept the ctx handed out by Transactor.WithTransaction,
+// writes made with it join the running transaction.
 type OrderRepository interface {
 	CreateOrder(ctx context.Context, order Orders) (primitive.ObjectID, error)
 	GetAllOrders(ctx context.Context, email string) (*[]Orders, error)
+	// FindByEmail returns the orders of the user email, see OrderPages.
+	FindByEmail(ctx context.Context, email string, page pagination.Request) (*pagination.Page[Orders], error)
 	GetOrder(ctx context.Context, orderID string, email ...string) (*Orders, error)
 	UpdateOrder(ctx context.Context, orderID string, req *dto.UpdatePaymentReq) (*mongo.UpdateResult, error)
 	UpdateStatusOrder(ctx context.Context, orderID, productID string, req *dto.OrderStatusUpdateReq) (*mongo.UpdateResult, error)
-	DeleteItem(ctx context.Context, orderID, productID string) (*mongo.UpdateResult, error)
+	DeleteItem(ctx context.Context, orderID, productID, variantID string) (*mongo.UpdateResult, error)
+	DeleteOrder(ctx context.Context, orderID string) (*mongo.DeleteResult, error)
+	UpdateTotalPrice(ctx context.Context, orderID string, value float64) (*mongo.UpdateResult, error)
+	// UpdateShippingFee adds value to the shipping fee and the total price of the order.
+	UpdateShippingFee(ctx context.Context, orderID string, value float64) (*mongo.UpdateResult, error)
+	UpdateItemStatus(ctx context.Context, orderID, productID, variantID string, history OrderStatusHistory) (*mongo.UpdateResult, error)
+	// UpdateItemQuantity replaces the quantity of a PROCESSED item with the
+	// packed quantity, only once.
+	UpdateItemQuantity(ctx context.Context, orderID, productID, variantID string, ordered, packed float64, updateAt time.Time) (*mongo.UpdateResult, error)
+	FindShippedBefore(ctx context.Context, before time.Time) (*[]Orders, error)
+	FindUnpaidBefore(ctx context.Context, before time.Time) (*[]Orders, error)
+	// FindAll returns the orders of every user, the latest first.
+	FindAll(ctx context.Context, page pagination.Request) (*pagination.Page[Orders], error)
 }
 
 type OrderService interface {
 	CreateOrder(ctx context.Context, email string) (*dto.InsertOrderRes, error)
-	GetAllOrders(ctx context.Context, email string) (*[]Orders, error)
+	GetAllOrders(ctx context.Context, email string, page pagination.Request) (*pagination.Page[Orders], error)
 	GetOrderByEmailAndId(ctx context.Context, email, orderID string) (*Orders, error)
-	FinishOrder(ctx context.Context, email, orderID, productID string, req *dto.OrderStatusUpdateReq) error
-	CancelOrder(ctx context.Context, email, orderID, productID string) error
+	FinishOrder(ctx context.Context, email, orderID, productID, variantID string, req *dto.OrderStatusUpdateReq) error
+	CancelOrder(ctx context.Context, email, orderID, productID, variantID string) error
 }
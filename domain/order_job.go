@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// OrderJobService holds the order work the scheduler runs in the background.
+type OrderJobService interface {
+	// FinishShippedItems finishes every item shipped before shippedBefore as if
+	// the buyer had confirmed it, and returns how many items it finished.
+	FinishShippedItems(ctx context.Context, shippedBefore time.Time) (int, error)
+	// ExpireUnpaidOrders expires every order not paid before orderedBefore,
+	// gives its stock back and returns how many orders it expired.
+	ExpireUnpaidOrders(ctx context.Context, orderedBefore time.Time) (int, error)
+}
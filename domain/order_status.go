@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// OrderStatus is the status of a single OrderItem, the matching SellerOrderItem
+// always carries the same status.
+type OrderStatus string
+
+const (
+	OrderPending   OrderStatus = "PENDING"
+	OrderProcessed OrderStatus = "PROCESSED"
+	OrderShipped   OrderStatus = "SHIPPED"
+	OrderFinished  OrderStatus = "FINISHED"
+	OrderCancelled OrderStatus = "CANCELLED"
+	OrderRefunded  OrderStatus = "REFUNDED"
+)
+
+// OrderActor is who asks for an order status change.
+type OrderActor string
+
+const (
+	ActorUser   OrderActor = "USER"
+	ActorSeller OrderActor = "SELLER"
+	// ActorSystem is the application itself, e.g. a payment notification or an approved refund.
+	ActorSystem OrderActor = "SYSTEM"
+)
+
+// orderTransitions lists, for every status, the statuses an item may move to
+// and the actors allowed to make that move. A status that is not a key here is final.
+var orderTransitions = map[OrderStatus]map[OrderStatus][]OrderActor{
+	OrderPending: {
+		OrderProcessed: {ActorSystem},
+		OrderCancelled: {ActorUser, ActorSeller, ActorSystem},
+	},
+	OrderProcessed: {
+		OrderShipped:  {ActorSeller},
+		OrderRefunded: {ActorSystem},
+	},
+	OrderShipped: {
+		OrderFinished: {ActorUser, ActorSystem},
+		OrderRefunded: {ActorSystem},
+	},
+	OrderFinished: {
+		OrderRefunded: {ActorSystem},
+	},
+}
+
+type OrderTransitionError struct {
+	From  OrderStatus
+	To    OrderStatus
+	Actor OrderActor
+}
+
+func (e *OrderTransitionError) Error() string {
+	return fmt.Sprintf("illegal order status transition from %s to %s by %s", e.From, e.To, e.Actor)
+}
+
+// CanTransitionTo reports whether actor may move an item in status s to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus, actor OrderActor) bool {
+	for _, allowed := range orderTransitions[s][next] {
+		if allowed == actor {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Transition returns next when the move is legal, otherwise a *OrderTransitionError.
+func (s OrderStatus) Transition(next OrderStatus, actor OrderActor) (OrderStatus, error) {
+	if !s.CanTransitionTo(next, actor) {
+		return s, &OrderTransitionError{From: s, To: next, Actor: actor}
+	}
+
+	return next, nil
+}
+
+// OrderStatusHistory records one status change of an item.
+type OrderStatusHistory struct {
+	Actor      OrderActor  `json:"actor" bson:"actor"`
+	From       OrderStatus `json:"from" bson:"from"`
+	To         OrderStatus `json:"to" bson:"to"`
+	Changed_At time.Time   `json:"changed_at" bson:"changed_at"`
+}
+
+// OrderStatusService is the only place that changes the status of an item, it
+// moves the OrderItem and its SellerOrderItem together and records the change
+// in their status history.
+type OrderStatusService interface {
+	Transition(ctx context.Context, orderID, productID, variantID string, next OrderStatus, actor OrderActor) error
+}
@@ -20,12 +20,17 @@ type Payment struct {
 	Status         string             `json:"status" bson:"status"`
 	TransactionID  string             `json:"transaction_id" bson:"transaction_id"`
 	Snap_Url       string             `json:"snap_url"`
+	// Parent_Order_Id is set on an extra charge of an order, like the
+	// difference of a weighed item packed heavier than ordered. An extra
+	// charge only moves its own status, the order follows its parent payment.
+	Parent_Order_Id string `json:"parent_order_id" bson:"parent_order_id,omitempty"`
 }
 
 type PaymentRepository interface {
 	FindByOrderId(ctx context.Context, orderID string) (*Payment, error)
 	Insert(ctx context.Context, p *Payment) error
 	Update(ctx context.Context, orderID string, req *dto.UpdatePaymentReq) (*mongo.UpdateResult, error)
+	UpdateFromStatus(ctx context.Context, orderID, fromStatus string, req *dto.UpdatePaymentReq) (*mongo.UpdateResult, error)
 }
 
 type PaymentService interface {
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// PaymentEvent is a webhook notification as it was received. The ID is made of
+// the transaction id and its status, so a notification that is delivered twice
+// maps to the same record.
+type PaymentEvent struct {
+	ID                 string    `json:"id" bson:"_id"`
+	Transaction_Id     string    `json:"transaction_id" bson:"transaction_id"`
+	Order_id           string    `json:"order_id" bson:"order_id"`
+	Transaction_Status string    `json:"transaction_status" bson:"transaction_status"`
+	Fraud_Status       string    `json:"fraud_status" bson:"fraud_status"`
+	Status_Code        string    `json:"status_code" bson:"status_code"`
+	Gross_Amount       string    `json:"gross_amount" bson:"gross_amount"`
+	Payment_Type       string    `json:"payment_type" bson:"payment_type"`
+	Payload            string    `json:"payload" bson:"payload"`
+	Processed          bool      `json:"processed" bson:"processed"`
+	Result             string    `json:"result" bson:"result"`
+	Locked_Until       time.Time `json:"locked_until" bson:"locked_until"`
+	Received_At        time.Time `json:"received_at" bson:"received_at"`
+	Processed_At       time.Time `json:"processed_at" bson:"processed_at"`
+}
+
+type PaymentEventRepository interface {
+	Insert(ctx context.Context, event *PaymentEvent) error
+	FindById(ctx context.Context, eventID string) (*PaymentEvent, error)
+	FindByTransactionId(ctx context.Context, transactionID string) (*[]PaymentEvent, error)
+	Claim(ctx context.Context, eventID string, now, lockUntil time.Time) (bool, error)
+	Unlock(ctx context.Context, eventID string) error
+	MarkProcessed(ctx context.Context, eventID, result string, processedAt time.Time) error
+}
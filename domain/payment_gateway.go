@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature key")
+
+// ErrChargeNotFound is returned by CheckStatus when the buyer never opened the payment at the gateway.
+var ErrChargeNotFound = errors.New("charge not found")
+
+// PaymentNotification is a webhook notification of any gateway, verified and
+// mapped to our payment status.
+type PaymentNotification struct {
+	EventID           string
+	OrderID           string
+	TransactionID     string
+	TransactionStatus string
+	FraudStatus       string
+	StatusCode        string
+	GrossAmount       string
+	PaymentType       string
+	// Status is empty when the gateway reported a status we do not know.
+	Status PaymentStatus
+}
+
+type PaymentGateway interface {
+	// CreateCharge starts a payment at the gateway and fills p.Snap_Url with the page the buyer pays on.
+	CreateCharge(ctx context.Context, p *Payment) error
+	CheckStatus(ctx context.Context, orderID string) (*PaymentNotification, error)
+	// Refund gives amount back to the buyer, a refundKey the gateway has seen
+	// before is not refunded again.
+	Refund(ctx context.Context, orderID, refundKey string, amount float64, reason string) error
+	// Expire closes a charge that is still pending so the buyer can no longer
+	// pay it, it returns ErrChargeNotFound when the buyer never opened the payment.
+	Expire(ctx context.Context, orderID string) error
+	// ParseWebhook returns ErrInvalidSignature when the payload was not sent by the gateway.
+	ParseWebhook(payload []byte) (*PaymentNotification, error)
+}
+
+type FakeCharge struct {
+	OrderID           string    `json:"order_id"`
+	TransactionID     string    `json:"transaction_id"`
+	Amount            float64   `json:"amount"`
+	TransactionStatus string    `json:"transaction_status"`
+	Refunded          float64   `json:"refunded"`
+	Created_At        time.Time `json:"created_at"`
+}
+
+// FakePaymentGateway is an in-process gateway for running checkout offline.
+type FakePaymentGateway interface {
+	PaymentGateway
+	GetCharge(ctx context.Context, orderID string) (*FakeCharge, error)
+	// Simulate moves a charge to transactionStatus, e.g. settlement or expire,
+	// and returns the webhook payload the gateway would have sent.
+	Simulate(ctx context.Context, orderID, transactionStatus string) ([]byte, error)
+}
+
+type PaymentNotificationService interface {
+	HandleNotification(ctx context.Context, payload []byte) (bool, error)
+	// ExpirePayment closes a payment that was not finished in time. The status
+	// at the gateway wins when it has one, otherwise the payment expires. It
+	// reports whether the payment expired.
+	ExpirePayment(ctx context.Context, orderID string) (bool, error)
+}
@@ -0,0 +1,91 @@
+package domain
+
+import "fmt"
+
+type PaymentStatus string
+
+const (
+	PaymentPending         PaymentStatus = "PENDING"
+	PaymentChallenge       PaymentStatus = "CHALLENGE"
+	PaymentSuccess         PaymentStatus = "SUCCESS"
+	PaymentExpired         PaymentStatus = "EXPIRED"
+	PaymentDenied          PaymentStatus = "DENIED"
+	PaymentCancelled       PaymentStatus = "CANCELLED"
+	PaymentRefunded        PaymentStatus = "REFUNDED"
+	PaymentPartialRefunded PaymentStatus = "PARTIAL_REFUNDED"
+)
+
+// paymentTransitions lists every status a payment may move to from a given
+// status. A status that is not a key here is final.
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentPending:         {PaymentChallenge, PaymentSuccess, PaymentExpired, PaymentDenied, PaymentCancelled},
+	PaymentChallenge:       {PaymentSuccess, PaymentDenied, PaymentCancelled},
+	PaymentSuccess:         {PaymentPartialRefunded, PaymentRefunded},
+	PaymentPartialRefunded: {PaymentPartialRefunded, PaymentRefunded},
+}
+
+type PaymentTransitionError struct {
+	From PaymentStatus
+	To   PaymentStatus
+}
+
+func (e *PaymentTransitionError) Error() string {
+	return fmt.Sprintf("illegal payment status transition from %s to %s", e.From, e.To)
+}
+
+// CanTransitionTo reports whether a payment in status s may move to next.
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	for _, status := range paymentTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Transition returns next when the move is legal, otherwise a *PaymentTransitionError.
+func (s PaymentStatus) Transition(next PaymentStatus) (PaymentStatus, error) {
+	if !s.CanTransitionTo(next) {
+		return s, &PaymentTransitionError{From: s, To: next}
+	}
+
+	return next, nil
+}
+
+// ReleasesStock reports whether reaching this status means the order will never
+// be paid, so its reserved stock has to go back to the sellers.
+func (s PaymentStatus) ReleasesStock() bool {
+	return s == PaymentExpired || s == PaymentDenied || s == PaymentCancelled
+}
+
+// PaymentStatusFromMidtrans maps a midtrans transaction_status and fraud_status
+// to our payment status, ok is false for a status we do not know.
+func PaymentStatusFromMidtrans(transactionStatus, fraudStatus string) (status PaymentStatus, ok bool) {
+	switch transactionStatus {
+	case "pending":
+		return PaymentPending, true
+	case "capture":
+		if fraudStatus == "challenge" {
+			return PaymentChallenge, true
+		}
+		if fraudStatus == "deny" {
+			return PaymentDenied, true
+		}
+		return PaymentSuccess, true
+	case "settlement":
+		return PaymentSuccess, true
+	case "deny", "failure":
+		return PaymentDenied, true
+	case "cancel":
+		return PaymentCancelled, true
+	case "expire":
+		return PaymentExpired, true
+	case "refund":
+		return PaymentRefunded, true
+	case "partial_refund":
+		return PaymentPartialRefunded, true
+	}
+
+	return "", false
+}
@@ -2,10 +2,14 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"math"
 	"time"
 
 	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -15,18 +19,63 @@ type Products struct {
 	Name        string             `json:"name" valid:"required,min=2,max=200" bson:"name"`
 	Description string             `json:"description" valid:"required" bson:"description"`
 	Price       float64            `json:"price" valid:"required" bson:"price"`
-	Stock       int                `json:"stock" valid:"required" bson:"stock"`
+	Stock       float64            `json:"stock" valid:"required" bson:"stock"`
 	Product_id  string             `json:"product_id" bson:"product_id"`
 	Category    string             `json:"category" valid:"required" bson:"category"`
 	Created_at  time.Time          `json:"created_at" bson:"created_at"`
 	Updated_at  time.Time          `json:"updated_at" bson:"updated_at"`
 	Store_id    string             `json:"store_id" bson:"store_id"`
 	Images      []string           `json:"images" valid:"required" bson:"images"`
+	// Weight is the shipping weight of one unit in grams.
+	Weight int `json:"weight" bson:"weight"`
+	// Unit is the unit Price and Stock are counted in, empty for a product
+	// sold by the piece. Min_Quantity and Quantity_Step are the QuantityRule
+	// of the product.
+	Unit          string  `json:"unit" bson:"unit"`
+	Min_Quantity  float64 `json:"min_quantity" bson:"min_quantity"`
+	Quantity_Step float64 `json:"quantity_step" bson:"quantity_step"`
+	// Discount is the percentage off the price while a batch of the product is
+	// near expiry, it is set by BatchService.
+	Discount float64 `json:"discount" bson:"discount"`
+	// Unpublished and Store_Suspended are set by AdminService, either one
+	// hides the product from guests and buyers.
+	Unpublished     bool `json:"unpublished" bson:"unpublished"`
+	Store_Suspended bool `json:"store_suspended" bson:"store_suspended"`
+	// Variants are the packs a product is sold in. When a product has
+	// variants, Price is the lowest variant price and Stock the sum of the
+	// variant stocks, and every cart and order item names its variant.
+	Variants []Variant `json:"variants" bson:"variants,omitempty"`
+}
+
+type Variant struct {
+	Variant_Id string `json:"variant_id" bson:"variant_id"`
+	Name       string `json:"name" bson:"name"`
+	SKU        string `json:"sku" bson:"sku"`
+	// Attributes describe the variant, like {"size": "500g"}.
+	Attributes map[string]string `json:"attributes" bson:"attributes"`
+	Price      float64           `json:"price" bson:"price"`
+	Stock      float64           `json:"stock" bson:"stock"`
+	Images     []string          `json:"images" bson:"images"`
+}
+
+var ErrVariantNotFound = errors.New("variant not found")
+
+var ErrProductNotFound = errors.New("product not found")
+
+// FindVariant returns the variant with variantID, or ErrVariantNotFound.
+func FindVariant(variants []Variant, variantID string) (*Variant, error) {
+	for i := range variants {
+		if variants[i].Variant_Id == variantID {
+			return &variants[i], nil
+		}
+	}
+
+	return nil, ErrVariantNotFound
 }
 
 type SalesData struct {
 	Average_rating float32 `bson:"average_rating"`
-	Total_sales    int64   `bson:"total_sales"`
+	Total_sales    float64 `bson:"total_sales"`
 }
 
 type ProductWithSalesData struct {
@@ -34,7 +83,8 @@ type ProductWithSalesData struct {
 	Name        string     `bson:"name"`
 	Description string     `bson:"description"`
 	Price       float64    `bson:"price"`
-	Stock       int        `bson:"stock"`
+	Stock       float64    `bson:"stock"`
+	Weight      int        `bson:"weight"`
 	Product_id  string     `bson:"product_id"`
 	Category    string     `bson:"category"`
 	Created_at  time.Time  `bson:"created_at"`
@@ -42,45 +92,172 @@ type ProductWithSalesData struct {
 	Store_id    string     `bson:"store_id"`
 	Images      []string   `bson:"images"`
 	SalesData   *SalesData `bson:"sales_data"`
+	Variants    []Variant  `bson:"variants"`
+
+	Unit          string  `bson:"unit"`
+	Min_Quantity  float64 `bson:"min_quantity"`
+	Quantity_Step float64 `bson:"quantity_step"`
+	Discount      float64 `bson:"discount"`
+
+	Unpublished     bool `bson:"unpublished"`
+	Store_Suspended bool `bson:"store_suspended"`
+}
+
+func (p *ProductWithSalesData) QuantityRule() QuantityRule {
+	return QuantityRule{Unit: UnitOf(p.Unit), Min: p.Min_Quantity, Step: p.Quantity_Step}
+}
+
+// Hidden tells whether an admin unpublished the product or suspended its
+// store.
+func (p *ProductWithSalesData) Hidden() bool {
+	return p.Unpublished || p.Store_Suspended
+}
+
+// SalePrice is price, the price of the product or of one of its variants,
+// after the discount of the product.
+func (p *ProductWithSalesData) SalePrice(price float64) float64 {
+	if p.Discount == 0 {
+		return price
+	}
+
+	return math.Round(price*(100-p.Discount)) / 100
 }
 
 type PagedProducts struct {
-	Products  []ProductWithSalesData `json:"products"`
-	Page      int                    `json:"page"`
-	TotalItem int                    `json:"total_item"`
-	LastPage  int                    `json:"last_page"`
+	Products    []ProductWithSalesData `json:"products"`
+	Page        int                    `json:"page"`
+	Size        int                    `json:"size"`
+	TotalItem   int                    `json:"total_item"`
+	LastPage    int                    `json:"last_page"`
+	Next_Cursor string                 `json:"next_cursor,omitempty"`
+}
+
+// productSortKeys are the sort keys of the product lists.
+var productSortKeys = map[string]string{
+	"name":           "name",
+	"price":          "price",
+	"stock":          "stock",
+	"created_at":     "created_at",
+	"total_sales":    "total_sales",
+	"average_rating": "average_rating",
+	"product_id":     "product_id",
+}
+
+// productSortTypes are the BSON types of the fields of productSortKeys.
+var productSortTypes = map[string]bsontype.Type{
+	"name":           bsontype.String,
+	"price":          bsontype.Double,
+	"stock":          bsontype.Double,
+	"created_at":     bsontype.DateTime,
+	"total_sales":    bsontype.Double,
+	"average_rating": bsontype.Double,
+	"product_id":     bsontype.String,
+}
+
+// ProductPages is the paging of the product lists.
+var ProductPages = pagination.Spec{
+	Keys:        productSortKeys,
+	Default:     "-created_at",
+	Tiebreaker:  "product_id",
+	Types:       productSortTypes,
+	DefaultSize: 9,
+	MaxSize:     48,
+}
+
+// ProductSearchPages is the paging of the searched product lists, they are
+// sorted by relevance unless asked otherwise.
+var ProductSearchPages = pagination.Spec{
+	Keys:        withKey(productSortKeys, "relevance", "rank"),
+	Default:     "relevance",
+	Tiebreaker:  "product_id",
+	Types:       withKey(productSortTypes, "rank", bsontype.Int32),
+	DefaultSize: 9,
+	MaxSize:     48,
+}
+
+func withKey[V any](keys map[string]V, name string, value V) map[string]V {
+	res := map[string]V{name: value}
+	for k, v := range keys {
+		res[k] = v
+	}
+	return res
+}
+
+// ErrInvalidCatalogueQuery is returned for a price range whose minimum is
+// above its maximum.
+var ErrInvalidCatalogueQuery = errors.New("invalid catalogue query")
+
+// CatalogueFilter narrows the products of GetCatalogue, zero fields do not
+// filter.
+type CatalogueFilter struct {
+	// Product_Ids are the products found by a search, nil without a search.
+	// The relevance sort keeps their order.
+	Product_Ids []string
+	Categories  []string
+	Min_Price   float64
+	Max_Price   float64
+	Min_Rating  float64
+	In_Stock    bool
+	City        string
+	Store_Id    string
+}
+
+type CataloguePage struct {
+	PagedProducts
+	Facets dto.CatalogueFacets
 }
 
+// ProductRepository methods without a storeID serve guests and buyers and leave out the products hidden by an admin.
 type ProductRepository interface {
 	CreateProduct(ctx context.Context, product Products) (primitive.ObjectID, error)
 	CheckNameExists(ctx context.Context, name string) (bool, error)
 	UpdateProduct(ctx context.Context, storeID, productID string, update bson.D) (*mongo.UpdateResult, error)
-	UpdateStockProduct(ctx context.Context, storeID, productID string, stock int, updateAt time.Time) (*mongo.UpdateResult, error)
+	UpdateProductsOfStore(ctx context.Context, storeID string, update bson.D) (*mongo.UpdateResult, error)
+	// UpdateStockProduct and ReserveStock change the stock of the variant and
+	// the product together, variantID is empty for a product without variants.
+	UpdateStockProduct(ctx context.Context, storeID, productID, variantID string, stock float64, updateAt time.Time) (*mongo.UpdateResult, error)
+	ReserveStock(ctx context.Context, storeID, productID, variantID string, quantity float64, updateAt time.Time) (*mongo.UpdateResult, error)
 	DeleteProductById(ctx context.Context, storeID, productID string) (*mongo.DeleteResult, error)
-	GetAllByCategory(ctx context.Context, category string, page int, storeID ...string) (*PagedProducts, error)
-	GetAllProductByQuery(ctx context.Context, query string, page int, storeID ...string) (*PagedProducts, error)
+	// GetAllByCategory returns the products in any of the category slugs.
+	GetAllByCategory(ctx context.Context, categories []string, page pagination.Request, storeID ...string) (*PagedProducts, error)
+	CountByCategory(ctx context.Context, category string) (int64, error)
+	// CountByImage counts the products or variants with the image url.
+	CountByImage(ctx context.Context, url string) (int64, error)
+	// GetAllProductByIds returns a page of the products in productIDs, the
+	// relevance sort keeps the order of productIDs. A nil productIDs is every
+	// product.
+	GetAllProductByIds(ctx context.Context, productIDs []string, page pagination.Request, storeID ...string) (*PagedProducts, error)
 	GetProductById(ctx context.Context, productID string, storeID ...string) (*ProductWithSalesData, error)
-	GetAllProduct(ctx context.Context, page int, storeID ...string) (*PagedProducts, error)
+	GetAllProduct(ctx context.Context, page pagination.Request, storeID ...string) (*PagedProducts, error)
 	GetAllProductWithNoPage(ctx context.Context, storeID string) (*[]ProductWithSalesData, error)
-	GetAllProductByQueryForCust(ctx context.Context, page int, query ...string) (*PagedProducts, error)
-	GetAllProductSorted(ctx context.Context, sortParams map[string]string, page int, storeID ...string) (*PagedProducts, error)
+	// GetCatalogue returns a page of the visible products matching filter and
+	// the facets of all of them, in one aggregation.
+	GetCatalogue(ctx context.Context, filter CatalogueFilter, page pagination.Request) (*CataloguePage, error)
+	// SetDiscounts gives the products in productIDs the discount and takes
+	// the discount off every other product.
+	SetDiscounts(ctx context.Context, productIDs []string, discount float64) error
+	// GetAllStock returns every product of every store, hidden or not.
+	GetAllStock(ctx context.Context) (*[]Products, error)
+	// GetSearchDocuments returns the text of the visible products in
+	// productIDs for the search index, or of every visible product when
+	// productIDs is empty.
+	GetSearchDocuments(ctx context.Context, productIDs ...string) (*[]SearchDocument, error)
 }
 
 type ProductService interface {
 	// seller
 	CreateProduct(ctx context.Context, storeID, email string, req *dto.ProductReq) (*dto.AddProductRes, error)
 	GetProductById(ctx context.Context, storeID, email, productID string) (*dto.GetProductRes, error)
-	GetAllProduct(ctx context.Context, storeID, email string, page int) (*dto.PagedProducts, error)
-	SearchProduct(ctx context.Context, email, storeID, query string, page int) (*dto.PagedProducts, error)
-	GetAllByCategory(ctx context.Context, email, storeID, category string, page int) (*dto.PagedProducts, error)
+	GetAllProduct(ctx context.Context, storeID, email string, page pagination.Request) (*dto.PagedProducts, error)
+	SearchProduct(ctx context.Context, email, storeID, query string, page pagination.Request) (*dto.PagedProducts, error)
+	GetAllByCategory(ctx context.Context, email, storeID, category string, descendants bool, page pagination.Request) (*dto.PagedProducts, error)
 	UpdateProduct(ctx context.Context, storeID, email, productID string, req *dto.ProductReq) (*dto.EditProductRes, error)
 	DeleteProductById(ctx context.Context, storeID, email, productID string) (*dto.DeleteProductRes, error)
-	GetAllProductSorted(ctx context.Context, sortParams map[string]string, page int, email, storeID string) (*dto.PagedProducts, error)
 
 	// user / guest
-	GetAllProductForGuest(ctx context.Context, page int) (*dto.PagedProducts, error)
+	GetAllProductForGuest(ctx context.Context, page pagination.Request) (*dto.PagedProducts, error)
 	GetProductByIdForGuest(ctx context.Context, productID string) (*dto.GetProductRes, error)
-	GetAllByCategoryForGuest(ctx context.Context, category string, page int) (*dto.PagedProducts, error)
-	SearchProductForGuest(ctx context.Context, page int, query ...string) (*dto.PagedProducts, error)
-	GetAllProductSortedForCust(ctx context.Context, sortParams map[string]string, page int) (*dto.PagedProducts, error)
+	GetAllByCategoryForGuest(ctx context.Context, category string, descendants bool, page pagination.Request) (*dto.PagedProducts, error)
+	SearchProductForGuest(ctx context.Context, query string, page pagination.Request) (*dto.PagedProducts, error)
+	GetCatalogue(ctx context.Context, query *dto.CatalogueQuery, page pagination.Request) (*dto.CatalogueRes, error)
 }
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/dto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ImportFormatCSV   = "csv"
+	ImportFormatJSONL = "jsonl"
+)
+
+const (
+	ImportPending = "PENDING"
+	ImportRunning = "RUNNING"
+	// ImportDone is a finished import, some of its rows may have failed.
+	ImportDone = "DONE"
+)
+
+// ImportJob is a file of products a seller imports into a store. The rows are
+// created in the background, each one like a product added by hand.
+type ImportJob struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Job_Id     string             `json:"job_id" bson:"job_id"`
+	Store_Id   string             `json:"store_id" bson:"store_id"`
+	Email      string             `json:"email" bson:"email"`
+	Format     string             `json:"format" bson:"format"`
+	Status     string             `json:"status" bson:"status"`
+	Total_Rows int                `json:"total_rows" bson:"total_rows"`
+	Created    int                `json:"created" bson:"created"`
+	// Errors are the rows that were not created.
+	Errors     []ImportRowError `json:"errors" bson:"errors"`
+	Created_At time.Time        `json:"created_at" bson:"created_at"`
+	Updated_At time.Time        `json:"updated_at" bson:"updated_at"`
+}
+
+type ImportRowError struct {
+	// Row is the line of the row in the file, the CSV header is line 1.
+	Row   int    `json:"row" bson:"row"`
+	Name  string `json:"name" bson:"name"`
+	Error string `json:"error" bson:"error"`
+}
+
+type ImportJobRepository interface {
+	Insert(ctx context.Context, job ImportJob) (primitive.ObjectID, error)
+	FindById(ctx context.Context, storeID, jobID string) (*ImportJob, error)
+	UpdateProgress(ctx context.Context, jobID, status string, created int, rowErrors []ImportRowError, updateAt time.Time) error
+}
+
+type ProductImportService interface {
+	// Import reads the products in file and creates them in the background,
+	// the returned job reports how the rows went.
+	Import(ctx context.Context, email, storeID, format string, file io.Reader) (*dto.ImportJobRes, error)
+	GetImportJob(ctx context.Context, email, storeID, jobID string) (*ImportJob, error)
+	// Export writes the products of the store to w as CSV, in the columns Import reads.
+	Export(ctx context.Context, email, storeID string, w io.Writer) error
+}
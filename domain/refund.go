@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	RefundRequested = "REQUESTED"
+	RefundApproved  = "APPROVED"
+	RefundRejected  = "REJECTED"
+	RefundRefunded  = "REFUNDED"
+)
+
+// Refund gives the money of one OrderItem back to the buyer. An Adjustment
+// refund only gives back the difference of a weighed item packed lighter than
+// ordered, the item itself is not refunded.
+type Refund struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	Refund_Id    string             `json:"refund_id" bson:"refund_id"`
+	Order_id     string             `json:"order_id" bson:"order_id"`
+	Product_Id   string             `json:"product_id" bson:"product_id"`
+	Variant_Id   string             `json:"variant_id" bson:"variant_id,omitempty"`
+	Store_Id     string             `json:"store_id" bson:"store_id"`
+	User_Email   string             `json:"user_email" bson:"user_email"`
+	Seller_Email string             `json:"seller_email" bson:"seller_email"`
+	Quantity     float64            `json:"quantity" bson:"quantity"`
+	Amount       float64            `json:"amount" bson:"amount"`
+	Reason       string             `json:"reason" bson:"reason"`
+	Status       string             `json:"status" bson:"status"`
+	Adjustment   bool               `json:"adjustment" bson:"adjustment"`
+	Created_At   time.Time          `json:"created_at" bson:"created_at"`
+	Updated_At   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// RefundPages is the paging of the refunds of a user or a seller.
+var RefundPages = pagination.Spec{
+	Keys:        map[string]string{"created_at": "created_at", "amount": "amount"},
+	Default:     "-created_at",
+	Tiebreaker:  "_id",
+	Types:       map[string]bsontype.Type{"created_at": bsontype.DateTime, "amount": bsontype.Double, "_id": bsontype.ObjectID},
+	DefaultSize: 20,
+	MaxSize:     100,
+}
+
+type RefundRepository interface {
+	Insert(ctx context.Context, refund Refund) (primitive.ObjectID, error)
+	FindById(ctx context.Context, refundID string) (*Refund, error)
+	FindByOrderId(ctx context.Context, orderID string) (*[]Refund, error)
+	FindByUserEmail(ctx context.Context, email string, page pagination.Request) (*pagination.Page[Refund], error)
+	FindBySellerEmail(ctx context.Context, email string, page pagination.Request) (*pagination.Page[Refund], error)
+	FindByStatus(ctx context.Context, status string, updatedBefore time.Time) (*[]Refund, error)
+	UpdateStatus(ctx context.Context, refundID, fromStatus, toStatus string, updateAt time.Time) (*mongo.UpdateResult, error)
+}
+
+type RefundService interface {
+	RequestRefund(ctx context.Context, email, orderID, productID, variantID string, req *dto.RefundReq) (*dto.RefundRes, error)
+	GetUserRefunds(ctx context.Context, email string, page pagination.Request) (*pagination.Page[Refund], error)
+	GetSellerRefunds(ctx context.Context, email string, page pagination.Request) (*pagination.Page[Refund], error)
+	ApproveRefund(ctx context.Context, email, refundID string) error
+	RejectRefund(ctx context.Context, email, refundID string) error
+	// RetryApprovedRefunds finishes every refund approved before approvedBefore
+	// that did not get past the gateway or the orders, and returns how many it
+	// finished.
+	RetryApprovedRefunds(ctx context.Context, approvedBefore time.Time) (int, error)
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Reservation struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	Reservation_Id string             `json:"reservation_id" bson:"reservation_id"`
+	Order_id       string             `json:"order_id" bson:"order_id"`
+	Product_Id     string             `json:"product_id" bson:"product_id"`
+	Variant_Id     string             `json:"variant_id" bson:"variant_id,omitempty"`
+	Store_Id       string             `json:"store_id" bson:"store_id"`
+	Quantity       float64            `json:"quantity" bson:"quantity"`
+	// Batches is the part of Quantity taken from the batches of the product.
+	Batches    []BatchAllocation `json:"batches" bson:"batches,omitempty"`
+	Status     string            `json:"status" bson:"status"`
+	Created_At time.Time         `json:"created_at" bson:"created_at"`
+	Updated_At time.Time         `json:"updated_at" bson:"updated_at"`
+}
+
+type ReservationRepository interface {
+	Insert(ctx context.Context, reservation Reservation) (primitive.ObjectID, error)
+	FindByOrderId(ctx context.Context, orderID string) (*[]Reservation, error)
+	UpdateStatus(ctx context.Context, reservationID, fromStatus, toStatus string, updateAt time.Time) (*mongo.UpdateResult, error)
+	// UpdateBatches replaces the quantity of a reservation and the batches it
+	// was taken from, like when a weighed item is packed.
+	UpdateBatches(ctx context.Context, reservationID string, quantity float64, batches []BatchAllocation, updateAt time.Time) (*mongo.UpdateResult, error)
+}
+
+type ReservationService interface {
+	ReserveStock(ctx context.Context, orderID string, items []OrderItem) error
+	// ReleaseStock gives back the stock reserved for every item of the order.
+	ReleaseStock(ctx context.Context, orderID string) error
+	// ReleaseItem gives back the stock reserved for one item of the order.
+	ReleaseItem(ctx context.Context, orderID, productID, variantID string) error
+	CommitStock(ctx context.Context, orderID string) error
+}
@@ -5,7 +5,9 @@ import (
 	"time"
 
 	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -23,6 +25,16 @@ type Review struct {
 	Updated_At      time.Time          `json:"updated_at" bson:"updated_at"`
 }
 
+// ReviewPages is the paging of the reviews of a product, a seller or a user.
+var ReviewPages = pagination.Spec{
+	Keys:        map[string]string{"reviewed_at": "reviewed_at", "rating": "rating"},
+	Default:     "-reviewed_at",
+	Tiebreaker:  "_id",
+	Types:       map[string]bsontype.Type{"reviewed_at": bsontype.DateTime, "rating": bsontype.Double, "_id": bsontype.ObjectID},
+	DefaultSize: 20,
+	MaxSize:     100,
+}
+
 type ReviewRepository interface {
 	InsertReview(ctx context.Context, input Review) (primitive.ObjectID, error)
 	UpdateReview(ctx context.Context, reviewID string, update bson.D) (*mongo.UpdateResult, error)
@@ -31,8 +43,9 @@ type ReviewRepository interface {
 	GetReviewById(ctx context.Context, reviewID string) (*Review, error)
 	GetReviewByProductId(ctx context.Context, productID string) (*Review, error)
 	GetAllReviewByProductId(ctx context.Context, productID, sellerEmail string) (*[]Review, error)
-	GetAllReviewByUserEmail(ctx context.Context, email string) (*[]Review, error)
-	GetAllReviewBySellerEmail(ctx context.Context, sellerEmail string) (*[]Review, error)
+	FindByProductId(ctx context.Context, productID, sellerEmail string, page pagination.Request) (*pagination.Page[Review], error)
+	FindByUserEmail(ctx context.Context, email string, page pagination.Request) (*pagination.Page[Review], error)
+	FindBySellerEmail(ctx context.Context, sellerEmail string, page pagination.Request) (*pagination.Page[Review], error)
 }
 
 type ReviewService interface {
@@ -40,8 +53,8 @@ type ReviewService interface {
 	UpdateReview(ctx context.Context, email, reviewID string, req *dto.AddReviewReq) error
 	DeleteReview(ctx context.Context, email, reviewID string) error
 	GetUserReviewById(ctx context.Context, email, reviewID string) (*dto.GetReviewRes, error)
-	GetAllReviewByUserEmail(ctx context.Context, email string) (*[]dto.GetReviewRes, error)
-	GetAllReviewBySellerEmail(ctx context.Context, email string) (*[]dto.GetReviewRes, error)
-	GetAllReviewByProductId(ctx context.Context, productID, sellerEmail string) (*[]dto.GetReviewRes, error)
+	GetAllReviewByUserEmail(ctx context.Context, email string, page pagination.Request) (*pagination.Page[dto.GetReviewRes], error)
+	GetAllReviewBySellerEmail(ctx context.Context, email string, page pagination.Request) (*pagination.Page[dto.GetReviewRes], error)
+	GetAllReviewByProductId(ctx context.Context, productID, sellerEmail string, page pagination.Request) (*pagination.Page[dto.GetReviewRes], error)
 	UpdateResponSeller(ctx context.Context, email, reviewID string, req *dto.ResponSellerReq) error
 }
@@ -0,0 +1,50 @@
+package domain
+
+// Roles are stored on the user and the seller documents and embedded in their tokens.
+const (
+	RoleUser   = "User"
+	RoleAdmin  = "Admin"
+	RoleSeller = "Seller"
+	// RoleSellerStaff is a seller account added by the seller owning a store
+	// to work on that store only, see StaffScope.
+	RoleSellerStaff = "Seller_Staff"
+)
+
+type Permission string
+
+const (
+	// PermissionAccount lets an account read and edit itself and log out.
+	PermissionAccount Permission = "account"
+	// PermissionShop covers the cart, orders, payments, refunds and reviews of a buyer.
+	PermissionShop Permission = "shop"
+	// PermissionManageStore covers the stores of a seller with their address and contact.
+	PermissionManageStore   Permission = "store:manage"
+	PermissionManageProduct Permission = "product:manage"
+	// PermissionFulfilOrder covers the seller orders, shipments, refunds and review responses.
+	PermissionFulfilOrder Permission = "order:fulfil"
+	PermissionViewReport  Permission = "report:view"
+	PermissionAdmin       Permission = "admin"
+)
+
+// RolePermissions is the permission matrix, a role not listed here can do nothing.
+var RolePermissions = map[string][]Permission{
+	RoleUser:        {PermissionAccount, PermissionShop},
+	RoleAdmin:       {PermissionAccount, PermissionAdmin},
+	RoleSeller:      {PermissionAccount, PermissionManageStore, PermissionManageProduct, PermissionFulfilOrder, PermissionViewReport},
+	RoleSellerStaff: {PermissionAccount, PermissionManageProduct, PermissionFulfilOrder},
+}
+
+// RolesWith returns the roles granted permission by RolePermissions.
+func RolesWith(permission Permission) []string {
+	var roles []string
+	for role, permissions := range RolePermissions {
+		for _, p := range permissions {
+			if p == permission {
+				roles = append(roles, role)
+				break
+			}
+		}
+	}
+
+	return roles
+}
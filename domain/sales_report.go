@@ -13,16 +13,25 @@ type Sales_Report struct {
 	ID            primitive.ObjectID `bson:"_id"`
 	Store_Id      string             `json:"store_id" bson:"store_id"`
 	Email         string             `json:"email" bson:"email"`
-	Total_Sales   int64              `json:"total_sales" bson:"total_sales"`
+	Total_Sales   float64            `json:"total_sales" bson:"total_sales"`
 	Total_Incomes float64            `json:"total_income" bson:"total_income"`
 	Products      []Product_Sales    `json:"products" bson:"products"`
 }
 
 type Product_Sales struct {
 	Product_Id     string  `json:"product_id" bson:"product_id"`
-	Total_Sales    int64   `json:"total_sales" bson:"total_sales"`
-	Stock          int32   `json:"stock" bson:"stock"`
+	Total_Sales    float64 `json:"total_sales" bson:"total_sales"`
+	Stock          float64 `json:"stock" bson:"stock"`
 	Average_Rating float32 `json:"average_rating" bson:"average_rating"`
+	// Variants breaks the sales down for a product sold in variants.
+	Variants []Variant_Sales `json:"variants" bson:"variants,omitempty"`
+}
+
+type Variant_Sales struct {
+	Variant_Id  string  `json:"variant_id" bson:"variant_id"`
+	Name        string  `json:"name" bson:"name"`
+	Total_Sales float64 `json:"total_sales" bson:"total_sales"`
+	Stock       float64 `json:"stock" bson:"stock"`
 }
 
 type SalesReportRepository interface {
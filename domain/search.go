@@ -0,0 +1,47 @@
+package domain
+
+import "context"
+
+// SearchDocument is the text of a product a ProductSearchIndex searches.
+type SearchDocument struct {
+	Product_Id  string `bson:"product_id"`
+	Store_Id    string `bson:"store_id"`
+	Name        string `bson:"name"`
+	Category    string `bson:"category"`
+	Description string `bson:"description"`
+	Store_Name  string `bson:"store_name"`
+}
+
+// SearchHit is a product matching a search, a higher Score is a better match.
+type SearchHit struct {
+	Product_Id string  `bson:"product_id"`
+	Score      float64 `bson:"score"`
+}
+
+// ProductSearchIndex ranks products by how well their name, category,
+// description and store name match a search. The index only knows the text of
+// a product, hidden products are left out when the products are fetched.
+type ProductSearchIndex interface {
+	// Index adds documents, or replaces them when they are already indexed.
+	Index(ctx context.Context, docs ...SearchDocument) error
+	Remove(ctx context.Context, productIDs ...string) error
+	// Search returns at most limit hits, best first. storeID limits the
+	// search to one store when it is not empty.
+	Search(ctx context.Context, text, storeID string, limit int) ([]SearchHit, error)
+	// Suggest returns at most limit product names for a search being typed.
+	Suggest(ctx context.Context, prefix string, limit int) ([]string, error)
+}
+
+type ProductSearchService interface {
+	// Search returns the ids of the products matching text, best first.
+	Search(ctx context.Context, text, storeID string) ([]string, error)
+	Suggest(ctx context.Context, prefix string) ([]string, error)
+	// SyncProducts indexes the products again, or removes them from the
+	// index when they were deleted.
+	SyncProducts(ctx context.Context, productIDs ...string) error
+	// SyncStore indexes the products of a store again, after its name changed.
+	SyncStore(ctx context.Context, storeID string) error
+	// Reindex builds the index again from every product and returns how many
+	// products it indexed.
+	Reindex(ctx context.Context) (int, error)
+}
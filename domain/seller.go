@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -26,6 +27,12 @@ type Seller struct {
 	Seller_Id       string             `json:"seller_id"`
 	Store_Id        string             `json:"store_id" bson:"store_id"`
 	Address_Details *Address           `json:"address" bson:"address"`
+	// Staff_Of is the store a RoleSellerStaff account works for.
+	Staff_Of string `json:"staff_of" bson:"staff_of,omitempty"`
+	// Suspended sellers can not log in and their products are hidden, see AdminService.
+	Suspended bool `json:"suspended"`
+	// Token_Version is kept by TokenRevocationStore, see SignedDetails.
+	Token_Version int `json:"-" bson:"token_version"`
 }
 
 type SellerRepository interface {
@@ -35,7 +42,14 @@ type SellerRepository interface {
 	FindSellerByEmail(ctx context.Context, email string) (*Seller, error)
 	FindSellerByStoreId(ctx context.Context, storeID string) (*Seller, error)
 	UpdateSeller(ctx context.Context, email string, update bson.D) (*mongo.UpdateResult, error)
+	ReplaceRefreshToken(ctx context.Context, email, oldToken, newToken string) (*mongo.UpdateResult, error)
 	AddStoreId(ctx context.Context, email string, storeID string) error
+	FindSellerById(ctx context.Context, sellerID string) (*Seller, error)
+	// SearchSellers matches query against the name and the email, an empty query returns every seller.
+	SearchSellers(ctx context.Context, query string, page pagination.Request) (*pagination.Page[Seller], error)
+	// FindStaff returns the staff accounts of the store.
+	FindStaff(ctx context.Context, storeID string) ([]Seller, error)
+	DeleteStaff(ctx context.Context, storeID, sellerID string) (*mongo.DeleteResult, error)
 }
 
 type SellerService interface {
@@ -44,4 +58,10 @@ type SellerService interface {
 	AuthenticateSeller(ctx context.Context, req *dto.SellerAuthReq) (*dto.SellerAuthRes, error)
 	GetSellerByEmail(ctx context.Context, email string) (*Seller, error)
 	UpdateSeller(ctx context.Context, email string, req *dto.SellerUpdateReq) (*dto.SellerUpdateRes, error)
+	// AddStaff adds a staff account to the store of the seller email, the
+	// account can log in right away.
+	AddStaff(ctx context.Context, email, storeID string, req *dto.SellerRegisterReq) (*dto.SellerRegisterRes, error)
+	GetStaff(ctx context.Context, email, storeID string) ([]dto.StaffRes, error)
+	// RemoveStaff deletes the staff account and revokes its tokens.
+	RemoveStaff(ctx context.Context, email, storeID, sellerID string) error
 }
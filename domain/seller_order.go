@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -16,6 +18,7 @@ type SellerOrder struct {
 	Ordered_At     time.Time          `json:"ordered_at" bson:"ordered_at"`
 	Updated_At     time.Time          `json:"updated_at" bson:"updated_at"`
 	Total_Price    float64            `json:"total_price" bson:"total_price"`
+	Shipping_Fee   float64            `json:"shipping_fee" bson:"shipping_fee"`
 	Payment_Status string             `json:"payment_status" bson:"payment_status"`
 	Items          []SellerOrderItem  `json:"items" bson:"items"`
 }
@@ -23,28 +26,65 @@ type SellerOrder struct {
 type SellerOrderItem struct {
 	User_Email       string   `json:"user_email" bson:"user_email"`
 	Product_Id       string   `json:"product_id" bson:"product_id"`
+	Variant_Id       string   `json:"variant_id" bson:"variant_id,omitempty"`
+	Variant_Name     string   `json:"variant_name" bson:"variant_name,omitempty"`
 	Product_Name     string   `json:"product_name" bson:"product_name"`
 	Product_Image    []string `json:"product_image" bson:"product_image"`
-	Quantity         int      `json:"quantity" bson:"quantity"`
+	Quantity         float64  `json:"quantity" bson:"quantity"`
+	Unit             string   `json:"unit" bson:"unit"`
+	Ordered_Quantity float64  `json:"ordered_quantity" bson:"ordered_quantity,omitempty"`
 	Price            float64  `json:"price" bson:"price"`
 	Status           string   `json:"status" bson:"status"`
 	Address_Shipping Address  `json:"address_shipping" bson:"address_shipping"`
+	// Status_History is appended by OrderStatusService on every status change.
+	Status_History []OrderStatusHistory `json:"status_history" bson:"status_history,omitempty"`
+	// Shipment is set once the seller adds the tracking number of the item.
+	Shipment *Shipment `json:"shipment" bson:"shipment,omitempty"`
+	// Charge_Id is the extra payment of a weighed item packed heavier than
+	// ordered, the item is not shipped before it is paid.
+	Charge_Id string `json:"charge_id" bson:"charge_id,omitempty"`
 }
 
+// SellerOrderPages is the paging of the orders of a seller.
+var SellerOrderPages = pagination.Spec{
+	Keys:        map[string]string{"ordered_at": "ordered_at", "total_price": "total_price"},
+	Default:     "-ordered_at",
+	Tiebreaker:  "_id",
+	Types:       map[string]bsontype.Type{"ordered_at": bsontype.DateTime, "total_price": bsontype.Double, "_id": bsontype.ObjectID},
+	DefaultSize: 20,
+	MaxSize:     100,
+}
+
+// Is reports whether the item is the variant variantID of the product
+// productID, see OrderItem.Is.
+func (item SellerOrderItem) Is(productID, variantID string) bool {
+	return item.Product_Id == productID && item.Variant_Id == variantID
+}
+
+// SellerOrderRepository methods accept the ctx handed out by Transactor.WithTransaction,
+// writes made with it join the running transaction.
 type SellerOrderRepository interface {
 	CreateOrderSeller(ctx context.Context, order SellerOrder) (primitive.ObjectID, error)
 	GetAllSellerOrders(ctx context.Context, email string) (*[]SellerOrder, error)
+	// FindByEmail returns the orders of the seller email, see SellerOrderPages.
+	FindByEmail(ctx context.Context, email string, page pagination.Request) (*pagination.Page[SellerOrder], error)
 	GetSellerOrderById(ctx context.Context, orderID string) (*[]SellerOrder, error)
 	GetSellerOrderByEmailAndId(ctx context.Context, email, orderID string) (*SellerOrder, error)
 	UpdateOrderSeller(ctx context.Context, orderID string, req *dto.OrderSellerUpdateReq) (*mongo.UpdateResult, error)
 	UpdateOrderSellerByEmail(ctx context.Context, email string, req *dto.OrderSellerUpdateReq) (*mongo.UpdateResult, error)
 	UpdateStatusOrderSeller(ctx context.Context, orderID, productID string, req *dto.OrderStatusUpdateReq) (*mongo.UpdateResult, error)
-	DeleteItem(ctx context.Context, email, orderID, productID string) (*mongo.UpdateResult, error)
+	DeleteItem(ctx context.Context, email, orderID, productID, variantID string) (*mongo.UpdateResult, error)
+	DeleteByOrderId(ctx context.Context, orderID string) (*mongo.DeleteResult, error)
+	UpdateTotalPrice(ctx context.Context, email, orderID string, value float64) (*mongo.UpdateResult, error)
+	// UpdateShippingFee adds value to the shipping fee and the total price of the seller order.
+	UpdateShippingFee(ctx context.Context, email, orderID string, value float64) (*mongo.UpdateResult, error)
+	UpdateItemStatus(ctx context.Context, orderID, productID, variantID string, history OrderStatusHistory) (*mongo.UpdateResult, error)
+	UpdateItemQuantity(ctx context.Context, orderID, productID, variantID string, ordered, packed float64, chargeID string, updateAt time.Time) (*mongo.UpdateResult, error)
 }
 
 type SellerOrderService interface {
-	GetAllSellerOrders(ctx context.Context, email string) (*[]SellerOrder, error)
+	GetAllSellerOrders(ctx context.Context, email string, page pagination.Request) (*pagination.Page[SellerOrder], error)
 	GetSellerOrderByEmailAndId(ctx context.Context, email, orderID string) (*SellerOrder, error)
-	UpdateSellerAndUserOrderStatus(ctx context.Context, email, orderID, productID string, req *dto.OrderStatusUpdateReq) error
-	CancelOrder(ctx context.Context, email, orderID, productID string) error
+	UpdateSellerAndUserOrderStatus(ctx context.Context, email, orderID, productID, variantID string, req *dto.OrderStatusUpdateReq) error
+	CancelOrder(ctx context.Context, email, orderID, productID, variantID string) error
 }
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/dto"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrTrackingNotFound is returned by a CarrierTracker when the carrier does not know the tracking number.
+var ErrTrackingNotFound = errors.New("tracking number not found")
+
+const (
+	TrackingInTransit = "IN_TRANSIT"
+	TrackingDelivered = "DELIVERED"
+)
+
+// Shipment is the parcel a SellerOrderItem was sent in.
+type Shipment struct {
+	Carrier            string          `json:"carrier" bson:"carrier"`
+	Tracking_Number    string          `json:"tracking_number" bson:"tracking_number"`
+	Estimated_Delivery time.Time       `json:"estimated_delivery" bson:"estimated_delivery"`
+	Delivered_At       *time.Time      `json:"delivered_at" bson:"delivered_at"`
+	Events             []TrackingEvent `json:"events" bson:"events"`
+	Created_At         time.Time       `json:"created_at" bson:"created_at"`
+	Updated_At         time.Time       `json:"updated_at" bson:"updated_at"`
+}
+
+// TrackingEvent is one step of a shipment as reported by the carrier.
+type TrackingEvent struct {
+	Status      string    `json:"status" bson:"status"`
+	Description string    `json:"description" bson:"description"`
+	Location    string    `json:"location" bson:"location"`
+	Occurred_At time.Time `json:"occurred_at" bson:"occurred_at"`
+}
+
+// TrackingInfo is what a carrier knows about a tracking number, Events are ordered oldest first.
+type TrackingInfo struct {
+	Estimated_Delivery time.Time
+	Delivered_At       *time.Time
+	Events             []TrackingEvent
+}
+
+// CarrierTracker looks up tracking numbers at the carriers.
+type CarrierTracker interface {
+	// Track returns ErrTrackingNotFound when the carrier does not know trackingNumber.
+	Track(ctx context.Context, carrier, trackingNumber string) (*TrackingInfo, error)
+}
+
+// FakeCarrierTracker is an in-process carrier for running shipments offline.
+type FakeCarrierTracker interface {
+	CarrierTracker
+	// Push adds event to the timeline of trackingNumber, a DELIVERED event
+	// marks the parcel as delivered.
+	Push(ctx context.Context, carrier, trackingNumber string, event TrackingEvent) error
+}
+
+// ItemShipment is the shipment of one item as shown to the buyer.
+type ItemShipment struct {
+	Product_Id   string    `json:"product_id"`
+	Variant_Id   string    `json:"variant_id,omitempty"`
+	Product_Name string    `json:"product_name"`
+	Status       string    `json:"status"`
+	Shipment     *Shipment `json:"shipment"`
+}
+
+// ShipmentRepository keeps the shipments inside the seller orders.
+type ShipmentRepository interface {
+	UpdateShipment(ctx context.Context, email, orderID, productID, variantID string, shipment Shipment) (*mongo.UpdateResult, error)
+	// FindInTransit returns the seller orders holding at least one shipment that has not been delivered.
+	FindInTransit(ctx context.Context) (*[]SellerOrder, error)
+}
+
+type ShipmentService interface {
+	AddShipment(ctx context.Context, email, orderID, productID, variantID string, req *dto.ShipmentReq) error
+	UpdateShipment(ctx context.Context, email, orderID, productID, variantID string, req *dto.ShipmentReq) error
+	GetOrderShipments(ctx context.Context, email, orderID string) (*[]ItemShipment, error)
+	// RefreshTracking asks the carriers for news on every shipment in transit
+	// and returns how many shipments changed.
+	RefreshTracking(ctx context.Context) (int, error)
+}
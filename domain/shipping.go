@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrNoShippingRate is returned by a ShippingRateProvider that can not ship between two cities.
+var ErrNoShippingRate = errors.New("no shipping rate for this route and weight")
+
+// ShippingRate is one row of the rate table, it ships parcels up to Max_Weight
+// grams from Origin_City to Destination_City for Fee.
+type ShippingRate struct {
+	ID               primitive.ObjectID `bson:"_id"`
+	Origin_City      string             `json:"origin_city" bson:"origin_city"`
+	Destination_City string             `json:"destination_city" bson:"destination_city"`
+	Max_Weight       int                `json:"max_weight" bson:"max_weight"`
+	Fee              float64            `json:"fee" bson:"fee"`
+}
+
+type ShippingRateRepository interface {
+	Insert(ctx context.Context, rate ShippingRate) (primitive.ObjectID, error)
+	// FindRates returns the rates of a route, cities match case-insensitively,
+	// lightest weight bracket first.
+	FindRates(ctx context.Context, origin, destination string) (*[]ShippingRate, error)
+}
+
+// ShippingRateProvider prices a parcel of weight grams.
+type ShippingRateProvider interface {
+	// Rate returns ErrNoShippingRate when the route or the weight is not served.
+	Rate(ctx context.Context, origin, destination string, weight int) (float64, error)
+}
+
+// StoreShipping is the parcel a single store sends for an order.
+type StoreShipping struct {
+	Store_Id    string  `json:"store_id"`
+	Store_Name  string  `json:"store_name"`
+	Origin_City string  `json:"origin_city"`
+	Weight      int     `json:"weight"`
+	Fee         float64 `json:"fee"`
+}
+
+type ShippingQuote struct {
+	Destination_City string          `json:"destination_city"`
+	Subtotal         float64         `json:"subtotal"`
+	Shipping_Fee     float64         `json:"shipping_fee"`
+	Total            float64         `json:"total"`
+	Stores           []StoreShipping `json:"stores"`
+}
+
+type ShippingService interface {
+	// Quote prices the shipping of items to destination, one parcel per store.
+	Quote(ctx context.Context, destination Address, items []OrderItem) (*ShippingQuote, error)
+	// QuoteCart quotes the selected items of the cart of the user to their address.
+	QuoteCart(ctx context.Context, email string) (*ShippingQuote, error)
+}
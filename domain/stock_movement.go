@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type StockMovementType string
+
+const (
+	// MovementRestock is stock received by the seller, with a new product or a batch.
+	MovementRestock StockMovementType = "RESTOCK"
+	// MovementReservation and MovementRelease are stock taken by a checkout and
+	// given back when the order does not go through. Paid stock is not moved
+	// again, it left the product at the reservation.
+	MovementReservation StockMovementType = "RESERVATION"
+	MovementRelease     StockMovementType = "RELEASE"
+	// MovementShipment is the difference between the reserved quantity of an
+	// item and the quantity packed for shipping.
+	MovementShipment StockMovementType = "SHIPMENT"
+	// MovementAdjustment is a change made by hand by the seller.
+	MovementAdjustment StockMovementType = "ADJUSTMENT"
+	// MovementReturn is the stock of a refunded item that never left the store.
+	MovementReturn StockMovementType = "RETURN"
+	// MovementExpiry is the stock of a batch withdrawn before it expires.
+	MovementExpiry StockMovementType = "EXPIRY"
+)
+
+// StockMovement is one change of the stock of a product or variant. Movements
+// are only ever added, the stock of a product is the sum of its movements.
+type StockMovement struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	Movement_Id string             `json:"movement_id" bson:"movement_id"`
+	Store_Id    string             `json:"store_id" bson:"store_id"`
+	Product_Id  string             `json:"product_id" bson:"product_id"`
+	Variant_Id  string             `json:"variant_id" bson:"variant_id"`
+	Type        StockMovementType  `json:"type" bson:"type"`
+	// Quantity is added to the stock, it is negative when stock goes out.
+	Quantity float64 `json:"quantity" bson:"quantity"`
+	// Actor is the email of who moved the stock, or SYSTEM.
+	Actor  string `json:"actor" bson:"actor"`
+	Reason string `json:"reason" bson:"reason"`
+	// Ref_Id is the order, batch or refund behind the movement.
+	Ref_Id     string    `json:"ref_id" bson:"ref_id"`
+	Created_At time.Time `json:"created_at" bson:"created_at"`
+}
+
+// StockTotal is the sum of the movements of a product or variant.
+type StockTotal struct {
+	Product_Id string  `bson:"product_id"`
+	Variant_Id string  `bson:"variant_id"`
+	Quantity   float64 `bson:"quantity"`
+}
+
+// MovementPages is the paging of the movements of a product.
+var MovementPages = pagination.Spec{
+	Keys:        map[string]string{"created_at": "created_at"},
+	Default:     "-created_at",
+	Tiebreaker:  "_id",
+	Types:       map[string]bsontype.Type{"created_at": bsontype.DateTime, "_id": bsontype.ObjectID},
+	DefaultSize: 20,
+	MaxSize:     100,
+}
+
+type StockMovementRepository interface {
+	Insert(ctx context.Context, movement StockMovement) (primitive.ObjectID, error)
+	// FindByProductId returns the latest movements first.
+	FindByProductId(ctx context.Context, storeID, productID string, page pagination.Request) (*pagination.Page[StockMovement], error)
+	Totals(ctx context.Context) (*[]StockTotal, error)
+}
+
+type StockLedgerService interface {
+	// Record writes a movement the stock has already been changed by, inside
+	// the transaction of the change when there is one.
+	Record(ctx context.Context, movement StockMovement) error
+	// AdjustStock changes the stock of a product by hand.
+	AdjustStock(ctx context.Context, email, storeID, productID string, req *dto.StockAdjustmentReq) error
+	GetMovements(ctx context.Context, email, storeID, productID string, page pagination.Request) (*pagination.Page[StockMovement], error)
+	// Reconcile compares the stock of every product with the sum of its
+	// movements and returns the ones that differ. opening first records the
+	// current stock of products without any movement as their opening
+	// balance, fix sets the stock of the products that differ to their ledger.
+	Reconcile(ctx context.Context, opening, fix bool) (*[]dto.StockDrift, error)
+}
@@ -2,9 +2,11 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -22,6 +24,23 @@ type Store struct {
 	Store_Id        string             `json:"store_id" bson:"store_id"`
 	Contact_Details *Contact           `json:"contact" bson:"contact"`
 	Address_Details *Address           `json:"address" bson:"address"`
+	// Suspended stores are hidden from guests together with their products.
+	Suspended bool `json:"suspended" bson:"suspended"`
+}
+
+// ErrStoreSuspended is returned when the seller of a suspended store logs in
+// or changes its products.
+var ErrStoreSuspended = errors.New("the store is suspended")
+
+// AnySuspended reports whether one of stores is suspended.
+func AnySuspended(stores []Store) bool {
+	for _, store := range stores {
+		if store.Suspended {
+			return true
+		}
+	}
+
+	return false
 }
 
 type StoreRepository interface {
@@ -29,9 +48,15 @@ type StoreRepository interface {
 	GetStore(ctx context.Context, storeID string, email ...string) (*Store, error)
 	GetStoreByEmail(ctx context.Context, email string) (*Store, error)
 	CheckNameExists(ctx context.Context, name string) (bool, error)
+	// CountByImage counts the stores with the logo or banner url.
+	CountByImage(ctx context.Context, url string) (int64, error)
 	UpdateStore(ctx context.Context, email, storeID string, update bson.D) (*mongo.UpdateResult, error)
 	RemoveStore(ctx context.Context, email, storeID string) (*mongo.DeleteResult, error)
+	// GetStoreByQuery only returns the stores that are not suspended.
 	GetStoreByQuery(ctx context.Context, query string) ([]Store, error)
+	GetStoresByEmail(ctx context.Context, email string) ([]Store, error)
+	// SearchStores returns suspended stores too.
+	SearchStores(ctx context.Context, query string, page pagination.Request) (*pagination.Page[Store], error)
 }
 
 type StoreService interface {
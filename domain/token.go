@@ -5,16 +5,46 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+const (
+	AccountUser   = "USER"
+	AccountSeller = "SELLER"
+)
+
 type SignedDetails struct {
 	Email      string
 	First_Name string
 	Last_Name  string
 	Uid        string
+	// Account tells whether Uid is a user or a seller id.
+	Account string
+	// Role is only set on access tokens, see RolePermissions.
+	Role string
+	// Family is only set on refresh tokens, every token rotated from the same
+	// login shares it.
+	Family string
+	// Token_Version is the token version of the account when the token was
+	// issued, logging out all sessions bumps it and rejects older tokens.
+	Token_Version int
+	// Staff is only set on access tokens of RoleSellerStaff.
+	Staff *StaffScope `json:",omitempty"`
 	jwt.StandardClaims
 }
 
+// StaffScope is the store a seller staff account works for.
+type StaffScope struct {
+	Store_Id string
+	// Store_Email is the email of the seller owning the store, the staff act
+	// for that seller on the store and its orders.
+	Store_Email string
+}
+
 type TokenService interface {
-	GenerateAllTokens(email string, firstname string, lastname string, uid string) (signedToken string, signedRefreshToken string, err error)
+	// GenerateAllTokens starts a new refresh token family, e.g. on login.
+	GenerateAllTokens(email string, firstname string, lastname string, uid string, account string, role string, staff *StaffScope, tokenVersion int) (signedToken string, signedRefreshToken string, err error)
+	// RotateTokens issues a new pair in the family of the refresh token that was
+	// exchanged, with the current role and staff scope of the account.
+	RotateTokens(refreshClaims *SignedDetails, firstname string, lastname string, role string, staff *StaffScope) (signedToken string, signedRefreshToken string, err error)
 	UpdateRefreshToken(signedRefreshToken string, userId string, usercol *mongo.Collection)
 	ValidateToken(signedToken string) (claims *SignedDetails, msg string)
+	ValidateRefreshToken(signedRefreshToken string) (*SignedDetails, error)
 }
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrTokenRevoked = errors.New("token has been revoked, please log in again")
+
+// TokenRevocationStore keeps the tokens that were logged out before they expired.
+type TokenRevocationStore interface {
+	// Revoke rejects the token with the id jti until it expires anyway.
+	Revoke(jti string, expiresAt time.Time) error
+	IsRevoked(jti string) (bool, error)
+	// TokenVersion is the current token version of the account, tokens issued
+	// with an older version are rejected.
+	TokenVersion(account string, uid string) (int, error)
+	// BumpTokenVersion rejects every token issued to the account so far and
+	// returns the new version.
+	BumpTokenVersion(account string, uid string) (int, error)
+}
+
+// TokenVersionRepository keeps the token versions on the accounts, so they
+// outlive the cache.
+type TokenVersionRepository interface {
+	// TokenVersion is 0 for an account that was never bumped.
+	TokenVersion(ctx context.Context, account string, uid string) (int, error)
+	// SaveTokenVersion raises the version of the account to version, a lower
+	// version is ignored so bumps saved out of order keep the highest.
+	SaveTokenVersion(ctx context.Context, account string, uid string, version int) error
+}
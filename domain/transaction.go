@@ -0,0 +1,10 @@
+package domain
+
+import "context"
+
+// Transactor runs fn as one unit of work. The ctx handed to fn is bound to the
+// running transaction, so every repository call made with it commits or rolls
+// back together.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Unit is the unit of measure a product is priced and sold in, the price of a
+// product is the price of one unit and quantities are counted in units.
+type Unit string
+
+const (
+	UnitPiece    Unit = "piece"
+	UnitGram     Unit = "gram"
+	UnitKilogram Unit = "kg"
+	UnitLitre    Unit = "litre"
+	UnitBundle   Unit = "bundle"
+)
+
+// quantityEpsilon absorbs the rounding of float quantities, e.g. 0.1 + 0.2.
+const quantityEpsilon = 1e-9
+
+// UnitOf returns the unit of a stored product, products saved before units
+// existed are sold by the piece.
+func UnitOf(unit string) Unit {
+	if unit == "" {
+		return UnitPiece
+	}
+
+	return Unit(unit)
+}
+
+func (u Unit) Valid() bool {
+	switch u {
+	case UnitPiece, UnitGram, UnitKilogram, UnitLitre, UnitBundle:
+		return true
+	}
+
+	return false
+}
+
+// Countable reports whether the unit can only be sold whole.
+func (u Unit) Countable() bool {
+	return u == UnitPiece || u == UnitBundle
+}
+
+// Weighed reports whether the quantity of an item is only known once it is
+// packed, the seller then enters the packed quantity before shipping.
+func (u Unit) Weighed() bool {
+	return u == UnitGram || u == UnitKilogram
+}
+
+type QuantityError struct {
+	Unit     Unit
+	Min      float64
+	Step     float64
+	Quantity float64
+}
+
+func (e *QuantityError) Error() string {
+	if e.Quantity < e.Min {
+		return fmt.Sprintf("quantity %g %s is less than the minimum of %g %s", e.Quantity, e.Unit, e.Min, e.Unit)
+	}
+
+	return fmt.Sprintf("quantity %g %s is not a multiple of %g %s", e.Quantity, e.Unit, e.Step, e.Unit)
+}
+
+// QuantityRule is how much of a product may be ordered. A zero Step lets a
+// measured unit be ordered in any amount and a countable unit by one, a zero
+// Min is one Step.
+type QuantityRule struct {
+	Unit Unit
+	Min  float64
+	Step float64
+}
+
+func (r QuantityRule) step() float64 {
+	if r.Step == 0 && r.Unit.Countable() {
+		return 1
+	}
+
+	return r.Step
+}
+
+func (r QuantityRule) min() float64 {
+	if r.Min == 0 {
+		return r.step()
+	}
+
+	return r.Min
+}
+
+// Check returns a *QuantityError when quantity can not be ordered.
+func (r QuantityRule) Check(quantity float64) error {
+	min, step := r.min(), r.step()
+	err := &QuantityError{Unit: r.Unit, Min: min, Step: step, Quantity: quantity}
+
+	if quantity <= 0 || quantity < min-quantityEpsilon {
+		return err
+	}
+
+	if step > 0 {
+		steps := math.Round(quantity / step)
+		if math.Abs(quantity-steps*step) > quantityEpsilon*math.Max(1, quantity) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Floor returns the largest quantity up to max that can be ordered, 0 when
+// max is below the minimum.
+func (r QuantityRule) Floor(max float64) float64 {
+	if r.Check(max) == nil {
+		return max
+	}
+
+	quantity := max
+	if step := r.step(); step > 0 {
+		// steps are counted from zero, as Check does
+		quantity = math.Floor(max/step+quantityEpsilon) * step
+	}
+
+	if r.Check(quantity) != nil {
+		return 0
+	}
+
+	return quantity
+}
+
+// Validate checks the rule a seller sets on a product.
+func (r QuantityRule) Validate() error {
+	if !r.Unit.Valid() {
+		return fmt.Errorf("invalid unit %q", r.Unit)
+	}
+
+	if r.Min < 0 || r.Step < 0 {
+		return errors.New("minimum quantity and quantity step can not be negative")
+	}
+
+	if r.Unit.Countable() && (r.Min != math.Trunc(r.Min) || r.Step != math.Trunc(r.Step)) {
+		return fmt.Errorf("a product sold by the %s is only sold whole", r.Unit)
+	}
+
+	return nil
+}
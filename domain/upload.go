@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/dto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrUnsupportedImage = errors.New("the file is not a jpeg, png or gif image")
+	ErrImageTooLarge    = errors.New("the image is too large")
+)
+
+// BlobStorage keeps uploaded files under a key and serves them at URL(key).
+type BlobStorage interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// Delete does not fail when key does not exist.
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// Upload is an image stored in BlobStorage, with its thumbnail.
+type Upload struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	Upload_Id     string             `json:"upload_id" bson:"upload_id"`
+	Email         string             `json:"email" bson:"email"`
+	Key           string             `json:"key" bson:"key"`
+	Url           string             `json:"url" bson:"url"`
+	Thumbnail_Key string             `json:"thumbnail_key" bson:"thumbnail_key"`
+	Thumbnail_Url string             `json:"thumbnail_url" bson:"thumbnail_url"`
+	Content_Type  string             `json:"content_type" bson:"content_type"`
+	Size          int64              `json:"size" bson:"size"`
+	Width         int                `json:"width" bson:"width"`
+	Height        int                `json:"height" bson:"height"`
+	Created_At    time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type UploadRepository interface {
+	Insert(ctx context.Context, upload Upload) (primitive.ObjectID, error)
+	// FindByUrls returns the uploads whose url or thumbnail url is in urls.
+	FindByUrls(ctx context.Context, urls []string) (*[]Upload, error)
+	Delete(ctx context.Context, uploadID string) error
+}
+
+type UploadService interface {
+	// UploadImage stores an image sent by email and a thumbnail of it.
+	UploadImage(ctx context.Context, email string, file io.Reader) (*dto.UploadRes, error)
+	// Release deletes the uploads behind urls that no product or store uses
+	// anymore, urls that are not uploads are skipped.
+	Release(ctx context.Context, urls ...string) error
+}
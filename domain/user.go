@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -26,6 +27,10 @@ type User struct {
 	EmailVerified   bool               `json:"email_verified" bson:"email_verified"`
 	Oauth_Id        string             `json:"oauth_id"`
 	Address_Details *Address           `json:"address" bson:"address"`
+	// Suspended users can not log in, see AdminService.
+	Suspended bool `json:"suspended" bson:"suspended"`
+	// Token_Version is kept by TokenRevocationStore, see SignedDetails.
+	Token_Version int `json:"-" bson:"token_version"`
 }
 
 type UserRepository interface {
@@ -35,6 +40,9 @@ type UserRepository interface {
 	FindUserByEmail(ctx context.Context, email string) (*User, error)
 	FindUserById(ctx context.Context, userId string) (*User, error)
 	UpdateUser(ctx context.Context, email string, update bson.D) (*mongo.UpdateResult, error)
+	ReplaceRefreshToken(ctx context.Context, email, oldToken, newToken string) (*mongo.UpdateResult, error)
+	// SearchUsers matches query against the name and the email, an empty query returns every user.
+	SearchUsers(ctx context.Context, query string, page pagination.Request) (*pagination.Page[User], error)
 }
 
 type UserService interface {
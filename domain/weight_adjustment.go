@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/IndraSty/GreenBasket/dto"
+)
+
+// WeightAdjustmentService settles a weighed item once the seller has packed
+// it. The item is priced at the packed quantity, the buyer gets the difference
+// back as an adjustment Refund or pays it as an extra charge.
+type WeightAdjustmentService interface {
+	// AdjustPackedQuantity is allowed once per item, while it is PROCESSED.
+	AdjustPackedQuantity(ctx context.Context, email, orderID, productID, variantID string, req *dto.PackedQuantityReq) (*dto.PackedQuantityRes, error)
+}
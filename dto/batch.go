@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+type BatchReq struct {
+	// Variant_Id is required when the product has variants.
+	Variant_Id string  `json:"variant_id"`
+	Quantity   float64 `json:"quantity" valid:"required"`
+	// Received_At defaults to now.
+	Received_At time.Time `json:"received_at"`
+	Expires_At  time.Time `json:"expires_at"`
+}
+
+type BatchRes struct {
+	Batch_Id string `json:"batch_id"`
+}
+
+type ExpiringStockRes struct {
+	Batch_Id     string    `json:"batch_id"`
+	Product_Id   string    `json:"product_id"`
+	Product_Name string    `json:"product_name"`
+	Variant_Id   string    `json:"variant_id,omitempty"`
+	Quantity     float64   `json:"quantity"`
+	Expires_At   time.Time `json:"expires_at"`
+	Days_Left    int       `json:"days_left"`
+}
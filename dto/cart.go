@@ -4,26 +4,48 @@ import "time"
 
 type GetCartItemRes struct {
 	Product_Id    string    `json:"product_id" bson:"product_id"`
+	Variant_Id    string    `json:"variant_id" bson:"variant_id"`
+	Variant_Name  string    `json:"variant_name" bson:"variant_name"`
 	Product_Name  string    `json:"product_name" bson:"product_name"`
 	Product_Image []string  `json:"product_image" bson:"product_image"`
 	Store_Name    string    `json:"store_name" bson:"store_name"`
-	Quantity      int       `json:"quantity" bson:"quantity"`
+	Quantity      float64   `json:"quantity" bson:"quantity"`
+	Unit          string    `json:"unit" bson:"unit"`
 	AddedAt       time.Time `json:"added_at" bson:"added_at"`
 	Selected      bool      `json:"selected" bson:"selected"`
 	Price         float64   `json:"price" bson:"price"`
 }
 
 type AddCartReq struct {
-	Quantity int `json:"quantity"`
+	Quantity float64 `json:"quantity"`
+	// Variant_Id is required when the product has variants.
+	Variant_Id string `json:"variant_id"`
 }
 
 type CartItemEditReq struct {
-	Quantity int  `json:"quantity"`
-	Selected bool `json:"selected"`
+	Quantity float64 `json:"quantity"`
+	Selected bool    `json:"selected"`
 }
 
 type CartItemEditRepo struct {
-	Quantity    int     `json:"quantity"`
+	Quantity    float64 `json:"quantity"`
 	Selected    bool    `json:"selected"`
 	Total_Price float64 `json:"total_price"`
 }
+
+// CartMergeRes is what became of the items of a guest cart merged into the
+// cart of a user at login.
+type CartMergeRes struct {
+	Items []CartMergeItem `json:"items"`
+}
+
+type CartMergeItem struct {
+	Product_Id   string `json:"product_id"`
+	Variant_Id   string `json:"variant_id"`
+	Product_Name string `json:"product_name"`
+	// Requested is the quantity of the guest cart, Quantity the quantity in
+	// the cart of the user after the merge, 0 for a dropped item.
+	Requested float64 `json:"requested"`
+	Quantity  float64 `json:"quantity"`
+	Result    string  `json:"result"`
+}
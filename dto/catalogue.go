@@ -0,0 +1,49 @@
+package dto
+
+// CatalogueQuery is a guest query of the catalogue, every filter is optional
+// and the filters are combined.
+type CatalogueQuery struct {
+	// Key is searched in the name, category, description and store name.
+	Key         string
+	Category    string
+	Descendants bool
+	// Min_Price and Max_Price are ignored when 0.
+	Min_Price  float64
+	Max_Price  float64
+	Min_Rating float64
+	In_Stock   bool
+	City       string
+	Store_Id   string
+}
+
+type FacetCount struct {
+	Value string `json:"value" bson:"_id"`
+	Count int    `json:"count" bson:"count"`
+}
+
+// PriceFacet counts the products priced from Min up to Max, Max is 0 for the
+// last bucket.
+type PriceFacet struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// RatingFacet counts the products rated Min or more.
+type RatingFacet struct {
+	Min   float64 `json:"min"`
+	Count int     `json:"count"`
+}
+
+// CatalogueFacets are counted over every product matching the query, not
+// only the page.
+type CatalogueFacets struct {
+	Categories []FacetCount  `json:"categories"`
+	Prices     []PriceFacet  `json:"prices"`
+	Ratings    []RatingFacet `json:"ratings"`
+}
+
+type CatalogueRes struct {
+	PagedProducts
+	Facets CatalogueFacets `json:"facets"`
+}
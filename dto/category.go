@@ -0,0 +1,18 @@
+package dto
+
+type CategoryReq struct {
+	// Slug is made from the default locale name when it is empty.
+	Slug       string            `json:"slug"`
+	Parent     string            `json:"parent"`
+	Names      map[string]string `json:"names" valid:"required"`
+	Icon       string            `json:"icon"`
+	Sort_Order int               `json:"sort_order"`
+}
+
+type CategoryRes struct {
+	Slug       string        `json:"slug"`
+	Name       string        `json:"name"`
+	Icon       string        `json:"icon"`
+	Sort_Order int           `json:"sort_order"`
+	Children   []CategoryRes `json:"children"`
+}
@@ -0,0 +1,26 @@
+package dto
+
+type MidtransNotification struct {
+	TransactionTime   string           `json:"transaction_time"`
+	TransactionStatus string           `json:"transaction_status"`
+	TransactionID     string           `json:"transaction_id"`
+	StatusMessage     string           `json:"status_message"`
+	StatusCode        string           `json:"status_code"`
+	SignatureKey      string           `json:"signature_key"`
+	PaymentType       string           `json:"payment_type"`
+	OrderID           string           `json:"order_id"`
+	MerchantID        string           `json:"merchant_id"`
+	GrossAmount       string           `json:"gross_amount"`
+	FraudStatus       string           `json:"fraud_status"`
+	Currency          string           `json:"currency"`
+	RefundAmount      string           `json:"refund_amount"`
+	Refunds           []MidtransRefund `json:"refunds"`
+}
+
+type MidtransRefund struct {
+	RefundChargebackID int64  `json:"refund_chargeback_id"`
+	RefundAmount       string `json:"refund_amount"`
+	CreatedAt          string `json:"created_at"`
+	Reason             string `json:"reason"`
+	RefundKey          string `json:"refund_key"`
+}
@@ -1,6 +1,10 @@
 package dto
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type InsertOrderRes struct {
 	InsertId primitive.ObjectID
@@ -14,3 +18,24 @@ type OrderSellerUpdateReq struct {
 type OrderStatusUpdateReq struct {
 	Status string `json:"status" bson:"status"`
 }
+
+type ShipmentReq struct {
+	Carrier            string    `json:"carrier"`
+	Tracking_Number    string    `json:"tracking_number"`
+	Estimated_Delivery time.Time `json:"estimated_delivery"`
+}
+
+type PackedQuantityReq struct {
+	Quantity float64 `json:"quantity" valid:"required"`
+}
+
+// PackedQuantityRes has a negative Amount when the buyer gets money back, a
+// positive Amount is paid on the Snap_Url of the extra charge Charge_Id.
+type PackedQuantityRes struct {
+	Ordered_Quantity float64 `json:"ordered_quantity"`
+	Packed_Quantity  float64 `json:"packed_quantity"`
+	Amount           float64 `json:"amount"`
+	Refund_Id        string  `json:"refund_id,omitempty"`
+	Charge_Id        string  `json:"charge_id,omitempty"`
+	Snap_Url         string  `json:"snap_url,omitempty"`
+}
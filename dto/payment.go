@@ -5,9 +5,11 @@ type PaymentRes struct {
 }
 
 type PaymentReq struct {
-	OrderID string  `json:"-"`
-	UserID  string  `json:"-"`
-	Amount  float64 `json:"amount"`
+	OrderID         string `json:"-"`
+	UserID          string `json:"-"`
+	Parent_Order_Id string `json:"-"`
+	// Amount is only set for an extra charge, an order is charged its total price.
+	Amount float64 `json:"-"`
 }
 
 type UpdatePaymentReq struct {
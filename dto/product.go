@@ -8,34 +8,73 @@ import (
 )
 
 type GetProductRes struct {
-	Name           string    `json:"name"`
-	Description    string    `json:"description"`
-	Price          float64   `json:"price"`
-	Stok           int       `json:"stock"`
-	Average_Rating float32   `json:"average_rating"`
-	Total_Sales    int64     `json:"total_sales"`
-	Product_id     string    `json:"product_id"`
-	Category       string    `json:"category"`
-	Created_at     time.Time `json:"created_at"`
-	Store_Name     string    `json:"store_name"`
-	City           string    `json:"city"`
-	Images         []string  `json:"images"`
+	Name           string       `json:"name"`
+	Description    string       `json:"description"`
+	Price          float64      `json:"price"`
+	Stok           float64      `json:"stock"`
+	Weight         int          `json:"weight"`
+	Unit           string       `json:"unit"`
+	Min_Quantity   float64      `json:"min_quantity"`
+	Quantity_Step  float64      `json:"quantity_step"`
+	Discount       float64      `json:"discount"`
+	Average_Rating float32      `json:"average_rating"`
+	Total_Sales    float64      `json:"total_sales"`
+	Product_id     string       `json:"product_id"`
+	Category       string       `json:"category"`
+	Created_at     time.Time    `json:"created_at"`
+	Store_Name     string       `json:"store_name"`
+	City           string       `json:"city"`
+	Images         []string     `json:"images"`
+	Variants       []VariantRes `json:"variants,omitempty"`
+}
+
+type VariantRes struct {
+	Variant_Id string            `json:"variant_id"`
+	Name       string            `json:"name"`
+	SKU        string            `json:"sku"`
+	Attributes map[string]string `json:"attributes"`
+	Price      float64           `json:"price"`
+	Stock      float64           `json:"stock"`
+	Images     []string          `json:"images"`
 }
 
 type PagedProducts struct {
-	Products  []GetProductRes `json:"products"`
-	Page      int             `json:"page"`
-	TotalItem int             `json:"total_item"`
-	LastPage  int             `json:"last_page"`
+	Products    []GetProductRes `json:"products"`
+	Page        int             `json:"page"`
+	Size        int             `json:"size"`
+	TotalItem   int             `json:"total_item"`
+	LastPage    int             `json:"last_page"`
+	Next_Cursor string          `json:"next_cursor,omitempty"`
 }
 
 type ProductReq struct {
-	Name        string   `json:"name" valid:"required,min=2,max=200" bson:"name"`
-	Description string   `json:"description" valid:"required" bson:"description"`
-	Price       float64  `json:"price" valid:"required" bson:"price"`
-	Stok        int      `json:"stock" valid:"required" bson:"stock"`
-	Category    string   `json:"category" valid:"required" bson:"category"`
-	Images      []string `json:"images" valid:"required" bson:"images"`
+	Name        string `json:"name" valid:"required,min=2,max=200" bson:"name"`
+	Description string `json:"description" valid:"required" bson:"description"`
+	// Price and Stok are required unless the product has variants, then they
+	// are taken from the variants.
+	Price  float64 `json:"price" bson:"price"`
+	Stok   float64 `json:"stock" bson:"stock"`
+	Weight int     `json:"weight" bson:"weight"`
+	// Unit defaults to piece, Min_Quantity and Quantity_Step limit the
+	// quantities a buyer can order, e.g. 0.25 kg steps from 0.5 kg.
+	Unit          string       `json:"unit" bson:"unit"`
+	Min_Quantity  float64      `json:"min_quantity" bson:"min_quantity"`
+	Quantity_Step float64      `json:"quantity_step" bson:"quantity_step"`
+	Category      string       `json:"category" valid:"required" bson:"category"`
+	Images        []string     `json:"images" valid:"required" bson:"images"`
+	Variants      []VariantReq `json:"variants" bson:"variants"`
+}
+
+type VariantReq struct {
+	// Variant_Id keeps an existing variant when a product is updated, it is
+	// left empty for a new variant.
+	Variant_Id string            `json:"variant_id"`
+	Name       string            `json:"name" valid:"required"`
+	SKU        string            `json:"sku" valid:"required"`
+	Attributes map[string]string `json:"attributes"`
+	Price      float64           `json:"price" valid:"required"`
+	Stock      float64           `json:"stock"`
+	Images     []string          `json:"images"`
 }
 
 type AddProductRes struct {
@@ -49,3 +88,13 @@ type EditProductRes struct {
 type DeleteProductRes struct {
 	DeleteResult *mongo.DeleteResult
 }
+
+type UnpublishReq struct {
+	Unpublished bool   `json:"unpublished"`
+	Reason      string `json:"reason" valid:"required"`
+}
+
+type ImportJobRes struct {
+	Job_Id     string `json:"job_id"`
+	Total_Rows int    `json:"total_rows"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+type RefundReq struct {
+	Reason string `json:"reason" bson:"reason"`
+}
+
+type RefundRes struct {
+	Refund_Id string  `json:"refund_id"`
+	Amount    float64 `json:"amount"`
+	Status    string  `json:"status"`
+}
+
+type RefundStatusUpdateReq struct {
+	Status string `json:"status"`
+}
@@ -1,6 +1,8 @@
 package dto
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -38,6 +40,15 @@ type SellerUserResponse struct {
 	User_Id    string `json:"user_id"`
 }
 
+type StaffRes struct {
+	Seller_Id  string    `json:"seller_id"`
+	First_Name string    `json:"first_name"`
+	Last_Name  string    `json:"last_name"`
+	Email      string    `json:"email"`
+	Phone      string    `json:"phone"`
+	Created_At time.Time `json:"created_at"`
+}
+
 type SellerUpdateReq struct {
 	First_Name string `json:"first_name" valid:"required,minstringlength(2),maxstringlength(100)"`
 	Last_Name  string `json:"last_name" valid:"required,minstringlength(2),maxstringlength(100)"`
@@ -47,3 +58,16 @@ type SellerUpdateReq struct {
 type SellerUpdateRes struct {
 	UpdateResult *mongo.UpdateResult
 }
+
+type AdminSellerRes struct {
+	Seller_Id      string    `json:"seller_id"`
+	First_Name     string    `json:"first_name"`
+	Last_Name      string    `json:"last_name"`
+	Email          string    `json:"email"`
+	Phone          string    `json:"phone"`
+	Role           string    `json:"role"`
+	Store_Id       string    `json:"store_id"`
+	Email_Verified bool      `json:"email_verified"`
+	Suspended      bool      `json:"suspended"`
+	Created_At     time.Time `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+type StockAdjustmentReq struct {
+	// Variant_Id is required when the product has variants.
+	Variant_Id string `json:"variant_id"`
+	// Quantity is added to the stock, negative to take stock out.
+	Quantity float64 `json:"quantity" valid:"required"`
+	Reason   string  `json:"reason" valid:"required"`
+}
+
+// StockDrift is a product or variant whose stock is not the sum of its movements.
+type StockDrift struct {
+	Store_Id   string  `json:"store_id"`
+	Product_Id string  `json:"product_id"`
+	Variant_Id string  `json:"variant_id,omitempty"`
+	Stock      float64 `json:"stock"`
+	Ledger     float64 `json:"ledger"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+type UploadRes struct {
+	Upload_Id     string `json:"upload_id"`
+	Url           string `json:"url"`
+	Thumbnail_Url string `json:"thumbnail_url"`
+	Content_Type  string `json:"content_type"`
+	Size          int64  `json:"size"`
+	Width         int    `json:"width"`
+	Height        int    `json:"height"`
+}
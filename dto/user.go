@@ -1,6 +1,8 @@
 package dto
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -27,6 +29,10 @@ type UserReqEmail struct {
 	Email string `json:"email" valid:"email,required"`
 }
 
+type RefreshTokenReq struct {
+	Refresh_Token string `json:"refresh_token"`
+}
+
 type UserAuthRes struct {
 	Access_Token  string `json:"access_token"`
 	Refresh_Token string `json:"refresh_token"`
@@ -56,3 +62,20 @@ type UserUpdateRes struct {
 type AddPhone struct {
 	PhoneNumber string `json:"phone" valid:"required,minstringlength(12)"`
 }
+
+type AdminUserRes struct {
+	User_Id        string    `json:"user_id"`
+	First_Name     string    `json:"first_name"`
+	Last_Name      string    `json:"last_name"`
+	Email          string    `json:"email"`
+	Phone          string    `json:"phone"`
+	Role           string    `json:"role"`
+	Email_Verified bool      `json:"email_verified"`
+	Suspended      bool      `json:"suspended"`
+	Created_At     time.Time `json:"created_at"`
+}
+
+type SuspendReq struct {
+	Suspended bool   `json:"suspended"`
+	Reason    string `json:"reason" valid:"required"`
+}
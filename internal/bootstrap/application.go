@@ -1,12 +1,18 @@
 package bootstrap
 
 import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
 	"github.com/IndraSty/GreenBasket/dto"
 	"github.com/IndraSty/GreenBasket/internal/config"
 	"github.com/IndraSty/GreenBasket/internal/delivery"
 	"github.com/IndraSty/GreenBasket/internal/middlewares"
 	"github.com/IndraSty/GreenBasket/internal/repository"
 	"github.com/IndraSty/GreenBasket/internal/routes"
+	"github.com/IndraSty/GreenBasket/internal/scheduler"
 	"github.com/IndraSty/GreenBasket/internal/service"
 	"github.com/IndraSty/GreenBasket/internal/sse"
 	"github.com/IndraSty/GreenBasket/internal/util"
@@ -42,72 +48,270 @@ func Application(cnf *ApplicationConfig) {
 	templateRepository := repository.NewTemplateRepository(cnf.Client)
 	reviewRepository := repository.NewReviewRepository(cnf.Client)
 	salesReportRepository := repository.NewSalesReportRepository(cnf.Client)
+	reservationRepository := repository.NewReservationRepository(cnf.Client)
+	paymentEventRepository := repository.NewPaymentEventRepository(cnf.Client)
+	refundRepository := repository.NewRefundRepository(cnf.Client)
+	shipmentRepository := repository.NewShipmentRepository(cnf.Client)
+	shippingRateRepository := repository.NewShippingRateRepository(cnf.Client)
+	auditLogRepository := repository.NewAuditLogRepository(cnf.Client)
+	categoryRepository := repository.NewCategoryRepository(cnf.Client)
+	batchRepository := repository.NewBatchRepository(cnf.Client)
+	stockMovementRepository := repository.NewStockMovementRepository(cnf.Client)
+	importJobRepository := repository.NewImportJobRepository(cnf.Client)
+	uploadRepository := repository.NewUploadRepository(cnf.Client)
+	tokenRevocationStore := repository.NewTokenRevocationStore(cacheRepository, repository.NewTokenVersionRepository(cnf.Client))
+	transactor := repository.NewTransactor(cnf.Client, cnf.Config.MongoDB.TxMode)
+
+	// setup payment gateway
+	var paymentGateway domain.PaymentGateway
+	var fakeGateway domain.FakePaymentGateway
+	if cnf.Config.Payment.Gateway == "fake" {
+		fakeGateway = service.NewFakeGateway(cnf.Config)
+		paymentGateway = fakeGateway
+	} else {
+		paymentGateway = service.NewMidtransGateway(cnf.Config)
+	}
+
+	// setup blob storage for uploaded images
+	var blobStorage domain.BlobStorage
+	var uploadDir string
+	if cnf.Config.Storage.Driver == "s3" {
+		s3Storage, err := service.NewS3Storage(cnf.Config)
+		if err != nil {
+			log.Fatalf("failed to setup s3 storage: %s", err.Error())
+		}
+		blobStorage = s3Storage
+	} else {
+		blobStorage = service.NewLocalStorage(cnf.Config)
+		uploadDir = cnf.Config.Storage.LocalDir
+	}
+
+	// setup product search index
+	var searchIndex domain.ProductSearchIndex
+	if cnf.Config.Search.Engine == "memory" {
+		searchIndex = repository.NewMemorySearchIndex()
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		mongoIndex, err := repository.NewMongoSearchIndex(ctx, cnf.Client)
+		cancel()
+		if err != nil {
+			log.Fatalf("failed to setup search index: %s", err.Error())
+		}
+		searchIndex = mongoIndex
+	}
+
+	// setup guest carts, mongo removes them once they expire
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	guestCartRepository, err := repository.NewGuestCartRepository(ctx, cnf.Client)
+	cancel()
+	if err != nil {
+		log.Fatalf("failed to setup guest carts: %s", err.Error())
+	}
+
+	// setup shipping rate provider
+	shippingRateProvider := service.NewTableRateProvider(shippingRateRepository)
+
+	// setup carrier tracker, only the fake carrier is available for now
+	carrierTracker := service.NewFakeTracker()
 
 	// setup service
 	tokenService := util.NewTokenService(cnf.Config)
 	addressService := service.NewAddressService(addressRepository, sellerRepository, userRepository, storeRepository)
 	cartService := service.NewCartService(cartRepository, productRepository, storeRepository, cacheRepository)
+	guestCartService := service.NewGuestCartService(guestCartRepository, cartRepository, productRepository, storeRepository,
+		cnf.Config.Token.Secret_Key, cnf.Config.Cart.GuestTTL)
 	contactService := service.NewContactService(contactRepository, storeRepository)
 	emailService := service.NewEmailService(cnf.Config)
 	notificationService := service.NewNotificationService(notificationRepository, templateRepository, hub)
 	salesReportService := service.NewSalesRepository(salesReportRepository, sellerOrderRepository, storeRepository, productRepository, reviewRepository, cacheRepository)
+	stockLedgerService := service.NewStockLedgerService(stockMovementRepository, productRepository, storeRepository, transactor)
+	batchService := service.NewBatchService(batchRepository, productRepository, storeRepository, sellerRepository,
+		stockLedgerService, notificationService, transactor, cnf.Config.Inventory)
+	reservationService := service.NewReservationService(reservationRepository, productRepository, batchService, stockLedgerService, transactor)
+	orderStatusService := service.NewOrderStatusService(orderRepository, sellerOrderRepository)
+	shippingService := service.NewShippingService(shippingRateProvider, productRepository, storeRepository, userRepository, cartRepository)
 	orderService := service.NewOrderService(orderRepository, userRepository, cartRepository, sellerRepository,
-		storeRepository, notificationService, sellerOrderRepository, salesReportService, cacheRepository)
-	sellerService := service.NewSellerService(sellerRepository, tokenService, cacheRepository, emailService)
-	midtransService := service.NewMidtransService(cnf.Config, paymentRepository, orderRepository, sellerOrderRepository)
-	paymentService := service.NewPaymentService(notificationService, paymentRepository, userRepository, midtransService)
-	productService := service.NewProductService(productRepository, storeRepository, salesReportRepository, cacheRepository)
-	sellerOrderService := service.NewSellerOrderService(sellerOrderRepository, sellerRepository, orderRepository, productRepository, notificationService, cacheRepository)
+		storeRepository, notificationService, sellerOrderRepository, salesReportService, reservationService, orderStatusService, shippingService, transactor, cacheRepository)
+	sellerService := service.NewSellerService(sellerRepository, storeRepository, tokenService, cacheRepository, tokenRevocationStore, emailService)
+	paymentNotificationService := service.NewPaymentNotificationService(paymentGateway, paymentRepository, orderRepository, sellerOrderRepository,
+		paymentEventRepository, reservationService, orderStatusService, notificationService, transactor)
+	paymentService := service.NewPaymentService(notificationService, paymentRepository, userRepository, orderRepository, paymentGateway)
+	uploadService := service.NewUploadService(uploadRepository, productRepository, storeRepository, blobStorage,
+		cnf.Config.Storage.MaxUploadSize, cnf.Config.Storage.ThumbnailSize)
+	productSearchService := service.NewProductSearchService(searchIndex, productRepository, storeRepository)
+	categoryService := service.NewCategoryService(categoryRepository, productRepository, auditLogRepository)
+	productService := service.NewProductService(productRepository, storeRepository, sellerRepository, salesReportRepository, cacheRepository, categoryService, stockLedgerService, uploadService, productSearchService, transactor)
+	productImportService := service.NewProductImportService(importJobRepository, productService, productRepository, storeRepository)
+	sellerOrderService := service.NewSellerOrderService(sellerOrderRepository, sellerRepository, orderRepository, productRepository, paymentRepository, reservationService, orderStatusService, notificationService, cacheRepository, transactor)
+	refundService := service.NewRefundService(refundRepository, orderRepository, sellerOrderRepository, sellerRepository,
+		paymentRepository, productRepository, stockLedgerService, orderStatusService, paymentGateway, salesReportService, notificationService, transactor)
 	userService := service.NewUserService(userRepository, emailService, cacheRepository, cartService)
 	reviewService := service.NewReviewService(reviewRepository, productRepository, orderRepository, storeRepository, notificationService, userRepository, salesReportRepository, cacheRepository)
-	storeService := service.NewStoreService(storeRepository, sellerRepository, salesReportRepository, cacheRepository)
-	authService := service.NewAuthService(userRepository, cacheRepository, tokenService, emailService)
+	storeService := service.NewStoreService(storeRepository, sellerRepository, salesReportRepository, cacheRepository, uploadService, productSearchService)
+	authService := service.NewAuthService(userRepository, sellerRepository, storeRepository, cacheRepository, tokenRevocationStore, tokenService, emailService)
+	orderJobService := service.NewOrderJobService(orderRepository, sellerOrderRepository, sellerRepository, paymentRepository,
+		orderStatusService, reservationService, paymentNotificationService, salesReportService, transactor)
+	shipmentService := service.NewShipmentService(shipmentRepository, orderRepository, sellerOrderRepository,
+		orderStatusService, carrierTracker, notificationService, cacheRepository)
+	weightAdjustmentService := service.NewWeightAdjustmentService(orderRepository, sellerOrderRepository, productRepository,
+		reservationRepository, refundRepository, refundService, paymentService, batchService, stockLedgerService, notificationService, cacheRepository, transactor)
+	adminService := service.NewAdminService(auditLogRepository, userRepository, sellerRepository, storeRepository,
+		productRepository, reviewRepository, orderRepository, tokenRevocationStore, cacheRepository, productSearchService)
 
 	// setup handler
-	authHandler := delivery.NewAuthHandler(userRepository, *authSetup, cnf.Config, authService)
+	authHandler := delivery.NewAuthHandler(userRepository, *authSetup, cnf.Config, authService, guestCartService)
 	addressHandler := delivery.NewAddressHandler(addressService)
 	cartHandler := delivery.NewCartHandler(cartService)
+	guestCartHandler := delivery.NewGuestCartHandler(guestCartService, cnf.Config.Cart.GuestTTL)
 	contactHandler := delivery.NewContactHandler(contactService)
-	midtransHandler := delivery.NewMidtransHandler(midtransService, paymentService)
+	paymentNotificationHandler := delivery.NewPaymentNotificationHandler(paymentNotificationService, fakeGateway)
 	notificationHandler := delivery.NewNotificationHandler(notificationService, userService)
 	orderHandler := delivery.NewOrderHandler(orderService)
 	paymentHandler := delivery.NewPaymentHandler(paymentService)
 	productHandler := delivery.NewProductHandler(productService)
-	sellerHandler := delivery.NewSellerHandler(sellerService)
+	sellerHandler := delivery.NewSellerHandler(sellerService, authService)
 	storeHandler := delivery.NewStoreHandler(storeService)
 	sellerOrderHandler := delivery.NewSellerOrderHandler(sellerOrderService)
 	userHandler := delivery.NewUserHandler(userService)
+	refundHandler := delivery.NewRefundHandler(refundService)
+	shipmentHandler := delivery.NewShipmentHandler(shipmentService)
+	weightAdjustmentHandler := delivery.NewWeightAdjustmentHandler(weightAdjustmentService)
+	batchHandler := delivery.NewBatchHandler(batchService)
+	stockLedgerHandler := delivery.NewStockLedgerHandler(stockLedgerService)
+	productImportHandler := delivery.NewProductImportHandler(productImportService)
+	searchHandler := delivery.NewSearchHandler(productSearchService)
+	uploadHandler := delivery.NewUploadHandler(uploadService, cnf.Config.Storage.MaxUploadSize, uploadDir)
+	shippingHandler := delivery.NewShippingHandler(shippingService)
+	adminHandler := delivery.NewAdminHandler(adminService)
+	categoryHandler := delivery.NewCategoryHandler(categoryService)
 	reviewHandler := delivery.NewReviewHandler(reviewService)
 	salesReportHandler := delivery.NewSalesReportHandler(salesReportService)
 	notificationSSE := sse.NewNotificationSSE(hub, userRepository)
 
 	// setup middleware
-	middleware := middlewares.NewMiddleware(tokenService)
+	middleware := middlewares.NewMiddleware(tokenService, tokenRevocationStore)
 
 	// setup routes
 	routeConfig := routes.RouteConfig{
-		App:                 cnf.App,
-		Middlewares:         middleware,
-		UserHandler:         userHandler,
-		SellerHandler:       sellerHandler,
-		StoreHandler:        storeHandler,
-		ProductHandler:      productHandler,
-		PaymentHandler:      paymentHandler,
-		OrderHandler:        orderHandler,
-		NotificationHandler: notificationHandler,
-		MidtransHandler:     midtransHandler,
-		ContactHandler:      contactHandler,
-		CartHandler:         cartHandler,
-		AddressHandler:      addressHandler,
-		NotificationSSE:     notificationSSE,
-		SellerOrderHandler:  sellerOrderHandler,
-		SalesReportHandler:  salesReportHandler,
-		ReviewHandler:       reviewHandler,
-		AuthHandler:         authHandler,
+		App:                        cnf.App,
+		Middlewares:                middleware,
+		UserHandler:                userHandler,
+		SellerHandler:              sellerHandler,
+		StoreHandler:               storeHandler,
+		ProductHandler:             productHandler,
+		PaymentHandler:             paymentHandler,
+		OrderHandler:               orderHandler,
+		NotificationHandler:        notificationHandler,
+		PaymentNotificationHandler: paymentNotificationHandler,
+		ContactHandler:             contactHandler,
+		CartHandler:                cartHandler,
+		GuestCartHandler:           guestCartHandler,
+		AddressHandler:             addressHandler,
+		NotificationSSE:            notificationSSE,
+		SellerOrderHandler:         sellerOrderHandler,
+		SalesReportHandler:         salesReportHandler,
+		RefundHandler:              refundHandler,
+		ShipmentHandler:            shipmentHandler,
+		WeightAdjustmentHandler:    weightAdjustmentHandler,
+		BatchHandler:               batchHandler,
+		StockLedgerHandler:         stockLedgerHandler,
+		ProductImportHandler:       productImportHandler,
+		UploadHandler:              uploadHandler,
+		SearchHandler:              searchHandler,
+		ShippingHandler:            shippingHandler,
+		AdminHandler:               adminHandler,
+		CategoryHandler:            categoryHandler,
+		ReviewHandler:              reviewHandler,
+		AuthHandler:                authHandler,
 	}
 
 	routeConfig.Setup()
 
+	// the memory index starts empty on every replica
+	if cnf.Config.Search.Engine == "memory" {
+		go func() {
+			indexed, err := productSearchService.Reindex(context.Background())
+			if err != nil {
+				log.Println("failed to build search index: ", err)
+				return
+			}
+			log.Println("indexed products for search: ", indexed)
+		}()
+	}
+
+	// setup scheduler
+	if cnf.Config.Scheduler.Enabled {
+		startScheduler(cnf.Config, cacheRepository, orderJobService, refundService, shipmentService, batchService)
+	}
+
 	// setup sse
 	sse.NewNotificationSSE(hub, userRepository)
 }
+
+func startScheduler(cnf *config.Config, cacheRepo domain.CacheRepository, orderJobSvc domain.OrderJobService,
+	refundSvc domain.RefundService, shipmentSvc domain.ShipmentService, batchSvc domain.BatchService) {
+	jobs := scheduler.NewScheduler(cacheRepo)
+
+	jobs.Add(scheduler.Job{
+		Name:     "finish-shipped-items",
+		Interval: cnf.Scheduler.Interval,
+		Run: func(ctx context.Context) error {
+			finished, err := orderJobSvc.FinishShippedItems(ctx, time.Now().Add(-cnf.Scheduler.AutoFinishAfter))
+			if finished > 0 {
+				log.Println("finished shipped order items: ", finished)
+			}
+			return err
+		},
+	})
+
+	jobs.Add(scheduler.Job{
+		Name:     "expire-unpaid-orders",
+		Interval: cnf.Scheduler.Interval,
+		Run: func(ctx context.Context) error {
+			expired, err := orderJobSvc.ExpireUnpaidOrders(ctx, time.Now().Add(-cnf.Scheduler.PaymentWindow))
+			if expired > 0 {
+				log.Println("expired unpaid orders: ", expired)
+			}
+			return err
+		},
+	})
+
+	jobs.Add(scheduler.Job{
+		Name:     "retry-approved-refunds",
+		Interval: cnf.Scheduler.Interval,
+		Run: func(ctx context.Context) error {
+			// a refund approved within the last interval may still be in flight
+			finished, err := refundSvc.RetryApprovedRefunds(ctx, time.Now().Add(-cnf.Scheduler.Interval))
+			if finished > 0 {
+				log.Println("finished approved refunds: ", finished)
+			}
+			return err
+		},
+	})
+
+	jobs.Add(scheduler.Job{
+		Name:     "refresh-shipment-tracking",
+		Interval: cnf.Scheduler.Interval,
+		Run: func(ctx context.Context) error {
+			updated, err := shipmentSvc.RefreshTracking(ctx)
+			if updated > 0 {
+				log.Println("updated shipment tracking: ", updated)
+			}
+			return err
+		},
+	})
+
+	jobs.Add(scheduler.Job{
+		Name:     "apply-batch-expiry-policy",
+		Interval: cnf.Scheduler.Interval,
+		Run: func(ctx context.Context) error {
+			withdrawn, err := batchSvc.ApplyExpiryPolicy(ctx, time.Now())
+			if withdrawn > 0 {
+				log.Println("withdrawn expiring batches: ", withdrawn)
+			}
+			return err
+		},
+	})
+
+	jobs.Start(context.Background())
+}
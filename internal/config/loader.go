@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -31,7 +33,8 @@ func Get() *Config {
 			IsProd: os.Getenv("MIDTRANS_ENV") == "production",
 		},
 		MongoDB{
-			URI: os.Getenv("MONGO_URI"),
+			URI:    os.Getenv("MONGO_URI"),
+			TxMode: os.Getenv("MONGO_TX_MODE"),
 		},
 		Server{
 			Host: os.Getenv("SERVER_HOST"),
@@ -56,5 +59,64 @@ func Get() *Config {
 			ClientID:     os.Getenv("FACEBOOK_CLIENT_ID"),
 			ClientSecret: os.Getenv("FACEBOOK_CLIENT_SECRET"),
 		},
+		Payment{
+			Gateway: os.Getenv("PAYMENT_GATEWAY"),
+		},
+		Scheduler{
+			Enabled:         os.Getenv("SCHEDULER_ENABLED") != "false",
+			Interval:        getEnvDuration("SCHEDULER_INTERVAL", 10*time.Minute),
+			AutoFinishAfter: time.Duration(getEnvInt("ORDER_AUTO_FINISH_DAYS", 7)) * 24 * time.Hour,
+			PaymentWindow:   getEnvDuration("PAYMENT_WINDOW", 24*time.Hour),
+		},
+		Inventory{
+			WithdrawBefore:  getEnvDuration("BATCH_WITHDRAW_BEFORE", 24*time.Hour),
+			DiscountBefore:  getEnvDuration("BATCH_DISCOUNT_BEFORE", 72*time.Hour),
+			DiscountPercent: float64(getEnvInt("BATCH_DISCOUNT_PERCENT", 30)),
+			AlertBefore:     time.Duration(getEnvInt("BATCH_ALERT_DAYS", 3)) * 24 * time.Hour,
+		},
+		Storage{
+			Driver:        getEnv("STORAGE_DRIVER", "local"),
+			LocalDir:      getEnv("STORAGE_LOCAL_DIR", "uploads"),
+			PublicURL:     os.Getenv("STORAGE_PUBLIC_URL"),
+			MaxUploadSize: int64(getEnvInt("UPLOAD_MAX_SIZE", 5<<20)),
+			ThumbnailSize: getEnvInt("UPLOAD_THUMBNAIL_SIZE", 320),
+			S3Endpoint:    os.Getenv("S3_ENDPOINT"),
+			S3Region:      getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:      os.Getenv("S3_BUCKET"),
+			S3AccessKey:   os.Getenv("S3_ACCESS_KEY"),
+			S3SecretKey:   os.Getenv("S3_SECRET_KEY"),
+		},
+		Search{
+			Engine: getEnv("SEARCH_ENGINE", "mongo"),
+		},
+		Cart{
+			GuestTTL: getEnvDuration("GUEST_CART_TTL", 7*24*time.Hour),
+		},
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	val, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+
+	return val
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	val, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
 	}
+
+	return val
 }
@@ -1,15 +1,23 @@
 package config
 
+import "time"
+
 type Config struct {
-	Token    Token
-	Email    Email
-	Redis    Redis
-	Midtrans Midtrans
-	MongoDB  MongoDB
-	Server   Server
-	Auth     Auth
-	Google   Google
-	Facebook Facebook
+	Token     Token
+	Email     Email
+	Redis     Redis
+	Midtrans  Midtrans
+	MongoDB   MongoDB
+	Server    Server
+	Auth      Auth
+	Google    Google
+	Facebook  Facebook
+	Payment   Payment
+	Scheduler Scheduler
+	Inventory Inventory
+	Storage   Storage
+	Search    Search
+	Cart      Cart
 }
 
 type Server struct {
@@ -37,8 +45,67 @@ type Midtrans struct {
 	IsProd bool
 }
 
+type Payment struct {
+	Gateway string
+}
+
+type Scheduler struct {
+	Enabled  bool
+	Interval time.Duration
+	// AutoFinishAfter is how long after shipping an item is finished for the buyer.
+	AutoFinishAfter time.Duration
+	// PaymentWindow is how long a buyer has to pay an order before it expires.
+	PaymentWindow time.Duration
+}
+
+// Inventory is the policy for batches near expiry, a zero duration or discount
+// turns that step off.
+type Inventory struct {
+	// WithdrawBefore is how long before expiry a batch is taken off sale.
+	WithdrawBefore time.Duration
+	// DiscountBefore is how long before expiry a product is discounted by DiscountPercent.
+	DiscountBefore  time.Duration
+	DiscountPercent float64
+	// AlertBefore is how long before expiry the seller is told about a batch.
+	AlertBefore time.Duration
+}
+
+// Storage is where uploaded images are kept, Driver is local or s3.
+type Storage struct {
+	Driver string
+	// LocalDir is the directory of the local driver, served under /uploads.
+	LocalDir string
+	// PublicURL is the address uploads are served from, it defaults to
+	// /uploads on this server for the local driver and to the bucket on
+	// S3Endpoint for s3.
+	PublicURL string
+	// MaxUploadSize is the largest image accepted, in bytes.
+	MaxUploadSize int64
+	// ThumbnailSize is the longest side of a thumbnail, in pixels.
+	ThumbnailSize int
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// Search is the engine behind product search, mongo for the text index of
+// MongoDB or memory for an index each replica keeps in memory, which matches
+// typos and word prefixes as well.
+type Search struct {
+	Engine string
+}
+
+// Cart is how long a guest cart is kept after its last change.
+type Cart struct {
+	GuestTTL time.Duration
+}
+
 type MongoDB struct {
-	URI string
+	URI    string
+	TxMode string
 }
 
 type Auth struct {
@@ -0,0 +1,205 @@
+package delivery
+
+import (
+	"net/http"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	service domain.AdminService
+}
+
+func NewAdminHandler(s domain.AdminService) *AdminHandler {
+	return &AdminHandler{
+		service: s,
+	}
+}
+
+func (h *AdminHandler) SearchUsers() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		page, ok := pageRequest(ctx, domain.AdminPages)
+		if !ok {
+			return
+		}
+
+		res, err := h.service.SearchUsers(ctx, ctx.Query("q"), page)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Fetch Users", "result": res})
+	}
+}
+
+func (h *AdminHandler) SuspendUser() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req dto.SuspendReq
+		email := ctx.MustGet("email").(string)
+		userID := ctx.Param("user_id")
+
+		if err := ctx.BindJSON(&req); err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err := h.service.SuspendUser(ctx, email, userID, &req)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Update User Suspension"})
+	}
+}
+
+func (h *AdminHandler) SearchSellers() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		page, ok := pageRequest(ctx, domain.AdminPages)
+		if !ok {
+			return
+		}
+
+		res, err := h.service.SearchSellers(ctx, ctx.Query("q"), page)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Fetch Sellers", "result": res})
+	}
+}
+
+func (h *AdminHandler) SuspendSeller() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req dto.SuspendReq
+		email := ctx.MustGet("email").(string)
+		sellerID := ctx.Param("seller_id")
+
+		if err := ctx.BindJSON(&req); err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err := h.service.SuspendSeller(ctx, email, sellerID, &req)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Update Seller Suspension"})
+	}
+}
+
+func (h *AdminHandler) SearchStores() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		page, ok := pageRequest(ctx, domain.AdminPages)
+		if !ok {
+			return
+		}
+
+		res, err := h.service.SearchStores(ctx, ctx.Query("q"), page)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Fetch Stores", "result": res})
+	}
+}
+
+func (h *AdminHandler) SuspendStore() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req dto.SuspendReq
+		email := ctx.MustGet("email").(string)
+		storeID := ctx.Param("store_id")
+
+		if err := ctx.BindJSON(&req); err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err := h.service.SuspendStore(ctx, email, storeID, &req)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Update Store Suspension"})
+	}
+}
+
+func (h *AdminHandler) UnpublishProduct() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req dto.UnpublishReq
+		email := ctx.MustGet("email").(string)
+		productID := ctx.Param("product_id")
+
+		if err := ctx.BindJSON(&req); err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err := h.service.UnpublishProduct(ctx, email, productID, &req)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Update Product Publication"})
+	}
+}
+
+// RemoveReview takes the reason from the query, DELETE requests carry no body.
+func (h *AdminHandler) RemoveReview() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		email := ctx.MustGet("email").(string)
+		reviewID := ctx.Param("review_id")
+
+		err := h.service.RemoveReview(ctx, email, reviewID, ctx.Query("reason"))
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Remove Review"})
+	}
+}
+
+func (h *AdminHandler) GetAllOrders() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		page, ok := pageRequest(ctx, domain.OrderPages)
+		if !ok {
+			return
+		}
+
+		res, err := h.service.GetAllOrders(ctx, page)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Fetch all Orders", "result": res})
+	}
+}
+
+func (h *AdminHandler) GetAuditLogs() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		page, ok := pageRequest(ctx, domain.AdminPages)
+		if !ok {
+			return
+		}
+
+		res, err := h.service.GetAuditLogs(ctx, page, ctx.Query("target_id"))
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Fetch Audit Logs", "result": res})
+	}
+}
@@ -2,6 +2,7 @@ package delivery
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -24,17 +25,44 @@ type AuthHandler struct {
 	auth     config.AuthSetup
 	google   config.Google
 	authSvc  domain.AuthService
+	cartSvc  domain.GuestCartService
 }
 
-func NewAuthHandler(userRepo domain.UserRepository, auth config.AuthSetup, cnf *config.Config, authSvc domain.AuthService) *AuthHandler {
+func NewAuthHandler(userRepo domain.UserRepository, auth config.AuthSetup, cnf *config.Config, authSvc domain.AuthService,
+	cartSvc domain.GuestCartService) *AuthHandler {
 	return &AuthHandler{
 		userRepo: userRepo,
 		auth:     auth,
 		google:   cnf.Google,
 		authSvc:  authSvc,
+		cartSvc:  cartSvc,
 	}
 }
 
+// mergeGuestCart moves the guest cart of the request into the cart of the
+// user, a login never fails because of it. The cookie is kept when the merge
+// fails, the next login tries again.
+func (h *AuthHandler) mergeGuestCart(ctx *gin.Context, email string) *dto.CartMergeRes {
+	token := cartToken(ctx)
+	if token == "" {
+		return nil
+	}
+
+	res, err := h.cartSvc.Merge(ctx, token, email)
+	if errors.Is(err, domain.ErrInvalidCartToken) || errors.Is(err, domain.ErrGuestCartNotFound) {
+		setCartTokenCookie(ctx, "", 0)
+		return nil
+	}
+	if err != nil {
+		log.Println("failed to merge guest cart: ", err)
+		return nil
+	}
+
+	setCartTokenCookie(ctx, "", 0)
+
+	return res
+}
+
 func (h *AuthHandler) BeginAuthHandler(c *gin.Context) {
 	provider := c.Param("provider")
 	r := c.Request
@@ -95,7 +123,7 @@ func (h *AuthHandler) GetAuthCallBackFunc(c *gin.Context) {
 			Email:         user.Email,
 			Image_Url:     user.AvatarURL,
 			Refresh_Token: user.RefreshToken,
-			Role:          "User",
+			Role:          domain.RoleUser,
 			Created_At:    time.Now(),
 			Updated_At:    time.Now(),
 			User_Id:       userID,
@@ -105,25 +133,48 @@ func (h *AuthHandler) GetAuthCallBackFunc(c *gin.Context) {
 		h.userRepo.CreateUser(c, input)
 	}
 
+	h.mergeGuestCart(c, user.Email)
+
 	http.Redirect(w, r, "http://localhost:8080", http.StatusFound)
 }
 
+// LogoutHandler revokes the access token when the request carries one, the
+// guest route only ends the oauth session.
 func (h *AuthHandler) LogoutHandler(c *gin.Context) {
 	r := c.Request
 	w := c.Writer
 
-	http.SetCookie(c.Writer, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    "",
-		Path:     "/api/users",
-		Expires:  time.Unix(0, 0),
-		HttpOnly: true,
-	})
+	if claims, ok := c.Get("claims"); ok {
+		err := h.authSvc.Logout(c, claims.(*domain.SignedDetails))
+		if err != nil {
+			util.HandleError(c, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	setRefreshTokenCookie(c, "")
 	gothic.Logout(w, r)
 	c.JSON(http.StatusOK, gin.H{"message": "Ok"})
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
+// LogoutAllSessions logs the user out on every device.
+func (h *AuthHandler) LogoutAllSessions() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims := ctx.MustGet("claims").(*domain.SignedDetails)
+
+		err := h.authSvc.LogoutAllSessions(ctx, claims)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		setRefreshTokenCookie(ctx, "")
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Logged out from all sessions"})
+	}
+}
+
 func (h *AuthHandler) ValidateOTP() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var req dto.ValidateOtpReq
@@ -156,17 +207,67 @@ func (h *AuthHandler) AuthenticateUser() gin.HandlerFunc {
 			return
 		}
 
-		http.SetCookie(ctx.Writer, &http.Cookie{
-			Name:     "refresh_token",
-			Value:    res.Refresh_Token,
-			Expires:  time.Now().Add(168 * time.Hour),
-			HttpOnly: true,
-		})
+		setRefreshTokenCookie(ctx, res.Refresh_Token)
+
+		// the cart field is only there when a guest cart was merged
+		body := gin.H{"access_token": res.Access_Token}
+		if merged := h.mergeGuestCart(ctx, req.Email); merged != nil {
+			body["cart"] = merged
+		}
+
+		ctx.JSON(http.StatusOK, body)
+	}
+}
+
+// RefreshToken exchanges the refresh token cookie, or the refresh_token of the
+// body for clients without cookies, for a new access token. The refresh token
+// is rotated on every call.
+func (h *AuthHandler) RefreshToken() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		refreshToken, err := ctx.Cookie("refresh_token")
+		if err != nil || refreshToken == "" {
+			var req dto.RefreshTokenReq
+			if err := ctx.ShouldBindJSON(&req); err != nil || req.Refresh_Token == "" {
+				util.HandleError(ctx, err, http.StatusUnauthorized, domain.ErrInvalidRefreshToken.Error())
+				return
+			}
+			refreshToken = req.Refresh_Token
+		}
+
+		res, err := h.authSvc.RefreshToken(ctx, refreshToken)
+		if errors.Is(err, domain.ErrInvalidRefreshToken) || errors.Is(err, domain.ErrRefreshTokenReused) {
+			setRefreshTokenCookie(ctx, "")
+			util.HandleError(ctx, err, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		setRefreshTokenCookie(ctx, res.Refresh_Token)
 
-		ctx.JSON(http.StatusOK, gin.H{"access_token": res.Access_Token})
+		ctx.JSON(http.StatusOK, gin.H{"access_token": res.Access_Token, "refresh_token": res.Refresh_Token})
 	}
 }
 
+// setRefreshTokenCookie stores the refresh token of users and sellers under
+// /api so it reaches /api/auth/refresh, an empty token removes the cookie.
+func setRefreshTokenCookie(ctx *gin.Context, refreshToken string) {
+	expires := time.Now().Add(168 * time.Hour)
+	if refreshToken == "" {
+		expires = time.Unix(0, 0)
+	}
+
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    refreshToken,
+		Path:     "/api",
+		Expires:  expires,
+		HttpOnly: true,
+	})
+}
+
 func (h *AuthHandler) RequestVerifyEmail() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var req dto.UserReqEmail
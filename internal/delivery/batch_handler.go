@@ -0,0 +1,76 @@
+package delivery
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type BatchHandler struct {
+	service domain.BatchService
+}
+
+func NewBatchHandler(s domain.BatchService) *BatchHandler {
+	return &BatchHandler{
+		service: s,
+	}
+}
+
+func (h *BatchHandler) AddBatch() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req dto.BatchReq
+		email := ctx.MustGet("email").(string)
+		storeID := ctx.Param("store_id")
+		productID := ctx.Query("product_id")
+
+		if err := ctx.BindJSON(&req); err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		res, err := h.service.AddBatch(ctx, email, storeID, productID, &req)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{"message": "Add Batch successfully", "result": res})
+	}
+}
+
+func (h *BatchHandler) FetchBatches() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		email := ctx.MustGet("email").(string)
+		storeID := ctx.Param("store_id")
+		productID := ctx.Query("product_id")
+
+		res, err := h.service.GetBatches(ctx, email, storeID, productID)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Fetch Batches Successfully", "result": res})
+	}
+}
+
+func (h *BatchHandler) FetchExpiringStock() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		email := ctx.MustGet("email").(string)
+		storeID := ctx.Param("store_id")
+		daysStr := ctx.DefaultQuery("days", "7")
+		days, _ := strconv.Atoi(daysStr)
+
+		res, err := h.service.GetExpiringStock(ctx, email, storeID, days)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Fetch Expiring Stock Successfully", "result": res})
+	}
+}
@@ -57,7 +57,12 @@ func (h *CartHandler) GetAllItemCart() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		email := ctx.MustGet("email").(string)
 
-		res, err := h.service.GetAllCartItem(ctx, email)
+		page, ok := pageRequest(ctx, domain.CartItemPages)
+		if !ok {
+			return
+		}
+
+		res, err := h.service.GetAllCartItem(ctx, email, page)
 		if err != nil {
 			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
 			return
@@ -72,13 +77,14 @@ func (h *CartHandler) UpdateItemInCart() gin.HandlerFunc {
 		var req dto.CartItemEditReq
 		email := ctx.MustGet("email").(string)
 		productID := ctx.Query("product_id")
+		variantID := ctx.Query("variant_id")
 
 		if err := ctx.BindJSON(&req); err != nil {
 			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		err := h.service.UpdateCartItemById(ctx, email, productID, &req)
+		err := h.service.UpdateCartItemById(ctx, email, productID, variantID, &req)
 		if err != nil {
 			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
 			return
@@ -92,8 +98,9 @@ func (h *CartHandler) RemoveItemInCart() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		email := ctx.MustGet("email").(string)
 		productID := ctx.Query("product_id")
+		variantID := ctx.Query("variant_id")
 
-		err := h.service.RemoveCartItemById(ctx, email, productID)
+		err := h.service.RemoveCartItemById(ctx, email, productID, variantID)
 		if err != nil {
 			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
 			return
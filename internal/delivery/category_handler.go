@@ -0,0 +1,90 @@
+package delivery
+
+import (
+	"net/http"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type CategoryHandler struct {
+	service domain.CategoryService
+}
+
+func NewCategoryHandler(s domain.CategoryService) *CategoryHandler {
+	return &CategoryHandler{
+		service: s,
+	}
+}
+
+func (h *CategoryHandler) GetCategories() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		locale := ctx.DefaultQuery("locale", domain.DefaultLocale)
+
+		res, err := h.service.GetTree(ctx, locale)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Fetch Categories", "result": res})
+	}
+}
+
+func (h *CategoryHandler) CreateCategory() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req dto.CategoryReq
+		email := ctx.MustGet("email").(string)
+
+		if err := ctx.BindJSON(&req); err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		res, err := h.service.CreateCategory(ctx, email, &req)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{"message": "Successfully Create Category", "result": res})
+	}
+}
+
+func (h *CategoryHandler) UpdateCategory() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req dto.CategoryReq
+		email := ctx.MustGet("email").(string)
+		slug := ctx.Param("slug")
+
+		if err := ctx.BindJSON(&req); err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err := h.service.UpdateCategory(ctx, email, slug, &req)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Update Category"})
+	}
+}
+
+func (h *CategoryHandler) DeleteCategory() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		email := ctx.MustGet("email").(string)
+		slug := ctx.Param("slug")
+
+		err := h.service.DeleteCategory(ctx, email, slug)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Delete Category"})
+	}
+}
@@ -0,0 +1,135 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+// cartTokenCookie holds the signed token of the guest cart of a visitor.
+const cartTokenCookie = "cart_token"
+
+type GuestCartHandler struct {
+	service domain.GuestCartService
+	ttl     time.Duration
+}
+
+func NewGuestCartHandler(s domain.GuestCartService, ttl time.Duration) *GuestCartHandler {
+	return &GuestCartHandler{
+		service: s,
+		ttl:     ttl,
+	}
+}
+
+// setCartTokenCookie stores the guest cart token under /api so it reaches the
+// guest cart and the logins, an empty token removes the cookie.
+func setCartTokenCookie(ctx *gin.Context, token string, ttl time.Duration) {
+	expires := time.Now().Add(ttl)
+	if token == "" {
+		expires = time.Unix(0, 0)
+	}
+
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     cartTokenCookie,
+		Value:    token,
+		Path:     "/api",
+		Expires:  expires,
+		HttpOnly: true,
+	})
+}
+
+func cartToken(ctx *gin.Context) string {
+	token, _ := ctx.Cookie(cartTokenCookie)
+	return token
+}
+
+func handleGuestCartError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCartToken), errors.Is(err, domain.ErrGuestCartNotFound),
+		errors.Is(err, domain.ErrCartItemNotFound), errors.Is(err, domain.ErrProductNotFound):
+		util.HandleError(ctx, err, http.StatusNotFound, err.Error())
+	default:
+		util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func (h *GuestCartHandler) GetAllItemCart() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		res, err := h.service.GetAllCartItem(ctx, cartToken(ctx))
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Fetch All cart items", "items": res})
+	}
+}
+
+func (h *GuestCartHandler) AddToCart() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req dto.AddCartReq
+		productID := ctx.Query("product_id")
+
+		if err := ctx.BindJSON(&req); err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		token, err := h.service.AddToCart(ctx, cartToken(ctx), productID, &req)
+		if err != nil {
+			handleGuestCartError(ctx, err)
+			return
+		}
+
+		setCartTokenCookie(ctx, token, h.ttl)
+
+		ctx.JSON(http.StatusCreated, gin.H{"message": "Successfully added product to the cart"})
+	}
+}
+
+func (h *GuestCartHandler) UpdateItemInCart() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req dto.CartItemEditReq
+		productID := ctx.Query("product_id")
+		variantID := ctx.Query("variant_id")
+
+		if err := ctx.BindJSON(&req); err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		token := cartToken(ctx)
+		err := h.service.UpdateCartItemById(ctx, token, productID, variantID, &req)
+		if err != nil {
+			handleGuestCartError(ctx, err)
+			return
+		}
+
+		setCartTokenCookie(ctx, token, h.ttl)
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully update product in the cart"})
+	}
+}
+
+func (h *GuestCartHandler) RemoveItemInCart() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		productID := ctx.Query("product_id")
+		variantID := ctx.Query("variant_id")
+
+		token := cartToken(ctx)
+		err := h.service.RemoveCartItemById(ctx, token, productID, variantID)
+		if err != nil {
+			handleGuestCartError(ctx, err)
+			return
+		}
+
+		setCartTokenCookie(ctx, token, h.ttl)
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully remove product from the cart"})
+	}
+}
@@ -1,6 +1,7 @@
 package delivery
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/IndraSty/GreenBasket/domain"
@@ -37,7 +38,12 @@ func (h *OrderHandler) GetAllOrders() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		email := ctx.MustGet("email").(string)
 
-		res, err := h.service.GetAllOrders(ctx, email)
+		page, ok := pageRequest(ctx, domain.OrderPages)
+		if !ok {
+			return
+		}
+
+		res, err := h.service.GetAllOrders(ctx, email, page)
 		if err != nil {
 			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
 			return
@@ -68,15 +74,16 @@ func (h *OrderHandler) FinishOrder() gin.HandlerFunc {
 		email := ctx.MustGet("email").(string)
 		orderID := ctx.Param("order_id")
 		productId := ctx.Query("product_id")
+		variantID := ctx.Query("variant_id")
 
 		if err := ctx.BindJSON(&req); err != nil {
 			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		err := h.service.FinishOrder(ctx, email, orderID, productId, &req)
+		err := h.service.FinishOrder(ctx, email, orderID, productId, variantID, &req)
 		if err != nil {
-			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			util.HandleError(ctx, err, orderStatusCode(err), err.Error())
 			return
 		}
 
@@ -89,13 +96,24 @@ func (h *OrderHandler) CancelOrder() gin.HandlerFunc {
 		email := ctx.MustGet("email").(string)
 		orderID := ctx.Param("order_id")
 		productID := ctx.Query("product_id")
+		variantID := ctx.Query("variant_id")
 
-		err := h.service.CancelOrder(ctx, email, orderID, productID)
+		err := h.service.CancelOrder(ctx, email, orderID, productID, variantID)
 		if err != nil {
-			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			util.HandleError(ctx, err, orderStatusCode(err), err.Error())
 			return
 		}
 
 		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Cancel the Order"})
 	}
 }
+
+// orderStatusCode answers an illegal order status change with 409 Conflict.
+func orderStatusCode(err error) int {
+	var transitionErr *domain.OrderTransitionError
+	if errors.As(err, &transitionErr) {
+		return http.StatusConflict
+	}
+
+	return http.StatusInternalServerError
+}
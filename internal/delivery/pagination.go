@@ -0,0 +1,56 @@
+package delivery
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+// pageRequest reads the sort, size, page and cursor query parameters of a
+// list. It answers 400 and returns false when they are invalid.
+func pageRequest(ctx *gin.Context, spec pagination.Spec) (pagination.Request, bool) {
+	return sortedPageRequest(ctx, spec, ctx.Query("sort"))
+}
+
+func sortedPageRequest(ctx *gin.Context, spec pagination.Spec, sort string) (pagination.Request, bool) {
+	page, err := spec.Parse(sort, ctx.Query("size"), ctx.Query("page"), ctx.Query("cursor"))
+	if err != nil {
+		util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+		return pagination.Request{}, false
+	}
+
+	return page, true
+}
+
+// searchPages is the paging of a product list searched by key, sorted by
+// relevance unless there is no key.
+func searchPages(key string) pagination.Spec {
+	if strings.TrimSpace(key) != "" {
+		return domain.ProductSearchPages
+	}
+	return domain.ProductPages
+}
+
+// legacySort is the sort of the ?price=asc&stock=desc parameters of the sort
+// endpoints, in a fixed order of the keys. A sort parameter takes precedence.
+func legacySort(ctx *gin.Context) string {
+	if sort := ctx.Query("sort"); sort != "" {
+		return sort
+	}
+
+	var keys []string
+	for _, param := range []string{"price", "stock", "created_at", "total_sales", "average_rating"} {
+		switch ctx.Query(param) {
+		case "asc":
+			keys = append(keys, param)
+		case "desc":
+			keys = append(keys, "-"+param)
+		}
+	}
+
+	return strings.Join(keys, ",")
+}
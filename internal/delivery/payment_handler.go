@@ -23,16 +23,9 @@ func (h *PaymentHandler) InitializePayment() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var req dto.PaymentReq
 
-		userID := ctx.MustGet("uid").(string)
-		req.UserID = userID
-
-		orderId := ctx.Query("order_id")
-		req.OrderID = orderId
-
-		if err := ctx.BindJSON(&req); err != nil {
-			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
-			return
-		}
+		// payments are kept by the email of the buyer, like their orders
+		req.UserID = ctx.MustGet("email").(string)
+		req.OrderID = ctx.Query("order_id")
 
 		res, err := h.service.InitializePayment(ctx, &req)
 		if err != nil {
@@ -0,0 +1,93 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentNotificationHandler struct {
+	service     domain.PaymentNotificationService
+	fakeGateway domain.FakePaymentGateway
+}
+
+// NewPaymentNotificationHandler takes a nil fakeGateway unless the fake payment gateway is in use.
+func NewPaymentNotificationHandler(svc domain.PaymentNotificationService, fakeGateway domain.FakePaymentGateway) *PaymentNotificationHandler {
+	return &PaymentNotificationHandler{
+		service:     svc,
+		fakeGateway: fakeGateway,
+	}
+}
+
+// FakeGatewayEnabled reports whether the fake gateway routes should be served.
+func (h *PaymentNotificationHandler) FakeGatewayEnabled() bool {
+	return h.fakeGateway != nil
+}
+
+func (h *PaymentNotificationHandler) PaymentHandlerNotification() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload, err := ctx.GetRawData()
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		h.handleNotification(ctx, payload)
+	}
+}
+
+func (h *PaymentNotificationHandler) GetFakeCharge() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		orderID := ctx.Param("order_id")
+
+		charge, err := h.fakeGateway.GetCharge(ctx, orderID)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusNotFound, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Get charge successfully", "result": charge})
+	}
+}
+
+func (h *PaymentNotificationHandler) SimulateFakePayment() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req struct {
+			TransactionStatus string `json:"transaction_status" binding:"required"`
+		}
+
+		orderID := ctx.Param("order_id")
+
+		if err := ctx.BindJSON(&req); err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		payload, err := h.fakeGateway.Simulate(ctx, orderID, req.TransactionStatus)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		h.handleNotification(ctx, payload)
+	}
+}
+
+// handleNotification processes a webhook payload the same way whether it came
+// from the gateway or from the fake gateway simulator.
+func (h *PaymentNotificationHandler) handleNotification(ctx *gin.Context, payload []byte) {
+	_, err := h.service.HandleNotification(ctx, payload)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidSignature) {
+			util.HandleError(ctx, err, http.StatusForbidden, err.Error())
+			return
+		}
+		util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
@@ -1,6 +1,7 @@
 package delivery
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -33,6 +34,10 @@ func (h *ProductHandler) AddProduct() gin.HandlerFunc {
 		}
 
 		res, err := h.service.CreateProduct(ctx, storeID, email, &req)
+		if errors.Is(err, domain.ErrStoreSuspended) {
+			util.HandleError(ctx, err, http.StatusForbidden, err.Error())
+			return
+		}
 		if err != nil {
 			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
 			return
@@ -55,6 +60,10 @@ func (h *ProductHandler) UpdateProduct() gin.HandlerFunc {
 		}
 
 		_, err := h.service.UpdateProduct(ctx, storeID, email, productID, &req)
+		if errors.Is(err, domain.ErrStoreSuspended) {
+			util.HandleError(ctx, err, http.StatusForbidden, err.Error())
+			return
+		}
 		if err != nil {
 			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
 			return
@@ -84,8 +93,10 @@ func (h *ProductHandler) FetchAllProductSeller() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		email := ctx.MustGet("email").(string)
 		storeID := ctx.Param("store_id")
-		pageStr := ctx.DefaultQuery("page", "1")
-		page, _ := strconv.Atoi(pageStr)
+		page, ok := pageRequest(ctx, domain.ProductPages)
+		if !ok {
+			return
+		}
 
 		res, err := h.service.GetAllProduct(ctx, storeID, email, page)
 		if err != nil {
@@ -102,10 +113,14 @@ func (h *ProductHandler) FetchAllProductByCategorySeller() gin.HandlerFunc {
 		email := ctx.MustGet("email").(string)
 		storeID := ctx.Param("store_id")
 		category := ctx.Query("key")
-		pageStr := ctx.DefaultQuery("page", "1")
-		page, _ := strconv.Atoi(pageStr)
+		page, ok := pageRequest(ctx, domain.ProductPages)
+		if !ok {
+			return
+		}
+		// subcategories are included unless ?descendants=false
+		descendants := ctx.DefaultQuery("descendants", "true") != "false"
 
-		res, err := h.service.GetAllByCategory(ctx, email, storeID, category, page)
+		res, err := h.service.GetAllByCategory(ctx, email, storeID, category, descendants, page)
 		if err != nil {
 			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
 			return
@@ -120,8 +135,10 @@ func (h *ProductHandler) SearchProduct() gin.HandlerFunc {
 		email := ctx.MustGet("email").(string)
 		storeID := ctx.Param("store_id")
 		query := ctx.Query("key")
-		pageStr := ctx.DefaultQuery("page", "1")
-		page, _ := strconv.Atoi(pageStr)
+		page, ok := pageRequest(ctx, searchPages(query))
+		if !ok {
+			return
+		}
 
 		res, err := h.service.SearchProduct(ctx, email, storeID, query, page)
 		if err != nil {
@@ -137,17 +154,12 @@ func (h *ProductHandler) SortProduct() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		email := ctx.MustGet("email").(string)
 		storeID := ctx.Param("store_id")
-		pageStr := ctx.DefaultQuery("page", "1")
-		page, _ := strconv.Atoi(pageStr)
-
-		sortParams := make(map[string]string)
-		for _, param := range []string{"price", "stock", "created_at", "total_sales", "average_rating"} {
-			if direction := ctx.Query(param); direction != "" {
-				sortParams[param] = direction
-			}
+		page, ok := sortedPageRequest(ctx, domain.ProductPages, legacySort(ctx))
+		if !ok {
+			return
 		}
 
-		res, err := h.service.GetAllProductSorted(ctx, sortParams, page, email, storeID)
+		res, err := h.service.GetAllProduct(ctx, storeID, email, page)
 		if err != nil {
 			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
 			return
@@ -177,8 +189,10 @@ func (h *ProductHandler) DeleteProduct() gin.HandlerFunc {
 
 func (h *ProductHandler) FetchAllProductForGuest() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		pageStr := ctx.DefaultQuery("page", "1")
-		page, _ := strconv.Atoi(pageStr)
+		page, ok := pageRequest(ctx, domain.ProductPages)
+		if !ok {
+			return
+		}
 		res, err := h.service.GetAllProductForGuest(ctx, page)
 		if err != nil {
 			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
@@ -193,9 +207,19 @@ func (h *ProductHandler) SearchProductForGuest() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		query := ctx.Query("key")
 		sort := ctx.Query("sort")
-		pageStr := ctx.DefaultQuery("page", "1")
-		page, _ := strconv.Atoi(pageStr)
-		res, err := h.service.SearchProductForGuest(ctx, page, query, sort)
+		// ?sort=asc and ?sort=desc sort by price, as they always did
+		switch sort {
+		case "asc":
+			sort = "price"
+		case "desc":
+			sort = "-price"
+		}
+		page, ok := sortedPageRequest(ctx, searchPages(query), sort)
+		if !ok {
+			return
+		}
+
+		res, err := h.service.SearchProductForGuest(ctx, query, page)
 		if err != nil {
 			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
 			return
@@ -208,10 +232,14 @@ func (h *ProductHandler) SearchProductForGuest() gin.HandlerFunc {
 func (h *ProductHandler) FetchAllProductByCategoryForGuest() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		category := ctx.Query("key")
-		pageStr := ctx.DefaultQuery("page", "1")
-		page, _ := strconv.Atoi(pageStr)
+		page, ok := pageRequest(ctx, domain.ProductPages)
+		if !ok {
+			return
+		}
+		// subcategories are included unless ?descendants=false
+		descendants := ctx.DefaultQuery("descendants", "true") != "false"
 
-		res, err := h.service.GetAllByCategoryForGuest(ctx, category, page)
+		res, err := h.service.GetAllByCategoryForGuest(ctx, category, descendants, page)
 		if err != nil {
 			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
 			return
@@ -236,16 +264,12 @@ func (h *ProductHandler) FetchProductForGuest() gin.HandlerFunc {
 
 func (h *ProductHandler) SortProductForGuest() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		pageStr := ctx.DefaultQuery("page", "1")
-		page, _ := strconv.Atoi(pageStr)
-		sortParams := make(map[string]string)
-		for _, param := range []string{"price", "stock", "created_at", "total_sales", "average_rating"} {
-			if direction := ctx.Query(param); direction != "" {
-				sortParams[param] = direction
-			}
+		page, ok := sortedPageRequest(ctx, domain.ProductPages, legacySort(ctx))
+		if !ok {
+			return
 		}
 
-		res, err := h.service.GetAllProductSortedForCust(ctx, sortParams, page)
+		res, err := h.service.GetAllProductForGuest(ctx, page)
 		if err != nil {
 			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
 			return
@@ -254,3 +278,49 @@ func (h *ProductHandler) SortProductForGuest() gin.HandlerFunc {
 		ctx.JSON(http.StatusOK, gin.H{"message": "Sort product for Cust Successfully", "result": res})
 	}
 }
+
+func (h *ProductHandler) FetchCatalogueForGuest() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		page, ok := pageRequest(ctx, searchPages(ctx.Query("key")))
+		if !ok {
+			return
+		}
+
+		query := dto.CatalogueQuery{
+			Key:      ctx.Query("key"),
+			Category: ctx.Query("category"),
+			// subcategories are included unless ?descendants=false
+			Descendants: ctx.DefaultQuery("descendants", "true") != "false",
+			In_Stock:    ctx.Query("in_stock") == "true",
+			City:        ctx.Query("city"),
+			Store_Id:    ctx.Query("store_id"),
+		}
+
+		for param, value := range map[string]*float64{
+			"min_price":  &query.Min_Price,
+			"max_price":  &query.Max_Price,
+			"min_rating": &query.Min_Rating,
+		} {
+			if raw := ctx.Query(param); raw != "" {
+				number, err := strconv.ParseFloat(raw, 64)
+				if err != nil {
+					util.HandleError(ctx, err, http.StatusBadRequest, "invalid "+param)
+					return
+				}
+				*value = number
+			}
+		}
+
+		res, err := h.service.GetCatalogue(ctx, &query, page)
+		if err != nil {
+			if errors.Is(err, domain.ErrInvalidCatalogueQuery) || errors.Is(err, domain.ErrCategoryNotFound) {
+				util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+				return
+			}
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Fetch catalogue for guest Successfully", "result": res})
+	}
+}
@@ -0,0 +1,107 @@
+package delivery
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize is the largest import file accepted, in bytes.
+const maxImportSize = 5 << 20
+
+type ProductImportHandler struct {
+	service domain.ProductImportService
+}
+
+func NewProductImportHandler(s domain.ProductImportService) *ProductImportHandler {
+	return &ProductImportHandler{
+		service: s,
+	}
+}
+
+// ImportProducts takes the products as a multipart "file", the format is
+// taken from the file extension unless the format query is set.
+func (h *ProductImportHandler) ImportProducts() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		email := ctx.MustGet("email").(string)
+		storeID := ctx.Param("store_id")
+
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportSize)
+		header, err := ctx.FormFile("file")
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		format := domain.ImportFormatCSV
+		switch strings.ToLower(filepath.Ext(header.Filename)) {
+		case ".jsonl", ".ndjson":
+			format = domain.ImportFormatJSONL
+		}
+		format = ctx.DefaultQuery("format", format)
+
+		file, err := header.Open()
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+		defer file.Close()
+
+		res, err := h.service.Import(ctx, email, storeID, format, file)
+		if errors.Is(err, domain.ErrStoreSuspended) {
+			util.HandleError(ctx, err, http.StatusForbidden, err.Error())
+			return
+		}
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusAccepted, gin.H{"message": "Import Products Started", "result": res})
+	}
+}
+
+func (h *ProductImportHandler) FetchImportJob() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		email := ctx.MustGet("email").(string)
+		storeID := ctx.Param("store_id")
+		jobID := ctx.Param("job_id")
+
+		res, err := h.service.GetImportJob(ctx, email, storeID, jobID)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Fetch Import Job Successfully", "result": res})
+	}
+}
+
+func (h *ProductImportHandler) ExportProducts() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		email := ctx.MustGet("email").(string)
+		storeID := ctx.Param("store_id")
+
+		ctx.Header("Content-Type", "text/csv")
+		ctx.Header("Content-Disposition", `attachment; filename="products-`+storeID+`.csv"`)
+
+		err := h.service.Export(ctx, email, storeID, ctx.Writer)
+		if err != nil {
+			// the status is gone once the first rows are out
+			if ctx.Writer.Written() {
+				log.Println("failed to export products of store "+storeID+": ", err)
+				return
+			}
+
+			ctx.Writer.Header().Del("Content-Type")
+			ctx.Writer.Header().Del("Content-Disposition")
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type RefundHandler struct {
+	service domain.RefundService
+}
+
+func NewRefundHandler(s domain.RefundService) *RefundHandler {
+	return &RefundHandler{
+		service: s,
+	}
+}
+
+func (h *RefundHandler) RequestRefund() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req dto.RefundReq
+		email := ctx.MustGet("email").(string)
+		orderID := ctx.Param("order_id")
+		productID := ctx.Query("product_id")
+		variantID := ctx.Query("variant_id")
+
+		if err := ctx.BindJSON(&req); err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		res, err := h.service.RequestRefund(ctx, email, orderID, productID, variantID, &req)
+		if err != nil {
+			util.HandleError(ctx, err, orderStatusCode(err), err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{"message": "Successfully Request a Refund", "result": res})
+	}
+}
+
+func (h *RefundHandler) GetUserRefunds() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		email := ctx.MustGet("email").(string)
+
+		page, ok := pageRequest(ctx, domain.RefundPages)
+		if !ok {
+			return
+		}
+
+		res, err := h.service.GetUserRefunds(ctx, email, page)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Fetch all Refunds", "result": res})
+	}
+}
+
+func (h *RefundHandler) GetSellerRefunds() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		email := ctx.MustGet("email").(string)
+
+		page, ok := pageRequest(ctx, domain.RefundPages)
+		if !ok {
+			return
+		}
+
+		res, err := h.service.GetSellerRefunds(ctx, email, page)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Fetch all Refunds", "result": res})
+	}
+}
+
+// UpdateRefundStatus lets the seller approve or reject a refund.
+func (h *RefundHandler) UpdateRefundStatus() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req dto.RefundStatusUpdateReq
+		email := ctx.MustGet("email").(string)
+		refundID := ctx.Param("refund_id")
+
+		if err := ctx.BindJSON(&req); err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var err error
+		switch req.Status {
+		case domain.RefundApproved:
+			err = h.service.ApproveRefund(ctx, email, refundID)
+		case domain.RefundRejected:
+			err = h.service.RejectRefund(ctx, email, refundID)
+		default:
+			err = errors.New("status must be '" + domain.RefundApproved + "' or '" + domain.RefundRejected + "'")
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err != nil {
+			util.HandleError(ctx, err, orderStatusCode(err), err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Update the Refund Status"})
+	}
+}
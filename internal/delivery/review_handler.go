@@ -97,7 +97,12 @@ func (h *ReviewHandler) GetAllReviewByProductId() gin.HandlerFunc {
 		email := ctx.MustGet("email").(string)
 		productId := ctx.Query("product_id")
 
-		res, err := h.service.GetAllReviewByProductId(ctx, email, productId)
+		page, ok := pageRequest(ctx, domain.ReviewPages)
+		if !ok {
+			return
+		}
+
+		res, err := h.service.GetAllReviewByProductId(ctx, productId, email, page)
 		if err != nil {
 			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
 			return
@@ -111,7 +116,12 @@ func (h *ReviewHandler) GetAllReviewBySellerEmail() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		email := ctx.MustGet("email").(string)
 
-		res, err := h.service.GetAllReviewBySellerEmail(ctx, email)
+		page, ok := pageRequest(ctx, domain.ReviewPages)
+		if !ok {
+			return
+		}
+
+		res, err := h.service.GetAllReviewBySellerEmail(ctx, email, page)
 		if err != nil {
 			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
 			return
@@ -125,7 +135,12 @@ func (h *ReviewHandler) GetAllReviewByUserEmail() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		email := ctx.MustGet("email").(string)
 
-		res, err := h.service.GetAllReviewByUserEmail(ctx, email)
+		page, ok := pageRequest(ctx, domain.ReviewPages)
+		if !ok {
+			return
+		}
+
+		res, err := h.service.GetAllReviewByUserEmail(ctx, email, page)
 		if err != nil {
 			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
 			return
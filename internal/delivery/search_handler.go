@@ -0,0 +1,34 @@
+package delivery
+
+import (
+	"net/http"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	service domain.ProductSearchService
+}
+
+func NewSearchHandler(s domain.ProductSearchService) *SearchHandler {
+	return &SearchHandler{
+		service: s,
+	}
+}
+
+// SuggestProducts returns product names for the search being typed in key.
+func (h *SearchHandler) SuggestProducts() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		prefix := ctx.Query("key")
+
+		res, err := h.service.Suggest(ctx, prefix)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Suggest products Successfully", "result": res})
+	}
+}
@@ -3,7 +3,6 @@ package delivery
 import (
 	"log"
 	"net/http"
-	"time"
 
 	"github.com/IndraSty/GreenBasket/domain"
 	dto "github.com/IndraSty/GreenBasket/dto"
@@ -13,11 +12,13 @@ import (
 
 type SellerHandler struct {
 	service domain.SellerService
+	authSvc domain.AuthService
 }
 
-func NewSellerHandler(s domain.SellerService) *SellerHandler {
+func NewSellerHandler(s domain.SellerService, authSvc domain.AuthService) *SellerHandler {
 	return &SellerHandler{
 		service: s,
+		authSvc: authSvc,
 	}
 }
 
@@ -53,12 +54,7 @@ func (sh *SellerHandler) AuthenticateSeller() gin.HandlerFunc {
 			return
 		}
 
-		http.SetCookie(ctx.Writer, &http.Cookie{
-			Name:     "refresh_token",
-			Value:    res.Refresh_Token,
-			Expires:  time.Now().Add(168 * time.Hour),
-			HttpOnly: true,
-		})
+		setRefreshTokenCookie(ctx, res.Refresh_Token)
 
 		ctx.JSON(http.StatusOK, gin.H{"access_token": res.Access_Token})
 	}
@@ -104,14 +100,83 @@ func (h *SellerHandler) LogoutSellerHandler() gin.HandlerFunc {
 			util.HandleError(ctx, nil, http.StatusUnauthorized, msg)
 			return
 		}
-		http.SetCookie(ctx.Writer, &http.Cookie{
-			Name:     "refresh_token",
-			Value:    "",
-			Path:     "/api/sellers",
-			Expires:  time.Unix(0, 0),
-			HttpOnly: true,
-		})
+
+		err := h.authSvc.Logout(ctx, ctx.MustGet("claims").(*domain.SignedDetails))
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+		setRefreshTokenCookie(ctx, "")
 
 		ctx.JSON(http.StatusOK, gin.H{"message": "Ok"})
 	}
 }
+
+// LogoutAllSellerSessions logs the seller out on every device.
+func (h *SellerHandler) LogoutAllSellerSessions() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims := ctx.MustGet("claims").(*domain.SignedDetails)
+
+		err := h.authSvc.LogoutAllSessions(ctx, claims)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+		setRefreshTokenCookie(ctx, "")
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Logged out from all sessions"})
+	}
+}
+
+// AddStaff adds a staff account working on one store of the seller.
+func (h *SellerHandler) AddStaff() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		email := ctx.MustGet("email").(string)
+		storeID := ctx.Param("store_id")
+
+		var req dto.SellerRegisterReq
+		if err := ctx.BindJSON(&req); err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		res, err := h.service.AddStaff(ctx, email, storeID, &req)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{"result": res})
+	}
+}
+
+func (h *SellerHandler) FetchStaff() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		email := ctx.MustGet("email").(string)
+		storeID := ctx.Param("store_id")
+
+		res, err := h.service.GetStaff(ctx, email, storeID)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Fetch Staff Successfully", "result": res})
+	}
+}
+
+func (h *SellerHandler) RemoveStaff() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		email := ctx.MustGet("email").(string)
+		storeID := ctx.Param("store_id")
+		sellerID := ctx.Param("seller_id")
+
+		err := h.service.RemoveStaff(ctx, email, storeID, sellerID)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Remove Staff Successfully"})
+	}
+}
@@ -23,7 +23,12 @@ func (h *SellerOrderHandler) GetAllSellerOrders() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		email := ctx.MustGet("email").(string)
 
-		res, err := h.service.GetAllSellerOrders(ctx, email)
+		page, ok := pageRequest(ctx, domain.SellerOrderPages)
+		if !ok {
+			return
+		}
+
+		res, err := h.service.GetAllSellerOrders(ctx, email, page)
 		if err != nil {
 			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
 			return
@@ -54,15 +59,16 @@ func (h *SellerOrderHandler) UpdateStatusOrder() gin.HandlerFunc {
 		email := ctx.MustGet("email").(string)
 		orderID := ctx.Param("order_id")
 		productID := ctx.Query("product_id")
+		variantID := ctx.Query("variant_id")
 
 		if err := ctx.BindJSON(&req); err != nil {
 			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
 			return
 		}
 
-		err := h.service.UpdateSellerAndUserOrderStatus(ctx, email, orderID, productID, &req)
+		err := h.service.UpdateSellerAndUserOrderStatus(ctx, email, orderID, productID, variantID, &req)
 		if err != nil {
-			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			util.HandleError(ctx, err, orderStatusCode(err), err.Error())
 			return
 		}
 
@@ -75,10 +81,11 @@ func (h *SellerOrderHandler) CancelOrder() gin.HandlerFunc {
 		email := ctx.MustGet("email").(string)
 		orderID := ctx.Param("order_id")
 		productID := ctx.Query("product_id")
+		variantID := ctx.Query("variant_id")
 
-		err := h.service.CancelOrder(ctx, email, orderID, productID)
+		err := h.service.CancelOrder(ctx, email, orderID, productID, variantID)
 		if err != nil {
-			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			util.HandleError(ctx, err, orderStatusCode(err), err.Error())
 			return
 		}
 
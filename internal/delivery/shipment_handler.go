@@ -0,0 +1,81 @@
+package delivery
+
+import (
+	"net/http"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type ShipmentHandler struct {
+	service domain.ShipmentService
+}
+
+func NewShipmentHandler(s domain.ShipmentService) *ShipmentHandler {
+	return &ShipmentHandler{
+		service: s,
+	}
+}
+
+func (h *ShipmentHandler) AddShipment() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req dto.ShipmentReq
+		email := ctx.MustGet("email").(string)
+		orderID := ctx.Param("order_id")
+		productID := ctx.Query("product_id")
+		variantID := ctx.Query("variant_id")
+
+		if err := ctx.BindJSON(&req); err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err := h.service.AddShipment(ctx, email, orderID, productID, variantID, &req)
+		if err != nil {
+			util.HandleError(ctx, err, orderStatusCode(err), err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{"message": "Successfully Add the Shipment"})
+	}
+}
+
+func (h *ShipmentHandler) UpdateShipment() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req dto.ShipmentReq
+		email := ctx.MustGet("email").(string)
+		orderID := ctx.Param("order_id")
+		productID := ctx.Query("product_id")
+		variantID := ctx.Query("variant_id")
+
+		if err := ctx.BindJSON(&req); err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err := h.service.UpdateShipment(ctx, email, orderID, productID, variantID, &req)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Update the Shipment"})
+	}
+}
+
+func (h *ShipmentHandler) GetOrderShipments() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		email := ctx.MustGet("email").(string)
+		orderID := ctx.Param("order_id")
+
+		res, err := h.service.GetOrderShipments(ctx, email, orderID)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Fetch the Shipments", "result": res})
+	}
+}
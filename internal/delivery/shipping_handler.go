@@ -0,0 +1,39 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type ShippingHandler struct {
+	service domain.ShippingService
+}
+
+func NewShippingHandler(s domain.ShippingService) *ShippingHandler {
+	return &ShippingHandler{
+		service: s,
+	}
+}
+
+// QuoteShipping shows the buyer the shipping of the selected cart items before the order is placed.
+func (h *ShippingHandler) QuoteShipping() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		email := ctx.MustGet("email").(string)
+
+		res, err := h.service.QuoteCart(ctx, email)
+		if errors.Is(err, domain.ErrNoShippingRate) {
+			util.HandleError(ctx, err, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Successfully Quote the Shipping", "result": res})
+	}
+}
@@ -0,0 +1,62 @@
+package delivery
+
+import (
+	"net/http"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type StockLedgerHandler struct {
+	service domain.StockLedgerService
+}
+
+func NewStockLedgerHandler(s domain.StockLedgerService) *StockLedgerHandler {
+	return &StockLedgerHandler{
+		service: s,
+	}
+}
+
+func (h *StockLedgerHandler) AdjustStock() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req dto.StockAdjustmentReq
+		email := ctx.MustGet("email").(string)
+		storeID := ctx.Param("store_id")
+		productID := ctx.Query("product_id")
+
+		if err := ctx.BindJSON(&req); err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err := h.service.AdjustStock(ctx, email, storeID, productID, &req)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Adjust Stock Successfully"})
+	}
+}
+
+func (h *StockLedgerHandler) FetchMovements() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		email := ctx.MustGet("email").(string)
+		storeID := ctx.Param("store_id")
+		productID := ctx.Query("product_id")
+		page, ok := pageRequest(ctx, domain.MovementPages)
+		if !ok {
+			return
+		}
+
+		res, err := h.service.GetMovements(ctx, email, storeID, productID, page)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "Fetch Stock Movements Successfully", "result": res})
+	}
+}
@@ -0,0 +1,77 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+// uploadFormOverhead is room for the multipart headers around the image.
+const uploadFormOverhead = 64 << 10
+
+type UploadHandler struct {
+	service  domain.UploadService
+	maxSize  int64
+	localDir string
+}
+
+// NewUploadHandler takes the largest image accepted, and the directory of
+// the local storage to serve, or "" when the images are stored elsewhere.
+func NewUploadHandler(s domain.UploadService, maxSize int64, localDir string) *UploadHandler {
+	return &UploadHandler{
+		service:  s,
+		maxSize:  maxSize,
+		localDir: localDir,
+	}
+}
+
+// LocalDir is the directory to serve the uploads from, "" when the storage
+// serves them itself.
+func (h *UploadHandler) LocalDir() string {
+	return h.localDir
+}
+
+// UploadImage takes the image as a multipart "file" and returns its url and
+// the url of its thumbnail.
+func (h *UploadHandler) UploadImage() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		email := ctx.MustGet("email").(string)
+
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, h.maxSize+uploadFormOverhead)
+		header, err := ctx.FormFile("file")
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				util.HandleError(ctx, err, http.StatusRequestEntityTooLarge, domain.ErrImageTooLarge.Error())
+				return
+			}
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+		defer file.Close()
+
+		res, err := h.service.UploadImage(ctx, email, file)
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrImageTooLarge):
+				util.HandleError(ctx, err, http.StatusRequestEntityTooLarge, err.Error())
+			case errors.Is(err, domain.ErrUnsupportedImage):
+				util.HandleError(ctx, err, http.StatusUnsupportedMediaType, err.Error())
+			default:
+				util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{"message": "Upload Image Successfully", "result": res})
+	}
+}
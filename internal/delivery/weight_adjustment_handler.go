@@ -0,0 +1,43 @@
+package delivery
+
+import (
+	"net/http"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type WeightAdjustmentHandler struct {
+	service domain.WeightAdjustmentService
+}
+
+func NewWeightAdjustmentHandler(s domain.WeightAdjustmentService) *WeightAdjustmentHandler {
+	return &WeightAdjustmentHandler{
+		service: s,
+	}
+}
+
+func (h *WeightAdjustmentHandler) AdjustPackedQuantity() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req dto.PackedQuantityReq
+		email := ctx.MustGet("email").(string)
+		orderID := ctx.Param("order_id")
+		productID := ctx.Query("product_id")
+		variantID := ctx.Query("variant_id")
+
+		if err := ctx.BindJSON(&req); err != nil {
+			util.HandleError(ctx, err, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		res, err := h.service.AdjustPackedQuantity(ctx, email, orderID, productID, variantID, &req)
+		if err != nil {
+			util.HandleError(ctx, err, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{"message": "Successfully Enter the Packed Quantity", "result": res})
+	}
+}
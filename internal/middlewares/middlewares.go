@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -9,20 +10,29 @@ import (
 )
 
 type Middleware struct {
-	tokenSvc domain.TokenService
+	tokenSvc        domain.TokenService
+	revocationStore domain.TokenRevocationStore
 }
 
-func NewMiddleware(tokenSvc domain.TokenService) *Middleware {
+func NewMiddleware(tokenSvc domain.TokenService, revocationStore domain.TokenRevocationStore) *Middleware {
 	return &Middleware{
-		tokenSvc: tokenSvc,
+		tokenSvc:        tokenSvc,
+		revocationStore: revocationStore,
 	}
 }
 
 func (m *Middleware) UserAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
-		clientToken := strings.Split(authHeader, " ")[1]
+		headerParts := strings.Split(authHeader, " ")
+
+		if len(headerParts) != 2 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header"})
+			c.Abort()
+			return
+		}
 
+		clientToken := headerParts[1]
 		if clientToken == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorization"})
 			c.Abort()
@@ -31,13 +41,19 @@ func (m *Middleware) UserAuthMiddleware() gin.HandlerFunc {
 
 		claims, err := m.tokenSvc.ValidateToken(clientToken)
 		if err != "" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err})
 			c.Abort()
 			return
 		}
 
+		if !m.checkRevoked(c, claims) {
+			return
+		}
+
 		c.Set("email", claims.Email)
 		c.Set("uid", claims.Uid)
+		c.Set("role", claims.Role)
+		c.Set("claims", claims)
 
 		c.Next()
 	}
@@ -68,9 +84,99 @@ func (m *Middleware) SellerAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		if !m.checkRevoked(c, claims) {
+			return
+		}
+
 		c.Set("email", claims.Email)
 		c.Set("uid", claims.Uid)
+		c.Set("role", claims.Role)
+		c.Set("claims", claims)
+
+		c.Next()
+	}
+}
+
+// RequireRole only lets tokens of one of roles through, it runs after
+// UserAuthMiddleware or SellerAuthMiddleware.
+func (m *Middleware) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+
+		for _, r := range roles {
+			if r == role {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "you don't have access to this resource"})
+		c.Abort()
+	}
+}
+
+// RequirePermission lets the roles granted permission by domain.RolePermissions through.
+func (m *Middleware) RequirePermission(permission domain.Permission) gin.HandlerFunc {
+	return m.RequireRole(domain.RolesWith(permission)...)
+}
+
+// ScopeStaff lets seller staff act for the seller owning their store, on that
+// store only. It runs after SellerAuthMiddleware.
+func (m *Middleware) ScopeStaff() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("claims").(*domain.SignedDetails)
+		if claims.Role != domain.RoleSellerStaff {
+			c.Next()
+			return
+		}
+
+		storeID := c.Param("store_id")
+		if claims.Staff == nil || (storeID != "" && storeID != claims.Staff.Store_Id) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you don't have access to this resource"})
+			c.Abort()
+			return
+		}
+
+		c.Set("email", claims.Staff.Store_Email)
 
 		c.Next()
 	}
 }
+
+// checkRevoked aborts the request when the token was logged out, on its own
+// or with all the sessions of its account, and reports whether it may go on.
+func (m *Middleware) checkRevoked(c *gin.Context, claims *domain.SignedDetails) bool {
+	err := m.tokenRevoked(claims)
+	if errors.Is(err, domain.ErrTokenRevoked) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Abort()
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Abort()
+		return false
+	}
+
+	return true
+}
+
+func (m *Middleware) tokenRevoked(claims *domain.SignedDetails) error {
+	revoked, err := m.revocationStore.IsRevoked(claims.Id)
+	if err != nil {
+		return errors.New("failed to check token revocation: " + err.Error())
+	}
+	if revoked {
+		return domain.ErrTokenRevoked
+	}
+
+	tokenVersion, err := m.revocationStore.TokenVersion(claims.Account, claims.Uid)
+	if err != nil {
+		return errors.New("failed to get token version: " + err.Error())
+	}
+	if claims.Token_Version < tokenVersion {
+		return domain.ErrTokenRevoked
+	}
+
+	return nil
+}
@@ -0,0 +1,333 @@
+// Package pagination parses the sort and paging parameters of the list
+// endpoints and turns them into mongo queries.
+//
+// A sort is a comma separated list of keys, each optionally prefixed with "-"
+// for a descending order, e.g. "-average_rating,price". Only the keys of the
+// Spec of a list are accepted and its tiebreaker is always sorted last, so
+// the order is total and pages never overlap.
+//
+// A list is paged either by page number or by cursor. The cursor returned
+// with a page holds the sort values of its last item, the next page starts
+// right after them without skipping documents, so deep pages stay fast.
+package pagination
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidSize   = errors.New("invalid page size")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Spec is what a list accepts.
+type Spec struct {
+	// Keys maps the sort keys accepted from a client to document fields. The
+	// fields must be set on every document, a cursor can not page past a
+	// missing value.
+	Keys map[string]string
+	// Default is the sort when the client gives none.
+	Default string
+	// Tiebreaker is a unique field sorted after the other keys, in the
+	// direction of the first one so that an ObjectID keeps "-created_at"
+	// newest first.
+	Tiebreaker string
+	// Types are the BSON types of the fields of Keys and of the Tiebreaker, a
+	// cursor holding a value of another type, e.g. a document that would be
+	// read as a query operator, is invalid. The number types stand for each
+	// other.
+	Types map[string]bsontype.Type
+	// DefaultSize is the page size when the client gives none, MaxSize the
+	// largest it may ask for.
+	DefaultSize int
+	MaxSize     int
+}
+
+// Key is a document field of a sort.
+type Key struct {
+	Field string
+	Desc  bool
+}
+
+// Request is a validated page of a list, built by Spec.Parse or
+// Spec.Offset.
+type Request struct {
+	Sort []Key
+	Size int
+	// Page is the page number, it is ignored when After is set.
+	Page int
+	// After are the sort values of the last item of the previous page.
+	After bson.A
+
+	// order identifies Sort in the cursors.
+	order string
+	// types are the BSON types of the fields of Sort.
+	types map[string]bsontype.Type
+}
+
+// Page is a page of a list without page numbers.
+type Page[T any] struct {
+	Items       []T    `json:"items"`
+	Next_Cursor string `json:"next_cursor,omitempty"`
+}
+
+// cursor is the decoded form of an opaque cursor.
+type cursor struct {
+	Order  string          `bson:"o"`
+	Values []bson.RawValue `bson:"v"`
+}
+
+// Parse validates the sort, size, page and cursor query parameters, an empty
+// parameter takes its default.
+func (s Spec) Parse(sort, size, page, after string) (Request, error) {
+	if sort == "" {
+		sort = s.Default
+	}
+
+	req := Request{Size: s.DefaultSize, Page: 1, types: s.Types}
+	var order []string
+	seen := make(map[string]bool)
+	var names []string
+	if sort != "" {
+		names = strings.Split(sort, ",")
+	}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		field, ok := s.Keys[strings.TrimPrefix(name, "-")]
+		if !ok || seen[field] {
+			return Request{}, ErrInvalidSort
+		}
+		seen[field] = true
+
+		req.Sort = append(req.Sort, Key{Field: field, Desc: strings.HasPrefix(name, "-")})
+		order = append(order, name)
+		if field == s.Tiebreaker {
+			// the tiebreaker is unique, nothing is sorted after it
+			break
+		}
+	}
+	if !seen[s.Tiebreaker] {
+		desc := len(req.Sort) > 0 && req.Sort[0].Desc
+		req.Sort = append(req.Sort, Key{Field: s.Tiebreaker, Desc: desc})
+	}
+	req.order = strings.Join(order, ",")
+
+	if size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 1 {
+			return Request{}, ErrInvalidSize
+		}
+		req.Size = min(n, s.MaxSize)
+	}
+
+	if page != "" {
+		// a bad page number is the first page, as the lists always did
+		if n, err := strconv.Atoi(page); err == nil && n > 1 {
+			req.Page = n
+		}
+	}
+
+	if after != "" {
+		values, err := req.decode(after)
+		if err != nil {
+			return Request{}, err
+		}
+		req.After = values
+	}
+
+	return req, nil
+}
+
+// Offset is page number page of the list in its default sort and size.
+func (s Spec) Offset(page int) Request {
+	req, err := s.Parse("", "", strconv.Itoa(page), "")
+	if err != nil {
+		panic("pagination: invalid default sort " + s.Default)
+	}
+	return req
+}
+
+// Skip is the number of documents before the page.
+func (r Request) Skip() int {
+	if r.After != nil || r.Page < 1 {
+		return 0
+	}
+	return (r.Page - 1) * r.Size
+}
+
+// SortDoc is the $sort document of the request.
+func (r Request) SortDoc() bson.D {
+	sort := make(bson.D, len(r.Sort))
+	for i, key := range r.Sort {
+		direction := 1
+		if key.Desc {
+			direction = -1
+		}
+		sort[i] = bson.E{Key: key.Field, Value: direction}
+	}
+	return sort
+}
+
+// AfterFilter matches the documents after the cursor, it is empty without a
+// cursor.
+func (r Request) AfterFilter() bson.M {
+	if r.After == nil {
+		return bson.M{}
+	}
+
+	var or []bson.M
+	for i, key := range r.Sort {
+		clause := bson.M{}
+		for j := 0; j < i; j++ {
+			clause[r.Sort[j].Field] = r.After[j]
+		}
+		op := "$gt"
+		if key.Desc {
+			op = "$lt"
+		}
+		clause[key.Field] = bson.M{op: r.After[i]}
+		or = append(or, clause)
+	}
+
+	return bson.M{"$or": or}
+}
+
+// Stages are the aggregation stages sorting and paging the documents, the
+// page holds one extra document telling Collect whether there is a next
+// page.
+func (r Request) Stages() []bson.M {
+	var stages []bson.M
+	if r.After != nil {
+		stages = append(stages, bson.M{"$match": r.AfterFilter()})
+	}
+	stages = append(stages, bson.M{"$sort": r.SortDoc()})
+	if skip := r.Skip(); skip > 0 {
+		stages = append(stages, bson.M{"$skip": skip})
+	}
+	return append(stages, bson.M{"$limit": r.Size + 1})
+}
+
+// Find is filter and the find options of the request, the find counterpart
+// of Stages.
+func (r Request) Find(filter bson.M) (bson.M, *options.FindOptions) {
+	if r.After != nil {
+		filter = bson.M{"$and": []bson.M{filter, r.AfterFilter()}}
+	}
+
+	opts := options.Find().
+		SetSort(r.SortDoc()).
+		SetSkip(int64(r.Skip())).
+		SetLimit(int64(r.Size + 1))
+	return filter, opts
+}
+
+// Collect decodes the page from cur, a cursor over Stages or Find, and
+// returns the cursor of the next page, empty on the last page.
+func Collect[T any](ctx context.Context, cur *mongo.Cursor, r Request) ([]T, string, error) {
+	var docs []bson.Raw
+	for cur.Next(ctx) {
+		docs = append(docs, append(bson.Raw(nil), cur.Current...))
+	}
+	if err := cur.Err(); err != nil {
+		return nil, "", err
+	}
+
+	return Decode[T](docs, r)
+}
+
+// Decode decodes the page from docs, the documents left by Stages, and
+// returns the cursor of the next page, empty on the last page.
+func Decode[T any](docs []bson.Raw, r Request) ([]T, string, error) {
+	var next string
+	if len(docs) > r.Size {
+		docs = docs[:r.Size]
+
+		var err error
+		next, err = r.encode(docs[len(docs)-1])
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	items := make([]T, len(docs))
+	for i, doc := range docs {
+		if err := bson.Unmarshal(doc, &items[i]); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return items, next, nil
+}
+
+func (r Request) encode(doc bson.Raw) (string, error) {
+	values := make([]bson.RawValue, len(r.Sort))
+	for i, key := range r.Sort {
+		value, err := doc.LookupErr(strings.Split(key.Field, ".")...)
+		if err != nil {
+			return "", errors.New("failed to read the cursor of " + key.Field + ": " + err.Error())
+		}
+		values[i] = value
+	}
+
+	data, err := bson.Marshal(cursor{Order: r.order, Values: values})
+	if err != nil {
+		return "", errors.New("failed to encode the cursor: " + err.Error())
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func (r Request) decode(after string) (bson.A, error) {
+	data, err := base64.RawURLEncoding.DecodeString(after)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := bson.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	// a cursor only pages the sort it was made for
+	if c.Order != r.order || len(c.Values) != len(r.Sort) {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make(bson.A, len(c.Values))
+	for i, value := range c.Values {
+		if !sameType(r.types[r.Sort[i].Field], value.Type) {
+			return nil, ErrInvalidCursor
+		}
+		if err := value.Unmarshal(&values[i]); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return values, nil
+}
+
+// sameType reports whether a cursor value of type got pages a field of type
+// want. A field without a type takes no cursor at all.
+func sameType(want, got bsontype.Type) bool {
+	if isNumber(want) {
+		return isNumber(got)
+	}
+	return want != 0 && want == got
+}
+
+func isNumber(t bsontype.Type) bool {
+	switch t {
+	case bsontype.Double, bsontype.Int32, bsontype.Int64, bsontype.Decimal128:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type auditLogRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewAuditLogRepository(client *mongo.Client) domain.AuditLogRepository {
+	return &auditLogRepository{
+		Collection: db.OpenCollection(client, "Audit_Logs"),
+	}
+}
+
+// Insert implements domain.AuditLogRepository.
+func (repo *auditLogRepository) Insert(ctx context.Context, log domain.AuditLog) (primitive.ObjectID, error) {
+	result, err := repo.Collection.InsertOne(ctx, log)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+// FindAll implements domain.AuditLogRepository.
+func (repo *auditLogRepository) FindAll(ctx context.Context, page pagination.Request, targetID ...string) (*pagination.Page[domain.AuditLog], error) {
+	filter := bson.M{}
+	if len(targetID) > 0 && targetID[0] != "" {
+		filter["target_id"] = targetID[0]
+	}
+
+	return findPage[domain.AuditLog](ctx, repo.Collection, filter, page)
+}
+
+// findPage returns a page of the documents of collection matching filter.
+func findPage[T any](ctx context.Context, collection *mongo.Collection, filter bson.M, page pagination.Request) (*pagination.Page[T], error) {
+	filter, opts := page.Find(filter)
+	cur, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	items, next, err := pagination.Collect[T](ctx, cur, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pagination.Page[T]{Items: items, Next_Cursor: next}, nil
+}
+
+// searchFilter matches query literally, ignoring case, in any of fields.
+func searchFilter(query string, fields ...string) bson.M {
+	if query == "" {
+		return bson.M{}
+	}
+
+	pattern := primitive.Regex{Pattern: regexp.QuoteMeta(query), Options: "i"}
+	or := bson.A{}
+	for _, field := range fields {
+		or = append(or, bson.M{field: pattern})
+	}
+
+	return bson.M{"$or": or}
+}
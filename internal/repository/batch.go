@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type batchRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewBatchRepository(client *mongo.Client) domain.BatchRepository {
+	return &batchRepository{
+		Collection: db.OpenCollection(client, "Batches"),
+	}
+}
+
+// Insert implements domain.BatchRepository.
+func (repo *batchRepository) Insert(ctx context.Context, batch domain.Batch) (primitive.ObjectID, error) {
+	result, err := repo.Collection.InsertOne(ctx, batch)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+// FindByProductId implements domain.BatchRepository.
+func (repo *batchRepository) FindByProductId(ctx context.Context, storeID, productID string) (*[]domain.Batch, error) {
+	return repo.find(ctx, bson.M{"store_id": storeID, "product_id": productID})
+}
+
+// FindAvailable implements domain.BatchRepository.
+func (repo *batchRepository) FindAvailable(ctx context.Context, productID, variantID string) (*[]domain.Batch, error) {
+	filter := bson.M{
+		"product_id": productID,
+		"variant_id": variantID,
+		"status":     domain.BatchActive,
+		"quantity":   bson.M{"$gt": 0},
+	}
+
+	return repo.find(ctx, filter)
+}
+
+// FindActiveExpiringBefore implements domain.BatchRepository.
+func (repo *batchRepository) FindActiveExpiringBefore(ctx context.Context, t time.Time, storeID ...string) (*[]domain.Batch, error) {
+	filter := bson.M{
+		"status":     domain.BatchActive,
+		"quantity":   bson.M{"$gt": 0},
+		"expires_at": bson.M{"$lt": t},
+	}
+
+	if len(storeID) > 0 {
+		filter["store_id"] = storeID[0]
+	}
+
+	return repo.find(ctx, filter)
+}
+
+// Take implements domain.BatchRepository.
+func (repo *batchRepository) Take(ctx context.Context, batchID string, quantity float64, updateAt time.Time) (*mongo.UpdateResult, error) {
+	filter := bson.M{"batch_id": batchID, "status": domain.BatchActive, "quantity": bson.M{"$gte": quantity}}
+	return repo.inc(ctx, filter, -quantity, updateAt)
+}
+
+// Restore implements domain.BatchRepository.
+func (repo *batchRepository) Restore(ctx context.Context, batchID string, quantity float64, updateAt time.Time) (*mongo.UpdateResult, error) {
+	filter := bson.M{"batch_id": batchID, "status": domain.BatchActive}
+	return repo.inc(ctx, filter, quantity, updateAt)
+}
+
+// Withdraw implements domain.BatchRepository.
+// It returns nil when the batch is not active anymore.
+func (repo *batchRepository) Withdraw(ctx context.Context, batchID string, updateAt time.Time) (*domain.Batch, error) {
+	filter := bson.M{"batch_id": batchID, "status": domain.BatchActive}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: domain.BatchWithdrawn},
+		{Key: "updated_at", Value: updateAt},
+	}}}
+
+	var batch domain.Batch
+	err := repo.Collection.FindOneAndUpdate(ctx, filter, update).Decode(&batch)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &batch, nil
+}
+
+// MarkAlerted implements domain.BatchRepository.
+func (repo *batchRepository) MarkAlerted(ctx context.Context, batchID string) (*mongo.UpdateResult, error) {
+	filter := bson.M{"batch_id": batchID}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "alerted", Value: true}}}}
+
+	return repo.Collection.UpdateOne(ctx, filter, update)
+}
+
+func (repo *batchRepository) inc(ctx context.Context, filter bson.M, quantity float64, updateAt time.Time) (*mongo.UpdateResult, error) {
+	update := bson.D{
+		{Key: "$inc", Value: bson.D{{Key: "quantity", Value: quantity}}},
+		{Key: "$set", Value: bson.D{{Key: "updated_at", Value: updateAt}}},
+	}
+
+	return repo.Collection.UpdateOne(ctx, filter, update)
+}
+
+// find returns the batches the first to expire first.
+func (repo *batchRepository) find(ctx context.Context, filter bson.M) (*[]domain.Batch, error) {
+	var batches []domain.Batch
+	opts := options.Find().SetSort(bson.D{{Key: "expires_at", Value: 1}, {Key: "received_at", Value: 1}})
+	cur, err := repo.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var batch domain.Batch
+		err := cur.Decode(&batch)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return &batches, nil
+}
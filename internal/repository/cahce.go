@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/IndraSty/GreenBasket/domain"
@@ -25,6 +26,9 @@ func NewRedisClient(cnf *config.Config) domain.CacheRepository {
 
 func (r redisCacheRepository) Get(key string) ([]byte, error) {
 	val, err := r.rdb.Get(context.Background(), key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrCacheMiss
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -35,6 +39,34 @@ func (r redisCacheRepository) Set(key string, entry []byte, expiration time.Dura
 	return r.rdb.Set(context.Background(), key, entry, expiration).Err()
 }
 
+func (r redisCacheRepository) SetNX(key string, entry []byte, expiration time.Duration) (bool, error) {
+	return r.rdb.SetNX(context.Background(), key, entry, expiration).Result()
+}
+
+// expireIfEqual compares and expires in one step, so a key taken over by
+// another client in between is left alone.
+var expireIfEqual = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[2]) > 0 then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return redis.call("DEL", KEYS[1])
+`)
+
+func (r redisCacheRepository) ExpireIfEqual(key string, entry []byte, expiration time.Duration) (bool, error) {
+	n, err := expireIfEqual.Run(context.Background(), r.rdb, []string{key}, entry, expiration.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r redisCacheRepository) Incr(key string) (int64, error) {
+	return r.rdb.Incr(context.Background(), key).Result()
+}
+
 func (r redisCacheRepository) Del(key string) error {
 	return r.rdb.Del(context.Background(), key).Err()
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/IndraSty/GreenBasket/db"
 	"github.com/IndraSty/GreenBasket/domain"
 	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -61,6 +62,31 @@ func (repo *cartRepository) GetAllCartItem(ctx context.Context, email string) (*
 	return &cart.Items, nil
 }
 
+// FindItems implements domain.CartRepository.
+func (repo *cartRepository) FindItems(ctx context.Context, email string, page pagination.Request) (*pagination.Page[domain.CartItem], error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"email": email}},
+		{"$unwind": "$items"},
+		{"$replaceRoot": bson.M{"newRoot": bson.M{"$mergeObjects": bson.A{"$items", bson.M{
+			"item_key": bson.M{"$concat": bson.A{"$items.product_id", ":", bson.M{"$ifNull": bson.A{"$items.variant_id", ""}}}},
+		}}}}},
+	}
+	pipeline = append(pipeline, page.Stages()...)
+
+	cur, err := repo.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	items, next, err := pagination.Collect[domain.CartItem](ctx, cur, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pagination.Page[domain.CartItem]{Items: items, Next_Cursor: next}, nil
+}
+
 // GetUserCart implements domain.CartRepository.
 func (repo *cartRepository) GetUserCart(ctx context.Context, email string) (*domain.Cart, error) {
 	var cart domain.Cart
@@ -74,10 +100,11 @@ func (repo *cartRepository) GetUserCart(ctx context.Context, email string) (*dom
 }
 
 // RemoveCartItemById implements domain.CartRepository.
-func (repo *cartRepository) RemoveCartItemById(ctx context.Context, email string, productID string, updateAt time.Time) (*mongo.UpdateResult, error) {
-	filter := bson.M{"email": email, "items.product_id": productID}
+func (repo *cartRepository) RemoveCartItemById(ctx context.Context, email, productID, variantID string, updateAt time.Time) (*mongo.UpdateResult, error) {
+	item := bson.M{"product_id": productID, "variant_id": variantMatch(variantID)}
+	filter := bson.M{"email": email, "items": bson.M{"$elemMatch": item}}
 	update := bson.M{
-		"$pull": bson.M{"items": bson.M{"product_id": productID}},
+		"$pull": bson.M{"items": item},
 		"$set":  bson.M{"updated_at": time.Now()},
 	}
 	result, err := repo.Collection.UpdateOne(ctx, filter, update)
@@ -89,8 +116,8 @@ func (repo *cartRepository) RemoveCartItemById(ctx context.Context, email string
 }
 
 // UpdateCartItemById implements domain.CartRepository.
-func (repo *cartRepository) UpdateCartItemById(ctx context.Context, email string, productID string, value *dto.CartItemEditRepo, updateAt time.Time) (*mongo.UpdateResult, error) {
-	filter := bson.M{"email": email, "items.product_id": productID}
+func (repo *cartRepository) UpdateCartItemById(ctx context.Context, email, productID, variantID string, value *dto.CartItemEditRepo, updateAt time.Time) (*mongo.UpdateResult, error) {
+	filter := bson.M{"email": email, "items": bson.M{"$elemMatch": bson.M{"product_id": productID, "variant_id": variantMatch(variantID)}}}
 	update := bson.M{
 		"$set": bson.M{
 			"items.$.quantity": value.Quantity,
@@ -120,3 +147,35 @@ func (repo *cartRepository) UpdateTotalPrice(ctx context.Context, email string,
 
 	return nil
 }
+
+// RemoveSelectedItems implements domain.CartRepository.
+func (repo *cartRepository) RemoveSelectedItems(ctx context.Context, email string, totalPrice float64, updateAt time.Time) (*mongo.UpdateResult, error) {
+	filter := bson.M{"email": email}
+	update := bson.M{
+		"$pull": bson.M{"items": bson.M{"selected": true}},
+		"$inc":  bson.M{"total_price": -totalPrice},
+		"$set":  bson.M{"updated_at": updateAt},
+	}
+
+	return repo.Collection.UpdateOne(ctx, filter, update)
+}
+
+// ReplaceItems implements domain.CartRepository.
+func (repo *cartRepository) ReplaceItems(ctx context.Context, email string, items []domain.CartItem, totalPrice float64, updateAt time.Time) (*mongo.UpdateResult, error) {
+	filter := bson.M{"email": email}
+	update := bson.M{
+		"$set": bson.M{"items": items, "total_price": totalPrice, "updated_at": updateAt},
+	}
+
+	return repo.Collection.UpdateOne(ctx, filter, update)
+}
+
+// variantMatch matches the variant id of a cart item, items added before
+// products had variants have no variant id at all.
+func variantMatch(variantID string) interface{} {
+	if variantID == "" {
+		return bson.M{"$in": bson.A{nil, ""}}
+	}
+
+	return variantID
+}
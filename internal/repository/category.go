@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type categoryRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewCategoryRepository(client *mongo.Client) domain.CategoryRepository {
+	return &categoryRepository{
+		Collection: db.OpenCollection(client, "Categories"),
+	}
+}
+
+// Insert implements domain.CategoryRepository.
+func (repo *categoryRepository) Insert(ctx context.Context, category domain.Category) (primitive.ObjectID, error) {
+	result, err := repo.Collection.InsertOne(ctx, category)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+// Update implements domain.CategoryRepository.
+func (repo *categoryRepository) Update(ctx context.Context, slug string, update bson.D) (*mongo.UpdateResult, error) {
+	filter := bson.M{"slug": slug}
+	return repo.Collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: update}})
+}
+
+// Delete implements domain.CategoryRepository.
+func (repo *categoryRepository) Delete(ctx context.Context, slug string) (*mongo.DeleteResult, error) {
+	result, err := repo.Collection.DeleteOne(ctx, bson.M{"slug": slug})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.DeletedCount == 0 {
+		return nil, errors.New("no category was deleted")
+	}
+
+	return result, nil
+}
+
+// FindBySlug implements domain.CategoryRepository.
+func (repo *categoryRepository) FindBySlug(ctx context.Context, slug string) (*domain.Category, error) {
+	var category domain.Category
+	err := repo.Collection.FindOne(ctx, bson.M{"slug": slug}).Decode(&category)
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+// FindAll implements domain.CategoryRepository.
+func (repo *categoryRepository) FindAll(ctx context.Context) (*[]domain.Category, error) {
+	var categories []domain.Category
+	opts := options.Find().SetSort(bson.D{{Key: "sort_order", Value: 1}, {Key: "slug", Value: 1}})
+
+	cur, err := repo.Collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var category domain.Category
+		err := cur.Decode(&category)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return &categories, nil
+}
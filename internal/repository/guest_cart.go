@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type guestCartRepository struct {
+	Collection *mongo.Collection
+}
+
+// NewGuestCartRepository keeps guest carts in their own collection with a TTL
+// index on expires_at, and creates the index when it does not exist.
+func NewGuestCartRepository(ctx context.Context, client *mongo.Client) (domain.GuestCartRepository, error) {
+	collection := db.OpenCollection(client, "Guest_Carts")
+
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &guestCartRepository{
+		Collection: collection,
+	}, nil
+}
+
+// FindById implements domain.GuestCartRepository.
+func (repo *guestCartRepository) FindById(ctx context.Context, cartID string) (*domain.GuestCart, error) {
+	// mongo removes expired carts about once a minute, until then they are
+	// left out here
+	filter := bson.M{"_id": cartID, "expires_at": bson.M{"$gt": time.Now()}}
+
+	var cart domain.GuestCart
+	err := repo.Collection.FindOne(ctx, filter).Decode(&cart)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, domain.ErrGuestCartNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &cart, nil
+}
+
+// Save implements domain.GuestCartRepository.
+func (repo *guestCartRepository) Save(ctx context.Context, cart *domain.GuestCart) error {
+	filter := bson.M{"_id": cart.ID}
+	_, err := repo.Collection.ReplaceOne(ctx, filter, cart, options.Replace().SetUpsert(true))
+	return err
+}
+
+// Delete implements domain.GuestCartRepository.
+func (repo *guestCartRepository) Delete(ctx context.Context, cartID string) error {
+	_, err := repo.Collection.DeleteOne(ctx, bson.M{"_id": cartID})
+	return err
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type importJobRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewImportJobRepository(client *mongo.Client) domain.ImportJobRepository {
+	return &importJobRepository{
+		Collection: db.OpenCollection(client, "Import_Jobs"),
+	}
+}
+
+// Insert implements domain.ImportJobRepository.
+func (repo *importJobRepository) Insert(ctx context.Context, job domain.ImportJob) (primitive.ObjectID, error) {
+	result, err := repo.Collection.InsertOne(ctx, job)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+// FindById implements domain.ImportJobRepository.
+func (repo *importJobRepository) FindById(ctx context.Context, storeID, jobID string) (*domain.ImportJob, error) {
+	var job domain.ImportJob
+	filter := bson.M{"store_id": storeID, "job_id": jobID}
+	err := repo.Collection.FindOne(ctx, filter).Decode(&job)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// UpdateProgress implements domain.ImportJobRepository.
+func (repo *importJobRepository) UpdateProgress(ctx context.Context, jobID, status string, created int, rowErrors []domain.ImportRowError, updateAt time.Time) error {
+	filter := bson.M{"job_id": jobID}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: status},
+		{Key: "created", Value: created},
+		{Key: "errors", Value: rowErrors},
+		{Key: "updated_at", Value: updateAt},
+	}}}
+
+	_, err := repo.Collection.UpdateOne(ctx, filter, update)
+	return err
+}
@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/IndraSty/GreenBasket/domain"
+)
+
+// memorySearchIndex is an inverted index kept in memory. Besides the words of
+// the search it matches words the last word of the search starts with, and
+// words one or two typos away, so it serves autocomplete as well. Every
+// replica builds its own index when it starts.
+type memorySearchIndex struct {
+	mu   sync.RWMutex
+	docs map[string]domain.SearchDocument
+	// all holds the words of every field, with the field weights, names
+	// only the words of the names for Suggest.
+	all   *termIndex
+	names *termIndex
+}
+
+func NewMemorySearchIndex() domain.ProductSearchIndex {
+	return &memorySearchIndex{
+		docs:  map[string]domain.SearchDocument{},
+		all:   newTermIndex(),
+		names: newTermIndex(),
+	}
+}
+
+// Index implements domain.ProductSearchIndex.
+func (idx *memorySearchIndex) Index(ctx context.Context, docs ...domain.SearchDocument) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, doc := range docs {
+		idx.remove(doc.Product_Id)
+
+		idx.docs[doc.Product_Id] = doc
+		for _, field := range searchFields(doc) {
+			idx.all.add(doc.Product_Id, field.text, field.weight)
+		}
+		idx.names.add(doc.Product_Id, doc.Name, 1)
+	}
+
+	return nil
+}
+
+// Remove implements domain.ProductSearchIndex.
+func (idx *memorySearchIndex) Remove(ctx context.Context, productIDs ...string) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, productID := range productIDs {
+		idx.remove(productID)
+	}
+
+	return nil
+}
+
+func (idx *memorySearchIndex) remove(productID string) {
+	doc, ok := idx.docs[productID]
+	if !ok {
+		return
+	}
+
+	for _, field := range searchFields(doc) {
+		idx.all.remove(productID, field.text)
+	}
+	idx.names.remove(productID, doc.Name)
+	delete(idx.docs, productID)
+}
+
+// Search implements domain.ProductSearchIndex.
+// A search sorts the words of the index after a change, so it takes the write lock.
+func (idx *memorySearchIndex) Search(ctx context.Context, text, storeID string, limit int) ([]domain.SearchHit, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	scores := idx.all.score(tokenize(text), len(idx.docs), func(productID string) bool {
+		return storeID == "" || idx.docs[productID].Store_Id == storeID
+	})
+
+	return topHits(scores, limit), nil
+}
+
+// Suggest implements domain.ProductSearchIndex.
+func (idx *memorySearchIndex) Suggest(ctx context.Context, prefix string, limit int) ([]string, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	scores := idx.names.score(tokenize(prefix), len(idx.docs), func(string) bool { return true })
+
+	// many stores sell a product of the same name
+	seen := map[string]bool{}
+	names := []string{}
+	for _, hit := range topHits(scores, len(scores)) {
+		name := idx.docs[hit.Product_Id].Name
+		if !seen[strings.ToLower(name)] {
+			seen[strings.ToLower(name)] = true
+			names = append(names, name)
+		}
+		if len(names) == limit {
+			break
+		}
+	}
+
+	return names, nil
+}
+
+type searchField struct {
+	text   string
+	weight float64
+}
+
+// searchFields are the fields of doc with the weights of the mongo text index.
+func searchFields(doc domain.SearchDocument) []searchField {
+	return []searchField{
+		{doc.Name, 10},
+		{doc.Category, 5},
+		{doc.Store_Name, 3},
+		{doc.Description, 1},
+	}
+}
+
+func topHits(scores map[string]float64, limit int) []domain.SearchHit {
+	hits := make([]domain.SearchHit, 0, len(scores))
+	for productID, score := range scores {
+		hits = append(hits, domain.SearchHit{Product_Id: productID, Score: score})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Product_Id < hits[j].Product_Id
+	})
+
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits
+}
+
+// termIndex maps each word to the products it is in and its weight there.
+type termIndex struct {
+	postings map[string]map[string]float64
+	// terms are the words in order for prefix matches, nil after a change.
+	terms []string
+}
+
+func newTermIndex() *termIndex {
+	return &termIndex{postings: map[string]map[string]float64{}}
+}
+
+func (t *termIndex) add(productID, text string, weight float64) {
+	for _, term := range tokenize(text) {
+		if t.postings[term] == nil {
+			t.postings[term] = map[string]float64{}
+			t.terms = nil
+		}
+		t.postings[term][productID] += weight
+	}
+}
+
+func (t *termIndex) remove(productID, text string) {
+	for _, term := range tokenize(text) {
+		delete(t.postings[term], productID)
+		if len(t.postings[term]) == 0 {
+			delete(t.postings, term)
+			t.terms = nil
+		}
+	}
+}
+
+// score adds up, for each word of the search, the best match of the word in
+// each product. A rare word among the total products weighs more than a
+// common one.
+func (t *termIndex) score(tokens []string, total int, keep func(productID string) bool) map[string]float64 {
+	if t.terms == nil {
+		t.terms = make([]string, 0, len(t.postings))
+		for term := range t.postings {
+			t.terms = append(t.terms, term)
+		}
+		sort.Strings(t.terms)
+	}
+
+	scores := map[string]float64{}
+	for i, token := range tokens {
+		best := map[string]float64{}
+		for term, factor := range t.expand(token, i == len(tokens)-1) {
+			postings := t.postings[term]
+			idf := math.Log(1 + float64(total)/float64(len(postings)))
+			for productID, weight := range postings {
+				if !keep(productID) {
+					continue
+				}
+				best[productID] = math.Max(best[productID], weight*factor*idf)
+			}
+		}
+
+		for productID, score := range best {
+			scores[productID] += score
+		}
+	}
+
+	return scores
+}
+
+// expand returns the words matching token with how well they match: the word
+// itself, the words it starts when it is the last word being typed, and the
+// words a typo or two away.
+func (t *termIndex) expand(token string, last bool) map[string]float64 {
+	matches := map[string]float64{}
+	if _, ok := t.postings[token]; ok {
+		matches[token] = 1
+	}
+
+	if last {
+		for i := sort.SearchStrings(t.terms, token); i < len(t.terms) && strings.HasPrefix(t.terms[i], token); i++ {
+			if t.terms[i] != token {
+				matches[t.terms[i]] = 0.8
+			}
+		}
+	}
+
+	maxEdits := 0
+	switch length := len([]rune(token)); {
+	case length >= 8:
+		maxEdits = 2
+	case length >= 4:
+		maxEdits = 1
+	}
+
+	if maxEdits > 0 {
+		for _, term := range t.terms {
+			if _, ok := matches[term]; ok {
+				continue
+			}
+			if edits := editDistance(token, term, maxEdits); edits <= maxEdits {
+				matches[term] = 0.6 / float64(edits)
+			}
+		}
+	}
+
+	return matches
+}
+
+// tokenize splits text into lower case words of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// editDistance is the Levenshtein distance of a and b, or limit+1 once it is
+// known to be more than limit.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff > limit || -diff > limit {
+		return limit + 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, cur[j])
+		}
+
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
@@ -2,13 +2,16 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/IndraSty/GreenBasket/db"
 	"github.com/IndraSty/GreenBasket/domain"
 	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type orderRepository struct {
@@ -58,6 +61,11 @@ func (repo *orderRepository) GetAllOrders(ctx context.Context, email string) (*[
 	return &orders, nil
 }
 
+// FindByEmail implements domain.OrderRepository.
+func (repo *orderRepository) FindByEmail(ctx context.Context, email string, page pagination.Request) (*pagination.Page[domain.Orders], error) {
+	return findPage[domain.Orders](ctx, repo.Collection, bson.M{"email": email}, page)
+}
+
 // GetOrderById implements domain.OrderRepository.
 func (repo *orderRepository) GetOrder(ctx context.Context, orderID string, email ...string) (*domain.Orders, error) {
 	var order domain.Orders
@@ -116,15 +124,152 @@ func (repo *orderRepository) UpdateStatusOrder(ctx context.Context, orderID stri
 }
 
 // DeleteItem implements domain.OrderRepository.
-func (repo *orderRepository) DeleteItem(ctx context.Context, orderID string, productID string) (*mongo.UpdateResult, error) {
+func (repo *orderRepository) DeleteItem(ctx context.Context, orderID, productID, variantID string) (*mongo.UpdateResult, error) {
 	filter := bson.M{"order_id": orderID}
 	update := bson.M{
 		"$pull": bson.M{
-			"items": bson.M{
-				"product_id": productID,
-			},
+			"items": orderItem(productID, variantID),
 		},
 	}
 
 	return repo.Collection.UpdateOne(ctx, filter, update)
 }
+
+// DeleteOrder implements domain.OrderRepository.
+func (repo *orderRepository) DeleteOrder(ctx context.Context, orderID string) (*mongo.DeleteResult, error) {
+	filter := bson.M{"order_id": orderID}
+	return repo.Collection.DeleteOne(ctx, filter)
+}
+
+// UpdateItemStatus implements domain.OrderRepository.
+// The item is only updated while it is still in history.From, so two status
+// changes racing on the same item cannot both succeed.
+func (repo *orderRepository) UpdateItemStatus(ctx context.Context, orderID, productID, variantID string, history domain.OrderStatusHistory) (*mongo.UpdateResult, error) {
+	item := orderItem(productID, variantID)
+	item["order_status"] = history.From
+	filter := bson.M{
+		"order_id": orderID,
+		"items":    bson.M{"$elemMatch": item},
+	}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "items.$.order_status", Value: history.To},
+			{Key: "updated_at", Value: history.Changed_At},
+		}},
+		{Key: "$push", Value: bson.D{{Key: "items.$.status_history", Value: history}}},
+	}
+
+	return repo.Collection.UpdateOne(ctx, filter, update)
+}
+
+// UpdateItemQuantity implements domain.OrderRepository.
+func (repo *orderRepository) UpdateItemQuantity(ctx context.Context, orderID, productID, variantID string, ordered, packed float64, updateAt time.Time) (*mongo.UpdateResult, error) {
+	item := orderItem(productID, variantID)
+	item["order_status"] = domain.OrderProcessed
+	item["ordered_quantity"] = bson.M{"$exists": false}
+	filter := bson.M{
+		"order_id": orderID,
+		"items":    bson.M{"$elemMatch": item},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "items.$.quantity", Value: packed},
+		{Key: "items.$.ordered_quantity", Value: ordered},
+		{Key: "updated_at", Value: updateAt},
+	}}}
+
+	return repo.Collection.UpdateOne(ctx, filter, update)
+}
+
+// UpdateTotalPrice implements domain.OrderRepository.
+func (repo *orderRepository) UpdateTotalPrice(ctx context.Context, orderID string, value float64) (*mongo.UpdateResult, error) {
+	filter := bson.M{"order_id": orderID}
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "total_price", Value: value}}}}
+
+	return repo.Collection.UpdateOne(ctx, filter, update)
+}
+
+// UpdateShippingFee implements domain.OrderRepository.
+func (repo *orderRepository) UpdateShippingFee(ctx context.Context, orderID string, value float64) (*mongo.UpdateResult, error) {
+	filter := bson.M{"order_id": orderID}
+	update := bson.D{{Key: "$inc", Value: bson.D{
+		{Key: "shipping_fee", Value: value},
+		{Key: "total_price", Value: value},
+	}}}
+
+	return repo.Collection.UpdateOne(ctx, filter, update)
+}
+
+// FindShippedBefore implements domain.OrderRepository.
+// Items shipped before the status history was recorded fall back to the
+// updated_at of their order.
+func (repo *orderRepository) FindShippedBefore(ctx context.Context, before time.Time) (*[]domain.Orders, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"items": bson.M{"$elemMatch": bson.M{
+			"order_status": domain.OrderShipped,
+			"status_history": bson.M{"$elemMatch": bson.M{
+				"to":         domain.OrderShipped,
+				"changed_at": bson.M{"$lte": before},
+			}},
+		}}},
+		bson.M{
+			"items": bson.M{"$elemMatch": bson.M{
+				"order_status":   domain.OrderShipped,
+				"status_history": bson.M{"$exists": false},
+			}},
+			"updated_at": bson.M{"$lte": before},
+		},
+	}}
+
+	return repo.find(ctx, filter)
+}
+
+// FindUnpaidBefore implements domain.OrderRepository.
+func (repo *orderRepository) FindUnpaidBefore(ctx context.Context, before time.Time) (*[]domain.Orders, error) {
+	filter := bson.M{
+		"order_date":         bson.M{"$lte": before},
+		"payment.status":     bson.M{"$in": bson.A{"", domain.PaymentPending}},
+		"items.order_status": domain.OrderPending,
+	}
+
+	return repo.find(ctx, filter)
+}
+
+// FindAll implements domain.OrderRepository.
+func (repo *orderRepository) FindAll(ctx context.Context, page pagination.Request) (*pagination.Page[domain.Orders], error) {
+	return findPage[domain.Orders](ctx, repo.Collection, bson.M{}, page)
+}
+
+func (repo *orderRepository) find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) (*[]domain.Orders, error) {
+	var orders []domain.Orders
+	cur, err := repo.Collection.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var order domain.Orders
+		err := cur.Decode(&order)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return &orders, nil
+}
+
+// orderItem matches the item of an order, or of a seller order, holding the
+// variant variantID of the product productID. Items of a product without
+// variants are stored without a variant_id.
+func orderItem(productID, variantID string) bson.M {
+	if variantID == "" {
+		return bson.M{"product_id": productID, "variant_id": bson.M{"$in": bson.A{nil, ""}}}
+	}
+
+	return bson.M{"product_id": productID, "variant_id": variantID}
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/IndraSty/GreenBasket/db"
 	"github.com/IndraSty/GreenBasket/domain"
@@ -46,6 +47,17 @@ func (r *paymentRepository) Insert(ctx context.Context, p *domain.Payment) error
 // Update implements domain.PaymentRepository.
 func (r *paymentRepository) Update(ctx context.Context, orderID string, req *dto.UpdatePaymentReq) (*mongo.UpdateResult, error) {
 	filter := bson.M{"order_id": orderID}
+	return r.update(ctx, filter, req)
+}
+
+// UpdateFromStatus implements domain.PaymentRepository.
+// The payment is only updated while it is still in fromStatus.
+func (r *paymentRepository) UpdateFromStatus(ctx context.Context, orderID, fromStatus string, req *dto.UpdatePaymentReq) (*mongo.UpdateResult, error) {
+	filter := bson.M{"order_id": orderID, "status": fromStatus}
+	return r.update(ctx, filter, req)
+}
+
+func (r *paymentRepository) update(ctx context.Context, filter bson.M, req *dto.UpdatePaymentReq) (*mongo.UpdateResult, error) {
 	var update primitive.D
 
 	if req.Payment_Method != "" {
@@ -57,6 +69,7 @@ func (r *paymentRepository) Update(ctx context.Context, orderID string, req *dto
 	if req.TransactionID != "" {
 		update = append(update, bson.E{Key: "transaction_id", Value: req.TransactionID})
 	}
+	update = append(update, bson.E{Key: "updated_at", Value: time.Now()})
 
 	res, err := r.Collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: update}})
 	if err != nil {
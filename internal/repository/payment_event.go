@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type paymentEventRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewPaymentEventRepository(client *mongo.Client) domain.PaymentEventRepository {
+	return &paymentEventRepository{
+		Collection: db.OpenCollection(client, "PaymentEvents"),
+	}
+}
+
+// Insert implements domain.PaymentEventRepository.
+// Inserting an event that was already received fails with a duplicate key error.
+func (repo *paymentEventRepository) Insert(ctx context.Context, event *domain.PaymentEvent) error {
+	_, err := repo.Collection.InsertOne(ctx, event)
+	return err
+}
+
+// FindById implements domain.PaymentEventRepository.
+func (repo *paymentEventRepository) FindById(ctx context.Context, eventID string) (*domain.PaymentEvent, error) {
+	var event domain.PaymentEvent
+	filter := bson.M{"_id": eventID}
+	err := repo.Collection.FindOne(ctx, filter).Decode(&event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// FindByTransactionId implements domain.PaymentEventRepository.
+func (repo *paymentEventRepository) FindByTransactionId(ctx context.Context, transactionID string) (*[]domain.PaymentEvent, error) {
+	var events []domain.PaymentEvent
+	filter := bson.M{"transaction_id": transactionID}
+	cur, err := repo.Collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var event domain.PaymentEvent
+		err := cur.Decode(&event)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return &events, nil
+}
+
+// Claim implements domain.PaymentEventRepository.
+// Only an unprocessed event whose previous claim has run out can be claimed,
+// so two deliveries of the same event are never processed at the same time.
+func (repo *paymentEventRepository) Claim(ctx context.Context, eventID string, now, lockUntil time.Time) (bool, error) {
+	filter := bson.M{"_id": eventID, "processed": false, "locked_until": bson.M{"$lt": now}}
+	update := bson.M{"$set": bson.M{"locked_until": lockUntil}}
+
+	res, err := repo.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+
+	return res.ModifiedCount == 1, nil
+}
+
+// Unlock implements domain.PaymentEventRepository.
+func (repo *paymentEventRepository) Unlock(ctx context.Context, eventID string) error {
+	filter := bson.M{"_id": eventID}
+	update := bson.M{"$set": bson.M{"locked_until": time.Time{}}}
+
+	_, err := repo.Collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// MarkProcessed implements domain.PaymentEventRepository.
+func (repo *paymentEventRepository) MarkProcessed(ctx context.Context, eventID, result string, processedAt time.Time) error {
+	filter := bson.M{"_id": eventID}
+	update := bson.M{"$set": bson.M{
+		"processed":    true,
+		"result":       result,
+		"processed_at": processedAt,
+	}}
+
+	_, err := repo.Collection.UpdateOne(ctx, filter, update)
+	return err
+}
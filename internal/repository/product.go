@@ -3,14 +3,19 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
+	"regexp"
 	"time"
 
 	"github.com/IndraSty/GreenBasket/db"
 	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type productRepository struct {
@@ -29,6 +34,17 @@ func (repo *productRepository) CheckNameExists(ctx context.Context, name string)
 	return count > 0, err
 }
 
+// CountByCategory implements domain.ProductRepository.
+func (repo *productRepository) CountByCategory(ctx context.Context, category string) (int64, error) {
+	return repo.Collection.CountDocuments(ctx, bson.M{"category": category})
+}
+
+// CountByImage implements domain.ProductRepository.
+func (repo *productRepository) CountByImage(ctx context.Context, url string) (int64, error) {
+	filter := bson.M{"$or": []bson.M{{"images": url}, {"variants.images": url}}}
+	return repo.Collection.CountDocuments(ctx, filter)
+}
+
 // GetAllProductWithNoPage implements domain.ProductRepository.
 func (repo *productRepository) GetAllProductWithNoPage(ctx context.Context, storeID string) (*[]domain.ProductWithSalesData, error) {
 	filter := bson.M{"store_id": storeID}
@@ -110,11 +126,47 @@ func (repo *productRepository) UpdateProduct(ctx context.Context, storeID, produ
 	return repo.Collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: update}})
 }
 
+// UpdateProductsOfStore implements domain.ProductRepository.
+func (repo *productRepository) UpdateProductsOfStore(ctx context.Context, storeID string, update bson.D) (*mongo.UpdateResult, error) {
+	filter := bson.M{"store_id": storeID}
+	return repo.Collection.UpdateMany(ctx, filter, bson.D{{Key: "$set", Value: update}})
+}
+
 // UpdateStockProduct implements domain.ProductRepository.
-func (repo *productRepository) UpdateStockProduct(ctx context.Context, storeID string, productID string, stock int, updatedAt time.Time) (*mongo.UpdateResult, error) {
+func (repo *productRepository) UpdateStockProduct(ctx context.Context, storeID, productID, variantID string, stock float64, updatedAt time.Time) (*mongo.UpdateResult, error) {
 	filter := bson.M{"store_id": storeID, "product_id": productID}
+	inc := bson.D{{Key: "stock", Value: stock}}
+
+	if variantID != "" {
+		filter["variants.variant_id"] = variantID
+		inc = append(inc, bson.E{Key: "variants.$.stock", Value: stock})
+	}
+
 	update := bson.D{
-		{Key: "$inc", Value: bson.D{{Key: "stock", Value: stock}}},
+		{Key: "$inc", Value: inc},
+		{Key: "$set", Value: bson.D{{Key: "updated_at", Value: updatedAt}}},
+	}
+	return repo.Collection.UpdateOne(ctx, filter, update)
+}
+
+// ReserveStock implements domain.ProductRepository.
+// Stock is only decremented when enough units are left, so concurrent
+// checkouts for the last unit cannot both match the filter. Hidden products
+// are never reserved.
+func (repo *productRepository) ReserveStock(ctx context.Context, storeID, productID, variantID string, quantity float64, updatedAt time.Time) (*mongo.UpdateResult, error) {
+	filter := visibleOnly(bson.M{"store_id": storeID, "product_id": productID, "stock": bson.M{"$gte": quantity}})
+	inc := bson.D{{Key: "stock", Value: -quantity}}
+
+	if variantID != "" {
+		filter["variants"] = bson.M{"$elemMatch": bson.M{"variant_id": variantID, "stock": bson.M{"$gte": quantity}}}
+		inc = append(inc, bson.E{Key: "variants.$.stock", Value: -quantity})
+	} else {
+		// the stock of a product with variants belongs to its variants
+		filter["variants.0"] = bson.M{"$exists": false}
+	}
+
+	update := bson.D{
+		{Key: "$inc", Value: inc},
 		{Key: "$set", Value: bson.D{{Key: "updated_at", Value: updatedAt}}},
 	}
 	return repo.Collection.UpdateOne(ctx, filter, update)
@@ -201,70 +253,47 @@ func (repo *productRepository) GetProductById(ctx context.Context, productID str
 	}
 
 	if product.Product_id == "" {
-		return nil, errors.New("product not found")
+		return nil, domain.ErrProductNotFound
 	}
 
 	return &product, nil
 }
 
 // GetAllProduct implements domain.ProductRepository.
-func (repo *productRepository) GetAllProduct(ctx context.Context, page int, storeID ...string) (*domain.PagedProducts, error) {
-	filter := bson.M{}
-	if len(storeID) > 0 {
-		filter["store_id"] = storeID[0]
-	}
+func (repo *productRepository) GetAllProduct(ctx context.Context, page pagination.Request, storeID ...string) (*domain.PagedProducts, error) {
+	return repo.listProducts(ctx, ofStore(bson.M{}, storeID), nil, page)
+}
 
-	pipeline := []bson.M{
-		{
-			"$match": filter,
-		},
-		{
-			"$lookup": bson.M{
-				"from": "Sales_Report",
-				"let":  bson.M{"product_id": "$product_id"},
-				"pipeline": []bson.M{
-					{
-						"$unwind": "$products",
-					},
-					{
-						"$match": bson.M{"$expr": bson.M{"$eq": []interface{}{"$$product_id", "$products.product_id"}}},
-					},
-					{
-						"$project": bson.M{
-							"_id":            0,
-							"average_rating": "$products.average_rating",
-							"total_sales":    "$products.total_sales",
-						},
-					},
-				},
-				"as": "sales_data",
-			},
-		},
-		{
-			"$unwind": bson.M{
-				"path":                       "$sales_data",
-				"preserveNullAndEmptyArrays": true,
-			},
-		},
+// GetAllProductByIds implements domain.ProductRepository.
+func (repo *productRepository) GetAllProductByIds(ctx context.Context, productIDs []string, page pagination.Request, storeID ...string) (*domain.PagedProducts, error) {
+	return repo.listProducts(ctx, ofStore(byIds(bson.M{}, productIDs), storeID), productIDs, page)
+}
 
-		{
-			"$addFields": bson.M{
-				"average_rating": "$sales_data.average_rating",
-				"total_sales":    "$sales_data.total_sales",
-			},
-		},
+// GetAllByCategory implements domain.ProductRepository.
+func (repo *productRepository) GetAllByCategory(ctx context.Context, categories []string, page pagination.Request, storeID ...string) (*domain.PagedProducts, error) {
+	filter := bson.M{
+		"category": bson.M{"$in": categories},
 	}
 
-	var limit int = 9
-	skip := (page - 1) * limit
+	return repo.listProducts(ctx, ofStore(filter, storeID), nil, page)
+}
 
+// listProducts returns a page of the products matching filter, productIDs
+// ranks them for the relevance sort.
+func (repo *productRepository) listProducts(ctx context.Context, filter bson.M, productIDs []string, page pagination.Request) (*domain.PagedProducts, error) {
 	totalCount, err := repo.Collection.CountDocuments(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	lastPage := int(math.Ceil(float64(totalCount) / float64(limit)))
-	pipeline = append(pipeline, bson.M{"$skip": skip}, bson.M{"$limit": limit})
+	pipeline := []bson.M{
+		{
+			"$match": filter,
+		},
+	}
+	pipeline = append(pipeline, salesData()...)
+	pipeline = append(pipeline, byRank(productIDs))
+	pipeline = append(pipeline, page.Stages()...)
 
 	cur, err := repo.Collection.Aggregate(ctx, pipeline)
 	if err != nil {
@@ -272,47 +301,25 @@ func (repo *productRepository) GetAllProduct(ctx context.Context, page int, stor
 	}
 	defer cur.Close(ctx)
 
-	var products []domain.ProductWithSalesData
-	for cur.Next(ctx) {
-		var product domain.ProductWithSalesData
-		err := cur.Decode(&product)
-		if err != nil {
-			return nil, err
-		}
-		products = append(products, product)
-	}
-
-	if err := cur.Err(); err != nil {
+	products, next, err := pagination.Collect[domain.ProductWithSalesData](ctx, cur, page)
+	if err != nil {
 		return nil, err
 	}
 
 	return &domain.PagedProducts{
-		Products:  products,
-		Page:      page,
-		TotalItem: int(totalCount),
-		LastPage:  lastPage,
+		Products:    products,
+		Page:        page.Page,
+		Size:        page.Size,
+		TotalItem:   int(totalCount),
+		LastPage:    int(math.Ceil(float64(totalCount) / float64(page.Size))),
+		Next_Cursor: next,
 	}, nil
 }
 
-// GetAllProductByQuery implements domain.ProductRepository.
-func (repo *productRepository) GetAllProductByQuery(ctx context.Context, query string, page int, storeID ...string) (*domain.PagedProducts, error) {
-	filter := bson.M{}
-	if query != "" {
-		filter["name"] = bson.M{
-			"$regex": primitive.Regex{
-				Pattern: query,
-				Options: "i",
-			},
-		}
-	}
-	if len(storeID) > 0 {
-		filter["store_id"] = storeID[0]
-	}
-
-	pipeline := []bson.M{
-		{
-			"$match": filter,
-		},
+// salesData adds the average_rating and total_sales of the products from the
+// sales reports, 0 for a product never sold, so the lists can sort on them.
+func salesData() []bson.M {
+	return []bson.M{
 		{
 			"$lookup": bson.M{
 				"from": "Sales_Report",
@@ -341,224 +348,253 @@ func (repo *productRepository) GetAllProductByQuery(ctx context.Context, query s
 				"preserveNullAndEmptyArrays": true,
 			},
 		},
-
 		{
 			"$addFields": bson.M{
-				"average_rating": "$sales_data.average_rating",
-				"total_sales":    "$sales_data.total_sales",
+				"average_rating": bson.M{"$ifNull": []interface{}{"$sales_data.average_rating", 0}},
+				"total_sales":    bson.M{"$ifNull": []interface{}{"$sales_data.total_sales", 0}},
 			},
 		},
 	}
+}
 
-	var limit int = 9
-	skip := (page - 1) * limit
-
-	totalCount, err := repo.Collection.CountDocuments(ctx, filter)
-	if err != nil {
-		return nil, err
-	}
+// priceBuckets are the lower bounds of the price facets of the catalogue.
+var priceBuckets = []float64{0, 10000, 25000, 50000, 100000, 250000}
 
-	lastPage := int(math.Ceil(float64(totalCount) / float64(limit)))
-	pipeline = append(pipeline, bson.M{"$skip": skip}, bson.M{"$limit": limit})
+// ratingFacets are the minimum ratings counted by the catalogue.
+var ratingFacets = []float64{4, 3, 2, 1}
 
-	cur, err := repo.Collection.Aggregate(ctx, pipeline)
-	if err != nil {
-		return nil, err
+// GetCatalogue implements domain.ProductRepository.
+func (repo *productRepository) GetCatalogue(ctx context.Context, filter domain.CatalogueFilter, page pagination.Request) (*domain.CataloguePage, error) {
+	match := visibleOnly(byIds(bson.M{}, filter.Product_Ids))
+	if filter.Categories != nil {
+		match["category"] = bson.M{"$in": filter.Categories}
+	}
+	price := bson.M{}
+	if filter.Min_Price > 0 {
+		price["$gte"] = filter.Min_Price
+	}
+	if filter.Max_Price > 0 {
+		price["$lte"] = filter.Max_Price
+	}
+	if len(price) > 0 {
+		match["price"] = price
+	}
+	if filter.In_Stock {
+		match["stock"] = bson.M{"$gt": 0}
+	}
+	if filter.Store_Id != "" {
+		match["store_id"] = filter.Store_Id
 	}
-	defer cur.Close(ctx)
 
-	var products []domain.ProductWithSalesData
-	for cur.Next(ctx) {
-		var product domain.ProductWithSalesData
-		err := cur.Decode(&product)
-		if err != nil {
-			return nil, err
-		}
-		products = append(products, product)
+	pipeline := []bson.M{
+		{
+			"$match": match,
+		},
 	}
 
-	if err := cur.Err(); err != nil {
-		return nil, err
+	if filter.City != "" {
+		pipeline = append(pipeline,
+			bson.M{
+				"$lookup": bson.M{
+					"from":         "Stores",
+					"localField":   "store_id",
+					"foreignField": "store_id",
+					"as":           "store",
+				},
+			},
+			bson.M{
+				"$match": bson.M{"store.address.city_name": primitive.Regex{
+					Pattern: "^" + regexp.QuoteMeta(filter.City) + "$",
+					Options: "i",
+				}},
+			},
+			bson.M{"$project": bson.M{"store": 0}},
+		)
 	}
 
-	return &domain.PagedProducts{
-		Products:  products,
-		Page:      page,
-		TotalItem: int(totalCount),
-		LastPage:  lastPage,
-	}, nil
-}
+	pipeline = append(pipeline, salesData()...)
 
-// GetAllProductByQueryForCust implements domain.ProductRepository.
-func (repo *productRepository) GetAllProductByQueryForCust(ctx context.Context, page int, query ...string) (*domain.PagedProducts, error) {
-	filter := bson.M{}
+	if filter.Min_Rating > 0 {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"average_rating": bson.M{"$gte": filter.Min_Rating}}})
+	}
 
-	if len(query) > 0 && query[0] != "" {
-		filter["name"] = bson.M{
-			"$regex": primitive.Regex{
-				Pattern: query[0],
-				Options: "i",
-			},
-		}
+	pipeline = append(pipeline, byRank(filter.Product_Ids))
+
+	ratings := bson.M{}
+	for i, min := range ratingFacets {
+		ratings[fmt.Sprintf("r%d", i)] = bson.M{"$sum": bson.M{"$cond": []interface{}{
+			bson.M{"$gte": []interface{}{"$average_rating", min}}, 1, 0,
+		}}}
 	}
+	ratings["_id"] = nil
 
-	pipeline := []bson.M{
-		{
-			"$lookup": bson.M{
-				"from": "Sales_Report",
-				"let":  bson.M{"product_id": "$product_id"},
-				"pipeline": []bson.M{
-					{
-						"$unwind": "$products",
-					},
-					{
-						"$match": filter,
-					},
-					{
-						"$project": bson.M{
-							"_id":            0,
-							"average_rating": "$products.average_rating",
-							"total_sales":    "$products.total_sales",
-						},
-					},
-				},
-				"as": "sales_data",
+	pipeline = append(pipeline, bson.M{
+		"$facet": bson.M{
+			"products": page.Stages(),
+			"total":    []bson.M{{"$count": "count"}},
+			"categories": []bson.M{
+				{"$group": bson.M{"_id": "$category", "count": bson.M{"$sum": 1}}},
+				{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
 			},
-		},
-		{
-			"$unwind": bson.M{
-				"path":                       "$sales_data",
-				"preserveNullAndEmptyArrays": true,
+			"prices": []bson.M{
+				{"$bucket": bson.M{
+					"groupBy":    "$price",
+					"boundaries": append(priceBuckets, math.MaxFloat64),
+					"default":    "other",
+					"output":     bson.M{"count": bson.M{"$sum": 1}},
+				}},
 			},
+			"ratings": []bson.M{{"$group": ratings}},
 		},
+	})
 
-		{
-			"$addFields": bson.M{
-				"average_rating": "$sales_data.average_rating",
-				"total_sales":    "$sales_data.total_sales",
-			},
-		},
+	cur, err := repo.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errors.New("failed to query the catalogue: " + err.Error())
 	}
+	defer cur.Close(ctx)
 
-	if len(query) > 1 && query[1] != "" {
-		if query[1] == "asc" {
-			pipeline = append(pipeline, bson.M{"$sort": bson.D{{Key: "price", Value: 1}}})
-		} else if query[1] == "desc" {
-			pipeline = append(pipeline, bson.M{"$sort": bson.D{{Key: "price", Value: -1}}})
-		}
+	var result []struct {
+		Products   []bson.Raw            `bson:"products"`
+		Total      []struct{ Count int } `bson:"total"`
+		Categories []dto.FacetCount      `bson:"categories"`
+		Prices     []struct {
+			Min   interface{} `bson:"_id"`
+			Count int         `bson:"count"`
+		} `bson:"prices"`
+		Ratings []map[string]interface{} `bson:"ratings"`
+	}
+	if err := cur.All(ctx, &result); err != nil {
+		return nil, errors.New("failed to query the catalogue: " + err.Error())
 	}
 
-	var limit int = 9
-	skip := (page - 1) * limit
+	res := &domain.CataloguePage{}
+	res.Page = page.Page
+	res.Size = page.Size
+	if len(result) == 0 {
+		return res, nil
+	}
+	facet := result[0]
 
-	totalCount, err := repo.Collection.CountDocuments(ctx, bson.M{})
+	res.Products, res.Next_Cursor, err = pagination.Decode[domain.ProductWithSalesData](facet.Products, page)
 	if err != nil {
 		return nil, err
 	}
+	if len(facet.Total) > 0 {
+		res.TotalItem = facet.Total[0].Count
+	}
+	res.LastPage = int(math.Ceil(float64(res.TotalItem) / float64(page.Size)))
 
-	lastPage := int(math.Ceil(float64(totalCount) / float64(limit)))
-	pipeline = append(pipeline, bson.M{"$skip": skip}, bson.M{"$limit": limit})
-
-	cur, err := repo.Collection.Aggregate(ctx, pipeline)
-	if err != nil {
-		return nil, err
+	res.Facets.Categories = facet.Categories
+	if res.Facets.Categories == nil {
+		res.Facets.Categories = []dto.FacetCount{}
 	}
-	defer cur.Close(ctx)
 
-	var products []domain.ProductWithSalesData
-	for cur.Next(ctx) {
-		var product domain.ProductWithSalesData
-		err := cur.Decode(&product)
-		if err != nil {
-			return nil, err
+	counts := make(map[float64]int)
+	for _, bucket := range facet.Prices {
+		if min, ok := bucket.Min.(float64); ok {
+			counts[min] += bucket.Count
 		}
-		products = append(products, product)
+	}
+	for i, min := range priceBuckets {
+		var max float64
+		if i+1 < len(priceBuckets) {
+			max = priceBuckets[i+1]
+		}
+		res.Facets.Prices = append(res.Facets.Prices, dto.PriceFacet{Min: min, Max: max, Count: counts[min]})
 	}
 
-	if err := cur.Err(); err != nil {
-		return nil, err
+	for i, min := range ratingFacets {
+		var count int
+		if len(facet.Ratings) > 0 {
+			count = toInt(facet.Ratings[0][fmt.Sprintf("r%d", i)])
+		}
+		res.Facets.Ratings = append(res.Facets.Ratings, dto.RatingFacet{Min: min, Count: count})
 	}
 
-	return &domain.PagedProducts{
-		Products:  products,
-		Page:      page,
-		TotalItem: int(totalCount),
-		LastPage:  lastPage,
-	}, nil
+	return res, nil
+}
 
+// toInt reads a count decoded into an interface, mongo returns int32 or int64.
+func toInt(v interface{}) int {
+	switch n := v.(type) {
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
 }
 
-// GetAllByCategory implements domain.ProductRepository.
-func (repo *productRepository) GetAllByCategory(ctx context.Context, category string, page int, storeID ...string) (*domain.PagedProducts, error) {
-	filter := bson.M{
-		"category": category,
+// byIds keeps the products in productIDs, or every product when it is nil.
+func byIds(filter bson.M, productIDs []string) bson.M {
+	if productIDs != nil {
+		filter["product_id"] = bson.M{"$in": productIDs}
 	}
+	return filter
+}
 
+// byRank adds the rank of the products in productIDs, the relevance sort. It
+// is 0 for every product when productIDs is nil.
+func byRank(productIDs []string) bson.M {
+	if productIDs == nil {
+		return bson.M{"$addFields": bson.M{"rank": 0}}
+	}
+
+	return bson.M{"$addFields": bson.M{"rank": bson.M{"$indexOfArray": []interface{}{productIDs, "$product_id"}}}}
+}
+
+// ofStore keeps the products of the store in storeID, or the visible products
+// of every store without a storeID.
+func ofStore(filter bson.M, storeID []string) bson.M {
 	if len(storeID) > 0 {
 		filter["store_id"] = storeID[0]
+		return filter
 	}
+	return visibleOnly(filter)
+}
 
-	pipeline := []bson.M{
-		{
-			"$match": filter,
-		},
-		{
-			"$lookup": bson.M{
-				"from": "Sales_Report",
-				"let":  bson.M{"product_id": "$product_id"},
-				"pipeline": []bson.M{
-					{
-						"$unwind": "$products",
-					},
-					{
-						"$match": bson.M{"$expr": bson.M{"$eq": []interface{}{"$$product_id", "$products.product_id"}}},
-					},
-					{
-						"$project": bson.M{
-							"_id":            0,
-							"average_rating": "$products.average_rating",
-							"total_sales":    "$products.total_sales",
-						},
-					},
-				},
-				"as": "sales_data",
-			},
-		},
-		{
-			"$unwind": bson.M{
-				"path":                       "$sales_data",
-				"preserveNullAndEmptyArrays": true,
-			},
-		},
+// visibleOnly leaves out the products an admin unpublished or whose store is suspended.
+func visibleOnly(filter bson.M) bson.M {
+	filter["unpublished"] = bson.M{"$ne": true}
+	filter["store_suspended"] = bson.M{"$ne": true}
+	return filter
+}
 
-		{
-			"$addFields": bson.M{
-				"average_rating": "$sales_data.average_rating",
-				"total_sales":    "$sales_data.total_sales",
-			},
-		},
+// SetDiscounts implements domain.ProductRepository.
+func (repo *productRepository) SetDiscounts(ctx context.Context, productIDs []string, discount float64) error {
+	if productIDs == nil {
+		productIDs = []string{}
 	}
 
-	var limit int = 9
-	skip := (page - 1) * limit
-
-	totalCount, err := repo.Collection.CountDocuments(ctx, filter)
-	if err != nil {
-		return nil, err
+	if len(productIDs) > 0 {
+		filter := bson.M{"product_id": bson.M{"$in": productIDs}}
+		_, err := repo.Collection.UpdateMany(ctx, filter, bson.D{{Key: "$set", Value: bson.D{{Key: "discount", Value: discount}}}})
+		if err != nil {
+			return err
+		}
 	}
 
-	lastPage := int(math.Ceil(float64(totalCount) / float64(limit)))
-	pipeline = append(pipeline, bson.M{"$skip": skip}, bson.M{"$limit": limit})
+	filter := bson.M{"product_id": bson.M{"$nin": productIDs}, "discount": bson.M{"$gt": 0}}
+	_, err := repo.Collection.UpdateMany(ctx, filter, bson.D{{Key: "$set", Value: bson.D{{Key: "discount", Value: 0}}}})
 
-	cur, err := repo.Collection.Aggregate(ctx, pipeline)
+	return err
+}
+
+// GetAllStock implements domain.ProductRepository.
+func (repo *productRepository) GetAllStock(ctx context.Context) (*[]domain.Products, error) {
+	var products []domain.Products
+	opts := options.Find().SetProjection(bson.M{"store_id": 1, "product_id": 1, "stock": 1, "variants": 1})
+	cur, err := repo.Collection.Find(ctx, bson.M{}, opts)
 	if err != nil {
 		return nil, err
 	}
 	defer cur.Close(ctx)
 
-	var products []domain.ProductWithSalesData
 	for cur.Next(ctx) {
-		var product domain.ProductWithSalesData
+		var product domain.Products
 		err := cur.Decode(&product)
 		if err != nil {
 			return nil, err
@@ -570,117 +606,36 @@ func (repo *productRepository) GetAllByCategory(ctx context.Context, category st
 		return nil, err
 	}
 
-	return &domain.PagedProducts{
-		Products:  products,
-		Page:      page,
-		TotalItem: int(totalCount),
-		LastPage:  lastPage,
-	}, nil
+	return &products, nil
 }
 
-func (repo *productRepository) GetAllProductSorted(ctx context.Context, sortParams map[string]string, page int, storeID ...string) (*domain.PagedProducts, error) {
-	filter := bson.M{}
-
-	if len(storeID) > 0 {
-		filter["store_id"] = storeID[0]
-	}
-
-	pipeline := []bson.M{
-		{
-			"$match": filter,
-		},
-		{
-			"$lookup": bson.M{
-				"from": "Sales_Report",
-				"let":  bson.M{"product_id": "$product_id"},
-				"pipeline": []bson.M{
-					{
-						"$unwind": "$products",
-					},
-					{
-						"$match": bson.M{
-							"$expr": bson.M{
-								"$eq": []string{"$$product_id", "$products.product_id"},
-							},
-						},
-					},
-					{
-						"$project": bson.M{
-							"_id":            0,
-							"average_rating": "$products.average_rating",
-							"total_sales":    "$products.total_sales",
-						},
-					},
-				},
-				"as": "sales_data",
-			},
-		},
-		{
-			"$unwind": bson.M{
-				"path":                       "$sales_data",
-				"preserveNullAndEmptyArrays": true,
-			},
-		},
-
-		{
-			"$addFields": bson.M{
-				"average_rating": "$sales_data.average_rating",
-				"total_sales":    "$sales_data.total_sales",
-			},
-		},
+// GetSearchDocuments implements domain.ProductRepository.
+func (repo *productRepository) GetSearchDocuments(ctx context.Context, productIDs ...string) (*[]domain.SearchDocument, error) {
+	filter := visibleOnly(bson.M{})
+	if len(productIDs) > 0 {
+		filter["product_id"] = bson.M{"$in": productIDs}
 	}
 
-	var limit int = 9
-	skip := (page - 1) * limit
-
-	totalCount, err := repo.Collection.CountDocuments(ctx, filter)
-	if err != nil {
-		return nil, err
-	}
-
-	lastPage := int(math.Ceil(float64(totalCount) / float64(limit)))
-	pipeline = append(pipeline, bson.M{"$skip": skip}, bson.M{"$limit": limit})
-
-	sortFields := bson.D{}
-	for param, direction := range sortParams {
-		sortOrder := 1
-		if direction == "desc" {
-			sortOrder = -1
-		} else if direction == "asc" {
-			sortOrder = 1
-		}
-		sortFields = append(sortFields, bson.E{Key: param, Value: sortOrder})
-	}
-	if len(sortFields) > 0 {
-		pipeline = append(pipeline, bson.M{"$sort": sortFields})
-	}
-
-	cur, err := repo.Collection.Aggregate(ctx, pipeline)
+	opts := options.Find().SetProjection(bson.M{"store_id": 1, "product_id": 1, "name": 1, "category": 1, "description": 1})
+	cur, err := repo.Collection.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
 	defer cur.Close(ctx)
 
-	var products []domain.ProductWithSalesData
+	docs := []domain.SearchDocument{}
 	for cur.Next(ctx) {
-		var product domain.ProductWithSalesData
-		err := cur.Decode(&product)
+		var doc domain.SearchDocument
+		err := cur.Decode(&doc)
 		if err != nil {
 			return nil, err
 		}
-		products = append(products, product)
+		docs = append(docs, doc)
 	}
 
 	if err := cur.Err(); err != nil {
 		return nil, err
 	}
 
-	productsres := domain.PagedProducts{
-		Products:  products,
-		Page:      page,
-		TotalItem: int(totalCount),
-		LastPage:  lastPage,
-	}
-
-	return &productsres, nil
+	return &docs, nil
 }
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type refundRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewRefundRepository(client *mongo.Client) domain.RefundRepository {
+	return &refundRepository{
+		Collection: db.OpenCollection(client, "Refunds"),
+	}
+}
+
+// Insert implements domain.RefundRepository.
+func (repo *refundRepository) Insert(ctx context.Context, refund domain.Refund) (primitive.ObjectID, error) {
+	result, err := repo.Collection.InsertOne(ctx, refund)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+// FindById implements domain.RefundRepository.
+func (repo *refundRepository) FindById(ctx context.Context, refundID string) (*domain.Refund, error) {
+	var refund domain.Refund
+	filter := bson.M{"refund_id": refundID}
+	err := repo.Collection.FindOne(ctx, filter).Decode(&refund)
+	if err != nil {
+		return nil, err
+	}
+
+	return &refund, nil
+}
+
+// FindByOrderId implements domain.RefundRepository.
+func (repo *refundRepository) FindByOrderId(ctx context.Context, orderID string) (*[]domain.Refund, error) {
+	return repo.find(ctx, bson.M{"order_id": orderID})
+}
+
+// FindByUserEmail implements domain.RefundRepository.
+func (repo *refundRepository) FindByUserEmail(ctx context.Context, email string, page pagination.Request) (*pagination.Page[domain.Refund], error) {
+	return findPage[domain.Refund](ctx, repo.Collection, bson.M{"user_email": email}, page)
+}
+
+// FindBySellerEmail implements domain.RefundRepository.
+func (repo *refundRepository) FindBySellerEmail(ctx context.Context, email string, page pagination.Request) (*pagination.Page[domain.Refund], error) {
+	return findPage[domain.Refund](ctx, repo.Collection, bson.M{"seller_email": email}, page)
+}
+
+// FindByStatus implements domain.RefundRepository.
+func (repo *refundRepository) FindByStatus(ctx context.Context, status string, updatedBefore time.Time) (*[]domain.Refund, error) {
+	return repo.find(ctx, bson.M{"status": status, "updated_at": bson.M{"$lt": updatedBefore}})
+}
+
+// UpdateStatus implements domain.RefundRepository.
+// The refund is only updated while it is still in fromStatus, so a refund
+// cannot be approved twice.
+func (repo *refundRepository) UpdateStatus(ctx context.Context, refundID, fromStatus, toStatus string, updateAt time.Time) (*mongo.UpdateResult, error) {
+	filter := bson.M{"refund_id": refundID, "status": fromStatus}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: toStatus},
+		{Key: "updated_at", Value: updateAt},
+	}}}
+
+	return repo.Collection.UpdateOne(ctx, filter, update)
+}
+
+func (repo *refundRepository) find(ctx context.Context, filter bson.M) (*[]domain.Refund, error) {
+	var refunds []domain.Refund
+	cur, err := repo.Collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var refund domain.Refund
+		err := cur.Decode(&refund)
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return &refunds, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type reservationRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewReservationRepository(client *mongo.Client) domain.ReservationRepository {
+	return &reservationRepository{
+		Collection: db.OpenCollection(client, "Reservations"),
+	}
+}
+
+// Insert implements domain.ReservationRepository.
+func (repo *reservationRepository) Insert(ctx context.Context, reservation domain.Reservation) (primitive.ObjectID, error) {
+	result, err := repo.Collection.InsertOne(ctx, reservation)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+// FindByOrderId implements domain.ReservationRepository.
+func (repo *reservationRepository) FindByOrderId(ctx context.Context, orderID string) (*[]domain.Reservation, error) {
+	var reservations []domain.Reservation
+	filter := bson.M{"order_id": orderID}
+	cur, err := repo.Collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var reservation domain.Reservation
+		err := cur.Decode(&reservation)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, reservation)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return &reservations, nil
+}
+
+// UpdateStatus implements domain.ReservationRepository.
+// The update only matches a reservation that is still in fromStatus, so two
+// callers racing to release or commit the same item cannot both succeed.
+func (repo *reservationRepository) UpdateStatus(ctx context.Context, reservationID, fromStatus, toStatus string, updateAt time.Time) (*mongo.UpdateResult, error) {
+	filter := bson.M{"reservation_id": reservationID, "status": fromStatus}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: toStatus},
+		{Key: "updated_at", Value: updateAt},
+	}}}
+
+	return repo.Collection.UpdateOne(ctx, filter, update)
+}
+
+// UpdateBatches implements domain.ReservationRepository.
+func (repo *reservationRepository) UpdateBatches(ctx context.Context, reservationID string, quantity float64, batches []domain.BatchAllocation, updateAt time.Time) (*mongo.UpdateResult, error) {
+	filter := bson.M{"reservation_id": reservationID}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "quantity", Value: quantity},
+		{Key: "batches", Value: batches},
+		{Key: "updated_at", Value: updateAt},
+	}}}
+
+	return repo.Collection.UpdateOne(ctx, filter, update)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/IndraSty/GreenBasket/db"
 	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -115,58 +116,17 @@ func (repo *reviewRepository) GetAllReviewByProductId(ctx context.Context, produ
 	return &reviews, nil
 }
 
-// GetAllReviewBySellerEmail implements domain.ReviewRepository.
-func (repo *reviewRepository) GetAllReviewBySellerEmail(ctx context.Context, sellerEmail string) (*[]domain.Review, error) {
-	var reviews []domain.Review
-	filter := bson.M{"seller_email": sellerEmail}
-
-	cur, err := repo.Collection.Find(ctx, filter)
-	if err != nil {
-		return nil, err
-	}
-	defer cur.Close(ctx)
-
-	for cur.Next(ctx) {
-		var review domain.Review
-		err := cur.Decode(&review)
-		if err != nil {
-			return nil, err
-		}
-
-		reviews = append(reviews, review)
-	}
-
-	if err := cur.Err(); err != nil {
-		return nil, err
-	}
-
-	return &reviews, nil
+// FindByProductId implements domain.ReviewRepository.
+func (repo *reviewRepository) FindByProductId(ctx context.Context, productID, sellerEmail string, page pagination.Request) (*pagination.Page[domain.Review], error) {
+	return findPage[domain.Review](ctx, repo.Collection, bson.M{"product_id": productID, "seller_email": sellerEmail}, page)
 }
 
-// GetAllReviewByUserEmail implements domain.ReviewRepository.
-func (repo *reviewRepository) GetAllReviewByUserEmail(ctx context.Context, email string) (*[]domain.Review, error) {
-	var reviews []domain.Review
-	filter := bson.M{"email": email}
-
-	cur, err := repo.Collection.Find(ctx, filter)
-	if err != nil {
-		return nil, err
-	}
-	defer cur.Close(ctx)
-
-	for cur.Next(ctx) {
-		var review domain.Review
-		err := cur.Decode(&review)
-		if err != nil {
-			return nil, err
-		}
-
-		reviews = append(reviews, review)
-	}
-
-	if err := cur.Err(); err != nil {
-		return nil, err
-	}
+// FindBySellerEmail implements domain.ReviewRepository.
+func (repo *reviewRepository) FindBySellerEmail(ctx context.Context, sellerEmail string, page pagination.Request) (*pagination.Page[domain.Review], error) {
+	return findPage[domain.Review](ctx, repo.Collection, bson.M{"seller_email": sellerEmail}, page)
+}
 
-	return &reviews, nil
+// FindByUserEmail implements domain.ReviewRepository.
+func (repo *reviewRepository) FindByUserEmail(ctx context.Context, email string, page pagination.Request) (*pagination.Page[domain.Review], error) {
+	return findPage[domain.Review](ctx, repo.Collection, bson.M{"email": email}, page)
 }
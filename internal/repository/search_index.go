@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// searchWeights are the weights of the fields of the text index, a word in
+// the name counts ten times a word in the description.
+var searchWeights = bson.D{
+	{Key: "name", Value: 10},
+	{Key: "category", Value: 5},
+	{Key: "store_name", Value: 3},
+	{Key: "description", Value: 1},
+}
+
+// searchEntry is a SearchDocument as it is stored, Name_Lower serves Suggest.
+type searchEntry struct {
+	domain.SearchDocument `bson:",inline"`
+	Name_Lower            string `bson:"name_lower"`
+}
+
+type mongoSearchIndex struct {
+	Collection *mongo.Collection
+}
+
+// NewMongoSearchIndex keeps the search documents in their own collection with
+// a weighted text index, and creates the indexes when they do not exist.
+func NewMongoSearchIndex(ctx context.Context, client *mongo.Client) (domain.ProductSearchIndex, error) {
+	collection := db.OpenCollection(client, "Search_Index")
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "name", Value: "text"},
+				{Key: "category", Value: "text"},
+				{Key: "store_name", Value: "text"},
+				{Key: "description", Value: "text"},
+			},
+			// product names are not all english, so words are not stemmed
+			Options: options.Index().SetName("product_text").SetWeights(searchWeights).SetDefaultLanguage("none"),
+		},
+		{
+			Keys:    bson.D{{Key: "product_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "name_lower", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongoSearchIndex{
+		Collection: collection,
+	}, nil
+}
+
+// Index implements domain.ProductSearchIndex.
+func (repo *mongoSearchIndex) Index(ctx context.Context, docs ...domain.SearchDocument) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, len(docs))
+	for i, doc := range docs {
+		entry := searchEntry{SearchDocument: doc, Name_Lower: strings.ToLower(doc.Name)}
+		models[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"product_id": doc.Product_Id}).
+			SetReplacement(entry).
+			SetUpsert(true)
+	}
+
+	_, err := repo.Collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// Remove implements domain.ProductSearchIndex.
+func (repo *mongoSearchIndex) Remove(ctx context.Context, productIDs ...string) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	_, err := repo.Collection.DeleteMany(ctx, bson.M{"product_id": bson.M{"$in": productIDs}})
+	return err
+}
+
+// Search implements domain.ProductSearchIndex.
+func (repo *mongoSearchIndex) Search(ctx context.Context, text, storeID string, limit int) ([]domain.SearchHit, error) {
+	filter := bson.M{"$text": bson.M{"$search": text}}
+	if storeID != "" {
+		filter["store_id"] = storeID
+	}
+
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 0, "product_id": 1, "score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "product_id", Value: 1}}).
+		SetLimit(int64(limit))
+	cur, err := repo.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	hits := []domain.SearchHit{}
+	for cur.Next(ctx) {
+		var hit domain.SearchHit
+		err := cur.Decode(&hit)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return hits, nil
+}
+
+// Suggest implements domain.ProductSearchIndex.
+// An anchored regex on name_lower uses its index, the text index does not
+// match the start of a word.
+func (repo *mongoSearchIndex) Suggest(ctx context.Context, prefix string, limit int) ([]string, error) {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" {
+		return []string{}, nil
+	}
+
+	filter := bson.M{"name_lower": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix)}}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 0, "name": 1}).
+		SetSort(bson.D{{Key: "name_lower", Value: 1}}).
+		SetLimit(int64(limit) * 4)
+	cur, err := repo.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	// many stores sell a product of the same name
+	seen := map[string]bool{}
+	names := []string{}
+	for cur.Next(ctx) && len(names) < limit {
+		var entry searchEntry
+		err := cur.Decode(&entry)
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[strings.ToLower(entry.Name)] {
+			seen[strings.ToLower(entry.Name)] = true
+			names = append(names, entry.Name)
+		}
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/IndraSty/GreenBasket/db"
 	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -76,3 +77,50 @@ func (sr *sellerRepository) AddStoreId(ctx context.Context, email string, storeI
 
 	return nil
 }
+
+// ReplaceRefreshToken implements domain.SellerRepository.
+// The token is only replaced while oldToken is still the stored one, so the
+// same refresh token can not be exchanged twice.
+func (sr *sellerRepository) ReplaceRefreshToken(ctx context.Context, email, oldToken, newToken string) (*mongo.UpdateResult, error) {
+	filter := bson.M{"email": email, "refresh_token": oldToken}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "refresh_token", Value: newToken}}}}
+
+	return sr.Collection.UpdateOne(ctx, filter, update)
+}
+
+// FindSellerById implements domain.SellerRepository.
+func (sr *sellerRepository) FindSellerById(ctx context.Context, sellerID string) (*domain.Seller, error) {
+	var seller domain.Seller
+	err := sr.Collection.FindOne(ctx, bson.M{"seller_id": sellerID}).Decode(&seller)
+	if err != nil {
+		return nil, err
+	}
+
+	return &seller, nil
+}
+
+// SearchSellers implements domain.SellerRepository.
+func (sr *sellerRepository) SearchSellers(ctx context.Context, query string, page pagination.Request) (*pagination.Page[domain.Seller], error) {
+	filter := searchFilter(query, "first_name", "last_name", "email")
+	return findPage[domain.Seller](ctx, sr.Collection, filter, page)
+}
+
+// FindStaff implements domain.SellerRepository.
+func (sr *sellerRepository) FindStaff(ctx context.Context, storeID string) ([]domain.Seller, error) {
+	cursor, err := sr.Collection.Find(ctx, bson.M{"staff_of": storeID, "role": domain.RoleSellerStaff})
+	if err != nil {
+		return nil, err
+	}
+
+	staff := []domain.Seller{}
+	if err := cursor.All(ctx, &staff); err != nil {
+		return nil, err
+	}
+
+	return staff, nil
+}
+
+// DeleteStaff implements domain.SellerRepository.
+func (sr *sellerRepository) DeleteStaff(ctx context.Context, storeID, sellerID string) (*mongo.DeleteResult, error) {
+	return sr.Collection.DeleteOne(ctx, bson.M{"staff_of": storeID, "seller_id": sellerID, "role": domain.RoleSellerStaff})
+}
@@ -2,10 +2,12 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/IndraSty/GreenBasket/db"
 	"github.com/IndraSty/GreenBasket/domain"
 	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -58,6 +60,11 @@ func (repo *sellerOrderRepository) GetAllSellerOrders(ctx context.Context, email
 	return &orders, nil
 }
 
+// FindByEmail implements domain.SellerOrderRepository.
+func (repo *sellerOrderRepository) FindByEmail(ctx context.Context, email string, page pagination.Request) (*pagination.Page[domain.SellerOrder], error) {
+	return findPage[domain.SellerOrder](ctx, repo.Collection, bson.M{"email": email}, page)
+}
+
 // GetSellerOrderByEmailAndId implements domain.SellerOrderRepository.
 func (repo *sellerOrderRepository) GetSellerOrderByEmailAndId(ctx context.Context, email string, orderID string) (*domain.SellerOrder, error) {
 	var order domain.SellerOrder
@@ -151,15 +158,79 @@ func (repo *sellerOrderRepository) UpdateStatusOrderSeller(ctx context.Context,
 }
 
 // DeleteItem implements domain.SellerOrderRepository.
-func (repo *sellerOrderRepository) DeleteItem(ctx context.Context, email, orderID string, productID string) (*mongo.UpdateResult, error) {
+func (repo *sellerOrderRepository) DeleteItem(ctx context.Context, email, orderID, productID, variantID string) (*mongo.UpdateResult, error) {
 	filter := bson.M{"order_id": orderID, "email": email}
 	update := bson.M{
 		"$pull": bson.M{
-			"items": bson.M{
-				"product_id": productID,
-			},
+			"items": orderItem(productID, variantID),
 		},
 	}
 
 	return repo.Collection.UpdateOne(ctx, filter, update)
 }
+
+// DeleteByOrderId implements domain.SellerOrderRepository.
+func (repo *sellerOrderRepository) DeleteByOrderId(ctx context.Context, orderID string) (*mongo.DeleteResult, error) {
+	filter := bson.M{"order_id": orderID}
+	return repo.Collection.DeleteMany(ctx, filter)
+}
+
+// UpdateItemStatus implements domain.SellerOrderRepository.
+func (repo *sellerOrderRepository) UpdateItemStatus(ctx context.Context, orderID, productID, variantID string, history domain.OrderStatusHistory) (*mongo.UpdateResult, error) {
+	item := orderItem(productID, variantID)
+	item["status"] = history.From
+	filter := bson.M{
+		"order_id": orderID,
+		"items":    bson.M{"$elemMatch": item},
+	}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "items.$.status", Value: history.To},
+			{Key: "updated_at", Value: history.Changed_At},
+		}},
+		{Key: "$push", Value: bson.D{{Key: "items.$.status_history", Value: history}}},
+	}
+
+	return repo.Collection.UpdateOne(ctx, filter, update)
+}
+
+// UpdateItemQuantity implements domain.SellerOrderRepository.
+func (repo *sellerOrderRepository) UpdateItemQuantity(ctx context.Context, orderID, productID, variantID string, ordered, packed float64, chargeID string, updateAt time.Time) (*mongo.UpdateResult, error) {
+	item := orderItem(productID, variantID)
+	item["status"] = domain.OrderProcessed
+	item["ordered_quantity"] = bson.M{"$exists": false}
+	filter := bson.M{
+		"order_id": orderID,
+		"items":    bson.M{"$elemMatch": item},
+	}
+	set := bson.D{
+		{Key: "items.$.quantity", Value: packed},
+		{Key: "items.$.ordered_quantity", Value: ordered},
+		{Key: "updated_at", Value: updateAt},
+	}
+	if chargeID != "" {
+		set = append(set, bson.E{Key: "items.$.charge_id", Value: chargeID})
+	}
+	update := bson.D{{Key: "$set", Value: set}}
+
+	return repo.Collection.UpdateOne(ctx, filter, update)
+}
+
+// UpdateTotalPrice implements domain.SellerOrderRepository.
+func (repo *sellerOrderRepository) UpdateTotalPrice(ctx context.Context, email, orderID string, value float64) (*mongo.UpdateResult, error) {
+	filter := bson.M{"order_id": orderID, "email": email}
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "total_price", Value: value}}}}
+
+	return repo.Collection.UpdateOne(ctx, filter, update)
+}
+
+// UpdateShippingFee implements domain.SellerOrderRepository.
+func (repo *sellerOrderRepository) UpdateShippingFee(ctx context.Context, email, orderID string, value float64) (*mongo.UpdateResult, error) {
+	filter := bson.M{"order_id": orderID, "email": email}
+	update := bson.D{{Key: "$inc", Value: bson.D{
+		{Key: "shipping_fee", Value: value},
+		{Key: "total_price", Value: value},
+	}}}
+
+	return repo.Collection.UpdateOne(ctx, filter, update)
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type shipmentRepository struct {
+	Collection *mongo.Collection
+}
+
+// NewShipmentRepository stores the shipments on the items of the seller orders.
+func NewShipmentRepository(client *mongo.Client) domain.ShipmentRepository {
+	return &shipmentRepository{
+		Collection: db.OpenCollection(client, "Seller_Orders"),
+	}
+}
+
+// UpdateShipment implements domain.ShipmentRepository.
+func (repo *shipmentRepository) UpdateShipment(ctx context.Context, email, orderID, productID, variantID string, shipment domain.Shipment) (*mongo.UpdateResult, error) {
+	filter := bson.M{"email": email, "order_id": orderID, "items": bson.M{"$elemMatch": orderItem(productID, variantID)}}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "items.$.shipment", Value: shipment},
+		{Key: "updated_at", Value: shipment.Updated_At},
+	}}}
+
+	return repo.Collection.UpdateOne(ctx, filter, update)
+}
+
+// FindInTransit implements domain.ShipmentRepository.
+func (repo *shipmentRepository) FindInTransit(ctx context.Context) (*[]domain.SellerOrder, error) {
+	var orders []domain.SellerOrder
+	filter := bson.M{
+		"items": bson.M{"$elemMatch": bson.M{
+			"shipment":              bson.M{"$exists": true},
+			"shipment.delivered_at": nil,
+		}},
+	}
+	cur, err := repo.Collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var order domain.SellerOrder
+		err := cur.Decode(&order)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return &orders, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type shippingRateRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewShippingRateRepository(client *mongo.Client) domain.ShippingRateRepository {
+	return &shippingRateRepository{
+		Collection: db.OpenCollection(client, "Shipping_Rates"),
+	}
+}
+
+// Insert implements domain.ShippingRateRepository.
+func (repo *shippingRateRepository) Insert(ctx context.Context, rate domain.ShippingRate) (primitive.ObjectID, error) {
+	result, err := repo.Collection.InsertOne(ctx, rate)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+// FindRates implements domain.ShippingRateRepository.
+func (repo *shippingRateRepository) FindRates(ctx context.Context, origin, destination string) (*[]domain.ShippingRate, error) {
+	var rates []domain.ShippingRate
+	filter := bson.M{
+		"origin_city":      cityPattern(origin),
+		"destination_city": cityPattern(destination),
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "max_weight", Value: 1}})
+
+	cur, err := repo.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var rate domain.ShippingRate
+		err := cur.Decode(&rate)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return &rates, nil
+}
+
+// cityPattern matches the whole city name ignoring case.
+func cityPattern(city string) primitive.Regex {
+	return primitive.Regex{Pattern: "^" + regexp.QuoteMeta(city) + "$", Options: "i"}
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type stockMovementRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewStockMovementRepository(client *mongo.Client) domain.StockMovementRepository {
+	return &stockMovementRepository{
+		Collection: db.OpenCollection(client, "StockMovements"),
+	}
+}
+
+// Insert implements domain.StockMovementRepository.
+func (repo *stockMovementRepository) Insert(ctx context.Context, movement domain.StockMovement) (primitive.ObjectID, error) {
+	result, err := repo.Collection.InsertOne(ctx, movement)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+// FindByProductId implements domain.StockMovementRepository.
+func (repo *stockMovementRepository) FindByProductId(ctx context.Context, storeID, productID string, page pagination.Request) (*pagination.Page[domain.StockMovement], error) {
+	filter := bson.M{"store_id": storeID, "product_id": productID}
+	return findPage[domain.StockMovement](ctx, repo.Collection, filter, page)
+}
+
+// Totals implements domain.StockMovementRepository.
+func (repo *stockMovementRepository) Totals(ctx context.Context) (*[]domain.StockTotal, error) {
+	pipeline := []bson.M{
+		{
+			"$group": bson.M{
+				"_id":      bson.M{"product_id": "$product_id", "variant_id": "$variant_id"},
+				"quantity": bson.M{"$sum": "$quantity"},
+			},
+		},
+		{
+			"$project": bson.M{
+				"_id":        0,
+				"product_id": "$_id.product_id",
+				"variant_id": "$_id.variant_id",
+				"quantity":   1,
+			},
+		},
+	}
+
+	cur, err := repo.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var totals []domain.StockTotal
+	for cur.Next(ctx) {
+		var total domain.StockTotal
+		err := cur.Decode(&total)
+		if err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return &totals, nil
+}
@@ -6,9 +6,11 @@ import (
 
 	"github.com/IndraSty/GreenBasket/db"
 	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type storeRepository struct {
@@ -60,8 +62,7 @@ func (repo *storeRepository) GetStoreByEmail(ctx context.Context, email string)
 
 // GetStoreByQuery implements domain.StoreRepository.
 func (repo *storeRepository) GetStoreByQuery(ctx context.Context, query string) ([]domain.Store, error) {
-	var stores []domain.Store
-	filter := bson.M{}
+	filter := bson.M{"suspended": bson.M{"$ne": true}}
 	if query != "" {
 		filter["name"] = bson.M{
 			"$regex": primitive.Regex{
@@ -71,7 +72,22 @@ func (repo *storeRepository) GetStoreByQuery(ctx context.Context, query string)
 		}
 	}
 
-	cur, err := repo.Collection.Find(ctx, filter)
+	return repo.find(ctx, filter)
+}
+
+// GetStoresByEmail implements domain.StoreRepository.
+func (repo *storeRepository) GetStoresByEmail(ctx context.Context, email string) ([]domain.Store, error) {
+	return repo.find(ctx, bson.M{"email": email})
+}
+
+// SearchStores implements domain.StoreRepository.
+func (repo *storeRepository) SearchStores(ctx context.Context, query string, page pagination.Request) (*pagination.Page[domain.Store], error) {
+	return findPage[domain.Store](ctx, repo.Collection, searchFilter(query, "name", "email"), page)
+}
+
+func (repo *storeRepository) find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]domain.Store, error) {
+	var stores []domain.Store
+	cur, err := repo.Collection.Find(ctx, filter, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -99,6 +115,12 @@ func (repo *storeRepository) CheckNameExists(ctx context.Context, name string) (
 	return count > 0, err
 }
 
+// CountByImage implements domain.StoreRepository.
+func (repo *storeRepository) CountByImage(ctx context.Context, url string) (int64, error) {
+	filter := bson.M{"$or": []bson.M{{"logo": url}, {"banner": url}}}
+	return repo.Collection.CountDocuments(ctx, filter)
+}
+
 func (repo *storeRepository) UpdateStore(ctx context.Context, email, storeID string, update bson.D) (*mongo.UpdateResult, error) {
 	filter := bson.M{"email": email, "store_id": storeID}
 	return repo.Collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: update}})
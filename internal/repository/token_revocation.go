@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+)
+
+type tokenRevocationStore struct {
+	cacheRepo domain.CacheRepository
+	versions  domain.TokenVersionRepository
+}
+
+// NewTokenRevocationStore keeps the revoked tokens and the token versions in
+// the cache, so every replica sees a logout right away. The token versions
+// are saved to versions as well and loaded back when the cache lost them.
+func NewTokenRevocationStore(cacheRepo domain.CacheRepository, versions domain.TokenVersionRepository) domain.TokenRevocationStore {
+	return &tokenRevocationStore{
+		cacheRepo: cacheRepo,
+		versions:  versions,
+	}
+}
+
+// Revoke implements domain.TokenRevocationStore.
+// The entry expires with the token, an expired token is rejected anyway.
+func (repo *tokenRevocationStore) Revoke(jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+
+	return repo.cacheRepo.Set("revoked-token:"+jti, []byte("1"), ttl)
+}
+
+// IsRevoked implements domain.TokenRevocationStore.
+// A cache that can not be read is an error, not a token that was not revoked.
+func (repo *tokenRevocationStore) IsRevoked(jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+
+	_, err := repo.cacheRepo.Get("revoked-token:" + jti)
+	if errors.Is(err, domain.ErrCacheMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.New("failed to check revoked token: " + err.Error())
+	}
+
+	return true, nil
+}
+
+// TokenVersion implements domain.TokenRevocationStore.
+// An account that never logged out all its sessions is at version 0.
+func (repo *tokenRevocationStore) TokenVersion(account string, uid string) (int, error) {
+	key := tokenVersionKey(account, uid)
+
+	val, err := repo.cacheRepo.Get(key)
+	if err == nil {
+		return strconv.Atoi(string(val))
+	}
+	if !errors.Is(err, domain.ErrCacheMiss) {
+		return 0, errors.New("failed to get token version: " + err.Error())
+	}
+
+	version, err := repo.versions.TokenVersion(context.Background(), account, uid)
+	if err != nil {
+		return 0, errors.New("failed to load token version: " + err.Error())
+	}
+
+	// a bump that raced the load already seeded the cache, its version wins
+	ok, err := repo.cacheRepo.SetNX(key, []byte(strconv.Itoa(version)), 0)
+	if err != nil {
+		return 0, errors.New("failed to cache token version: " + err.Error())
+	}
+	if !ok {
+		return repo.TokenVersion(account, uid)
+	}
+
+	return version, nil
+}
+
+// BumpTokenVersion implements domain.TokenRevocationStore.
+// The version is kept without an expiration so it outlives every token
+// issued before the bump, and incremented in the cache so concurrent bumps
+// each get a version of their own.
+func (repo *tokenRevocationStore) BumpTokenVersion(account string, uid string) (int, error) {
+	// seed the cache with the saved version first, so the increment does not
+	// start over at 0 after the cache lost the key
+	if _, err := repo.TokenVersion(account, uid); err != nil {
+		return 0, err
+	}
+
+	version, err := repo.cacheRepo.Incr(tokenVersionKey(account, uid))
+	if err != nil {
+		return 0, errors.New("failed to bump token version: " + err.Error())
+	}
+
+	err = repo.versions.SaveTokenVersion(context.Background(), account, uid, int(version))
+	if err != nil {
+		return 0, errors.New("failed to save token version: " + err.Error())
+	}
+
+	return int(version), nil
+}
+
+func tokenVersionKey(account string, uid string) string {
+	return "token-version:" + account + ":" + uid
+}
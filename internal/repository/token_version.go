@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type tokenVersionRepository struct {
+	Users   *mongo.Collection
+	Sellers *mongo.Collection
+}
+
+// NewTokenVersionRepository keeps the token version on the user and seller
+// documents.
+func NewTokenVersionRepository(client *mongo.Client) domain.TokenVersionRepository {
+	return &tokenVersionRepository{
+		Users:   db.OpenCollection(client, "Users"),
+		Sellers: db.OpenCollection(client, "Sellers"),
+	}
+}
+
+func (repo *tokenVersionRepository) collection(account string, uid string) (*mongo.Collection, bson.M, error) {
+	switch account {
+	case domain.AccountUser:
+		return repo.Users, bson.M{"user_id": uid}, nil
+	case domain.AccountSeller:
+		return repo.Sellers, bson.M{"seller_id": uid}, nil
+	default:
+		return nil, nil, errors.New("unknown account: " + account)
+	}
+}
+
+// TokenVersion implements domain.TokenVersionRepository.
+func (repo *tokenVersionRepository) TokenVersion(ctx context.Context, account string, uid string) (int, error) {
+	collection, filter, err := repo.collection(account, uid)
+	if err != nil {
+		return 0, err
+	}
+
+	var doc struct {
+		Token_Version int `bson:"token_version"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"token_version": 1})
+	err = collection.FindOne(ctx, filter, opts).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return doc.Token_Version, nil
+}
+
+// SaveTokenVersion implements domain.TokenVersionRepository.
+func (repo *tokenVersionRepository) SaveTokenVersion(ctx context.Context, account string, uid string, version int) error {
+	collection, filter, err := repo.collection(account, uid)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$max": bson.M{"token_version": version}}
+	_, err = collection.UpdateOne(ctx, filter, update)
+	return err
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// TxModeAuto uses transactions only when the deployment supports them.
+	TxModeAuto = "auto"
+	// TxModeReplica always uses transactions, it needs a replica set or a sharded cluster.
+	TxModeReplica = "replica"
+	// TxModeStandalone never uses transactions, for a standalone mongod.
+	TxModeStandalone = "standalone"
+)
+
+type mongoTransactor struct {
+	client    *mongo.Client
+	mode      string
+	once      sync.Once
+	supported bool
+}
+
+func NewTransactor(client *mongo.Client, mode string) domain.Transactor {
+	if mode == "" {
+		mode = TxModeAuto
+	}
+
+	return &mongoTransactor{
+		client: client,
+		mode:   mode,
+	}
+}
+
+// WithTransaction implements domain.Transactor.
+// Without transaction support fn simply runs with the given ctx, the caller is
+// then responsible for undoing the writes of a failed fn. A ctx that already
+// carries a transaction is handed to fn as is, so fn joins it.
+func (t *mongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !t.useTransaction(ctx) || mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := t.client.StartSession()
+	if err != nil {
+		return errors.New("failed to start session: " + err.Error())
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+
+	return err
+}
+
+func (t *mongoTransactor) useTransaction(ctx context.Context) bool {
+	switch t.mode {
+	case TxModeReplica:
+		return true
+	case TxModeStandalone:
+		return false
+	}
+
+	t.once.Do(func() {
+		var res struct {
+			SetName string `bson:"setName"`
+			Msg     string `bson:"msg"`
+		}
+
+		err := t.client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&res)
+		if err != nil {
+			log.Println("failed to detect transaction support, running without transactions: ", err)
+			return
+		}
+
+		t.supported = res.SetName != "" || res.Msg == "isdbgrid"
+	})
+
+	return t.supported
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type uploadRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewUploadRepository(client *mongo.Client) domain.UploadRepository {
+	return &uploadRepository{
+		Collection: db.OpenCollection(client, "Uploads"),
+	}
+}
+
+// Insert implements domain.UploadRepository.
+func (repo *uploadRepository) Insert(ctx context.Context, upload domain.Upload) (primitive.ObjectID, error) {
+	result, err := repo.Collection.InsertOne(ctx, upload)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+// FindByUrls implements domain.UploadRepository.
+func (repo *uploadRepository) FindByUrls(ctx context.Context, urls []string) (*[]domain.Upload, error) {
+	filter := bson.M{"$or": []bson.M{
+		{"url": bson.M{"$in": urls}},
+		{"thumbnail_url": bson.M{"$in": urls}},
+	}}
+
+	cur, err := repo.Collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	uploads := []domain.Upload{}
+	for cur.Next(ctx) {
+		var upload domain.Upload
+		if err := cur.Decode(&upload); err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return &uploads, nil
+}
+
+// Delete implements domain.UploadRepository.
+func (repo *uploadRepository) Delete(ctx context.Context, uploadID string) error {
+	_, err := repo.Collection.DeleteOne(ctx, bson.M{"upload_id": uploadID})
+	return err
+}
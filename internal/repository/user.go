@@ -5,6 +5,7 @@ import (
 
 	"github.com/IndraSty/GreenBasket/db"
 	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -63,3 +64,19 @@ func (ur *userRepository) UpdateUser(ctx context.Context, email string, update b
 	opt := options.Update().SetUpsert(true)
 	return ur.Collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: update}}, opt)
 }
+
+// ReplaceRefreshToken implements domain.UserRepository.
+// The token is only replaced while oldToken is still the stored one, so the
+// same refresh token can not be exchanged twice.
+func (ur *userRepository) ReplaceRefreshToken(ctx context.Context, email, oldToken, newToken string) (*mongo.UpdateResult, error) {
+	filter := bson.M{"email": email, "refresh_token": oldToken}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "refresh_token", Value: newToken}}}}
+
+	return ur.Collection.UpdateOne(ctx, filter, update)
+}
+
+// SearchUsers implements domain.UserRepository.
+func (ur *userRepository) SearchUsers(ctx context.Context, query string, page pagination.Request) (*pagination.Page[domain.User], error) {
+	filter := searchFilter(query, "first_name", "last_name", "email")
+	return findPage[domain.User](ctx, ur.Collection, filter, page)
+}
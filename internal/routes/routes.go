@@ -6,6 +6,7 @@ import (
 	"os"
 	"runtime"
 
+	"github.com/IndraSty/GreenBasket/domain"
 	"github.com/IndraSty/GreenBasket/internal/delivery"
 	"github.com/IndraSty/GreenBasket/internal/middlewares"
 	"github.com/IndraSty/GreenBasket/internal/sse"
@@ -13,30 +14,43 @@ import (
 )
 
 type RouteConfig struct {
-	App                 *gin.Engine
-	Middlewares         *middlewares.Middleware
-	UserHandler         *delivery.UserHandler
-	SellerHandler       *delivery.SellerHandler
-	StoreHandler        *delivery.StoreHandler
-	ProductHandler      *delivery.ProductHandler
-	PaymentHandler      *delivery.PaymentHandler
-	OrderHandler        *delivery.OrderHandler
-	SellerOrderHandler  *delivery.SellerOrderHandler
-	NotificationHandler *delivery.NotificationHandler
-	MidtransHandler     *delivery.MidtransHandler
-	ContactHandler      *delivery.ContactHandler
-	CartHandler         *delivery.CartHandler
-	AddressHandler      *delivery.AddressHandler
-	ReviewHandler       *delivery.ReviewHandler
-	SalesReportHandler  *delivery.SalesReportHandler
-	AuthHandler         *delivery.AuthHandler
-	PasswordHandler     *delivery.PasswordHandler
-	NotificationSSE     *sse.NotificationSSE
+	App                        *gin.Engine
+	Middlewares                *middlewares.Middleware
+	UserHandler                *delivery.UserHandler
+	SellerHandler              *delivery.SellerHandler
+	StoreHandler               *delivery.StoreHandler
+	ProductHandler             *delivery.ProductHandler
+	PaymentHandler             *delivery.PaymentHandler
+	OrderHandler               *delivery.OrderHandler
+	SellerOrderHandler         *delivery.SellerOrderHandler
+	NotificationHandler        *delivery.NotificationHandler
+	PaymentNotificationHandler *delivery.PaymentNotificationHandler
+	ContactHandler             *delivery.ContactHandler
+	CartHandler                *delivery.CartHandler
+	GuestCartHandler           *delivery.GuestCartHandler
+	AddressHandler             *delivery.AddressHandler
+	ReviewHandler              *delivery.ReviewHandler
+	SalesReportHandler         *delivery.SalesReportHandler
+	RefundHandler              *delivery.RefundHandler
+	ShipmentHandler            *delivery.ShipmentHandler
+	WeightAdjustmentHandler    *delivery.WeightAdjustmentHandler
+	BatchHandler               *delivery.BatchHandler
+	StockLedgerHandler         *delivery.StockLedgerHandler
+	ProductImportHandler       *delivery.ProductImportHandler
+	UploadHandler              *delivery.UploadHandler
+	SearchHandler              *delivery.SearchHandler
+	ShippingHandler            *delivery.ShippingHandler
+	AdminHandler               *delivery.AdminHandler
+	CategoryHandler            *delivery.CategoryHandler
+	AuthHandler                *delivery.AuthHandler
+	PasswordHandler            *delivery.PasswordHandler
+	NotificationSSE            *sse.NotificationSSE
 }
 
 func (c *RouteConfig) Setup() {
 	c.SetupGuestRoute()
 	c.SetupSellerAuthRoute()
+	c.SetupAdminRoute()
 	c.SetupUserAuthRoute()
 }
 
@@ -53,6 +67,9 @@ func (c *RouteConfig) SetupGuestRoute() {
 	c.App.GET("/api/auth/callback/:provider", c.AuthHandler.GetAuthCallBackFunc)
 	c.App.DELETE("/api/auth/logout", c.AuthHandler.LogoutHandler)
 
+	// access token refresh for users and sellers
+	c.App.POST("/api/auth/refresh", c.AuthHandler.RefreshToken())
+
 	// seller route
 	c.App.POST("/api/sellers/signup", c.SellerHandler.RegisterSeller())
 	c.App.POST("/api/sellers/login", c.SellerHandler.AuthenticateSeller())
@@ -60,72 +77,165 @@ func (c *RouteConfig) SetupGuestRoute() {
 	// product for guest
 	c.App.GET("/api/products", c.ProductHandler.FetchAllProductForGuest())
 	c.App.GET("/api/products/search", c.ProductHandler.SearchProductForGuest())
+	c.App.GET("/api/products/catalogue", c.ProductHandler.FetchCatalogueForGuest())
+	c.App.GET("/api/products/suggest", c.SearchHandler.SuggestProducts())
 	c.App.GET("/api/products/:product_id", c.ProductHandler.FetchProductForGuest())
 	c.App.GET("/api/products/category", c.ProductHandler.FetchAllProductByCategoryForGuest())
 	c.App.GET("/api/products/sort", c.ProductHandler.SortProductForGuest())
 
+	// cart for guest, kept by the cart_token cookie and merged into the
+	// cart of the user at login
+	c.App.GET("/api/cart", c.GuestCartHandler.GetAllItemCart())
+	c.App.POST("/api/cart", c.GuestCartHandler.AddToCart())
+	c.App.PATCH("/api/cart/item", c.GuestCartHandler.UpdateItemInCart())
+	c.App.DELETE("/api/cart/item", c.GuestCartHandler.RemoveItemInCart())
+
+	// category tree for guest
+	c.App.GET("/api/categories", c.CategoryHandler.GetCategories())
+
+	// uploaded images, only served when STORAGE_DRIVER=local
+	if dir := c.UploadHandler.LocalDir(); dir != "" {
+		c.App.Static("/uploads", dir)
+	}
+
 	// store for guest
 	c.App.GET("/api/stores", c.StoreHandler.SearchStore())
 
 	// midtrans callback
-	c.App.POST("/api/midtrans/payment-callback", c.MidtransHandler.PaymentHandlerNotification())
+	c.App.POST("/api/midtrans/payment-callback", c.PaymentNotificationHandler.PaymentHandlerNotification())
+
+	// fake payment gateway, only served when PAYMENT_GATEWAY=fake
+	if c.PaymentNotificationHandler.FakeGatewayEnabled() {
+		c.App.GET("/api/fake-gateway/charges/:order_id", c.PaymentNotificationHandler.GetFakeCharge())
+		c.App.POST("/api/fake-gateway/charges/:order_id/simulate", c.PaymentNotificationHandler.SimulateFakePayment())
+	}
 }
 
 func (c *RouteConfig) SetupSellerAuthRoute() {
 	sellerRoutes := c.App.Group("/api/sellers")
 	{
 		sellerRoutes.Use(c.Middlewares.SellerAuthMiddleware())
-		sellerRoutes.GET("/current", c.SellerHandler.GetSellerHandler())
-		sellerRoutes.PATCH("/current", c.SellerHandler.UpdateSellerHandler())
-		sellerRoutes.DELETE("/current", c.SellerHandler.LogoutSellerHandler())
+		sellerRoutes.Use(c.Middlewares.RequireRole(domain.RoleSeller, domain.RoleSellerStaff))
+
+		account := sellerRoutes.Group("", c.Middlewares.RequirePermission(domain.PermissionAccount))
+		account.GET("/current", c.SellerHandler.GetSellerHandler())
+		account.PATCH("/current", c.SellerHandler.UpdateSellerHandler())
+		account.DELETE("/current", c.SellerHandler.LogoutSellerHandler())
+		account.DELETE("/current/sessions", c.SellerHandler.LogoutAllSellerSessions())
 
 		// seller address
-		sellerRoutes.POST("/current/addresses", c.AddressHandler.AddSellerAddress())
-		sellerRoutes.GET("/current/addresses", c.AddressHandler.GetSellerAddress())
-		sellerRoutes.PUT("/current/addresses", c.AddressHandler.UpdateSellerAddress())
-		sellerRoutes.DELETE("/current/addresses", c.AddressHandler.RemoveSellerAddress())
+		account.POST("/current/addresses", c.AddressHandler.AddSellerAddress())
+		account.GET("/current/addresses", c.AddressHandler.GetSellerAddress())
+		account.PUT("/current/addresses", c.AddressHandler.UpdateSellerAddress())
+		account.DELETE("/current/addresses", c.AddressHandler.RemoveSellerAddress())
+
+		// seller image upload, for products, store logo and banner and the profile
+		sellerRoutes.POST("/current/uploads", c.UploadHandler.UploadImage())
+
+		stores := sellerRoutes.Group("", c.Middlewares.RequirePermission(domain.PermissionManageStore))
 
 		// seller store
-		sellerRoutes.POST("/current/stores", c.StoreHandler.CreateStore())
-		sellerRoutes.GET("/current/stores/:store_id", c.StoreHandler.DetailStore())
-		sellerRoutes.PUT("/current/stores/:store_id", c.StoreHandler.EditStore())
-		sellerRoutes.DELETE("/current/stores/:store_id", c.StoreHandler.DeleteStore())
+		stores.POST("/current/stores", c.StoreHandler.CreateStore())
+		stores.GET("/current/stores/:store_id", c.StoreHandler.DetailStore())
+		stores.PUT("/current/stores/:store_id", c.StoreHandler.EditStore())
+		stores.DELETE("/current/stores/:store_id", c.StoreHandler.DeleteStore())
 
 		// seller store address
-		sellerRoutes.POST("/current/stores/:store_id/address", c.AddressHandler.AddStoreAddress())
-		sellerRoutes.GET("/current/stores/:store_id/address", c.AddressHandler.GetStoreAddress())
-		sellerRoutes.PUT("/current/stores/:store_id/address", c.AddressHandler.EditStoreAddress())
-		sellerRoutes.DELETE("/current/stores/:store_id/address", c.AddressHandler.RemoveStoreAddress())
+		stores.POST("/current/stores/:store_id/address", c.AddressHandler.AddStoreAddress())
+		stores.GET("/current/stores/:store_id/address", c.AddressHandler.GetStoreAddress())
+		stores.PUT("/current/stores/:store_id/address", c.AddressHandler.EditStoreAddress())
+		stores.DELETE("/current/stores/:store_id/address", c.AddressHandler.RemoveStoreAddress())
 
 		// seller store contact
-		sellerRoutes.POST("/current/stores/:store_id/contact", c.ContactHandler.AddStoreContact())
-		sellerRoutes.GET("/current/stores/:store_id/contact", c.ContactHandler.GetStoreContact())
-		sellerRoutes.PUT("/current/stores/:store_id/contact", c.ContactHandler.EditStoreContact())
-		sellerRoutes.DELETE("/current/stores/:store_id/contact", c.ContactHandler.DeleteStoreContact())
+		stores.POST("/current/stores/:store_id/contact", c.ContactHandler.AddStoreContact())
+		stores.GET("/current/stores/:store_id/contact", c.ContactHandler.GetStoreContact())
+		stores.PUT("/current/stores/:store_id/contact", c.ContactHandler.EditStoreContact())
+		stores.DELETE("/current/stores/:store_id/contact", c.ContactHandler.DeleteStoreContact())
+
+		// seller store staff
+		stores.POST("/current/stores/:store_id/staff", c.SellerHandler.AddStaff())
+		stores.GET("/current/stores/:store_id/staff", c.SellerHandler.FetchStaff())
+		stores.DELETE("/current/stores/:store_id/staff/:seller_id", c.SellerHandler.RemoveStaff())
+
+		// staff work on the products and the orders of their own store only
+		products := sellerRoutes.Group("", c.Middlewares.RequirePermission(domain.PermissionManageProduct), c.Middlewares.ScopeStaff())
 
 		// seller store product
-		sellerRoutes.POST("/current/stores/:store_id/product", c.ProductHandler.AddProduct())
-		sellerRoutes.GET("/current/stores/:store_id/product", c.ProductHandler.FetchProductById())
-		sellerRoutes.GET("/current/stores/:store_id/products/category", c.ProductHandler.FetchAllProductByCategorySeller())
-		sellerRoutes.GET("/current/stores/:store_id/products", c.ProductHandler.FetchAllProductSeller())
-		sellerRoutes.GET("/current/stores/:store_id/products/search", c.ProductHandler.SearchProduct())
-		sellerRoutes.GET("/current/stores/:store_id/products/sort", c.ProductHandler.SortProduct())
-		sellerRoutes.PUT("/current/stores/:store_id/product", c.ProductHandler.UpdateProduct())
-		sellerRoutes.DELETE("/current/stores/:store_id/product", c.ProductHandler.DeleteProduct())
+		products.POST("/current/stores/:store_id/product", c.ProductHandler.AddProduct())
+		products.GET("/current/stores/:store_id/product", c.ProductHandler.FetchProductById())
+		products.GET("/current/stores/:store_id/products/category", c.ProductHandler.FetchAllProductByCategorySeller())
+		products.GET("/current/stores/:store_id/products", c.ProductHandler.FetchAllProductSeller())
+		products.GET("/current/stores/:store_id/products/search", c.ProductHandler.SearchProduct())
+		products.GET("/current/stores/:store_id/products/sort", c.ProductHandler.SortProduct())
+		products.PUT("/current/stores/:store_id/product", c.ProductHandler.UpdateProduct())
+		products.DELETE("/current/stores/:store_id/product", c.ProductHandler.DeleteProduct())
+		products.POST("/current/stores/:store_id/products/import", c.ProductImportHandler.ImportProducts())
+		products.GET("/current/stores/:store_id/products/import/:job_id", c.ProductImportHandler.FetchImportJob())
+		products.GET("/current/stores/:store_id/products/export", c.ProductImportHandler.ExportProducts())
+
+		// seller store product batches
+		products.POST("/current/stores/:store_id/product/batches", c.BatchHandler.AddBatch())
+		products.GET("/current/stores/:store_id/product/batches", c.BatchHandler.FetchBatches())
+		products.GET("/current/stores/:store_id/batches/expiring", c.BatchHandler.FetchExpiringStock())
+
+		// seller store product stock ledger
+		products.POST("/current/stores/:store_id/product/stock", c.StockLedgerHandler.AdjustStock())
+		products.GET("/current/stores/:store_id/product/stock/movements", c.StockLedgerHandler.FetchMovements())
+
+		orders := sellerRoutes.Group("", c.Middlewares.RequirePermission(domain.PermissionFulfilOrder), c.Middlewares.ScopeStaff())
 
 		// seller order
-		sellerRoutes.GET("/current/orders/:order_id", c.SellerOrderHandler.DetailSellerOrder())
-		sellerRoutes.GET("/current/orders", c.SellerOrderHandler.GetAllSellerOrders())
-		sellerRoutes.PATCH("/current/orders/:order_id", c.SellerOrderHandler.UpdateStatusOrder())
-		sellerRoutes.DELETE("/current/orders/:order_id", c.SellerOrderHandler.CancelOrder())
+		orders.GET("/current/orders/:order_id", c.SellerOrderHandler.DetailSellerOrder())
+		orders.GET("/current/orders", c.SellerOrderHandler.GetAllSellerOrders())
+		orders.PATCH("/current/orders/:order_id", c.SellerOrderHandler.UpdateStatusOrder())
+		orders.DELETE("/current/orders/:order_id", c.SellerOrderHandler.CancelOrder())
+
+		// seller packed quantity of a weighed item
+		orders.POST("/current/orders/:order_id/packed-quantity", c.WeightAdjustmentHandler.AdjustPackedQuantity())
+
+		// seller shipment
+		orders.POST("/current/orders/:order_id/shipment", c.ShipmentHandler.AddShipment())
+		orders.PUT("/current/orders/:order_id/shipment", c.ShipmentHandler.UpdateShipment())
+
+		// seller refund
+		orders.GET("/current/refunds", c.RefundHandler.GetSellerRefunds())
+		orders.PATCH("/current/refunds/:refund_id", c.RefundHandler.UpdateRefundStatus())
 
 		// seller review
-		sellerRoutes.GET("/current/reviews/product", c.ReviewHandler.GetAllReviewByProductId())
-		sellerRoutes.GET("/current/reviews", c.ReviewHandler.GetAllReviewBySellerEmail())
-		sellerRoutes.PATCH("/current/reviews/:review_id", c.ReviewHandler.UpdateResponSeller())
+		orders.GET("/current/reviews/product", c.ReviewHandler.GetAllReviewByProductId())
+		orders.GET("/current/reviews", c.ReviewHandler.GetAllReviewBySellerEmail())
+		orders.PATCH("/current/reviews/:review_id", c.ReviewHandler.UpdateResponSeller())
 
 		// seller sales report
-		sellerRoutes.GET("/current/stores/:store_id/report", c.SalesReportHandler.GetSalesReport())
+		reports := sellerRoutes.Group("", c.Middlewares.RequirePermission(domain.PermissionViewReport))
+		reports.GET("/current/stores/:store_id/report", c.SalesReportHandler.GetSalesReport())
+	}
+}
+
+func (c *RouteConfig) SetupAdminRoute() {
+	adminRoutes := c.App.Group("/api/admin")
+	{
+		adminRoutes.Use(c.Middlewares.UserAuthMiddleware())
+		adminRoutes.Use(c.Middlewares.RequirePermission(domain.PermissionAdmin))
+
+		// admin accounts
+		adminRoutes.GET("/users", c.AdminHandler.SearchUsers())
+		adminRoutes.PATCH("/users/:user_id/suspension", c.AdminHandler.SuspendUser())
+		adminRoutes.GET("/sellers", c.AdminHandler.SearchSellers())
+		adminRoutes.PATCH("/sellers/:seller_id/suspension", c.AdminHandler.SuspendSeller())
+
+		// admin catalogue
+		adminRoutes.GET("/stores", c.AdminHandler.SearchStores())
+		adminRoutes.PATCH("/stores/:store_id/suspension", c.AdminHandler.SuspendStore())
+		adminRoutes.PATCH("/products/:product_id/publication", c.AdminHandler.UnpublishProduct())
+		adminRoutes.DELETE("/reviews/:review_id", c.AdminHandler.RemoveReview())
+		adminRoutes.POST("/categories", c.CategoryHandler.CreateCategory())
+		adminRoutes.PUT("/categories/:slug", c.CategoryHandler.UpdateCategory())
+		adminRoutes.DELETE("/categories/:slug", c.CategoryHandler.DeleteCategory())
+
+		// admin orders and audit log
+		adminRoutes.GET("/orders", c.AdminHandler.GetAllOrders())
+		adminRoutes.GET("/audit-logs", c.AdminHandler.GetAuditLogs())
 	}
 }
 
@@ -134,23 +244,32 @@ func (c *RouteConfig) SetupUserAuthRoute() {
 	userRoutes := c.App.Group("/api/users")
 	{
 		userRoutes.Use(c.Middlewares.UserAuthMiddleware())
-		userRoutes.GET("/current", c.UserHandler.GetUserHandler())
-		userRoutes.PATCH("/current", c.UserHandler.UpdateUserHandler())
-		userRoutes.PATCH("/current/add-phone", c.UserHandler.AddPhoneNumber())
-		userRoutes.DELETE("/current/logout", c.AuthHandler.LogoutHandler)
+		userRoutes.Use(c.Middlewares.RequireRole(domain.RoleUser, domain.RoleAdmin))
+
+		account := userRoutes.Group("", c.Middlewares.RequirePermission(domain.PermissionAccount))
+		account.GET("/current", c.UserHandler.GetUserHandler())
+		account.PATCH("/current", c.UserHandler.UpdateUserHandler())
+		account.PATCH("/current/add-phone", c.UserHandler.AddPhoneNumber())
+		account.DELETE("/current/logout", c.AuthHandler.LogoutHandler)
+		account.DELETE("/current/sessions", c.AuthHandler.LogoutAllSessions())
 
 		// user address
-		userRoutes.POST("/current/addresses", c.AddressHandler.AddUserAddress())
-		userRoutes.GET("/current/addresses", c.AddressHandler.GetUserAddress())
-		userRoutes.PUT("/current/addresses", c.AddressHandler.UpdateUserAddress())
-		userRoutes.DELETE("/current/addresses", c.AddressHandler.RemoveUserAddress())
+		account.POST("/current/addresses", c.AddressHandler.AddUserAddress())
+		account.GET("/current/addresses", c.AddressHandler.GetUserAddress())
+		account.PUT("/current/addresses", c.AddressHandler.UpdateUserAddress())
+		account.DELETE("/current/addresses", c.AddressHandler.RemoveUserAddress())
+
+		// user image upload, for the profile
+		account.POST("/current/uploads", c.UploadHandler.UploadImage())
+
+		shop := userRoutes.Group("", c.Middlewares.RequirePermission(domain.PermissionShop))
 
 		// user cart
-		userRoutes.POST("/current/cart", c.CartHandler.AddToCart())
-		userRoutes.GET("/current/cart", c.CartHandler.GetCart())
-		userRoutes.PATCH("/current/cart-item", c.CartHandler.UpdateItemInCart())
-		userRoutes.DELETE("/current/cart-item", c.CartHandler.RemoveItemInCart())
-		userRoutes.GET("/current/cart-items", c.CartHandler.GetAllItemCart())
+		shop.POST("/current/cart", c.CartHandler.AddToCart())
+		shop.GET("/current/cart", c.CartHandler.GetCart())
+		shop.PATCH("/current/cart-item", c.CartHandler.UpdateItemInCart())
+		shop.DELETE("/current/cart-item", c.CartHandler.RemoveItemInCart())
+		shop.GET("/current/cart-items", c.CartHandler.GetAllItemCart())
 
 		// user product
 		c.App.GET("/current/products", c.ProductHandler.FetchAllProductForGuest())
@@ -160,21 +279,27 @@ func (c *RouteConfig) SetupUserAuthRoute() {
 		c.App.GET("/current/products/sort", c.ProductHandler.SortProductForGuest())
 
 		// user order
-		userRoutes.POST("/current/order", c.OrderHandler.CreateOrder())
-		userRoutes.GET("/current/order/:order_id", c.OrderHandler.DetailOrder())
-		userRoutes.PATCH("/current/order/:order_id", c.OrderHandler.FinishOrder())
-		userRoutes.GET("/current/orders", c.OrderHandler.GetAllOrders())
-		userRoutes.DELETE("/current/order/:order_id", c.OrderHandler.CancelOrder())
+		shop.GET("/current/shipping-quote", c.ShippingHandler.QuoteShipping())
+		shop.POST("/current/order", c.OrderHandler.CreateOrder())
+		shop.GET("/current/order/:order_id", c.OrderHandler.DetailOrder())
+		shop.PATCH("/current/order/:order_id", c.OrderHandler.FinishOrder())
+		shop.GET("/current/orders", c.OrderHandler.GetAllOrders())
+		shop.DELETE("/current/order/:order_id", c.OrderHandler.CancelOrder())
+		shop.GET("/current/order/:order_id/shipment", c.ShipmentHandler.GetOrderShipments())
+
+		// user refund
+		shop.POST("/current/order/:order_id/refund", c.RefundHandler.RequestRefund())
+		shop.GET("/current/refunds", c.RefundHandler.GetUserRefunds())
 
 		// user payment
-		userRoutes.POST("/current/payment", c.PaymentHandler.InitializePayment())
+		shop.POST("/current/payment", c.PaymentHandler.InitializePayment())
 
 		// user review
-		userRoutes.POST("/current/review/:order_id", c.ReviewHandler.AddReview())
-		userRoutes.GET("/current/review/:review_id", c.ReviewHandler.GetUserReviewById())
-		userRoutes.GET("/current/reviews", c.ReviewHandler.GetAllReviewByUserEmail())
-		userRoutes.PATCH("/current/review/:review_id", c.ReviewHandler.UpdateReview())
-		userRoutes.DELETE("/current/review/:review_id", c.ReviewHandler.DeleteReview())
+		shop.POST("/current/review/:order_id", c.ReviewHandler.AddReview())
+		shop.GET("/current/review/:review_id", c.ReviewHandler.GetUserReviewById())
+		shop.GET("/current/reviews", c.ReviewHandler.GetAllReviewByUserEmail())
+		shop.PATCH("/current/review/:review_id", c.ReviewHandler.UpdateReview())
+		shop.DELETE("/current/review/:review_id", c.ReviewHandler.DeleteReview())
 
 	}
 
@@ -0,0 +1,158 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+)
+
+// Job is background work the scheduler runs every Interval. Run must be safe to
+// repeat, its ctx is cancelled when the replica loses the lock of the job.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs its jobs inside the server. Every replica ticks, but a redis
+// lock makes sure only one of them runs a job per interval.
+type Scheduler struct {
+	cacheRepo domain.CacheRepository
+	owner     string
+	jobs      []Job
+}
+
+func NewScheduler(cacheRepo domain.CacheRepository) *Scheduler {
+	owner, err := os.Hostname()
+	if err != nil {
+		owner = "unknown"
+	}
+
+	return &Scheduler{
+		cacheRepo: cacheRepo,
+		owner:     owner,
+	}
+}
+
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every job in its own goroutine until ctx is done.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		go s.loop(ctx, job)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RunOnce(ctx, job); err != nil {
+			log.Println("scheduler job "+job.Name+" failed: ", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce runs job unless another replica already ran it in this interval,
+// and reports whether it ran.
+func (s *Scheduler) RunOnce(ctx context.Context, job Job) (bool, error) {
+	key := "scheduler-lock:" + job.Name
+	token, err := s.token()
+	if err != nil {
+		return false, err
+	}
+
+	locked, err := s.cacheRepo.SetNX(key, token, job.Interval)
+	if err != nil {
+		return false, errors.New("failed to take scheduler lock: " + err.Error())
+	}
+
+	if !locked {
+		return false, nil
+	}
+
+	started := time.Now()
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stop := s.renew(runCtx, cancel, key, token, job.Interval)
+	err = job.Run(runCtx)
+	stop()
+
+	// the lock is kept until the end of the interval, so the job runs at most
+	// once per interval however many replicas are ticking
+	if _, relErr := s.cacheRepo.ExpireIfEqual(key, token, job.Interval-time.Since(started)); relErr != nil {
+		log.Println("failed to release scheduler lock of "+job.Name+": ", relErr)
+	}
+
+	return true, err
+}
+
+// renew keeps the lock of a running job for another interval, a third of an
+// interval at a time. When the lock was taken over by another replica it
+// cancels the run. The returned func stops renewing.
+func (s *Scheduler) renew(ctx context.Context, cancel context.CancelFunc, key string, token []byte, interval time.Duration) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			owned, err := s.cacheRepo.ExpireIfEqual(key, token, interval)
+			if err != nil {
+				log.Println("failed to renew scheduler lock of "+key+": ", err)
+				continue
+			}
+			if !owned {
+				log.Println("scheduler lost the lock " + key)
+				cancel()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// token tells the runs of a job apart, only the run holding the lock renews
+// and releases it.
+func (s *Scheduler) token() ([]byte, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, errors.New("failed to create scheduler lock token: " + err.Error())
+	}
+
+	return []byte(s.owner + ":" + hex.EncodeToString(id)), nil
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
+	"github.com/asaskevich/govalidator"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type adminService struct {
+	auditRepo       domain.AuditLogRepository
+	userRepo        domain.UserRepository
+	sellerRepo      domain.SellerRepository
+	storeRepo       domain.StoreRepository
+	productRepo     domain.ProductRepository
+	reviewRepo      domain.ReviewRepository
+	orderRepo       domain.OrderRepository
+	revocationStore domain.TokenRevocationStore
+	cacheRepo       domain.CacheRepository
+	searchSvc       domain.ProductSearchService
+}
+
+func NewAdminService(auditRepo domain.AuditLogRepository, userRepo domain.UserRepository, sellerRepo domain.SellerRepository,
+	storeRepo domain.StoreRepository, productRepo domain.ProductRepository, reviewRepo domain.ReviewRepository,
+	orderRepo domain.OrderRepository, revocationStore domain.TokenRevocationStore, cacheRepo domain.CacheRepository,
+	searchSvc domain.ProductSearchService) domain.AdminService {
+	return &adminService{
+		auditRepo:       auditRepo,
+		userRepo:        userRepo,
+		sellerRepo:      sellerRepo,
+		storeRepo:       storeRepo,
+		productRepo:     productRepo,
+		reviewRepo:      reviewRepo,
+		orderRepo:       orderRepo,
+		revocationStore: revocationStore,
+		cacheRepo:       cacheRepo,
+		searchSvc:       searchSvc,
+	}
+}
+
+// SearchUsers implements domain.AdminService.
+func (s *adminService) SearchUsers(ctx context.Context, query string, page pagination.Request) (*pagination.Page[dto.AdminUserRes], error) {
+	users, err := s.userRepo.SearchUsers(ctx, query, page)
+	if err != nil {
+		return nil, errors.New("failed to search users: " + err.Error())
+	}
+
+	res := make([]dto.AdminUserRes, len(users.Items))
+	for i, user := range users.Items {
+		res[i] = dto.AdminUserRes{
+			User_Id:        user.User_Id,
+			First_Name:     user.First_Name,
+			Last_Name:      user.Last_Name,
+			Email:          user.Email,
+			Phone:          user.Phone,
+			Role:           user.Role,
+			Email_Verified: user.EmailVerified,
+			Suspended:      user.Suspended,
+			Created_At:     user.Created_At,
+		}
+	}
+
+	return &pagination.Page[dto.AdminUserRes]{Items: res, Next_Cursor: users.Next_Cursor}, nil
+}
+
+// SuspendUser implements domain.AdminService.
+// A suspended user is logged out of every session right away.
+func (s *adminService) SuspendUser(ctx context.Context, adminEmail, userID string, req *dto.SuspendReq) error {
+	_, err := govalidator.ValidateStruct(req)
+	if err != nil {
+		return errors.New("Invalid request body: " + err.Error())
+	}
+
+	user, err := s.userRepo.FindUserById(ctx, userID)
+	if err != nil {
+		return errors.New("failed to find user: " + err.Error())
+	}
+
+	if user.Role == domain.RoleAdmin {
+		return errors.New("an admin can not be suspended")
+	}
+
+	update := bson.D{{Key: "suspended", Value: req.Suspended}, {Key: "updated_at", Value: time.Now()}}
+	if req.Suspended {
+		update = append(update, bson.E{Key: "refresh_token", Value: ""})
+	}
+
+	_, err = s.userRepo.UpdateUser(ctx, user.Email, update)
+	if err != nil {
+		return errors.New("failed to update user: " + err.Error())
+	}
+
+	action := domain.AuditUnsuspendUser
+	if req.Suspended {
+		action = domain.AuditSuspendUser
+
+		_, err = s.revocationStore.BumpTokenVersion(domain.AccountUser, user.User_Id)
+		if err != nil {
+			return errors.New("failed to revoke user tokens: " + err.Error())
+		}
+	}
+
+	return s.audit(ctx, adminEmail, action, domain.AuditTargetUser, userID, req.Reason)
+}
+
+// SearchSellers implements domain.AdminService.
+func (s *adminService) SearchSellers(ctx context.Context, query string, page pagination.Request) (*pagination.Page[dto.AdminSellerRes], error) {
+	sellers, err := s.sellerRepo.SearchSellers(ctx, query, page)
+	if err != nil {
+		return nil, errors.New("failed to search sellers: " + err.Error())
+	}
+
+	res := make([]dto.AdminSellerRes, len(sellers.Items))
+	for i, seller := range sellers.Items {
+		res[i] = dto.AdminSellerRes{
+			Seller_Id:      seller.Seller_Id,
+			First_Name:     seller.First_Name,
+			Last_Name:      seller.Last_Name,
+			Email:          seller.Email,
+			Phone:          seller.Phone,
+			Role:           seller.Role,
+			Store_Id:       seller.Store_Id,
+			Email_Verified: seller.EmailVerified,
+			Suspended:      seller.Suspended,
+			Created_At:     seller.Created_At,
+		}
+	}
+
+	return &pagination.Page[dto.AdminSellerRes]{Items: res, Next_Cursor: sellers.Next_Cursor}, nil
+}
+
+// SuspendSeller implements domain.AdminService.
+func (s *adminService) SuspendSeller(ctx context.Context, adminEmail, sellerID string, req *dto.SuspendReq) error {
+	_, err := govalidator.ValidateStruct(req)
+	if err != nil {
+		return errors.New("Invalid request body: " + err.Error())
+	}
+
+	seller, err := s.sellerRepo.FindSellerById(ctx, sellerID)
+	if err != nil {
+		return errors.New("failed to find seller: " + err.Error())
+	}
+
+	update := bson.D{{Key: "suspended", Value: req.Suspended}, {Key: "updated_at", Value: time.Now()}}
+	if req.Suspended {
+		update = append(update, bson.E{Key: "refresh_token", Value: ""})
+	}
+
+	_, err = s.sellerRepo.UpdateSeller(ctx, seller.Email, update)
+	if err != nil {
+		return errors.New("failed to update seller: " + err.Error())
+	}
+
+	stores, err := s.storeRepo.GetStoresByEmail(ctx, seller.Email)
+	if err != nil {
+		return errors.New("failed to get stores of the seller: " + err.Error())
+	}
+
+	for _, store := range stores {
+		err = s.hideStoreProducts(ctx, store.Store_Id, store.Suspended || req.Suspended)
+		if err != nil {
+			return err
+		}
+	}
+
+	action := domain.AuditUnsuspendSeller
+	if req.Suspended {
+		action = domain.AuditSuspendSeller
+
+		_, err = s.revocationStore.BumpTokenVersion(domain.AccountSeller, seller.Seller_Id)
+		if err != nil {
+			return errors.New("failed to revoke seller tokens: " + err.Error())
+		}
+	}
+
+	return s.audit(ctx, adminEmail, action, domain.AuditTargetSeller, sellerID, req.Reason)
+}
+
+// SearchStores implements domain.AdminService.
+func (s *adminService) SearchStores(ctx context.Context, query string, page pagination.Request) (*pagination.Page[domain.Store], error) {
+	stores, err := s.storeRepo.SearchStores(ctx, query, page)
+	if err != nil {
+		return nil, errors.New("failed to search stores: " + err.Error())
+	}
+
+	return stores, nil
+}
+
+// SuspendStore implements domain.AdminService.
+// The products stay hidden while the seller of the store is suspended.
+func (s *adminService) SuspendStore(ctx context.Context, adminEmail, storeID string, req *dto.SuspendReq) error {
+	_, err := govalidator.ValidateStruct(req)
+	if err != nil {
+		return errors.New("Invalid request body: " + err.Error())
+	}
+
+	store, err := s.storeRepo.GetStore(ctx, storeID)
+	if err != nil {
+		return errors.New("failed to find store: " + err.Error())
+	}
+
+	seller, err := s.sellerRepo.FindSellerByEmail(ctx, store.Email)
+	if err != nil {
+		return errors.New("failed to find seller of the store: " + err.Error())
+	}
+
+	update := bson.D{{Key: "suspended", Value: req.Suspended}, {Key: "updated_at", Value: time.Now()}}
+	_, err = s.storeRepo.UpdateStore(ctx, store.Email, storeID, update)
+	if err != nil {
+		return errors.New("failed to update store: " + err.Error())
+	}
+
+	err = s.cacheRepo.Del("seller_store:" + store.Email)
+	if err != nil {
+		return errors.New("failed to delete store cache: " + err.Error())
+	}
+
+	err = s.hideStoreProducts(ctx, storeID, req.Suspended || seller.Suspended)
+	if err != nil {
+		return err
+	}
+
+	action := domain.AuditUnsuspendStore
+	if req.Suspended {
+		action = domain.AuditSuspendStore
+
+		// the seller of a suspended store can not log in either
+		_, err = s.sellerRepo.UpdateSeller(ctx, seller.Email, bson.D{{Key: "refresh_token", Value: ""}})
+		if err != nil {
+			return errors.New("failed to update seller: " + err.Error())
+		}
+
+		_, err = s.revocationStore.BumpTokenVersion(domain.AccountSeller, seller.Seller_Id)
+		if err != nil {
+			return errors.New("failed to revoke seller tokens: " + err.Error())
+		}
+
+		staff, err := s.sellerRepo.FindStaff(ctx, storeID)
+		if err != nil {
+			return errors.New("failed to get staff of the store: " + err.Error())
+		}
+
+		for _, account := range staff {
+			_, err = s.revocationStore.BumpTokenVersion(domain.AccountSeller, account.Seller_Id)
+			if err != nil {
+				return errors.New("failed to revoke staff tokens: " + err.Error())
+			}
+		}
+	}
+
+	return s.audit(ctx, adminEmail, action, domain.AuditTargetStore, storeID, req.Reason)
+}
+
+// UnpublishProduct implements domain.AdminService.
+func (s *adminService) UnpublishProduct(ctx context.Context, adminEmail, productID string, req *dto.UnpublishReq) error {
+	_, err := govalidator.ValidateStruct(req)
+	if err != nil {
+		return errors.New("Invalid request body: " + err.Error())
+	}
+
+	product, err := s.productRepo.GetProductById(ctx, productID)
+	if err != nil {
+		return errors.New("failed to get product by id: " + err.Error())
+	}
+
+	update := bson.D{{Key: "unpublished", Value: req.Unpublished}, {Key: "updated_at", Value: time.Now()}}
+	_, err = s.productRepo.UpdateProduct(ctx, product.Store_id, productID, update)
+	if err != nil {
+		return errors.New("failed to update product: " + err.Error())
+	}
+
+	if err := s.searchSvc.SyncProducts(ctx, productID); err != nil {
+		log.Println("failed to sync product "+productID+" to the search index: ", err)
+	}
+
+	action := domain.AuditRepublishProduct
+	if req.Unpublished {
+		action = domain.AuditUnpublishProduct
+	}
+
+	return s.audit(ctx, adminEmail, action, domain.AuditTargetProduct, productID, req.Reason)
+}
+
+// RemoveReview implements domain.AdminService.
+func (s *adminService) RemoveReview(ctx context.Context, adminEmail, reviewID, reason string) error {
+	if reason == "" {
+		return errors.New("reason is required")
+	}
+
+	_, err := s.reviewRepo.GetReviewById(ctx, reviewID)
+	if err != nil {
+		return errors.New("failed to get review by id: " + err.Error())
+	}
+
+	_, err = s.reviewRepo.DeleteReview(ctx, reviewID)
+	if err != nil {
+		return errors.New("failed to delete the review: " + err.Error())
+	}
+
+	return s.audit(ctx, adminEmail, domain.AuditRemoveReview, domain.AuditTargetReview, reviewID, reason)
+}
+
+// GetAllOrders implements domain.AdminService.
+func (s *adminService) GetAllOrders(ctx context.Context, page pagination.Request) (*pagination.Page[domain.Orders], error) {
+	orders, err := s.orderRepo.FindAll(ctx, page)
+	if err != nil {
+		return nil, errors.New("failed to get all orders: " + err.Error())
+	}
+
+	return orders, nil
+}
+
+// GetAuditLogs implements domain.AdminService.
+func (s *adminService) GetAuditLogs(ctx context.Context, page pagination.Request, targetID string) (*pagination.Page[domain.AuditLog], error) {
+	logs, err := s.auditRepo.FindAll(ctx, page, targetID)
+	if err != nil {
+		return nil, errors.New("failed to get audit logs: " + err.Error())
+	}
+
+	return logs, nil
+}
+
+// hideStoreProducts hides or shows every product of the store to guests and
+// buyers, and takes them out of or puts them back in the search index.
+func (s *adminService) hideStoreProducts(ctx context.Context, storeID string, hidden bool) error {
+	_, err := s.productRepo.UpdateProductsOfStore(ctx, storeID, bson.D{{Key: "store_suspended", Value: hidden}})
+	if err != nil {
+		return errors.New("failed to update products of the store: " + err.Error())
+	}
+
+	// the products are hidden already, the index catches up on the next reindex
+	if err := s.searchSvc.SyncStore(ctx, storeID); err != nil {
+		log.Println("failed to sync products of store "+storeID+" to the search index: ", err)
+	}
+
+	return nil
+}
+
+func (s *adminService) audit(ctx context.Context, adminEmail, action, targetType, targetID, reason string) error {
+	_, err := s.auditRepo.Insert(ctx, domain.AuditLog{
+		ID:          primitive.NewObjectID(),
+		Admin_Email: adminEmail,
+		Action:      action,
+		Target_Type: targetType,
+		Target_Id:   targetID,
+		Reason:      reason,
+		Created_At:  time.Now(),
+	})
+	if err != nil {
+		return errors.New("failed to write audit log: " + err.Error())
+	}
+
+	return nil
+}
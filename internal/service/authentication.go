@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
+	"time"
 
 	"github.com/IndraSty/GreenBasket/domain"
 	"github.com/IndraSty/GreenBasket/dto"
@@ -10,22 +12,29 @@ import (
 	"github.com/asaskevich/govalidator"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type authService struct {
-	userRepo  domain.UserRepository
-	cacheRepo domain.CacheRepository
-	tokenSvc  domain.TokenService
-	emailSvc  domain.EmailService
+	userRepo        domain.UserRepository
+	sellerRepo      domain.SellerRepository
+	storeRepo       domain.StoreRepository
+	cacheRepo       domain.CacheRepository
+	revocationStore domain.TokenRevocationStore
+	tokenSvc        domain.TokenService
+	emailSvc        domain.EmailService
 }
 
-func NewAuthService(userRepo domain.UserRepository, cacheRepo domain.CacheRepository,
-	tokenSvc domain.TokenService, emailSvc domain.EmailService) domain.AuthService {
+func NewAuthService(userRepo domain.UserRepository, sellerRepo domain.SellerRepository, storeRepo domain.StoreRepository, cacheRepo domain.CacheRepository,
+	revocationStore domain.TokenRevocationStore, tokenSvc domain.TokenService, emailSvc domain.EmailService) domain.AuthService {
 	return &authService{
-		userRepo:  userRepo,
-		cacheRepo: cacheRepo,
-		tokenSvc:  tokenSvc,
-		emailSvc:  emailSvc,
+		userRepo:        userRepo,
+		sellerRepo:      sellerRepo,
+		storeRepo:       storeRepo,
+		cacheRepo:       cacheRepo,
+		revocationStore: revocationStore,
+		tokenSvc:        tokenSvc,
+		emailSvc:        emailSvc,
 	}
 }
 
@@ -88,7 +97,26 @@ func (s *authService) AuthenticateUser(ctx context.Context, req *dto.UserAuthReq
 		return nil, errors.New("email or password incorrect")
 	}
 
-	acc_token, refreshToken, _ := s.tokenSvc.GenerateAllTokens(user.Email, user.First_Name, user.Last_Name, user.User_Id)
+	// only told after the password, so a suspension does not give away the account
+	if user.Suspended {
+		return nil, errors.New("your account is suspended")
+	}
+
+	tokenVersion, err := s.revocationStore.TokenVersion(domain.AccountUser, user.User_Id)
+	if err != nil {
+		return nil, errors.New("failed to get token version: " + err.Error())
+	}
+
+	acc_token, refreshToken, err := s.tokenSvc.GenerateAllTokens(user.Email, user.First_Name, user.Last_Name, user.User_Id, domain.AccountUser, user.Role, nil, tokenVersion)
+	if err != nil {
+		return nil, errors.New("failed to generate tokens: " + err.Error())
+	}
+
+	// the stored refresh token is the only one of its family that can be exchanged
+	_, err = s.userRepo.UpdateUser(ctx, user.Email, bson.D{{Key: "refresh_token", Value: refreshToken}})
+	if err != nil {
+		return nil, errors.New("failed to update refresh token: " + err.Error())
+	}
 
 	return &dto.UserAuthRes{
 		Access_Token:  acc_token,
@@ -131,3 +159,169 @@ func (s *authService) RequestEmail(ctx context.Context, req dto.UserReqEmail, ac
 
 	return nil
 }
+
+// tokenAccount is the user or the seller a refresh token was issued to.
+type tokenAccount struct {
+	firstName    string
+	lastName     string
+	role         string
+	staff        *domain.StaffScope
+	refreshToken string
+	replace      func(ctx context.Context, email, oldToken, newToken string) (*mongo.UpdateResult, error)
+}
+
+// RefreshToken implements domain.AuthService.
+// Every refresh token is exchanged once. An older token of the same family
+// coming back means it was stolen, so the family is revoked and both the
+// thief and the owner have to log in again.
+func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*dto.UserAuthRes, error) {
+	claims, err := s.tokenSvc.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	// all the sessions of the account were logged out after the token was issued
+	tokenVersion, err := s.revocationStore.TokenVersion(claims.Account, claims.Uid)
+	if err != nil {
+		return nil, errors.New("failed to get token version: " + err.Error())
+	}
+	if claims.Token_Version < tokenVersion {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	account, err := s.findTokenAccount(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.refreshToken != refreshToken {
+		if s.revokeFamily(ctx, claims) {
+			return nil, domain.ErrRefreshTokenReused
+		}
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	accToken, newRefreshToken, err := s.tokenSvc.RotateTokens(claims, account.firstName, account.lastName, account.role, account.staff)
+	if err != nil {
+		return nil, errors.New("failed to generate tokens: " + err.Error())
+	}
+
+	res, err := account.replace(ctx, claims.Email, refreshToken, newRefreshToken)
+	if err != nil {
+		return nil, errors.New("failed to update refresh token: " + err.Error())
+	}
+
+	// another request exchanged the same token first
+	if res.MatchedCount == 0 {
+		s.revokeFamily(ctx, claims)
+		return nil, domain.ErrRefreshTokenReused
+	}
+
+	return &dto.UserAuthRes{
+		Access_Token:  accToken,
+		Refresh_Token: newRefreshToken,
+	}, nil
+}
+
+func (s *authService) findTokenAccount(ctx context.Context, claims *domain.SignedDetails) (*tokenAccount, error) {
+	switch claims.Account {
+	case domain.AccountUser:
+		user, err := s.userRepo.FindUserByEmail(ctx, claims.Email)
+		if err != nil || user.Suspended {
+			return nil, domain.ErrInvalidRefreshToken
+		}
+
+		return &tokenAccount{
+			firstName:    user.First_Name,
+			lastName:     user.Last_Name,
+			role:         user.Role,
+			refreshToken: user.Refresh_Token,
+			replace:      s.userRepo.ReplaceRefreshToken,
+		}, nil
+	case domain.AccountSeller:
+		seller, err := s.sellerRepo.FindSellerByEmail(ctx, claims.Email)
+		if err != nil || seller.Suspended {
+			return nil, domain.ErrInvalidRefreshToken
+		}
+
+		staff, err := sellerAccess(ctx, s.storeRepo, seller)
+		if err != nil {
+			return nil, domain.ErrInvalidRefreshToken
+		}
+
+		return &tokenAccount{
+			firstName:    seller.First_Name,
+			lastName:     seller.Last_Name,
+			role:         seller.Role,
+			staff:        staff,
+			refreshToken: seller.Refresh_Token,
+			replace:      s.sellerRepo.ReplaceRefreshToken,
+		}, nil
+	}
+
+	return nil, domain.ErrInvalidRefreshToken
+}
+
+// revokeFamily clears the stored refresh token when it belongs to the family
+// of claims and reports whether it did. A token of an older family, e.g. from
+// before the last login, is just invalid.
+func (s *authService) revokeFamily(ctx context.Context, claims *domain.SignedDetails) bool {
+	account, err := s.findTokenAccount(ctx, claims)
+	if err != nil || account.refreshToken == "" {
+		return false
+	}
+
+	stored, err := s.tokenSvc.ValidateRefreshToken(account.refreshToken)
+	if err != nil || stored.Family != claims.Family {
+		return false
+	}
+
+	_, err = account.replace(ctx, claims.Email, account.refreshToken, "")
+	if err != nil {
+		log.Println("failed to revoke refresh token family: ", err)
+	}
+
+	return true
+}
+
+// Logout implements domain.AuthService.
+func (s *authService) Logout(ctx context.Context, claims *domain.SignedDetails) error {
+	err := s.revocationStore.Revoke(claims.Id, time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		return errors.New("failed to revoke access token: " + err.Error())
+	}
+
+	return s.clearRefreshToken(ctx, claims)
+}
+
+// LogoutAllSessions implements domain.AuthService.
+// Bumping the token version rejects the access tokens of every device
+// without knowing their ids.
+func (s *authService) LogoutAllSessions(ctx context.Context, claims *domain.SignedDetails) error {
+	_, err := s.revocationStore.BumpTokenVersion(claims.Account, claims.Uid)
+	if err != nil {
+		return errors.New("failed to bump token version: " + err.Error())
+	}
+
+	return s.clearRefreshToken(ctx, claims)
+}
+
+// clearRefreshToken removes the stored refresh token so the account can not
+// get a new access token without logging in again.
+func (s *authService) clearRefreshToken(ctx context.Context, claims *domain.SignedDetails) error {
+	account, err := s.findTokenAccount(ctx, claims)
+	if err != nil {
+		return errors.New("failed to find account of the token")
+	}
+
+	if account.refreshToken == "" {
+		return nil
+	}
+
+	_, err = account.replace(ctx, claims.Email, account.refreshToken, "")
+	if err != nil {
+		return errors.New("failed to clear refresh token: " + err.Error())
+	}
+
+	return nil
+}
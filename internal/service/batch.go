@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"github.com/asaskevich/govalidator"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type batchService struct {
+	repo        domain.BatchRepository
+	productRepo domain.ProductRepository
+	storeRepo   domain.StoreRepository
+	sellerRepo  domain.SellerRepository
+	ledgerSvc   domain.StockLedgerService
+	notifSvc    domain.NotificationService
+	transactor  domain.Transactor
+	policy      config.Inventory
+}
+
+func NewBatchService(repo domain.BatchRepository, productRepo domain.ProductRepository,
+	storeRepo domain.StoreRepository, sellerRepo domain.SellerRepository, ledgerSvc domain.StockLedgerService,
+	notifSvc domain.NotificationService, transactor domain.Transactor, policy config.Inventory) domain.BatchService {
+	return &batchService{
+		repo:        repo,
+		productRepo: productRepo,
+		storeRepo:   storeRepo,
+		sellerRepo:  sellerRepo,
+		ledgerSvc:   ledgerSvc,
+		notifSvc:    notifSvc,
+		transactor:  transactor,
+		policy:      policy,
+	}
+}
+
+// AddBatch implements domain.BatchService.
+func (s *batchService) AddBatch(ctx context.Context, email, storeID, productID string, req *dto.BatchReq) (*dto.BatchRes, error) {
+	_, err := govalidator.ValidateStruct(req)
+	if err != nil {
+		return nil, errors.New("Invalid request body: " + err.Error())
+	}
+
+	if err := s.checkStore(ctx, storeID, email); err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepo.GetProductById(ctx, productID, storeID)
+	if err != nil {
+		return nil, errors.New("failed to get product: " + err.Error())
+	}
+
+	if len(product.Variants) > 0 {
+		if _, err := domain.FindVariant(product.Variants, req.Variant_Id); err != nil {
+			return nil, err
+		}
+	} else if req.Variant_Id != "" {
+		return nil, errors.New("product has no variants")
+	}
+
+	if req.Quantity <= 0 {
+		return nil, errors.New("batch quantity must be more than 0")
+	}
+
+	unit := domain.UnitOf(product.Unit)
+	if unit.Countable() && req.Quantity != math.Trunc(req.Quantity) {
+		return nil, fmt.Errorf("a product sold by the %s is only received whole", unit)
+	}
+
+	now := time.Now()
+	receivedAt := req.Received_At
+	if receivedAt.IsZero() {
+		receivedAt = now
+	}
+
+	if !req.Expires_At.After(receivedAt) {
+		return nil, errors.New("batch must expire after it is received")
+	}
+
+	batch := domain.Batch{
+		ID:          primitive.NewObjectID(),
+		Batch_Id:    primitive.NewObjectID().Hex(),
+		Product_Id:  productID,
+		Variant_Id:  req.Variant_Id,
+		Store_Id:    storeID,
+		Quantity:    req.Quantity,
+		Received_At: receivedAt,
+		Expires_At:  req.Expires_At,
+		Status:      domain.BatchActive,
+		Created_At:  now,
+		Updated_At:  now,
+	}
+
+	err = s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		_, err := s.repo.Insert(ctx, batch)
+		if err != nil {
+			return errors.New("failed to insert batch: " + err.Error())
+		}
+
+		_, err = s.productRepo.UpdateStockProduct(ctx, storeID, productID, req.Variant_Id, req.Quantity, now)
+		if err != nil {
+			return errors.New("failed to update stock product: " + err.Error())
+		}
+
+		return s.ledgerSvc.Record(ctx, domain.StockMovement{
+			Store_Id:   storeID,
+			Product_Id: productID,
+			Variant_Id: req.Variant_Id,
+			Type:       domain.MovementRestock,
+			Quantity:   req.Quantity,
+			Actor:      email,
+			Reason:     "batch received",
+			Ref_Id:     batch.Batch_Id,
+			Created_At: now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.BatchRes{Batch_Id: batch.Batch_Id}, nil
+}
+
+// GetBatches implements domain.BatchService.
+func (s *batchService) GetBatches(ctx context.Context, email, storeID, productID string) (*[]domain.Batch, error) {
+	if err := s.checkStore(ctx, storeID, email); err != nil {
+		return nil, err
+	}
+
+	batches, err := s.repo.FindByProductId(ctx, storeID, productID)
+	if err != nil {
+		return nil, errors.New("failed to get batches: " + err.Error())
+	}
+
+	return batches, nil
+}
+
+// GetExpiringStock implements domain.BatchService.
+func (s *batchService) GetExpiringStock(ctx context.Context, email, storeID string, days int) (*[]dto.ExpiringStockRes, error) {
+	if days <= 0 {
+		return nil, errors.New("days must be more than 0")
+	}
+
+	if err := s.checkStore(ctx, storeID, email); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	batches, err := s.repo.FindActiveExpiringBefore(ctx, now.AddDate(0, 0, days), storeID)
+	if err != nil {
+		return nil, errors.New("failed to get batches: " + err.Error())
+	}
+
+	names := map[string]string{}
+	res := []dto.ExpiringStockRes{}
+	for _, batch := range *batches {
+		name, ok := names[batch.Product_Id]
+		if !ok {
+			product, err := s.productRepo.GetProductById(ctx, batch.Product_Id, storeID)
+			if err != nil {
+				return nil, errors.New("failed to get product: " + err.Error())
+			}
+			name = product.Name
+			names[batch.Product_Id] = name
+		}
+
+		res = append(res, dto.ExpiringStockRes{
+			Batch_Id:     batch.Batch_Id,
+			Product_Id:   batch.Product_Id,
+			Product_Name: name,
+			Variant_Id:   batch.Variant_Id,
+			Quantity:     batch.Quantity,
+			Expires_At:   batch.Expires_At,
+			Days_Left:    int(math.Ceil(batch.Expires_At.Sub(now).Hours() / 24)),
+		})
+	}
+
+	return &res, nil
+}
+
+// Allocate implements domain.BatchService.
+func (s *batchService) Allocate(ctx context.Context, productID, variantID string, quantity float64) ([]domain.BatchAllocation, error) {
+	batches, err := s.repo.FindAvailable(ctx, productID, variantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batches: %w", err)
+	}
+
+	var allocations []domain.BatchAllocation
+	for _, batch := range *batches {
+		if quantity <= 0 {
+			break
+		}
+
+		take := math.Min(quantity, batch.Quantity)
+		res, err := s.repo.Take(ctx, batch.Batch_Id, take, time.Now())
+		if err != nil {
+			if _, err := s.Restore(ctx, allocations); err != nil {
+				log.Println("failed to give back batches: ", err)
+			}
+			return nil, fmt.Errorf("failed to take stock from batch: %w", err)
+		}
+
+		// the batch was taken or withdrawn since we read it, the rest comes
+		// from the next batches
+		if res.ModifiedCount == 0 {
+			continue
+		}
+
+		allocations = append(allocations, domain.BatchAllocation{Batch_Id: batch.Batch_Id, Quantity: take})
+		quantity -= take
+	}
+
+	return allocations, nil
+}
+
+// Restore implements domain.BatchService.
+func (s *batchService) Restore(ctx context.Context, allocations []domain.BatchAllocation) (float64, error) {
+	var withdrawn float64
+	for _, allocation := range allocations {
+		res, err := s.repo.Restore(ctx, allocation.Batch_Id, allocation.Quantity, time.Now())
+		if err != nil {
+			return withdrawn, fmt.Errorf("failed to give back batch: %w", err)
+		}
+
+		if res.ModifiedCount == 0 {
+			withdrawn += allocation.Quantity
+		}
+	}
+
+	return withdrawn, nil
+}
+
+// ApplyExpiryPolicy implements domain.BatchService.
+// A product keeps its discount while any of its batches is near expiry, and
+// loses it once those batches are sold or withdrawn.
+func (s *batchService) ApplyExpiryPolicy(ctx context.Context, now time.Time) (int, error) {
+	withdrawn := 0
+	if s.policy.WithdrawBefore > 0 {
+		batches, err := s.repo.FindActiveExpiringBefore(ctx, now.Add(s.policy.WithdrawBefore))
+		if err != nil {
+			return 0, errors.New("failed to get expiring batches: " + err.Error())
+		}
+
+		for _, batch := range *batches {
+			ok, err := s.withdraw(ctx, batch.Batch_Id, now)
+			if err != nil {
+				return withdrawn, err
+			}
+			if ok {
+				withdrawn++
+			}
+		}
+	}
+
+	var discounted []string
+	if s.policy.DiscountBefore > 0 && s.policy.DiscountPercent > 0 {
+		batches, err := s.repo.FindActiveExpiringBefore(ctx, now.Add(s.policy.DiscountBefore))
+		if err != nil {
+			return withdrawn, errors.New("failed to get expiring batches: " + err.Error())
+		}
+
+		seen := map[string]bool{}
+		for _, batch := range *batches {
+			if !seen[batch.Product_Id] {
+				seen[batch.Product_Id] = true
+				discounted = append(discounted, batch.Product_Id)
+			}
+		}
+	}
+
+	if err := s.productRepo.SetDiscounts(ctx, discounted, s.policy.DiscountPercent); err != nil {
+		return withdrawn, errors.New("failed to discount products: " + err.Error())
+	}
+
+	if s.policy.AlertBefore > 0 {
+		batches, err := s.repo.FindActiveExpiringBefore(ctx, now.Add(s.policy.AlertBefore))
+		if err != nil {
+			return withdrawn, errors.New("failed to get expiring batches: " + err.Error())
+		}
+
+		for _, batch := range *batches {
+			if !batch.Alerted {
+				s.alert(ctx, batch)
+			}
+		}
+	}
+
+	return withdrawn, nil
+}
+
+// withdraw takes a batch off sale together with its stock, it reports false
+// when the batch was not active anymore.
+func (s *batchService) withdraw(ctx context.Context, batchID string, now time.Time) (bool, error) {
+	var batch *domain.Batch
+	err := s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		batch, err = s.repo.Withdraw(ctx, batchID, now)
+		if err != nil {
+			return errors.New("failed to withdraw batch: " + err.Error())
+		}
+
+		if batch == nil {
+			return nil
+		}
+
+		_, err = s.productRepo.UpdateStockProduct(ctx, batch.Store_Id, batch.Product_Id, batch.Variant_Id, -batch.Quantity, now)
+		if err != nil {
+			return errors.New("failed to update stock product: " + err.Error())
+		}
+
+		return s.ledgerSvc.Record(ctx, domain.StockMovement{
+			Store_Id:   batch.Store_Id,
+			Product_Id: batch.Product_Id,
+			Variant_Id: batch.Variant_Id,
+			Type:       domain.MovementExpiry,
+			Quantity:   -batch.Quantity,
+			Actor:      string(domain.ActorSystem),
+			Reason:     "batch expires on " + batch.Expires_At.Format("2006-01-02"),
+			Ref_Id:     batch.Batch_Id,
+			Created_At: now,
+		})
+	})
+	if err != nil || batch == nil {
+		return false, err
+	}
+
+	product, err := s.productRepo.GetProductById(ctx, batch.Product_Id, batch.Store_Id)
+	if err != nil {
+		log.Println("failed to get product: ", err)
+		return true, nil
+	}
+
+	if product.Stock <= 2 {
+		s.notifySeller(ctx, batch.Store_Id, "SELLER_LESS_STOCK", map[string]string{
+			"product_id": batch.Product_Id,
+		})
+	}
+
+	return true, nil
+}
+
+func (s *batchService) alert(ctx context.Context, batch domain.Batch) {
+	s.notifySeller(ctx, batch.Store_Id, "SELLER_EXPIRING_STOCK", map[string]string{
+		"batch_id":   batch.Batch_Id,
+		"product_id": batch.Product_Id,
+		"quantity":   fmt.Sprintf("%g", batch.Quantity),
+		"expires_at": batch.Expires_At.Format("2006-01-02"),
+	})
+
+	if _, err := s.repo.MarkAlerted(ctx, batch.Batch_Id); err != nil {
+		log.Println("failed to mark batch alerted: ", err)
+	}
+}
+
+func (s *batchService) notifySeller(ctx context.Context, storeID, code string, data map[string]string) {
+	seller, err := s.sellerRepo.FindSellerByStoreId(ctx, storeID)
+	if err != nil {
+		log.Println("failed to find seller of store "+storeID+": ", err)
+		return
+	}
+
+	if err := s.notifSvc.Insert(ctx, seller.Email, code, data); err != nil {
+		log.Println("failed to insert seller notification: ", err)
+	}
+}
+
+func (s *batchService) checkStore(ctx context.Context, storeID, email string) error {
+	store, err := s.storeRepo.GetStore(ctx, storeID, email)
+	if err != nil {
+		return errors.New("failed to find store: " + err.Error())
+	}
+
+	if store == nil {
+		return errors.New("store not found")
+	}
+
+	return nil
+}
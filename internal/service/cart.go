@@ -4,11 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"log"
 	"time"
 
 	"github.com/IndraSty/GreenBasket/domain"
 	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -29,82 +29,60 @@ func NewCartService(repo domain.CartRepository, productRepo domain.ProductReposi
 	}
 }
 
-func (s *cartService) setRedisCart(item []dto.GetCartItemRes, email string) error {
-	itemData, err := json.Marshal(item)
-	if err != nil {
-		return errors.New("failed to marshal user cart item: " + err.Error())
+// cartItemOf is the cart item of the product, or of its variant, at the price
+// it sells for now, and the stock it is sold from.
+func cartItemOf(product *domain.ProductWithSalesData, variantID string) (domain.CartItem, float64, error) {
+	item := domain.CartItem{
+		Product_Id:    product.Product_id,
+		Product_Name:  product.Name,
+		Product_Image: product.Images,
+		StoreID:       product.Store_id,
+		Unit:          string(domain.UnitOf(product.Unit)),
+		AddedAt:       time.Now(),
+		Selected:      true,
+		Price:         product.Price,
 	}
+	stock := product.Stock
 
-	err = s.cacheRepo.Set("usercart-item:"+email, itemData, time.Hour*1)
-	if err != nil {
-		return errors.New("failed to set user cart item in cache: " + err.Error())
-	}
+	if len(product.Variants) > 0 {
+		if variantID == "" {
+			return item, 0, errors.New("choose a variant of this product")
+		}
 
-	return nil
-}
+		variant, err := domain.FindVariant(product.Variants, variantID)
+		if err != nil {
+			return item, 0, err
+		}
 
-func (s *cartService) delRedisCartItem(email string) error {
-	err := s.cacheRepo.Del("usercart-item:" + email)
-	if err != nil {
-		return errors.New("failed to delete cart item in cache: " + err.Error())
+		item.Variant_Id = variant.Variant_Id
+		item.Variant_Name = variant.Name
+		item.Price = variant.Price
+		stock = variant.Stock
+		if len(variant.Images) != 0 {
+			item.Product_Image = variant.Images
+		}
 	}
 
-	return nil
-}
-
-func (s *cartService) updateRedisCart(ctx context.Context, email string) error {
-	cartItems, err := s.getAllCartItemWithNoAct(ctx, email)
-	if err != nil {
-		return errors.New("failed to get all item in user cart: " + err.Error())
-	}
+	// a product with a batch near expiry is sold at a discount
+	item.Price = product.SalePrice(item.Price)
 
-	return s.setRedisCart(*cartItems, email)
+	return item, stock, nil
 }
 
-func (s *cartService) getAllCartItemWithNoAct(ctx context.Context, email string) (*[]dto.GetCartItemRes, error) {
-	cart, err := s.repo.CheckUserCart(ctx, email)
-	if err != nil {
-		return nil, errors.New("failed check user cart: " + err.Error())
-	}
-
-	if !cart {
-		return nil, errors.New("user doesn't have a cart")
-	}
-
-	items, err := s.repo.GetAllCartItem(ctx, email)
-	if err != nil {
-		return nil, errors.New("failed to get all item in user cart: " + err.Error())
-	}
-
-	itemRes := make([]dto.GetCartItemRes, len(*items))
-	for i, item := range *items {
-		store, err := s.storeRepo.GetStore(ctx, item.StoreID)
-		if err != nil {
-			return nil, errors.New("failed to get store: " + err.Error())
-		}
-
-		itemRes[i] = dto.GetCartItemRes{
-			Product_Id:    item.Product_Id,
-			Product_Name:  item.Product_Name,
-			Product_Image: item.Product_Image,
-			Store_Name:    store.Name,
-			Quantity:      item.Quantity,
-			AddedAt:       item.AddedAt,
-			Selected:      item.Selected,
-			Price:         item.Price,
+// findCartItem is the index of the item of the product and variant, -1 when
+// it is not in items.
+func findCartItem(items []domain.CartItem, productID, variantID string) int {
+	for i, item := range items {
+		if item.Product_Id == productID && item.Variant_Id == variantID {
+			return i
 		}
 	}
 
-	return &itemRes, nil
+	return -1
 }
 
 // AddToCart implements domain.CartService.
 func (s *cartService) AddToCart(ctx context.Context, email, productID string, req *dto.AddCartReq) error {
-	err := s.delRedisCartItem(email)
-	if err != nil {
-		return err
-	}
-
 	cart, err := s.repo.CheckUserCart(ctx, email)
 	if err != nil {
 		return errors.New("failed check user cart: " + err.Error())
@@ -119,22 +97,25 @@ func (s *cartService) AddToCart(ctx context.Context, email, productID string, re
 		return errors.New("failed to get product: " + err.Error())
 	}
 
-	if req.Quantity > product.Stock {
-		return errors.New("product stock is less than quantity")
+	if product.Hidden() {
+		return domain.ErrProductNotFound
 	}
 
-	item := domain.CartItem{
-		Product_Id:    productID,
-		Product_Name:  product.Name,
-		Product_Image: product.Images,
-		StoreID:       product.Store_id,
-		Quantity:      req.Quantity,
-		AddedAt:       time.Now(),
-		Selected:      true,
-		Price:         product.Price,
+	item, stock, err := cartItemOf(product, req.Variant_Id)
+	if err != nil {
+		return err
+	}
+	item.Quantity = req.Quantity
+
+	if err := product.QuantityRule().Check(req.Quantity); err != nil {
+		return err
+	}
+
+	if req.Quantity > stock {
+		return errors.New("product stock is less than quantity")
 	}
 
-	totalPrice := float64(req.Quantity) * product.Price
+	totalPrice := req.Quantity * item.Price
 
 	result, err := s.repo.AddToCart(ctx, email, &item)
 	if err != nil {
@@ -149,23 +130,12 @@ func (s *cartService) AddToCart(ctx context.Context, email, productID string, re
 		return errors.New("failed update total price in the cart")
 	}
 
-	defer func() {
-		if err := s.updateRedisCart(ctx, email); err != nil {
-			log.Println("failed to update user cart in cache: ", err)
-		}
-	}()
-
 	return nil
 
 }
 
 // RemoveCartItemById implements domain.CartService.
-func (s *cartService) RemoveCartItemById(ctx context.Context, email string, productID string) error {
-	err := s.delRedisCartItem(email)
-	if err != nil {
-		return err
-	}
-
+func (s *cartService) RemoveCartItemById(ctx context.Context, email, productID, variantID string) error {
 	cartExist, err := s.repo.CheckUserCart(ctx, email)
 	if err != nil {
 		return errors.New("failed to check user cart: " + err.Error())
@@ -182,7 +152,7 @@ func (s *cartService) RemoveCartItemById(ctx context.Context, email string, prod
 
 	updateAT := time.Now()
 
-	result, err := s.repo.RemoveCartItemById(ctx, email, productID, updateAT)
+	result, err := s.repo.RemoveCartItemById(ctx, email, productID, variantID, updateAT)
 	if err != nil {
 		return errors.New("failed to remove item from user cart: " + err.Error())
 	}
@@ -191,12 +161,6 @@ func (s *cartService) RemoveCartItemById(ctx context.Context, email string, prod
 		return errors.New("no item was removed")
 	}
 
-	defer func() {
-		if err := s.updateRedisCart(ctx, email); err != nil {
-			log.Println("failed to update user cart in cache: ", err)
-		}
-	}()
-
 	return nil
 }
 
@@ -227,17 +191,7 @@ func (s *cartService) CreateCart(ctx context.Context, email string) error {
 }
 
 // GetAllCartItem implements domain.CartService.
-func (s *cartService) GetAllCartItem(ctx context.Context, email string) (*[]dto.GetCartItemRes, error) {
-	val, err := s.cacheRepo.Get("usercart-item:" + email)
-	if err == nil {
-		var cartitem []dto.GetCartItemRes
-		err = json.Unmarshal(val, &cartitem)
-		if err != nil {
-			return nil, errors.New("failed to unmarshal user cart item: " + err.Error())
-		}
-		return &cartitem, nil
-	}
-
+func (s *cartService) GetAllCartItem(ctx context.Context, email string, page pagination.Request) (*pagination.Page[dto.GetCartItemRes], error) {
 	cart, err := s.repo.CheckUserCart(ctx, email)
 	if err != nil {
 		return nil, errors.New("failed check user cart: " + err.Error())
@@ -247,13 +201,13 @@ func (s *cartService) GetAllCartItem(ctx context.Context, email string) (*[]dto.
 		return nil, errors.New("user doesn't have a cart")
 	}
 
-	items, err := s.repo.GetAllCartItem(ctx, email)
+	items, err := s.repo.FindItems(ctx, email, page)
 	if err != nil {
 		return nil, errors.New("failed to get all item in user cart: " + err.Error())
 	}
 
-	itemRes := make([]dto.GetCartItemRes, len(*items))
-	for i, item := range *items {
+	itemRes := make([]dto.GetCartItemRes, len(items.Items))
+	for i, item := range items.Items {
 		store, err := s.storeRepo.GetStore(ctx, item.StoreID)
 		if err != nil {
 			return nil, errors.New("failed to get store: " + err.Error())
@@ -261,22 +215,20 @@ func (s *cartService) GetAllCartItem(ctx context.Context, email string) (*[]dto.
 
 		itemRes[i] = dto.GetCartItemRes{
 			Product_Id:    item.Product_Id,
+			Variant_Id:    item.Variant_Id,
+			Variant_Name:  item.Variant_Name,
 			Product_Name:  item.Product_Name,
 			Product_Image: item.Product_Image,
 			Store_Name:    store.Name,
 			Quantity:      item.Quantity,
+			Unit:          item.Unit,
 			AddedAt:       item.AddedAt,
 			Selected:      item.Selected,
 			Price:         item.Price,
 		}
 	}
 
-	err = s.setRedisCart(itemRes, email)
-	if err != nil {
-		return nil, err
-	}
-
-	return &itemRes, nil
+	return &pagination.Page[dto.GetCartItemRes]{Items: itemRes, Next_Cursor: items.Next_Cursor}, nil
 }
 
 // GetUserCart implements domain.CartService.
@@ -319,12 +271,7 @@ func (s *cartService) GetUserCart(ctx context.Context, email string) (*domain.Ca
 }
 
 // UpdateCartItemById implements domain.CartService.
-func (s *cartService) UpdateCartItemById(ctx context.Context, email string, productID string, input *dto.CartItemEditReq) error {
-	err := s.delRedisCartItem(email)
-	if err != nil {
-		return err
-	}
-
+func (s *cartService) UpdateCartItemById(ctx context.Context, email, productID, variantID string, input *dto.CartItemEditReq) error {
 	cartExist, err := s.repo.CheckUserCart(ctx, email)
 	if err != nil {
 		return errors.New("failed to check user cart: " + err.Error())
@@ -344,10 +291,20 @@ func (s *cartService) UpdateCartItemById(ctx context.Context, email string, prod
 		return errors.New("failed to get product: " + err.Error())
 	}
 
+	price := product.Price
+	if variantID != "" {
+		variant, err := domain.FindVariant(product.Variants, variantID)
+		if err != nil {
+			return err
+		}
+		price = variant.Price
+	}
+	price = product.SalePrice(price)
+
 	updateAT := time.Now()
-	oldQuantity := 0
+	oldQuantity := float64(0)
 	for _, item := range cart.Items {
-		if item.Product_Id == productID {
+		if item.Product_Id == productID && item.Variant_Id == variantID {
 			oldQuantity = item.Quantity
 			break
 		}
@@ -358,8 +315,14 @@ func (s *cartService) UpdateCartItemById(ctx context.Context, email string, prod
 		return errors.New("quantity cannot be less than 0")
 	}
 
+	if input.Quantity != 0 {
+		if err := product.QuantityRule().Check(input.Quantity); err != nil {
+			return err
+		}
+	}
+
 	// Calculate the total price difference
-	totalPriceDifference := float64(input.Quantity-oldQuantity) * product.Price
+	totalPriceDifference := (input.Quantity - oldQuantity) * price
 
 	// If the total price difference is negative, set it to 0
 	if totalPriceDifference < 0 {
@@ -371,7 +334,7 @@ func (s *cartService) UpdateCartItemById(ctx context.Context, email string, prod
 		Selected:    input.Selected,
 		Total_Price: totalPriceDifference,
 	}
-	result, err := s.repo.UpdateCartItemById(ctx, email, productID, &update, updateAT)
+	result, err := s.repo.UpdateCartItemById(ctx, email, productID, variantID, &update, updateAT)
 	if err != nil {
 		return errors.New("failed to update product in the cart: " + err.Error())
 	}
@@ -380,12 +343,6 @@ func (s *cartService) UpdateCartItemById(ctx context.Context, email string, prod
 		return errors.New("no item was updated")
 	}
 
-	defer func() {
-		if err := s.updateRedisCart(ctx, email); err != nil {
-			log.Println("failed to update user cart in cache: ", err)
-		}
-	}()
-
 	return nil
 
 }
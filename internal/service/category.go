@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/asaskevich/govalidator"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type categoryService struct {
+	repo        domain.CategoryRepository
+	productRepo domain.ProductRepository
+	auditRepo   domain.AuditLogRepository
+}
+
+func NewCategoryService(repo domain.CategoryRepository, productRepo domain.ProductRepository,
+	auditRepo domain.AuditLogRepository) domain.CategoryService {
+	return &categoryService{
+		repo:        repo,
+		productRepo: productRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+// GetTree implements domain.CategoryService.
+func (s *categoryService) GetTree(ctx context.Context, locale string) ([]dto.CategoryRes, error) {
+	categories, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, errors.New("failed to get categories: " + err.Error())
+	}
+
+	children := childrenOf(*categories)
+
+	var build func(parent string) []dto.CategoryRes
+	build = func(parent string) []dto.CategoryRes {
+		res := []dto.CategoryRes{}
+		for _, category := range children[parent] {
+			name, ok := category.Names[locale]
+			if !ok {
+				name = category.Names[domain.DefaultLocale]
+			}
+
+			res = append(res, dto.CategoryRes{
+				Slug:       category.Slug,
+				Name:       name,
+				Icon:       category.Icon,
+				Sort_Order: category.Sort_Order,
+				Children:   build(category.Slug),
+			})
+		}
+		return res
+	}
+
+	return build(""), nil
+}
+
+// CreateCategory implements domain.CategoryService.
+func (s *categoryService) CreateCategory(ctx context.Context, adminEmail string, req *dto.CategoryReq) (*domain.Category, error) {
+	_, err := govalidator.ValidateStruct(req)
+	if err != nil {
+		return nil, errors.New("Invalid request body: " + err.Error())
+	}
+
+	if req.Names[domain.DefaultLocale] == "" {
+		return nil, errors.New("category needs a name in locale " + domain.DefaultLocale)
+	}
+
+	slug := req.Slug
+	if slug == "" {
+		slug = req.Names[domain.DefaultLocale]
+	}
+	slug = util.Slugify(slug)
+	if slug == "" {
+		return nil, errors.New("invalid category slug")
+	}
+
+	if existing, _ := s.repo.FindBySlug(ctx, slug); existing != nil {
+		return nil, errors.New("category " + slug + " already exists")
+	}
+
+	if req.Parent != "" {
+		_, err := s.repo.FindBySlug(ctx, req.Parent)
+		if err != nil {
+			return nil, errors.New("failed to find parent category: " + err.Error())
+		}
+	}
+
+	category := domain.Category{
+		ID:         primitive.NewObjectID(),
+		Slug:       slug,
+		Parent:     req.Parent,
+		Names:      req.Names,
+		Icon:       req.Icon,
+		Sort_Order: req.Sort_Order,
+		Created_At: time.Now(),
+		Updated_At: time.Now(),
+	}
+
+	_, err = s.repo.Insert(ctx, category)
+	if err != nil {
+		return nil, errors.New("failed to create category: " + err.Error())
+	}
+
+	err = s.audit(ctx, adminEmail, domain.AuditCreateCategory, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+// UpdateCategory implements domain.CategoryService.
+func (s *categoryService) UpdateCategory(ctx context.Context, adminEmail, slug string, req *dto.CategoryReq) error {
+	_, err := govalidator.ValidateStruct(req)
+	if err != nil {
+		return errors.New("Invalid request body: " + err.Error())
+	}
+
+	if req.Names[domain.DefaultLocale] == "" {
+		return errors.New("category needs a name in locale " + domain.DefaultLocale)
+	}
+
+	if req.Slug != "" && req.Slug != slug {
+		return errors.New("the slug of a category can not be changed")
+	}
+
+	categories, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return errors.New("failed to get categories: " + err.Error())
+	}
+
+	if !hasCategory(*categories, slug) {
+		return domain.ErrCategoryNotFound
+	}
+
+	if req.Parent != "" {
+		if !hasCategory(*categories, req.Parent) {
+			return errors.New("failed to find parent category: " + req.Parent)
+		}
+
+		// a category can not become its own ancestor
+		for _, descendant := range descendantsOf(*categories, slug) {
+			if descendant == req.Parent {
+				return errors.New("category " + slug + " can not be moved under " + req.Parent)
+			}
+		}
+	}
+
+	update := bson.D{
+		{Key: "parent", Value: req.Parent},
+		{Key: "names", Value: req.Names},
+		{Key: "icon", Value: req.Icon},
+		{Key: "sort_order", Value: req.Sort_Order},
+		{Key: "updated_at", Value: time.Now()},
+	}
+
+	_, err = s.repo.Update(ctx, slug, update)
+	if err != nil {
+		return errors.New("failed to update category: " + err.Error())
+	}
+
+	return s.audit(ctx, adminEmail, domain.AuditUpdateCategory, slug)
+}
+
+// DeleteCategory implements domain.CategoryService.
+func (s *categoryService) DeleteCategory(ctx context.Context, adminEmail, slug string) error {
+	categories, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return errors.New("failed to get categories: " + err.Error())
+	}
+
+	if !hasCategory(*categories, slug) {
+		return domain.ErrCategoryNotFound
+	}
+
+	if len(childrenOf(*categories)[slug]) > 0 {
+		return errors.New("category " + slug + " still has subcategories")
+	}
+
+	count, err := s.productRepo.CountByCategory(ctx, slug)
+	if err != nil {
+		return errors.New("failed to count products: " + err.Error())
+	}
+
+	if count > 0 {
+		return errors.New("category " + slug + " still has " + strconv.FormatInt(count, 10) + " products")
+	}
+
+	_, err = s.repo.Delete(ctx, slug)
+	if err != nil {
+		return errors.New("failed to delete category: " + err.Error())
+	}
+
+	return s.audit(ctx, adminEmail, domain.AuditDeleteCategory, slug)
+}
+
+// Resolve implements domain.CategoryService.
+func (s *categoryService) Resolve(ctx context.Context, category string, descendants bool) ([]string, error) {
+	slug := util.Slugify(category)
+
+	categories, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, errors.New("failed to get categories: " + err.Error())
+	}
+
+	if !hasCategory(*categories, slug) {
+		return nil, domain.ErrCategoryNotFound
+	}
+
+	slugs := []string{slug}
+	if descendants {
+		slugs = append(slugs, descendantsOf(*categories, slug)...)
+	}
+
+	return slugs, nil
+}
+
+func (s *categoryService) audit(ctx context.Context, adminEmail, action, slug string) error {
+	_, err := s.auditRepo.Insert(ctx, domain.AuditLog{
+		ID:          primitive.NewObjectID(),
+		Admin_Email: adminEmail,
+		Action:      action,
+		Target_Type: domain.AuditTargetCategory,
+		Target_Id:   slug,
+		Created_At:  time.Now(),
+	})
+	if err != nil {
+		return errors.New("failed to write audit log: " + err.Error())
+	}
+
+	return nil
+}
+
+// childrenOf groups categories by the slug of their parent, keeping their order.
+// A category whose parent is gone is listed as a root.
+func childrenOf(categories []domain.Category) map[string][]domain.Category {
+	children := map[string][]domain.Category{}
+	for _, category := range categories {
+		parent := category.Parent
+		if !hasCategory(categories, parent) {
+			parent = ""
+		}
+		children[parent] = append(children[parent], category)
+	}
+
+	return children
+}
+
+// descendantsOf returns the slugs below slug, breadth first.
+func descendantsOf(categories []domain.Category, slug string) []string {
+	children := childrenOf(categories)
+
+	var slugs []string
+	queue := []string{slug}
+	for len(queue) > 0 {
+		for _, child := range children[queue[0]] {
+			slugs = append(slugs, child.Slug)
+			queue = append(queue, child.Slug)
+		}
+		queue = queue[1:]
+	}
+
+	return slugs
+}
+
+func hasCategory(categories []domain.Category, slug string) bool {
+	for _, category := range categories {
+		if category.Slug == slug {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeServerKey signs the fake webhooks when no midtrans key is configured.
+const fakeServerKey = "fake-server-key"
+
+// fakeStatusCodes are the status codes midtrans sends along with each transaction status.
+var fakeStatusCodes = map[string]string{
+	"pending":    "201",
+	"capture":    "200",
+	"settlement": "200",
+	"deny":       "202",
+	"cancel":     "202",
+	"expire":     "407",
+}
+
+type fakeGateway struct {
+	mu        sync.Mutex
+	charges   map[string]*domain.FakeCharge
+	refunds   map[string]bool
+	serverKey string
+	baseURL   string
+}
+
+func NewFakeGateway(cnf *config.Config) domain.FakePaymentGateway {
+	serverKey := cnf.Midtrans.Key
+	if serverKey == "" {
+		serverKey = fakeServerKey
+	}
+
+	return &fakeGateway{
+		charges:   map[string]*domain.FakeCharge{},
+		refunds:   map[string]bool{},
+		serverKey: serverKey,
+		baseURL:   "http://" + cnf.Server.Host + ":" + cnf.Server.Port,
+	}
+}
+
+// CreateCharge implements domain.PaymentGateway.
+func (g *fakeGateway) CreateCharge(ctx context.Context, p *domain.Payment) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.charges[p.OrderID] = &domain.FakeCharge{
+		OrderID:           p.OrderID,
+		TransactionID:     primitive.NewObjectID().Hex(),
+		Amount:            p.Amount,
+		TransactionStatus: "pending",
+		Created_At:        time.Now(),
+	}
+
+	p.Snap_Url = g.baseURL + "/api/fake-gateway/charges/" + p.OrderID
+	return nil
+}
+
+// CheckStatus implements domain.PaymentGateway.
+func (g *fakeGateway) CheckStatus(ctx context.Context, orderID string) (*domain.PaymentNotification, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	charge, ok := g.charges[orderID]
+	if !ok {
+		return nil, domain.ErrChargeNotFound
+	}
+
+	return toPaymentNotification(g.notification(charge)), nil
+}
+
+// Refund implements domain.PaymentGateway.
+func (g *fakeGateway) Refund(ctx context.Context, orderID, refundKey string, amount float64, reason string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	charge, ok := g.charges[orderID]
+	if !ok {
+		return errors.New("charge not found")
+	}
+
+	// midtrans ignores a refund key it has seen already
+	if g.refunds[refundKey] {
+		return nil
+	}
+
+	if charge.TransactionStatus != "settlement" && charge.TransactionStatus != "partial_refund" {
+		return errors.New("charge is not settled")
+	}
+
+	if charge.Refunded+amount > charge.Amount {
+		return errors.New("refund amount is more than the charge")
+	}
+
+	g.refunds[refundKey] = true
+	charge.Refunded += amount
+	charge.TransactionStatus = "partial_refund"
+	if charge.Refunded == charge.Amount {
+		charge.TransactionStatus = "refund"
+	}
+
+	return nil
+}
+
+// Expire implements domain.PaymentGateway.
+func (g *fakeGateway) Expire(ctx context.Context, orderID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	charge, ok := g.charges[orderID]
+	if !ok {
+		return domain.ErrChargeNotFound
+	}
+
+	if charge.TransactionStatus != "pending" {
+		return errors.New("charge is not pending")
+	}
+
+	charge.TransactionStatus = "expire"
+	return nil
+}
+
+// ParseWebhook implements domain.PaymentGateway.
+func (g *fakeGateway) ParseWebhook(payload []byte) (*domain.PaymentNotification, error) {
+	return parseMidtransNotification(payload, g.serverKey)
+}
+
+// GetCharge implements domain.FakePaymentGateway.
+func (g *fakeGateway) GetCharge(ctx context.Context, orderID string) (*domain.FakeCharge, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	charge, ok := g.charges[orderID]
+	if !ok {
+		return nil, errors.New("charge not found")
+	}
+
+	result := *charge
+	return &result, nil
+}
+
+// Simulate implements domain.FakePaymentGateway.
+func (g *fakeGateway) Simulate(ctx context.Context, orderID, transactionStatus string) ([]byte, error) {
+	if _, ok := fakeStatusCodes[transactionStatus]; !ok {
+		return nil, errors.New("unsupported transaction status: " + transactionStatus)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	charge, ok := g.charges[orderID]
+	if !ok {
+		return nil, errors.New("charge not found")
+	}
+
+	charge.TransactionStatus = transactionStatus
+
+	payload, err := json.Marshal(g.notification(charge))
+	if err != nil {
+		return nil, errors.New("failed to marshal notification: " + err.Error())
+	}
+
+	return payload, nil
+}
+
+// notification builds the webhook midtrans would send for the charge in its current status.
+func (g *fakeGateway) notification(charge *domain.FakeCharge) *dto.MidtransNotification {
+	statusCode, ok := fakeStatusCodes[charge.TransactionStatus]
+	if !ok {
+		statusCode = "200"
+	}
+	grossAmount := fmt.Sprintf("%.2f", charge.Amount)
+
+	return &dto.MidtransNotification{
+		TransactionTime:   time.Now().Format("2006-01-02 15:04:05"),
+		TransactionStatus: charge.TransactionStatus,
+		TransactionID:     charge.TransactionID,
+		StatusMessage:     "fake payment notification",
+		StatusCode:        statusCode,
+		SignatureKey:      midtransSignature(charge.OrderID, statusCode, grossAmount, g.serverKey),
+		PaymentType:       "fake",
+		OrderID:           charge.OrderID,
+		MerchantID:        "FAKE",
+		GrossAmount:       grossAmount,
+		FraudStatus:       "accept",
+		Currency:          "IDR",
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+)
+
+// fakeDeliveryDays is the estimated delivery the fake carrier gives to a new parcel.
+const fakeDeliveryDays = 3
+
+type fakeTracker struct {
+	mu      sync.Mutex
+	parcels map[string]*domain.TrackingInfo
+}
+
+func NewFakeTracker() domain.FakeCarrierTracker {
+	return &fakeTracker{
+		parcels: map[string]*domain.TrackingInfo{},
+	}
+}
+
+// Track implements domain.CarrierTracker.
+func (t *fakeTracker) Track(ctx context.Context, carrier, trackingNumber string) (*domain.TrackingInfo, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	parcel, ok := t.parcels[carrier+":"+trackingNumber]
+	if !ok {
+		return nil, domain.ErrTrackingNotFound
+	}
+
+	info := *parcel
+	info.Events = append([]domain.TrackingEvent(nil), parcel.Events...)
+	return &info, nil
+}
+
+// Push implements domain.FakeCarrierTracker.
+func (t *fakeTracker) Push(ctx context.Context, carrier, trackingNumber string, event domain.TrackingEvent) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if event.Occurred_At.IsZero() {
+		event.Occurred_At = time.Now()
+	}
+
+	key := carrier + ":" + trackingNumber
+	parcel, ok := t.parcels[key]
+	if !ok {
+		parcel = &domain.TrackingInfo{
+			Estimated_Delivery: event.Occurred_At.AddDate(0, 0, fakeDeliveryDays),
+		}
+		t.parcels[key] = parcel
+	}
+
+	parcel.Events = append(parcel.Events, event)
+	if event.Status == domain.TrackingDelivered {
+		deliveredAt := event.Occurred_At
+		parcel.Delivered_At = &deliveredAt
+	}
+
+	return nil
+}
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type guestCartService struct {
+	repo        domain.GuestCartRepository
+	cartRepo    domain.CartRepository
+	productRepo domain.ProductRepository
+	storeRepo   domain.StoreRepository
+	secret      []byte
+	ttl         time.Duration
+}
+
+// NewGuestCartService signs the cart tokens with secret, a guest cart is kept
+// for ttl after its last change.
+func NewGuestCartService(repo domain.GuestCartRepository, cartRepo domain.CartRepository, productRepo domain.ProductRepository,
+	storeRepo domain.StoreRepository, secret string, ttl time.Duration) domain.GuestCartService {
+	return &guestCartService{
+		repo:        repo,
+		cartRepo:    cartRepo,
+		productRepo: productRepo,
+		storeRepo:   storeRepo,
+		secret:      []byte(secret),
+		ttl:         ttl,
+	}
+}
+
+// sign is the token of a cart, its id followed by an HMAC of the id, so a
+// visitor can not make up the token of another cart.
+func (s *guestCartService) sign(cartID string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(cartID))
+	return cartID + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *guestCartService) findCart(ctx context.Context, token string) (*domain.GuestCart, error) {
+	cartID, _, ok := strings.Cut(token, ".")
+	if !ok || cartID == "" || !hmac.Equal([]byte(s.sign(cartID)), []byte(token)) {
+		return nil, domain.ErrInvalidCartToken
+	}
+
+	cart, err := s.repo.FindById(ctx, cartID)
+	if err != nil && !errors.Is(err, domain.ErrGuestCartNotFound) {
+		return nil, errors.New("failed to get guest cart: " + err.Error())
+	}
+
+	return cart, err
+}
+
+func (s *guestCartService) saveCart(ctx context.Context, cart *domain.GuestCart) error {
+	cart.UpdatedAt = time.Now()
+	cart.Expires_At = cart.UpdatedAt.Add(s.ttl)
+
+	if err := s.repo.Save(ctx, cart); err != nil {
+		return errors.New("failed to save guest cart: " + err.Error())
+	}
+
+	return nil
+}
+
+func newGuestCart() (*domain.GuestCart, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, errors.New("failed to generate guest cart id: " + err.Error())
+	}
+
+	return &domain.GuestCart{ID: hex.EncodeToString(id), Items: make([]domain.CartItem, 0)}, nil
+}
+
+func (s *guestCartService) getProduct(ctx context.Context, productID string) (*domain.ProductWithSalesData, error) {
+	product, err := s.productRepo.GetProductById(ctx, productID)
+	if errors.Is(err, domain.ErrProductNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.New("failed to get product: " + err.Error())
+	}
+
+	if product.Hidden() {
+		return nil, domain.ErrProductNotFound
+	}
+
+	return product, nil
+}
+
+// GetAllCartItem implements domain.GuestCartService.
+func (s *guestCartService) GetAllCartItem(ctx context.Context, token string) (*[]dto.GetCartItemRes, error) {
+	itemRes := make([]dto.GetCartItemRes, 0)
+
+	cart, err := s.findCart(ctx, token)
+	if errors.Is(err, domain.ErrInvalidCartToken) || errors.Is(err, domain.ErrGuestCartNotFound) {
+		// a visitor without a cart has an empty one
+		return &itemRes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range cart.Items {
+		store, err := s.storeRepo.GetStore(ctx, item.StoreID)
+		if err != nil {
+			return nil, errors.New("failed to get store: " + err.Error())
+		}
+
+		itemRes = append(itemRes, dto.GetCartItemRes{
+			Product_Id:    item.Product_Id,
+			Variant_Id:    item.Variant_Id,
+			Variant_Name:  item.Variant_Name,
+			Product_Name:  item.Product_Name,
+			Product_Image: item.Product_Image,
+			Store_Name:    store.Name,
+			Quantity:      item.Quantity,
+			Unit:          item.Unit,
+			AddedAt:       item.AddedAt,
+			Selected:      item.Selected,
+			Price:         item.Price,
+		})
+	}
+
+	return &itemRes, nil
+}
+
+// AddToCart implements domain.GuestCartService.
+func (s *guestCartService) AddToCart(ctx context.Context, token, productID string, req *dto.AddCartReq) (string, error) {
+	cart, err := s.findCart(ctx, token)
+	if errors.Is(err, domain.ErrInvalidCartToken) || errors.Is(err, domain.ErrGuestCartNotFound) {
+		cart, err = newGuestCart()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return "", err
+	}
+
+	item, stock, err := cartItemOf(product, req.Variant_Id)
+	if err != nil {
+		return "", err
+	}
+
+	// adding a product twice adds to the quantity of its item
+	quantity := req.Quantity
+	i := findCartItem(cart.Items, item.Product_Id, item.Variant_Id)
+	if i >= 0 {
+		quantity += cart.Items[i].Quantity
+	}
+
+	if err := product.QuantityRule().Check(quantity); err != nil {
+		return "", err
+	}
+
+	if quantity > stock {
+		return "", errors.New("product stock is less than quantity")
+	}
+
+	item.Quantity = quantity
+	if i >= 0 {
+		item.AddedAt = cart.Items[i].AddedAt
+		cart.Items[i] = item
+	} else {
+		cart.Items = append(cart.Items, item)
+	}
+
+	if err := s.saveCart(ctx, cart); err != nil {
+		return "", err
+	}
+
+	return s.sign(cart.ID), nil
+}
+
+// UpdateCartItemById implements domain.GuestCartService.
+func (s *guestCartService) UpdateCartItemById(ctx context.Context, token, productID, variantID string, input *dto.CartItemEditReq) error {
+	if input.Quantity < 0 {
+		return errors.New("quantity cannot be less than 0")
+	}
+
+	cart, err := s.findCart(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	i := findCartItem(cart.Items, productID, variantID)
+	if i < 0 {
+		return domain.ErrCartItemNotFound
+	}
+
+	if input.Quantity == 0 {
+		cart.Items = append(cart.Items[:i], cart.Items[i+1:]...)
+		return s.saveCart(ctx, cart)
+	}
+
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	item, stock, err := cartItemOf(product, variantID)
+	if err != nil {
+		return err
+	}
+
+	if err := product.QuantityRule().Check(input.Quantity); err != nil {
+		return err
+	}
+
+	if input.Quantity > stock {
+		return errors.New("product stock is less than quantity")
+	}
+
+	item.Quantity = input.Quantity
+	item.Selected = input.Selected
+	item.AddedAt = cart.Items[i].AddedAt
+	cart.Items[i] = item
+
+	return s.saveCart(ctx, cart)
+}
+
+// RemoveCartItemById implements domain.GuestCartService.
+func (s *guestCartService) RemoveCartItemById(ctx context.Context, token, productID, variantID string) error {
+	cart, err := s.findCart(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	i := findCartItem(cart.Items, productID, variantID)
+	if i < 0 {
+		return domain.ErrCartItemNotFound
+	}
+
+	cart.Items = append(cart.Items[:i], cart.Items[i+1:]...)
+
+	return s.saveCart(ctx, cart)
+}
+
+// Merge implements domain.GuestCartService.
+func (s *guestCartService) Merge(ctx context.Context, token, email string) (*dto.CartMergeRes, error) {
+	guestCart, err := s.findCart(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	cartExist, err := s.cartRepo.CheckUserCart(ctx, email)
+	if err != nil {
+		return nil, errors.New("failed check user cart: " + err.Error())
+	}
+
+	// users signing in with oauth have no cart until now
+	if !cartExist {
+		cart := domain.Cart{
+			ID:        primitive.NewObjectID(),
+			Email:     email,
+			UpdatedAt: time.Now(),
+			Items:     make([]domain.CartItem, 0),
+		}
+		if err := s.cartRepo.CreateCart(ctx, &cart); err != nil {
+			return nil, errors.New("failed to create user cart: " + err.Error())
+		}
+	}
+
+	cart, err := s.cartRepo.GetUserCart(ctx, email)
+	if err != nil {
+		return nil, errors.New("failed to get user cart: " + err.Error())
+	}
+
+	items := cart.Items
+	if items == nil {
+		items = make([]domain.CartItem, 0)
+	}
+
+	res := dto.CartMergeRes{Items: make([]dto.CartMergeItem, 0, len(guestCart.Items))}
+	for _, guestItem := range guestCart.Items {
+		var merged dto.CartMergeItem
+		items, merged, err = s.mergeItem(ctx, items, guestItem)
+		if err != nil {
+			return nil, err
+		}
+		res.Items = append(res.Items, merged)
+	}
+
+	totalPrice := float64(0)
+	for _, item := range items {
+		totalPrice += item.Quantity * item.Price
+	}
+
+	if _, err := s.cartRepo.ReplaceItems(ctx, email, items, totalPrice, time.Now()); err != nil {
+		return nil, errors.New("failed to merge guest cart: " + err.Error())
+	}
+
+	if err := s.repo.Delete(ctx, guestCart.ID); err != nil {
+		return nil, errors.New("failed to delete guest cart: " + err.Error())
+	}
+
+	return &res, nil
+}
+
+// mergeItem merges an item of a guest cart into items, the items of the cart
+// of a user. The item is priced at the current price and its quantity, added
+// to the quantity the user already had, is lowered to the stock. An item the
+// user can not order at all is dropped and the item they had is left as it
+// was.
+func (s *guestCartService) mergeItem(ctx context.Context, items []domain.CartItem, guestItem domain.CartItem) ([]domain.CartItem, dto.CartMergeItem, error) {
+	merged := dto.CartMergeItem{
+		Product_Id:   guestItem.Product_Id,
+		Variant_Id:   guestItem.Variant_Id,
+		Product_Name: guestItem.Product_Name,
+		Requested:    guestItem.Quantity,
+	}
+
+	product, err := s.getProduct(ctx, guestItem.Product_Id)
+	if errors.Is(err, domain.ErrProductNotFound) {
+		merged.Result = domain.MergeUnavailable
+		return items, merged, nil
+	}
+	if err != nil {
+		return nil, merged, err
+	}
+
+	item, stock, err := cartItemOf(product, guestItem.Variant_Id)
+	if err != nil {
+		merged.Result = domain.MergeUnavailable
+		return items, merged, nil
+	}
+	merged.Product_Name = item.Product_Name
+
+	quantity := guestItem.Quantity
+	merged.Result = domain.MergeMoved
+	item.AddedAt = guestItem.AddedAt
+	i := findCartItem(items, item.Product_Id, item.Variant_Id)
+	if i >= 0 {
+		quantity += items[i].Quantity
+		merged.Result = domain.MergeCombined
+		item.AddedAt = items[i].AddedAt
+	}
+
+	capped := product.QuantityRule().Floor(math.Min(quantity, stock))
+	if capped == 0 {
+		merged.Result = domain.MergeOutOfStock
+		return items, merged, nil
+	}
+	if capped != quantity {
+		merged.Result = domain.MergeCapped
+	}
+
+	item.Quantity = capped
+	merged.Quantity = capped
+	if i >= 0 {
+		items[i] = item
+	} else {
+		items = append(items, item)
+	}
+
+	return items, merged, nil
+}
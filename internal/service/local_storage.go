@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/config"
+)
+
+type localStorage struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStorage keeps blobs under cnf.Storage.LocalDir, the routes serve
+// that directory under /uploads.
+func NewLocalStorage(cnf *config.Config) domain.BlobStorage {
+	baseURL := cnf.Storage.PublicURL
+	if baseURL == "" {
+		baseURL = "http://" + cnf.Server.Host + ":" + cnf.Server.Port + "/uploads"
+	}
+
+	return &localStorage{
+		dir:     cnf.Storage.LocalDir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Put implements domain.BlobStorage.
+func (s *localStorage) Put(ctx context.Context, key, contentType string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.New("failed to create upload directory: " + err.Error())
+	}
+
+	// write aside and rename, so a blob is never served half written
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return errors.New("failed to write blob: " + err.Error())
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return errors.New("failed to write blob: " + err.Error())
+	}
+
+	return nil
+}
+
+// Delete implements domain.BlobStorage.
+func (s *localStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.New("failed to delete blob: " + err.Error())
+	}
+
+	return nil
+}
+
+// URL implements domain.BlobStorage.
+func (s *localStorage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+func (s *localStorage) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if clean == "." || filepath.IsAbs(clean) || strings.HasPrefix(clean, "..") {
+		return "", errors.New("invalid blob key " + key)
+	}
+
+	return filepath.Join(s.dir, clean), nil
+}
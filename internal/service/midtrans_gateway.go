@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"github.com/midtrans/midtrans-go"
+	"github.com/midtrans/midtrans-go/coreapi"
+	"github.com/midtrans/midtrans-go/snap"
+)
+
+type midtransGateway struct {
+	config config.Midtrans
+	envi   midtrans.EnvironmentType
+}
+
+func NewMidtransGateway(cnf *config.Config) domain.PaymentGateway {
+	envi := midtrans.Sandbox
+	if cnf.Midtrans.IsProd {
+		envi = midtrans.Production
+	}
+
+	return &midtransGateway{
+		config: cnf.Midtrans,
+		envi:   envi,
+	}
+}
+
+// CreateCharge implements domain.PaymentGateway.
+func (g *midtransGateway) CreateCharge(ctx context.Context, p *domain.Payment) error {
+	req := &snap.Request{
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:  p.OrderID,
+			GrossAmt: int64(p.Amount),
+		},
+	}
+
+	var client snap.Client
+	client.New(g.config.Key, g.envi)
+
+	snapResp, err := client.CreateTransaction(req)
+	if err != nil {
+		return errors.New("failed create transaction: " + err.Error())
+	}
+	p.Snap_Url = snapResp.RedirectURL
+	return nil
+}
+
+// CheckStatus implements domain.PaymentGateway.
+func (g *midtransGateway) CheckStatus(ctx context.Context, orderID string) (*domain.PaymentNotification, error) {
+	var client coreapi.Client
+	client.New(g.config.Key, g.envi)
+
+	resp, err := client.CheckTransaction(orderID)
+	if err != nil {
+		if err.StatusCode == http.StatusNotFound {
+			return nil, domain.ErrChargeNotFound
+		}
+		return nil, errors.New("failed check transaction: " + err.Error())
+	}
+
+	// midtrans answers an unknown order with status_code 404 in the body
+	if resp.StatusCode == "404" {
+		return nil, domain.ErrChargeNotFound
+	}
+
+	notif := dto.MidtransNotification{
+		TransactionStatus: resp.TransactionStatus,
+		TransactionID:     resp.TransactionID,
+		StatusCode:        resp.StatusCode,
+		PaymentType:       resp.PaymentType,
+		OrderID:           resp.OrderID,
+		GrossAmount:       resp.GrossAmount,
+		FraudStatus:       resp.FraudStatus,
+	}
+
+	return toPaymentNotification(&notif), nil
+}
+
+// Refund implements domain.PaymentGateway.
+func (g *midtransGateway) Refund(ctx context.Context, orderID, refundKey string, amount float64, reason string) error {
+	var client coreapi.Client
+	client.New(g.config.Key, g.envi)
+
+	req := &coreapi.RefundReq{
+		RefundKey: refundKey,
+		Amount:    int64(amount),
+		Reason:    reason,
+	}
+
+	_, err := client.RefundTransaction(orderID, req)
+	if err != nil {
+		return errors.New("failed refund transaction: " + err.Error())
+	}
+
+	return nil
+}
+
+// Expire implements domain.PaymentGateway.
+func (g *midtransGateway) Expire(ctx context.Context, orderID string) error {
+	var client coreapi.Client
+	client.New(g.config.Key, g.envi)
+
+	resp, err := client.ExpireTransaction(orderID)
+	if err != nil {
+		if err.StatusCode == http.StatusNotFound {
+			return domain.ErrChargeNotFound
+		}
+		return errors.New("failed expire transaction: " + err.Error())
+	}
+
+	if resp.StatusCode == "404" {
+		return domain.ErrChargeNotFound
+	}
+
+	return nil
+}
+
+// ParseWebhook implements domain.PaymentGateway.
+func (g *midtransGateway) ParseWebhook(payload []byte) (*domain.PaymentNotification, error) {
+	return parseMidtransNotification(payload, g.config.Key)
+}
+
+// parseMidtransNotification checks the signature_key midtrans puts in every
+// notification, it is the SHA512 of order_id, status_code, gross_amount and the
+// server key.
+func parseMidtransNotification(payload []byte, serverKey string) (*domain.PaymentNotification, error) {
+	var notif dto.MidtransNotification
+	if err := json.Unmarshal(payload, &notif); err != nil {
+		return nil, errors.New("failed to parse notification: " + err.Error())
+	}
+
+	expected := midtransSignature(notif.OrderID, notif.StatusCode, notif.GrossAmount, serverKey)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(notif.SignatureKey)) != 1 {
+		return nil, domain.ErrInvalidSignature
+	}
+
+	if notif.OrderID == "" || notif.TransactionID == "" {
+		return nil, errors.New("order_id and transaction_id are required")
+	}
+
+	return toPaymentNotification(&notif), nil
+}
+
+func midtransSignature(orderID, statusCode, grossAmount, serverKey string) string {
+	hash := sha512.Sum512([]byte(orderID + statusCode + grossAmount + serverKey))
+	return hex.EncodeToString(hash[:])
+}
+
+func toPaymentNotification(notif *dto.MidtransNotification) *domain.PaymentNotification {
+	status, _ := domain.PaymentStatusFromMidtrans(notif.TransactionStatus, notif.FraudStatus)
+
+	// every partial refund of a transaction has the same status, the refund key tells them apart
+	eventID := notif.TransactionID + ":" + notif.TransactionStatus
+	if len(notif.Refunds) > 0 {
+		eventID += ":" + notif.Refunds[len(notif.Refunds)-1].RefundKey
+	}
+
+	return &domain.PaymentNotification{
+		EventID:           eventID,
+		OrderID:           notif.OrderID,
+		TransactionID:     notif.TransactionID,
+		TransactionStatus: notif.TransactionStatus,
+		FraudStatus:       notif.FraudStatus,
+		StatusCode:        notif.StatusCode,
+		GrossAmount:       notif.GrossAmount,
+		PaymentType:       notif.PaymentType,
+		Status:            status,
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"time"
 
 	"github.com/IndraSty/GreenBasket/domain"
 	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -21,13 +23,18 @@ type orderService struct {
 	notifSvc        domain.NotificationService
 	sellerOrderRepo domain.SellerOrderRepository
 	salesReportSvc  domain.SalesReportService
+	reservationSvc  domain.ReservationService
+	orderStatusSvc  domain.OrderStatusService
+	shippingSvc     domain.ShippingService
+	transactor      domain.Transactor
 	cacheRepo       domain.CacheRepository
 }
 
 func NewOrderService(repo domain.OrderRepository, userRepo domain.UserRepository, cartRepo domain.CartRepository,
 	sellerRepo domain.SellerRepository, storeRepo domain.StoreRepository, notifSvc domain.NotificationService,
 	sellerOrderRepo domain.SellerOrderRepository, salesReportSvc domain.SalesReportService,
-	cacheRepo domain.CacheRepository) domain.OrderService {
+	reservationSvc domain.ReservationService, orderStatusSvc domain.OrderStatusService,
+	shippingSvc domain.ShippingService, transactor domain.Transactor, cacheRepo domain.CacheRepository) domain.OrderService {
 	return &orderService{
 		repo:            repo,
 		userRepo:        userRepo,
@@ -37,6 +44,10 @@ func NewOrderService(repo domain.OrderRepository, userRepo domain.UserRepository
 		notifSvc:        notifSvc,
 		sellerOrderRepo: sellerOrderRepo,
 		salesReportSvc:  salesReportSvc,
+		reservationSvc:  reservationSvc,
+		orderStatusSvc:  orderStatusSvc,
+		shippingSvc:     shippingSvc,
+		transactor:      transactor,
 		cacheRepo:       cacheRepo,
 	}
 }
@@ -55,61 +66,29 @@ func (s *orderService) setRedisOrder(item any, name, email string) error {
 	return nil
 }
 
-func (s *orderService) delRedisOrder(email string, name ...string) error {
-	err := s.cacheRepo.Del(name[0] + email)
+func (s *orderService) delRedisOrder(email, name string) error {
+	err := s.cacheRepo.Del(name + email)
 	if err != nil {
 		return errors.New("failed to delete order data in cache: " + err.Error())
 	}
 
-	if len(name[1]) != 0 {
-		err = s.cacheRepo.Del(name[1] + email)
-		if err != nil {
-			return errors.New("failed to delete all order data in cache: " + err.Error())
-		}
-	}
-
 	return nil
 }
 
-func (s *orderService) updateRedisOrder(ctx context.Context, email, orderID string, name ...string) error {
+func (s *orderService) updateRedisOrder(ctx context.Context, email, orderID, name string) error {
 	data1, err := s.getOrderByEmailAndId(ctx, email, orderID)
 	if err != nil {
 		return errors.New("failed to get order data: " + err.Error())
 	}
 
-	err = s.setRedisOrder(*data1, name[0], email)
+	err = s.setRedisOrder(*data1, name, email)
 	if err != nil {
-		return errors.New("failed to set user order data1 in cache: " + err.Error())
-	}
-
-	if len(name[1]) != 0 {
-		data2, err := s.getAllOrders(ctx, email)
-		if err != nil {
-			return errors.New("failed to get order data: " + err.Error())
-		}
-		err = s.setRedisOrder(*data2, name[1], email)
-		if err != nil {
-			return errors.New("failed to set user order data2 in cache: " + err.Error())
-		}
+		return errors.New("failed to set user order data in cache: " + err.Error())
 	}
 
 	return nil
 }
 
-func (s *orderService) getAllOrders(ctx context.Context, email string) (*[]domain.Orders, error) {
-	_, err := s.userRepo.FindUserByEmail(ctx, email)
-	if err != nil {
-		return nil, errors.New("failed to find user: " + err.Error())
-	}
-
-	result, err := s.repo.GetAllOrders(ctx, email)
-	if err != nil {
-		return nil, errors.New("failed to get all orders: " + err.Error())
-	}
-
-	return result, nil
-}
-
 func (s *orderService) getOrderByEmailAndId(ctx context.Context, email string, orderID string) (*domain.Orders, error) {
 	_, err := s.userRepo.FindUserByEmail(ctx, email)
 	if err != nil {
@@ -126,7 +105,7 @@ func (s *orderService) getOrderByEmailAndId(ctx context.Context, email string, o
 
 // CreateOrder implements domain.OrderService.
 func (s *orderService) CreateOrder(ctx context.Context, email string) (*dto.InsertOrderRes, error) {
-	err := s.delRedisOrder(email, "user-order:", "all_user-order:")
+	err := s.delRedisOrder(email, "user-order:")
 	if err != nil {
 		return nil, err
 	}
@@ -156,15 +135,18 @@ func (s *orderService) CreateOrder(ctx context.Context, email string) (*dto.Inse
 		if item.Selected {
 			orderItem := domain.OrderItem{
 				Product_Id:    item.Product_Id,
+				Variant_Id:    item.Variant_Id,
+				Variant_Name:  item.Variant_Name,
 				Product_Name:  item.Product_Name,
 				Product_Image: item.Product_Image,
 				StoreID:       item.StoreID,
-				Order_Status:  "PENDING",
+				Order_Status:  string(domain.OrderPending),
 				Quantity:      item.Quantity,
+				Unit:          item.Unit,
 				Price:         item.Price,
 			}
 			items = append(items, orderItem)
-			totalPrice += item.Price * float64(item.Quantity)
+			totalPrice += item.Price * item.Quantity
 		}
 	}
 
@@ -172,6 +154,16 @@ func (s *orderService) CreateOrder(ctx context.Context, email string) (*dto.Inse
 		return nil, errors.New("no items selected in the cart")
 	}
 
+	quote, err := s.shippingSvc.Quote(ctx, *user.Address_Details, items)
+	if err != nil {
+		return nil, err
+	}
+
+	shippingFees := make(map[string]float64)
+	for _, store := range quote.Stores {
+		shippingFees[store.Store_Id] = store.Fee
+	}
+
 	id := primitive.NewObjectID()
 	orderID := id.Hex()
 	order := domain.Orders{
@@ -180,66 +172,97 @@ func (s *orderService) CreateOrder(ctx context.Context, email string) (*dto.Inse
 		Email:            email,
 		Order_Date:       time.Now(),
 		Updated_At:       time.Now(),
-		Total_Price:      totalPrice,
+		Total_Price:      totalPrice + quote.Shipping_Fee,
+		Shipping_Fee:     quote.Shipping_Fee,
 		Address_Shipping: *user.Address_Details,
 		Payment:          &domain.PaymentOrder{},
 		Items:            items,
 	}
 
-	result, err := s.repo.CreateOrder(ctx, order)
-	if err != nil {
-		return nil, errors.New("failed to create an order: " + err.Error())
-	}
+	var result primitive.ObjectID
+	var sellerEmails []string
 
-	sellerItems := make(map[string][]domain.OrderItem)
+	err = s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		sellerEmails = nil
 
-	for _, item := range items {
-		sellerItems[item.StoreID] = append(sellerItems[item.StoreID], item)
-	}
+		err := s.reservationSvc.ReserveStock(ctx, orderID, items)
+		if err != nil {
+			return err
+		}
+
+		result, err = s.repo.CreateOrder(ctx, order)
+		if err != nil {
+			return fmt.Errorf("failed to create an order: %w", err)
+		}
 
-	for _, items := range sellerItems {
-		var sellerOrderItems []domain.SellerOrderItem
-		var totalPriceSeller float64
-		var emailSeller string
+		sellerItems := make(map[string][]domain.OrderItem)
 
 		for _, item := range items {
-			sellerOrderItem := domain.SellerOrderItem{
-				User_Email:       email,
-				Product_Id:       item.Product_Id,
-				Product_Name:     item.Product_Name,
-				Product_Image:    item.Product_Image,
-				Quantity:         item.Quantity,
-				Price:            item.Price,
-				Status:           "PENDING",
-				Address_Shipping: *user.Address_Details,
+			sellerItems[item.StoreID] = append(sellerItems[item.StoreID], item)
+		}
+
+		for storeID, items := range sellerItems {
+			var sellerOrderItems []domain.SellerOrderItem
+			var totalPriceSeller float64
+			var emailSeller string
+
+			for _, item := range items {
+				sellerOrderItem := domain.SellerOrderItem{
+					User_Email:       email,
+					Product_Id:       item.Product_Id,
+					Variant_Id:       item.Variant_Id,
+					Variant_Name:     item.Variant_Name,
+					Product_Name:     item.Product_Name,
+					Product_Image:    item.Product_Image,
+					Quantity:         item.Quantity,
+					Unit:             item.Unit,
+					Price:            item.Price,
+					Status:           string(domain.OrderPending),
+					Address_Shipping: *user.Address_Details,
+				}
+
+				seller, err := s.sellerRepo.FindSellerByStoreId(ctx, item.StoreID)
+				if err != nil {
+					return fmt.Errorf("failed to find seller for add order: %w", err)
+				}
+				emailSeller = seller.Email
+				sellerOrderItems = append(sellerOrderItems, sellerOrderItem)
+				totalPriceSeller += item.Price * item.Quantity
 			}
 
-			seller, err := s.sellerRepo.FindSellerByStoreId(ctx, item.StoreID)
-			if err != nil {
-				return nil, errors.New("failed to find seller for add order: " + err.Error())
+			sellerOrder := domain.SellerOrder{
+				ID:             primitive.NewObjectID(),
+				Order_id:       orderID,
+				Email:          emailSeller,
+				Ordered_At:     time.Now(),
+				Updated_At:     time.Now(),
+				Total_Price:    totalPriceSeller + shippingFees[storeID],
+				Shipping_Fee:   shippingFees[storeID],
+				Payment_Status: "UNPAID",
+				Items:          sellerOrderItems,
 			}
-			emailSeller = seller.Email
-			sellerOrderItems = append(sellerOrderItems, sellerOrderItem)
-			totalPriceSeller += item.Price * float64(item.Quantity)
-		}
 
-		sellerOrder := domain.SellerOrder{
-			ID:             primitive.NewObjectID(),
-			Order_id:       orderID,
-			Email:          emailSeller,
-			Ordered_At:     time.Now(),
-			Updated_At:     time.Now(),
-			Total_Price:    totalPriceSeller,
-			Payment_Status: "UNPAID",
-			Items:          sellerOrderItems,
+			_, err := s.sellerOrderRepo.CreateOrderSeller(ctx, sellerOrder)
+			if err != nil {
+				return fmt.Errorf("failed to create a seller order: %w", err)
+			}
+			sellerEmails = append(sellerEmails, emailSeller)
 		}
 
-		_, err := s.sellerOrderRepo.CreateOrderSeller(ctx, sellerOrder)
+		_, err = s.cartRepo.RemoveSelectedItems(ctx, email, totalPrice, time.Now())
 		if err != nil {
-			return nil, errors.New("failed to create a seller order: " + err.Error())
+			return fmt.Errorf("failed to clear user cart: %w", err)
 		}
 
-		err = s.delRedisOrder(emailSeller, "seller-order:", "all_seller-order:")
+		return nil
+	})
+	if err != nil {
+		s.rollbackOrder(ctx, orderID)
+		return nil, err
+	}
+
+	for _, emailSeller := range sellerEmails {
+		err = s.delRedisOrder(emailSeller, "seller-order:")
 		if err != nil {
 			log.Println("failed to update seller order in cache: ", err)
 		}
@@ -269,7 +292,7 @@ func (s *orderService) CreateOrder(ctx context.Context, email string) (*dto.Inse
 	}
 
 	defer func() {
-		err := s.updateRedisOrder(ctx, email, orderID, "user-order:", "all_seller-order")
+		err := s.updateRedisOrder(ctx, email, orderID, "user-order:")
 		if err != nil {
 			log.Println("failed to update order in cache: ", err)
 		}
@@ -280,32 +303,33 @@ func (s *orderService) CreateOrder(ctx context.Context, email string) (*dto.Inse
 	}, nil
 }
 
-// GetAllOrders implements domain.OrderService.
-func (s *orderService) GetAllOrders(ctx context.Context, email string) (*[]domain.Orders, error) {
-	val, err := s.cacheRepo.Get("all_user-order:" + email)
-	if err == nil {
-		var data []domain.Orders
-		err = json.Unmarshal(val, &data)
-		if err != nil {
-			return nil, errors.New("failed to unmarshal order data: " + err.Error())
-		}
+// rollbackOrder undoes what a failed CreateOrder may have left behind. With
+// transactions the writes are already gone and this finds nothing to undo, on
+// a standalone deployment it is the only way back.
+func (s *orderService) rollbackOrder(ctx context.Context, orderID string) {
+	if err := s.reservationSvc.ReleaseStock(ctx, orderID); err != nil {
+		log.Println("failed to release reserved stock: ", err)
+	}
 
-		return &data, nil
+	if _, err := s.sellerOrderRepo.DeleteByOrderId(ctx, orderID); err != nil {
+		log.Println("failed to delete seller orders: ", err)
 	}
 
-	_, err = s.userRepo.FindUserByEmail(ctx, email)
-	if err != nil {
-		return nil, errors.New("failed to find user: " + err.Error())
+	if _, err := s.repo.DeleteOrder(ctx, orderID); err != nil {
+		log.Println("failed to delete order: ", err)
 	}
+}
 
-	result, err := s.repo.GetAllOrders(ctx, email)
+// GetAllOrders implements domain.OrderService.
+func (s *orderService) GetAllOrders(ctx context.Context, email string, page pagination.Request) (*pagination.Page[domain.Orders], error) {
+	_, err := s.userRepo.FindUserByEmail(ctx, email)
 	if err != nil {
-		return nil, errors.New("failed to get all orders: " + err.Error())
+		return nil, errors.New("failed to find user: " + err.Error())
 	}
 
-	err = s.setRedisOrder(result, "all_user-order:", email)
+	result, err := s.repo.FindByEmail(ctx, email, page)
 	if err != nil {
-		return nil, err
+		return nil, errors.New("failed to get all orders: " + err.Error())
 	}
 
 	return result, nil
@@ -343,45 +367,26 @@ func (s *orderService) GetOrderByEmailAndId(ctx context.Context, email string, o
 }
 
 // FinishOrder implements domain.OrderService.
-func (s *orderService) FinishOrder(ctx context.Context, email string, orderID string, productID string, req *dto.OrderStatusUpdateReq) error {
-	err := s.delRedisOrder(email, "user-order:", "all_user-order:")
+func (s *orderService) FinishOrder(ctx context.Context, email, orderID, productID, variantID string, req *dto.OrderStatusUpdateReq) error {
+	err := s.delRedisOrder(email, "user-order:")
 	if err != nil {
 		return err
 	}
 
-	order, err := s.repo.GetOrder(ctx, orderID, email)
+	_, err = s.repo.GetOrder(ctx, orderID, email)
 	if err != nil {
 		return errors.New("failed to get the order: " + err.Error())
 	}
 
-	for _, item := range order.Items {
-		if item.Product_Id == productID {
-			if item.Order_Status != "SHIPPED" {
-				return errors.New("order status is not 'SHIPPED'")
-			}
-		}
-	}
-
-	if req.Status != "FINISHED" {
+	if req.Status != string(domain.OrderFinished) {
 		return errors.New("order status request is not 'FINISHED'")
 	}
 
-	res, err := s.repo.UpdateStatusOrder(ctx, orderID, productID, req)
-	if err != nil {
-		return errors.New("failed to update status order: " + err.Error())
-	}
-
-	if res.ModifiedCount == 0 {
-		return errors.New("failed to update status order")
-	}
-
-	res, err = s.sellerOrderRepo.UpdateStatusOrderSeller(ctx, orderID, productID, req)
+	err = s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		return s.orderStatusSvc.Transition(ctx, orderID, productID, variantID, domain.OrderFinished, domain.ActorUser)
+	})
 	if err != nil {
-		return errors.New("failed to update status order seller: " + err.Error())
-	}
-
-	if res.ModifiedCount == 0 {
-		return errors.New("failed to update status order seller")
+		return err
 	}
 
 	newOrder, err := s.repo.GetOrder(ctx, orderID, email)
@@ -390,8 +395,8 @@ func (s *orderService) FinishOrder(ctx context.Context, email string, orderID st
 	}
 	var sellerID string
 	for _, item := range newOrder.Items {
-		if item.Product_Id == productID {
-			if item.Order_Status == "FINISHED" {
+		if item.Is(productID, variantID) {
+			if item.Order_Status == string(domain.OrderFinished) {
 				seller, err := s.sellerRepo.FindSellerByStoreId(ctx, item.StoreID)
 				if err != nil {
 					return errors.New("failed to get seller with email: " + err.Error())
@@ -407,7 +412,7 @@ func (s *orderService) FinishOrder(ctx context.Context, email string, orderID st
 	}
 
 	defer func() {
-		err := s.updateRedisOrder(ctx, email, orderID, "user-order:", "all_seller-order")
+		err := s.updateRedisOrder(ctx, email, orderID, "user-order:")
 		if err != nil {
 			log.Println("failed to update order in cache: ", err)
 		}
@@ -423,8 +428,8 @@ func (s *orderService) FinishOrder(ctx context.Context, email string, orderID st
 }
 
 // CancelOrder implements domain.OrderService.
-func (s *orderService) CancelOrder(ctx context.Context, email string, orderID string, productID string) error {
-	err := s.delRedisOrder(email, "user-order:", "all_user-order:")
+func (s *orderService) CancelOrder(ctx context.Context, email, orderID, productID, variantID string) error {
+	err := s.delRedisOrder(email, "user-order:")
 	if err != nil {
 		return err
 	}
@@ -434,43 +439,32 @@ func (s *orderService) CancelOrder(ctx context.Context, email string, orderID st
 		return errors.New("failed to get the order: " + err.Error())
 	}
 
-	for _, item := range order.Items {
-		if item.Product_Id == productID {
-			if item.Order_Status != "PENDING" {
-				return errors.New("order status is not 'PENDING'")
-			}
+	var item *domain.OrderItem
+	for i := range order.Items {
+		if order.Items[i].Is(productID, variantID) {
+			item = &order.Items[i]
 		}
 	}
 
-	res, err := s.repo.DeleteItem(ctx, orderID, productID)
-	if err != nil {
-		return errors.New("failed to delete item: " + err.Error())
+	if item == nil {
+		return errors.New("no item found in the order")
 	}
 
-	if res.ModifiedCount == 0 {
-		return errors.New("failed to delete item")
+	seller, err := s.sellerRepo.FindSellerByStoreId(ctx, item.StoreID)
+	if err != nil {
+		return errors.New("failed to find seller: " + err.Error())
 	}
 
-	for _, item := range order.Items {
-		if item.Product_Id == productID {
-			seller, err := s.sellerRepo.FindSellerByStoreId(ctx, item.StoreID)
-			if err != nil {
-				return errors.New("failed to find seller: " + err.Error())
-			}
-
-			res, err := s.sellerOrderRepo.DeleteItem(ctx, seller.Email, orderID, item.Product_Id)
-			if err != nil {
-				return errors.New("failed to delete item: " + err.Error())
-			}
-
-			if res.ModifiedCount == 0 {
-				return errors.New("failed to delete item")
-			}
-		}
+	err = s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		return cancelOrderItem(ctx, s.orderStatusSvc, s.repo, s.sellerOrderRepo, s.reservationSvc,
+			seller.Email, orderID, productID, variantID, item.Price*item.Quantity, domain.ActorUser)
+	})
+	if err != nil {
+		return err
 	}
 
 	defer func() {
-		err := s.updateRedisOrder(ctx, email, orderID, "user-order:", "all_seller-order")
+		err := s.updateRedisOrder(ctx, email, orderID, "user-order:")
 		if err != nil {
 			log.Println("failed to update order in cache: ", err)
 		}
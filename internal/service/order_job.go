@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type orderJobService struct {
+	orderRepo       domain.OrderRepository
+	sellerOrderRepo domain.SellerOrderRepository
+	sellerRepo      domain.SellerRepository
+	paymentRepo     domain.PaymentRepository
+	orderStatusSvc  domain.OrderStatusService
+	reservationSvc  domain.ReservationService
+	paymentNotifSvc domain.PaymentNotificationService
+	salesReportSvc  domain.SalesReportService
+	transactor      domain.Transactor
+}
+
+func NewOrderJobService(orderRepo domain.OrderRepository, sellerOrderRepo domain.SellerOrderRepository,
+	sellerRepo domain.SellerRepository, paymentRepo domain.PaymentRepository,
+	orderStatusSvc domain.OrderStatusService, reservationSvc domain.ReservationService,
+	paymentNotifSvc domain.PaymentNotificationService, salesReportSvc domain.SalesReportService,
+	transactor domain.Transactor) domain.OrderJobService {
+	return &orderJobService{
+		orderRepo:       orderRepo,
+		sellerOrderRepo: sellerOrderRepo,
+		sellerRepo:      sellerRepo,
+		paymentRepo:     paymentRepo,
+		orderStatusSvc:  orderStatusSvc,
+		reservationSvc:  reservationSvc,
+		paymentNotifSvc: paymentNotifSvc,
+		salesReportSvc:  salesReportSvc,
+		transactor:      transactor,
+	}
+}
+
+// FinishShippedItems implements domain.OrderJobService.
+func (s *orderJobService) FinishShippedItems(ctx context.Context, shippedBefore time.Time) (int, error) {
+	orders, err := s.orderRepo.FindShippedBefore(ctx, shippedBefore)
+	if err != nil {
+		return 0, errors.New("failed to get shipped orders: " + err.Error())
+	}
+
+	var finished int
+	stores := map[string]bool{}
+	for _, order := range *orders {
+		for _, item := range order.Items {
+			if item.Order_Status != string(domain.OrderShipped) || shippedAt(&order, &item).After(shippedBefore) {
+				continue
+			}
+
+			err := s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+				return s.orderStatusSvc.Transition(ctx, order.Order_id, item.Product_Id, item.Variant_Id, domain.OrderFinished, domain.ActorSystem)
+			})
+			if err != nil {
+				// the buyer may have finished or asked a refund for the item in the meantime
+				log.Println("failed to finish order item "+item.Product_Id+" of order "+order.Order_id+": ", err)
+				continue
+			}
+
+			finished++
+			stores[item.StoreID] = true
+		}
+	}
+
+	s.updateSalesReports(ctx, stores)
+
+	return finished, nil
+}
+
+// ExpireUnpaidOrders implements domain.OrderJobService.
+func (s *orderJobService) ExpireUnpaidOrders(ctx context.Context, orderedBefore time.Time) (int, error) {
+	orders, err := s.orderRepo.FindUnpaidBefore(ctx, orderedBefore)
+	if err != nil {
+		return 0, errors.New("failed to get unpaid orders: " + err.Error())
+	}
+
+	var expired int
+	stores := map[string]bool{}
+	for _, order := range *orders {
+		ok, err := s.expireOrder(ctx, &order)
+		if err != nil {
+			log.Println("failed to expire order "+order.Order_id+": ", err)
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		expired++
+		for _, item := range order.Items {
+			stores[item.StoreID] = true
+		}
+	}
+
+	s.updateSalesReports(ctx, stores)
+
+	return expired, nil
+}
+
+func (s *orderJobService) expireOrder(ctx context.Context, order *domain.Orders) (bool, error) {
+	_, err := s.paymentRepo.FindByOrderId(ctx, order.Order_id)
+	if err == nil {
+		return s.paymentNotifSvc.ExpirePayment(ctx, order.Order_id)
+	}
+
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return false, errors.New("failed to find payment: " + err.Error())
+	}
+
+	// the buyer never started paying, so there is no payment to expire
+	err = s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		return s.cancelUnpaidOrder(ctx, order)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *orderJobService) cancelUnpaidOrder(ctx context.Context, order *domain.Orders) error {
+	orderID := order.Order_id
+
+	for _, item := range order.Items {
+		if item.Order_Status != string(domain.OrderPending) {
+			continue
+		}
+
+		err := s.orderStatusSvc.Transition(ctx, orderID, item.Product_Id, item.Variant_Id, domain.OrderCancelled, domain.ActorSystem)
+		if err != nil {
+			return err
+		}
+	}
+
+	var req dto.UpdatePaymentReq
+	req.Status = string(domain.PaymentExpired)
+
+	_, err := s.orderRepo.UpdateOrder(ctx, orderID, &req)
+	if err != nil {
+		return errors.New("Failed to update order: " + err.Error())
+	}
+
+	var reqSO dto.OrderSellerUpdateReq
+	reqSO.Payment_Status = req.Status
+
+	_, err = s.sellerOrderRepo.UpdateOrderSeller(ctx, orderID, &reqSO)
+	if err != nil {
+		return errors.New("Failed to update seller order: " + err.Error())
+	}
+
+	return s.reservationSvc.ReleaseStock(ctx, orderID)
+}
+
+// updateSalesReports recalculates the sales report of every store a job has touched.
+func (s *orderJobService) updateSalesReports(ctx context.Context, stores map[string]bool) {
+	for storeID := range stores {
+		seller, err := s.sellerRepo.FindSellerByStoreId(ctx, storeID)
+		if err != nil {
+			log.Println("failed to find seller: ", err)
+			continue
+		}
+
+		err = s.salesReportSvc.UpdateSalesReport(ctx, storeID, seller.Email)
+		if err != nil {
+			log.Println("failed to update sales report: ", err)
+		}
+	}
+}
+
+// shippedAt is when the item was last shipped, items shipped before the
+// status history was recorded fall back to the last update of their order.
+func shippedAt(order *domain.Orders, item *domain.OrderItem) time.Time {
+	at := order.Updated_At
+	for _, history := range item.Status_History {
+		if history.To == domain.OrderShipped {
+			at = history.Changed_At
+		}
+	}
+
+	return at
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+)
+
+type orderStatusService struct {
+	orderRepo       domain.OrderRepository
+	sellerOrderRepo domain.SellerOrderRepository
+}
+
+func NewOrderStatusService(orderRepo domain.OrderRepository, sellerOrderRepo domain.SellerOrderRepository) domain.OrderStatusService {
+	return &orderStatusService{
+		orderRepo:       orderRepo,
+		sellerOrderRepo: sellerOrderRepo,
+	}
+}
+
+// Transition implements domain.OrderStatusService.
+// An illegal move returns a *domain.OrderTransitionError as is, so the caller
+// can tell it apart from a failed write. The writes join the transaction of ctx
+// when there is one.
+func (s *orderStatusService) Transition(ctx context.Context, orderID, productID, variantID string, next domain.OrderStatus, actor domain.OrderActor) error {
+	order, err := s.orderRepo.GetOrder(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to get the order: %w", err)
+	}
+
+	var item *domain.OrderItem
+	for i := range order.Items {
+		if order.Items[i].Is(productID, variantID) {
+			item = &order.Items[i]
+		}
+	}
+
+	if item == nil {
+		return errors.New("no item found in the order")
+	}
+
+	current := domain.OrderStatus(item.Order_Status)
+	if _, err := current.Transition(next, actor); err != nil {
+		return err
+	}
+
+	history := domain.OrderStatusHistory{
+		Actor:      actor,
+		From:       current,
+		To:         next,
+		Changed_At: time.Now(),
+	}
+
+	res, err := s.orderRepo.UpdateItemStatus(ctx, orderID, productID, variantID, history)
+	if err != nil {
+		return fmt.Errorf("failed to update the status user order: %w", err)
+	}
+
+	// the item has moved since we read it, the caller is racing someone else
+	if res.MatchedCount == 0 {
+		return errors.New("order status has changed, try again")
+	}
+
+	res, err = s.sellerOrderRepo.UpdateItemStatus(ctx, orderID, productID, variantID, history)
+	if err != nil {
+		return fmt.Errorf("failed to update the status seller order: %w", err)
+	}
+
+	if res.MatchedCount == 0 {
+		return errors.New("seller order status is not '" + string(current) + "'")
+	}
+
+	return nil
+}
+
+// cancelOrderItem cancels the item of the order sold by sellerEmail, takes its
+// price, and the shipping of a store left with nothing to ship, off both
+// orders and gives its reserved stock back. It joins the
+// transaction of ctx, so the item never ends up cancelled with its stock held.
+func cancelOrderItem(ctx context.Context, orderStatusSvc domain.OrderStatusService, orderRepo domain.OrderRepository,
+	sellerOrderRepo domain.SellerOrderRepository, reservationSvc domain.ReservationService,
+	sellerEmail, orderID, productID, variantID string, price float64, actor domain.OrderActor) error {
+	err := orderStatusSvc.Transition(ctx, orderID, productID, variantID, domain.OrderCancelled, actor)
+	if err != nil {
+		return err
+	}
+
+	_, err = orderRepo.UpdateTotalPrice(ctx, orderID, -price)
+	if err != nil {
+		return fmt.Errorf("failed to update total price order: %w", err)
+	}
+
+	_, err = sellerOrderRepo.UpdateTotalPrice(ctx, sellerEmail, orderID, -price)
+	if err != nil {
+		return fmt.Errorf("failed to update total price seller order: %w", err)
+	}
+
+	sellerOrder, err := sellerOrderRepo.GetSellerOrderByEmailAndId(ctx, sellerEmail, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to get seller order: %w", err)
+	}
+
+	// the store ships nothing once its last item is cancelled
+	if sellerOrder.Shipping_Fee > 0 && allCancelled(sellerOrder.Items) {
+		_, err = orderRepo.UpdateShippingFee(ctx, orderID, -sellerOrder.Shipping_Fee)
+		if err != nil {
+			return fmt.Errorf("failed to update shipping fee order: %w", err)
+		}
+
+		_, err = sellerOrderRepo.UpdateShippingFee(ctx, sellerEmail, orderID, -sellerOrder.Shipping_Fee)
+		if err != nil {
+			return fmt.Errorf("failed to update shipping fee seller order: %w", err)
+		}
+	}
+
+	return reservationSvc.ReleaseItem(ctx, orderID, productID, variantID)
+}
+
+func allCancelled(items []domain.SellerOrderItem) bool {
+	for _, item := range items {
+		if item.Status != string(domain.OrderCancelled) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -12,34 +12,60 @@ import (
 )
 
 type paymentService struct {
-	notifSvc    domain.NotificationService
-	midtransSvc domain.MidtransService
-	repo        domain.PaymentRepository
-	userRepo    domain.UserRepository
+	notifSvc  domain.NotificationService
+	gateway   domain.PaymentGateway
+	repo      domain.PaymentRepository
+	userRepo  domain.UserRepository
+	orderRepo domain.OrderRepository
 }
 
-func NewPaymentService(notifSvc domain.NotificationService, repo domain.PaymentRepository, userRepo domain.UserRepository, midtransSvc domain.MidtransService) domain.PaymentService {
+func NewPaymentService(notifSvc domain.NotificationService, repo domain.PaymentRepository, userRepo domain.UserRepository,
+	orderRepo domain.OrderRepository, gateway domain.PaymentGateway) domain.PaymentService {
 	return &paymentService{
-		notifSvc:    notifSvc,
-		repo:        repo,
-		midtransSvc: midtransSvc,
-		userRepo:    userRepo,
+		notifSvc:  notifSvc,
+		repo:      repo,
+		gateway:   gateway,
+		userRepo:  userRepo,
+		orderRepo: orderRepo,
 	}
 }
 
 // InitializePayment implements domain.PaymentService.
+// The buyer pays the total price of the order, only an extra charge of an
+// order, e.g. for a heavier packed item, is charged at req.Amount.
 func (s *paymentService) InitializePayment(ctx context.Context, req *dto.PaymentReq) (*dto.PaymentRes, error) {
+	orderID := req.OrderID
+	if req.Parent_Order_Id != "" {
+		orderID = req.Parent_Order_Id
+	}
+
+	order, err := s.orderRepo.GetOrder(ctx, orderID, req.UserID)
+	if err != nil {
+		return &dto.PaymentRes{}, errors.New("failed to get the order: " + err.Error())
+	}
+
+	amount := req.Amount
+	if req.Parent_Order_Id == "" {
+		amount = order.Total_Price
+	}
+
+	if amount <= 0 {
+		return &dto.PaymentRes{}, errors.New("there is nothing to pay for this order")
+	}
+
 	payment := domain.Payment{
 		ID:        primitive.NewObjectID(),
 		OrderID:   req.OrderID,
 		UserID:    req.UserID,
 		CreatedAt: time.Now(),
 		UpdateAt:  time.Now(),
-		Status:    "PENDING",
-		Amount:    req.Amount,
+		Status:    string(domain.PaymentPending),
+		Amount:    amount,
+
+		Parent_Order_Id: req.Parent_Order_Id,
 	}
 
-	err := s.midtransSvc.GenerateSnapURL(ctx, &payment)
+	err = s.gateway.CreateCharge(ctx, &payment)
 	if err != nil {
 		return &dto.PaymentRes{}, err
 	}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// paymentEventLock is how long a delivery may work on a payment event before
+// a redelivery of the same event is allowed to take it over.
+const paymentEventLock = time.Minute
+
+var paymentNotifTemplates = map[domain.PaymentStatus]string{
+	domain.PaymentSuccess:         "USER_PAYMENT",
+	domain.PaymentChallenge:       "USER_PAYMENT_CHALLENGE",
+	domain.PaymentExpired:         "USER_PAYMENT_EXPIRED",
+	domain.PaymentDenied:          "USER_PAYMENT_DENIED",
+	domain.PaymentCancelled:       "USER_PAYMENT_CANCELLED",
+	domain.PaymentRefunded:        "USER_PAYMENT_REFUNDED",
+	domain.PaymentPartialRefunded: "USER_PAYMENT_REFUNDED",
+}
+
+type paymentNotificationService struct {
+	gateway         domain.PaymentGateway
+	paymentRepo     domain.PaymentRepository
+	orderRepo       domain.OrderRepository
+	sellerOrderRepo domain.SellerOrderRepository
+	eventRepo       domain.PaymentEventRepository
+	reservationSvc  domain.ReservationService
+	orderStatusSvc  domain.OrderStatusService
+	notifSvc        domain.NotificationService
+	transactor      domain.Transactor
+}
+
+func NewPaymentNotificationService(gateway domain.PaymentGateway, paymentRepo domain.PaymentRepository,
+	orderRepo domain.OrderRepository, sellerOrderRepo domain.SellerOrderRepository,
+	eventRepo domain.PaymentEventRepository, reservationSvc domain.ReservationService,
+	orderStatusSvc domain.OrderStatusService, notifSvc domain.NotificationService,
+	transactor domain.Transactor) domain.PaymentNotificationService {
+	return &paymentNotificationService{
+		gateway:         gateway,
+		paymentRepo:     paymentRepo,
+		orderRepo:       orderRepo,
+		sellerOrderRepo: sellerOrderRepo,
+		eventRepo:       eventRepo,
+		reservationSvc:  reservationSvc,
+		orderStatusSvc:  orderStatusSvc,
+		notifSvc:        notifSvc,
+		transactor:      transactor,
+	}
+}
+
+// HandleNotification implements domain.PaymentNotificationService.
+// Every notification is saved as a payment event first, a notification that was
+// already processed is acknowledged without touching the payment again.
+func (s *paymentNotificationService) HandleNotification(ctx context.Context, payload []byte) (bool, error) {
+	notif, err := s.gateway.ParseWebhook(payload)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	event := domain.PaymentEvent{
+		ID:                 notif.EventID,
+		Transaction_Id:     notif.TransactionID,
+		Order_id:           notif.OrderID,
+		Transaction_Status: notif.TransactionStatus,
+		Fraud_Status:       notif.FraudStatus,
+		Status_Code:        notif.StatusCode,
+		Gross_Amount:       notif.GrossAmount,
+		Payment_Type:       notif.PaymentType,
+		Payload:            string(payload),
+		Locked_Until:       now.Add(paymentEventLock),
+		Received_At:        now,
+	}
+
+	err = s.eventRepo.Insert(ctx, &event)
+	if err != nil {
+		if !mongo.IsDuplicateKeyError(err) {
+			return false, errors.New("failed to save payment event: " + err.Error())
+		}
+
+		// the event was delivered before, only pick it up again when that attempt did not finish
+		claimed, err := s.eventRepo.Claim(ctx, event.ID, now, now.Add(paymentEventLock))
+		if err != nil {
+			return false, errors.New("failed to claim payment event: " + err.Error())
+		}
+
+		if !claimed {
+			existing, err := s.eventRepo.FindById(ctx, event.ID)
+			if err != nil {
+				return false, errors.New("failed to find payment event: " + err.Error())
+			}
+
+			if existing.Processed {
+				return false, nil
+			}
+
+			// let the gateway retry later instead of losing the event
+			return false, errors.New("payment event is still being processed")
+		}
+	}
+
+	success, result, err := s.applyNotification(ctx, notif)
+	if err != nil {
+		if err := s.eventRepo.Unlock(ctx, event.ID); err != nil {
+			log.Println("failed to unlock payment event: ", err)
+		}
+		return false, err
+	}
+
+	err = s.eventRepo.MarkProcessed(ctx, event.ID, result, time.Now())
+	if err != nil {
+		return false, errors.New("failed to mark payment event as processed: " + err.Error())
+	}
+
+	return success, nil
+}
+
+// ExpirePayment implements domain.PaymentNotificationService.
+func (s *paymentNotificationService) ExpirePayment(ctx context.Context, orderID string) (bool, error) {
+	notif, err := s.gateway.CheckStatus(ctx, orderID)
+	if err != nil && !errors.Is(err, domain.ErrChargeNotFound) {
+		return false, errors.New("failed to check payment status: " + err.Error())
+	}
+
+	// the buyer may have paid while the notification got lost, only a payment
+	// that is still pending at the gateway expires
+	if err != nil || notif.Status == "" || notif.Status == domain.PaymentPending {
+		// close the charge first, the buyer could still pay it through the
+		// payment page otherwise. A charge paid in the meantime can not be
+		// closed, the next run picks its status up.
+		err = s.gateway.Expire(ctx, orderID)
+		if err != nil && !errors.Is(err, domain.ErrChargeNotFound) {
+			return false, errors.New("failed to expire payment at the gateway: " + err.Error())
+		}
+
+		notif = &domain.PaymentNotification{
+			OrderID: orderID,
+			Status:  domain.PaymentExpired,
+		}
+	}
+
+	_, result, err := s.applyNotification(ctx, notif)
+	if err != nil {
+		return false, err
+	}
+
+	return result == string(domain.PaymentExpired), nil
+}
+
+func (s *paymentNotificationService) applyNotification(ctx context.Context, notif *domain.PaymentNotification) (bool, string, error) {
+	next := notif.Status
+	if next == "" {
+		log.Println("unknown transaction status: ", notif.TransactionStatus)
+		return false, "IGNORED", nil
+	}
+
+	payment, err := s.paymentRepo.FindByOrderId(ctx, notif.OrderID)
+	if err != nil {
+		return false, "", errors.New("failed to find payment: " + err.Error())
+	}
+
+	current := domain.PaymentStatus(payment.Status)
+	if current == next {
+		return false, "IGNORED", nil
+	}
+
+	// a late or forged notification must never move the payment backwards
+	if _, err := current.Transition(next); err != nil {
+		if next == domain.PaymentSuccess && current.ReleasesStock() {
+			return s.refundLatePayment(ctx, payment, notif)
+		}
+
+		log.Println("rejected payment notification for order "+notif.OrderID+": ", err)
+		return false, "REJECTED", nil
+	}
+
+	// the gateway charges whole rupiah, a settlement of another amount than the
+	// payment was tampered with or belongs to another charge
+	if next == domain.PaymentSuccess && !paidInFull(payment, notif) {
+		log.Println("payment of order "+notif.OrderID+" settled for "+notif.GrossAmount+" instead of ", payment.Amount, ", it needs a manual review")
+		return false, "AMOUNT_MISMATCH", nil
+	}
+
+	err = s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		return s.updatePaymentStatus(ctx, payment, notif, current, next)
+	})
+	if err != nil {
+		return false, "", err
+	}
+
+	s.notifyPaymentStatus(ctx, payment, next)
+
+	return next == domain.PaymentSuccess, string(next), nil
+}
+
+// updatePaymentStatus moves the payment, the user order and the seller orders
+// to the next status together.
+func (s *paymentNotificationService) updatePaymentStatus(ctx context.Context, payment *domain.Payment, notif *domain.PaymentNotification, current, next domain.PaymentStatus) error {
+	orderID := notif.OrderID
+
+	var req dto.UpdatePaymentReq
+	req.Payment_Method = notif.PaymentType
+	req.Status = string(next)
+	req.TransactionID = notif.TransactionID
+
+	res, err := s.paymentRepo.UpdateFromStatus(ctx, orderID, string(current), &req)
+	if err != nil {
+		return errors.New("Failed to update payment: " + err.Error())
+	}
+
+	// another notification has moved the payment since we read it, let the gateway retry
+	if res.MatchedCount == 0 {
+		return errors.New("payment status has changed, try again")
+	}
+
+	// the order was paid with its parent payment, an extra charge leaves it alone
+	if payment.Parent_Order_Id != "" {
+		return nil
+	}
+
+	_, err = s.orderRepo.UpdateOrder(ctx, orderID, &req)
+	if err != nil {
+		return errors.New("Failed to update order: " + err.Error())
+	}
+
+	var reqSO dto.OrderSellerUpdateReq
+	reqSO.Payment_Status = req.Status
+
+	_, err = s.sellerOrderRepo.UpdateOrderSeller(ctx, orderID, &reqSO)
+	if err != nil {
+		return errors.New("Failed to update seller order: " + err.Error())
+	}
+
+	if next == domain.PaymentSuccess {
+		err = s.transitionItems(ctx, orderID, domain.OrderProcessed)
+		if err != nil {
+			return err
+		}
+
+		err = s.reservationSvc.CommitStock(ctx, orderID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the buyer will never pay this order, give the reserved stock back to the sellers
+	if next.ReleasesStock() {
+		err = s.transitionItems(ctx, orderID, domain.OrderCancelled)
+		if err != nil {
+			return err
+		}
+
+		err = s.reservationSvc.ReleaseStock(ctx, orderID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// paidInFull reports whether the gross amount of the notification is the amount of the payment.
+func paidInFull(payment *domain.Payment, notif *domain.PaymentNotification) bool {
+	amount, err := strconv.ParseFloat(notif.GrossAmount, 64)
+	if err != nil {
+		return false
+	}
+
+	return int64(amount) == int64(payment.Amount)
+}
+
+// refundLatePayment gives back a payment made after its order was closed, e.g.
+// at the gateway while the order expired. The order stays closed, its stock
+// is gone already. A refund that fails is retried with the notification.
+func (s *paymentNotificationService) refundLatePayment(ctx context.Context, payment *domain.Payment, notif *domain.PaymentNotification) (bool, string, error) {
+	amount, err := strconv.ParseFloat(notif.GrossAmount, 64)
+	if err != nil || amount <= 0 {
+		amount = payment.Amount
+	}
+
+	err = s.gateway.Refund(ctx, notif.OrderID, "late-"+notif.OrderID, amount, "paid after the order was closed")
+	if err != nil {
+		log.Println("failed to refund the late payment of order "+notif.OrderID+", it needs a manual refund: ", err)
+		return false, "", errors.New("failed to refund late payment: " + err.Error())
+	}
+
+	s.notifyPaymentStatus(ctx, payment, domain.PaymentRefunded)
+
+	return false, "REFUNDED_LATE", nil
+}
+
+// transitionItems moves every item of the order that is still PENDING to next.
+func (s *paymentNotificationService) transitionItems(ctx context.Context, orderID string, next domain.OrderStatus) error {
+	order, err := s.orderRepo.GetOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range order.Items {
+		if item.Order_Status != string(domain.OrderPending) {
+			continue
+		}
+
+		err = s.orderStatusSvc.Transition(ctx, orderID, item.Product_Id, item.Variant_Id, next, domain.ActorSystem)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notifyPaymentStatus tells the buyer about a payment status change.
+func (s *paymentNotificationService) notifyPaymentStatus(ctx context.Context, payment *domain.Payment, status domain.PaymentStatus) {
+	code, ok := paymentNotifTemplates[status]
+	if !ok {
+		return
+	}
+
+	data := map[string]string{
+		"order_id": payment.OrderID,
+		"amount":   fmt.Sprintf("%.2f", payment.Amount),
+	}
+
+	err := s.notifSvc.Insert(ctx, payment.UserID, code, data)
+	if err != nil {
+		log.Println("failed to insert payment notification: ", err)
+	}
+}
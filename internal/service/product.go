@@ -3,37 +3,51 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/IndraSty/GreenBasket/domain"
 	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"github.com/IndraSty/GreenBasket/internal/util"
 	"github.com/asaskevich/govalidator"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type productService struct {
 	repo            domain.ProductRepository
 	storeRepo       domain.StoreRepository
+	sellerRepo      domain.SellerRepository
 	salesReportRepo domain.SalesReportRepository
 	cacheRepo       domain.CacheRepository
+	categorySvc     domain.CategoryService
+	ledgerSvc       domain.StockLedgerService
+	uploadSvc       domain.UploadService
+	searchSvc       domain.ProductSearchService
+	transactor      domain.Transactor
 }
 
-func NewProductService(repo domain.ProductRepository, storeRepo domain.StoreRepository,
-	salesReportRepo domain.SalesReportRepository,
-	cacheRepo domain.CacheRepository) domain.ProductService {
+func NewProductService(repo domain.ProductRepository, storeRepo domain.StoreRepository, sellerRepo domain.SellerRepository,
+	salesReportRepo domain.SalesReportRepository, cacheRepo domain.CacheRepository,
+	categorySvc domain.CategoryService, ledgerSvc domain.StockLedgerService, uploadSvc domain.UploadService,
+	searchSvc domain.ProductSearchService, transactor domain.Transactor) domain.ProductService {
 	return &productService{
 		repo:            repo,
 		storeRepo:       storeRepo,
+		sellerRepo:      sellerRepo,
 		salesReportRepo: salesReportRepo,
 		cacheRepo:       cacheRepo,
+		categorySvc:     categorySvc,
+		ledgerSvc:       ledgerSvc,
+		uploadSvc:       uploadSvc,
+		searchSvc:       searchSvc,
+		transactor:      transactor,
 	}
 }
 
-var validCategories = []string{"Vegetables", "Fruits", "Protein", "Ready to Eat", "Staples",
-	"Snacks", "Mother & Baby", "Spices", "Milk & Dairy", "Breakfast"}
-
 // CreateProduct implements domain.ProductService.
 func (s *productService) CreateProduct(ctx context.Context, storeID, email string, req *dto.ProductReq) (*dto.AddProductRes, error) {
 	_, err := govalidator.ValidateStruct(req)
@@ -50,16 +64,29 @@ func (s *productService) CreateProduct(ctx context.Context, storeID, email strin
 		return nil, errors.New("store not found")
 	}
 
-	isValidCategory := false
-	for _, category := range validCategories {
-		if category == req.Category {
-			isValidCategory = true
-			break
-		}
+	if store.Suspended {
+		return nil, domain.ErrStoreSuspended
+	}
+
+	// an import can still be running when the seller is suspended
+	seller, err := s.sellerRepo.FindSellerByEmail(ctx, store.Email)
+	if err != nil {
+		return nil, errors.New("failed to find seller of the store: " + err.Error())
+	}
+
+	categories, err := s.categorySvc.Resolve(ctx, req.Category, false)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := quantityRule(req)
+	if err != nil {
+		return nil, err
 	}
 
-	if !isValidCategory {
-		return nil, errors.New("invalid category")
+	variants, err := buildVariants(req, nil)
+	if err != nil {
+		return nil, err
 	}
 
 	products, err := s.repo.GetAllProductWithNoPage(ctx, storeID)
@@ -79,23 +106,51 @@ func (s *productService) CreateProduct(ctx context.Context, storeID, email strin
 	req.Price = num
 
 	product := domain.Products{
-		ID:          id,
-		Product_id:  productID,
-		Name:        req.Name,
-		Description: req.Description,
-		Price:       req.Price,
-		Stock:       req.Stok,
-		Category:    req.Category,
-		Created_at:  time.Now(),
-		Updated_at:  time.Now(),
-		Store_id:    storeID,
-		Images:      req.Images,
-	}
-
-	result, err := s.repo.CreateProduct(ctx, product)
+		ID:            id,
+		Product_id:    productID,
+		Name:          req.Name,
+		Description:   req.Description,
+		Price:         req.Price,
+		Stock:         req.Stok,
+		Weight:        req.Weight,
+		Unit:          string(rule.Unit),
+		Min_Quantity:  rule.Min,
+		Quantity_Step: rule.Step,
+		Category:      categories[0],
+		Created_at:    time.Now(),
+		Updated_at:    time.Now(),
+		Store_id:      storeID,
+		Images:        req.Images,
+		Variants:      variants,
+		// hidden like the products AdminService hides on suspension
+		Store_Suspended: store.Suspended || seller.Suspended,
+	}
+
+	var result primitive.ObjectID
+	err = s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = s.repo.CreateProduct(ctx, product)
+		if err != nil {
+			return errors.New("failed to created store" + err.Error())
+		}
+
+		opening := []domain.Variant{{Stock: product.Stock}}
+		if len(variants) > 0 {
+			opening = variants
+		}
+		for _, variant := range opening {
+			err = s.recordStock(ctx, email, storeID, productID, variant.Variant_Id, domain.MovementRestock, variant.Stock, "product created")
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
-		return nil, errors.New("failed to created store" + err.Error())
+		return nil, err
 	}
+	s.syncSearch(ctx, productID)
 
 	return &dto.AddProductRes{
 		InsertId: &result,
@@ -109,6 +164,10 @@ func (s *productService) DeleteProductById(ctx context.Context, storeID, email,
 		return nil, errors.New("store not found" + err.Error())
 	}
 
+	if store.Suspended {
+		return nil, domain.ErrStoreSuspended
+	}
+
 	product, err := s.repo.GetProductById(ctx, productID, storeID)
 	if err != nil || product == nil {
 		return nil, errors.New("product not found" + err.Error())
@@ -119,13 +178,24 @@ func (s *productService) DeleteProductById(ctx context.Context, storeID, email,
 		return nil, errors.New("failed to delete product: " + err.Error())
 	}
 
+	images := product.Images
+	for _, variant := range product.Variants {
+		images = append(images, variant.Images...)
+	}
+
+	// the product is gone either way, a blob left behind is only wasted space
+	if err := s.uploadSvc.Release(ctx, images...); err != nil {
+		log.Println("failed to release images of product "+productID+": ", err)
+	}
+	s.syncSearch(ctx, productID)
+
 	return &dto.DeleteProductRes{
 		DeleteResult: result,
 	}, nil
 }
 
 // GetAllProductSeller implements domain.ProductService.
-func (s *productService) GetAllProduct(ctx context.Context, storeID, email string, page int) (*dto.PagedProducts, error) {
+func (s *productService) GetAllProduct(ctx context.Context, storeID, email string, page pagination.Request) (*dto.PagedProducts, error) {
 	store, err := s.storeRepo.GetStore(ctx, storeID, email)
 	if err != nil || store == nil {
 		return nil, errors.New("store not found" + err.Error())
@@ -139,7 +209,7 @@ func (s *productService) GetAllProduct(ctx context.Context, storeID, email strin
 	productRes := make([]dto.GetProductRes, len(products.Products))
 	for i, product := range products.Products {
 		averageRating := float32(0)
-		totalSales := int64(0)
+		totalSales := float64(0)
 		if product.SalesData != nil {
 			averageRating = product.SalesData.Average_rating
 			totalSales = product.SalesData.Total_sales
@@ -150,10 +220,16 @@ func (s *productService) GetAllProduct(ctx context.Context, storeID, email strin
 			Description:    product.Description,
 			Price:          product.Price,
 			Stok:           product.Stock,
+			Weight:         product.Weight,
+			Unit:           string(domain.UnitOf(product.Unit)),
+			Min_Quantity:   product.Min_Quantity,
+			Quantity_Step:  product.Quantity_Step,
+			Discount:       product.Discount,
 			Product_id:     product.Product_id,
 			Category:       product.Category,
 			Created_at:     product.Created_at,
 			Images:         product.Images,
+			Variants:       variantRes(product.Variants),
 			Store_Name:     store.Name,
 			City:           store.Address_Details.City,
 			Average_Rating: averageRating,
@@ -162,21 +238,28 @@ func (s *productService) GetAllProduct(ctx context.Context, storeID, email strin
 	}
 
 	return &dto.PagedProducts{
-		Products:  productRes,
-		Page:      page,
-		TotalItem: products.TotalItem,
-		LastPage:  products.LastPage,
+		Products:    productRes,
+		Page:        products.Page,
+		Size:        products.Size,
+		TotalItem:   products.TotalItem,
+		LastPage:    products.LastPage,
+		Next_Cursor: products.Next_Cursor,
 	}, nil
 }
 
 // GetAllProductSellerByCategory implements domain.ProductService.
-func (s *productService) GetAllByCategory(ctx context.Context, email, storeID string, category string, page int) (*dto.PagedProducts, error) {
+func (s *productService) GetAllByCategory(ctx context.Context, email, storeID string, category string, descendants bool, page pagination.Request) (*dto.PagedProducts, error) {
 	store, err := s.storeRepo.GetStore(ctx, storeID, email)
 	if err != nil || store == nil {
 		return nil, errors.New("store not found" + err.Error())
 	}
 
-	products, err := s.repo.GetAllByCategory(ctx, category, page, storeID)
+	categories, err := s.categorySvc.Resolve(ctx, category, descendants)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := s.repo.GetAllByCategory(ctx, categories, page, storeID)
 	if err != nil {
 		return nil, errors.New("failed to get all products: " + err.Error())
 	}
@@ -189,7 +272,7 @@ func (s *productService) GetAllByCategory(ctx context.Context, email, storeID st
 		}
 
 		averageRating := float32(0)
-		totalSales := int64(0)
+		totalSales := float64(0)
 		if product.SalesData != nil {
 			averageRating = product.SalesData.Average_rating
 			totalSales = product.SalesData.Total_sales
@@ -200,10 +283,16 @@ func (s *productService) GetAllByCategory(ctx context.Context, email, storeID st
 			Description:    product.Description,
 			Price:          product.Price,
 			Stok:           product.Stock,
+			Weight:         product.Weight,
+			Unit:           string(domain.UnitOf(product.Unit)),
+			Min_Quantity:   product.Min_Quantity,
+			Quantity_Step:  product.Quantity_Step,
+			Discount:       product.Discount,
 			Product_id:     product.Product_id,
 			Category:       product.Category,
 			Created_at:     product.Created_at,
 			Images:         product.Images,
+			Variants:       variantRes(product.Variants),
 			Store_Name:     store.Name,
 			City:           store.Address_Details.City,
 			Average_Rating: averageRating,
@@ -212,10 +301,12 @@ func (s *productService) GetAllByCategory(ctx context.Context, email, storeID st
 	}
 
 	return &dto.PagedProducts{
-		Products:  productRes,
-		Page:      page,
-		TotalItem: products.TotalItem,
-		LastPage:  products.LastPage,
+		Products:    productRes,
+		Page:        products.Page,
+		Size:        products.Size,
+		TotalItem:   products.TotalItem,
+		LastPage:    products.LastPage,
+		Next_Cursor: products.Next_Cursor,
 	}, nil
 }
 
@@ -236,7 +327,7 @@ func (s *productService) GetProductById(ctx context.Context, storeID, email, pro
 	}
 
 	averageRating := float32(0)
-	totalSales := int64(0)
+	totalSales := float64(0)
 	if product.SalesData != nil {
 		averageRating = product.SalesData.Average_rating
 		totalSales = product.SalesData.Total_sales
@@ -247,6 +338,11 @@ func (s *productService) GetProductById(ctx context.Context, storeID, email, pro
 		Description:    product.Description,
 		Price:          product.Price,
 		Stok:           product.Stock,
+		Weight:         product.Weight,
+		Unit:           string(domain.UnitOf(product.Unit)),
+		Min_Quantity:   product.Min_Quantity,
+		Quantity_Step:  product.Quantity_Step,
+		Discount:       product.Discount,
 		Product_id:     product.Product_id,
 		Category:       product.Category,
 		Created_at:     product.Created_at,
@@ -255,17 +351,26 @@ func (s *productService) GetProductById(ctx context.Context, storeID, email, pro
 		Total_Sales:    totalSales,
 		City:           store.Address_Details.City,
 		Images:         product.Images,
+		Variants:       variantRes(product.Variants),
 	}, nil
 }
 
 // SearchSellerProduct implements domain.ProductService.
-func (s *productService) SearchProduct(ctx context.Context, email, storeID, query string, page int) (*dto.PagedProducts, error) {
+func (s *productService) SearchProduct(ctx context.Context, email, storeID, query string, page pagination.Request) (*dto.PagedProducts, error) {
 	store, err := s.storeRepo.GetStore(ctx, storeID, email)
 	if err != nil || store == nil {
 		return nil, errors.New("store not found" + err.Error())
 	}
 
-	products, err := s.repo.GetAllProductByQuery(ctx, query, page, storeID)
+	var productIDs []string
+	if strings.TrimSpace(query) != "" {
+		productIDs, err = s.searchSvc.Search(ctx, query, storeID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	products, err := s.repo.GetAllProductByIds(ctx, productIDs, page, storeID)
 	if err != nil {
 		return nil, errors.New("failed to get all products by the query: " + err.Error())
 	}
@@ -273,7 +378,7 @@ func (s *productService) SearchProduct(ctx context.Context, email, storeID, quer
 	productRes := make([]dto.GetProductRes, len(products.Products))
 	for i, product := range products.Products {
 		averageRating := float32(0)
-		totalSales := int64(0)
+		totalSales := float64(0)
 		if product.SalesData != nil {
 			averageRating = product.SalesData.Average_rating
 			totalSales = product.SalesData.Total_sales
@@ -284,10 +389,16 @@ func (s *productService) SearchProduct(ctx context.Context, email, storeID, quer
 			Description:    product.Description,
 			Price:          product.Price,
 			Stok:           product.Stock,
+			Weight:         product.Weight,
+			Unit:           string(domain.UnitOf(product.Unit)),
+			Min_Quantity:   product.Min_Quantity,
+			Quantity_Step:  product.Quantity_Step,
+			Discount:       product.Discount,
 			Product_id:     product.Product_id,
 			Category:       product.Category,
 			Created_at:     product.Created_at,
 			Images:         product.Images,
+			Variants:       variantRes(product.Variants),
 			Store_Name:     store.Name,
 			City:           store.Address_Details.City,
 			Average_Rating: averageRating,
@@ -296,10 +407,12 @@ func (s *productService) SearchProduct(ctx context.Context, email, storeID, quer
 	}
 
 	return &dto.PagedProducts{
-		Products:  productRes,
-		Page:      page,
-		TotalItem: products.TotalItem,
-		LastPage:  products.LastPage,
+		Products:    productRes,
+		Page:        products.Page,
+		Size:        products.Size,
+		TotalItem:   products.TotalItem,
+		LastPage:    products.LastPage,
+		Next_Cursor: products.Next_Cursor,
 	}, nil
 }
 
@@ -334,74 +447,65 @@ func (s *productService) UpdateProduct(ctx context.Context, storeID, email, prod
 	if product.Price != 0 {
 		update = append(update, bson.E{Key: "price", Value: product.Price})
 	}
-	if product.Category != "" {
-		update = append(update, bson.E{Key: "category", Value: product.Category})
+	if req.Category != "" {
+		categories, err := s.categorySvc.Resolve(ctx, req.Category, false)
+		if err != nil {
+			return nil, err
+		}
+		update = append(update, bson.E{Key: "category", Value: categories[0]})
 	}
 	if product.Stock != 0 {
 		update = append(update, bson.E{Key: "stock", Value: product.Stock})
 	}
-
-	update = append(update, bson.E{Key: "updated_at", Value: updateAT})
-
-	result, err := s.repo.UpdateProduct(ctx, storeID, productID, update)
-	if err != nil {
-		return nil, errors.New("Failed to update the product: " + err.Error())
+	if req.Weight != 0 {
+		update = append(update, bson.E{Key: "weight", Value: req.Weight})
 	}
-
-	return &dto.EditProductRes{
-		UpdateResult: result,
-	}, nil
-}
-
-// GetAllProductSorted implements domain.ProductService.
-func (s *productService) GetAllProductSorted(ctx context.Context, sortParams map[string]string, page int, email, storeID string) (*dto.PagedProducts, error) {
-	store, err := s.storeRepo.GetStore(ctx, storeID, email)
-	if err != nil || store == nil {
-		return nil, errors.New("store not found" + err.Error())
+	if req.Unit != "" {
+		rule, err := quantityRule(req)
+		if err != nil {
+			return nil, err
+		}
+		update = append(update, bson.E{Key: "unit", Value: rule.Unit}, bson.E{Key: "min_quantity", Value: rule.Min},
+			bson.E{Key: "quantity_step", Value: rule.Step})
 	}
-
-	products, err := s.repo.GetAllProductSorted(ctx, sortParams, page, storeID)
-	if err != nil {
-		return nil, errors.New("failed to get all sorted products: " + err.Error())
+	var variants []domain.Variant
+	if len(req.Variants) != 0 {
+		variants, err = buildVariants(req, product.Variants)
+		if err != nil {
+			return nil, err
+		}
+		update = append(update, bson.E{Key: "variants", Value: variants}, bson.E{Key: "price", Value: req.Price}, bson.E{Key: "stock", Value: req.Stok})
 	}
 
-	productRes := make([]dto.GetProductRes, len(products.Products))
-	for i, product := range products.Products {
-		averageRating := float32(0)
-		totalSales := int64(0)
-		if product.SalesData != nil {
-			averageRating = product.SalesData.Average_rating
-			totalSales = product.SalesData.Total_sales
+	update = append(update, bson.E{Key: "updated_at", Value: updateAT})
+
+	var result *mongo.UpdateResult
+	err = s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = s.repo.UpdateProduct(ctx, storeID, productID, update)
+		if err != nil {
+			return errors.New("Failed to update the product: " + err.Error())
 		}
 
-		productRes[i] = dto.GetProductRes{
-			Name:           product.Name,
-			Description:    product.Description,
-			Price:          product.Price,
-			Stok:           product.Stock,
-			Product_id:     product.Product_id,
-			Category:       product.Category,
-			Created_at:     product.Created_at,
-			Images:         product.Images,
-			Store_Name:     store.Name,
-			City:           store.Address_Details.City,
-			Average_Rating: averageRating,
-			Total_Sales:    totalSales,
+		if variants == nil {
+			return nil
 		}
+		return s.recordVariantStock(ctx, email, storeID, product, variants)
+	})
+	if err != nil {
+		return nil, err
 	}
+	s.syncSearch(ctx, productID)
 
-	return &dto.PagedProducts{
-		Products:  productRes,
-		Page:      page,
-		TotalItem: products.TotalItem,
-		LastPage:  products.LastPage,
+	return &dto.EditProductRes{
+		UpdateResult: result,
 	}, nil
 }
 
 // user / guest
 
 // GetAllProductForGuest implements domain.ProductService.
-func (s *productService) GetAllProductForGuest(ctx context.Context, page int) (*dto.PagedProducts, error) {
+func (s *productService) GetAllProductForGuest(ctx context.Context, page pagination.Request) (*dto.PagedProducts, error) {
 	products, err := s.repo.GetAllProduct(ctx, page)
 	if err != nil {
 		return nil, errors.New("failed to get all products: " + err.Error())
@@ -415,7 +519,7 @@ func (s *productService) GetAllProductForGuest(ctx context.Context, page int) (*
 		}
 
 		var average_rating float32
-		var total_sales int64
+		var total_sales float64
 		salesReport, err := s.salesReportRepo.GetByStoreId(ctx, product.Store_id)
 		if err != nil {
 			return nil, errors.New("failed to get sales report by store id: " + err.Error())
@@ -434,10 +538,16 @@ func (s *productService) GetAllProductForGuest(ctx context.Context, page int) (*
 			Description:    product.Description,
 			Price:          product.Price,
 			Stok:           product.Stock,
+			Weight:         product.Weight,
+			Unit:           string(domain.UnitOf(product.Unit)),
+			Min_Quantity:   product.Min_Quantity,
+			Quantity_Step:  product.Quantity_Step,
+			Discount:       product.Discount,
 			Product_id:     product.Product_id,
 			Category:       product.Category,
 			Created_at:     product.Created_at,
 			Images:         product.Images,
+			Variants:       variantRes(product.Variants),
 			Store_Name:     store.Name,
 			City:           store.Address_Details.City,
 			Average_Rating: average_rating,
@@ -446,28 +556,23 @@ func (s *productService) GetAllProductForGuest(ctx context.Context, page int) (*
 	}
 
 	return &dto.PagedProducts{
-		Products:  productRes,
-		Page:      page,
-		TotalItem: products.TotalItem,
-		LastPage:  products.LastPage,
+		Products:    productRes,
+		Page:        products.Page,
+		Size:        products.Size,
+		TotalItem:   products.TotalItem,
+		LastPage:    products.LastPage,
+		Next_Cursor: products.Next_Cursor,
 	}, nil
 }
 
 // GetAllByCategory implements domain.ProductService.
-func (s *productService) GetAllByCategoryForGuest(ctx context.Context, category string, page int) (*dto.PagedProducts, error) {
-	isValidCategory := false
-	for _, categoryItem := range validCategories {
-		if category == categoryItem {
-			isValidCategory = true
-			break
-		}
-	}
-
-	if !isValidCategory {
-		return nil, errors.New("invalid category")
+func (s *productService) GetAllByCategoryForGuest(ctx context.Context, category string, descendants bool, page pagination.Request) (*dto.PagedProducts, error) {
+	categories, err := s.categorySvc.Resolve(ctx, category, descendants)
+	if err != nil {
+		return nil, err
 	}
 
-	products, err := s.repo.GetAllByCategory(ctx, category, page)
+	products, err := s.repo.GetAllByCategory(ctx, categories, page)
 	if err != nil {
 		return nil, errors.New("failed to get all products: " + err.Error())
 	}
@@ -480,7 +585,7 @@ func (s *productService) GetAllByCategoryForGuest(ctx context.Context, category
 		}
 
 		averageRating := float32(0)
-		totalSales := int64(0)
+		totalSales := float64(0)
 		if product.SalesData != nil {
 			averageRating = product.SalesData.Average_rating
 			totalSales = product.SalesData.Total_sales
@@ -491,10 +596,16 @@ func (s *productService) GetAllByCategoryForGuest(ctx context.Context, category
 			Description:    product.Description,
 			Price:          product.Price,
 			Stok:           product.Stock,
+			Weight:         product.Weight,
+			Unit:           string(domain.UnitOf(product.Unit)),
+			Min_Quantity:   product.Min_Quantity,
+			Quantity_Step:  product.Quantity_Step,
+			Discount:       product.Discount,
 			Product_id:     product.Product_id,
 			Category:       product.Category,
 			Created_at:     product.Created_at,
 			Images:         product.Images,
+			Variants:       variantRes(product.Variants),
 			Store_Name:     store.Name,
 			City:           store.Address_Details.City,
 			Average_Rating: averageRating,
@@ -503,10 +614,12 @@ func (s *productService) GetAllByCategoryForGuest(ctx context.Context, category
 	}
 
 	return &dto.PagedProducts{
-		Products:  productRes,
-		Page:      page,
-		TotalItem: products.TotalItem,
-		LastPage:  products.LastPage,
+		Products:    productRes,
+		Page:        products.Page,
+		Size:        products.Size,
+		TotalItem:   products.TotalItem,
+		LastPage:    products.LastPage,
+		Next_Cursor: products.Next_Cursor,
 	}, nil
 }
 
@@ -517,13 +630,18 @@ func (s *productService) GetProductByIdForGuest(ctx context.Context, productID s
 		return nil, errors.New("failed to get product by id: " + err.Error())
 	}
 
+	// taken down by an admin
+	if product.Unpublished || product.Store_Suspended {
+		return nil, errors.New("failed to get product by id: product not found")
+	}
+
 	store, err := s.storeRepo.GetStore(ctx, product.Store_id)
 	if err != nil {
 		return nil, errors.New("failed to get store by id: " + err.Error())
 	}
 
 	var average_rating float32
-	var total_sales int64
+	var total_sales float64
 	salesReport, err := s.salesReportRepo.GetByStoreId(ctx, product.Store_id)
 	if err != nil {
 		return nil, errors.New("failed to get sales report by store id: " + err.Error())
@@ -542,10 +660,16 @@ func (s *productService) GetProductByIdForGuest(ctx context.Context, productID s
 		Description:    product.Description,
 		Price:          product.Price,
 		Stok:           product.Stock,
+		Weight:         product.Weight,
+		Unit:           string(domain.UnitOf(product.Unit)),
+		Min_Quantity:   product.Min_Quantity,
+		Quantity_Step:  product.Quantity_Step,
+		Discount:       product.Discount,
 		Product_id:     product.Product_id,
 		Category:       product.Category,
 		Created_at:     product.Created_at,
 		Images:         product.Images,
+		Variants:       variantRes(product.Variants),
 		Store_Name:     store.Name,
 		City:           store.Address_Details.City,
 		Average_Rating: average_rating,
@@ -556,8 +680,17 @@ func (s *productService) GetProductByIdForGuest(ctx context.Context, productID s
 }
 
 // SearchProductForGuest implements domain.ProductService.
-func (s *productService) SearchProductForGuest(ctx context.Context, page int, query ...string) (*dto.PagedProducts, error) {
-	products, err := s.repo.GetAllProductByQueryForCust(ctx, page, query[0], query[1])
+func (s *productService) SearchProductForGuest(ctx context.Context, query string, page pagination.Request) (*dto.PagedProducts, error) {
+	var productIDs []string
+	if strings.TrimSpace(query) != "" {
+		var err error
+		productIDs, err = s.searchSvc.Search(ctx, query, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	products, err := s.repo.GetAllProductByIds(ctx, productIDs, page)
 	if err != nil {
 		return nil, errors.New("failed to get all products by the query: " + err.Error())
 	}
@@ -570,7 +703,7 @@ func (s *productService) SearchProductForGuest(ctx context.Context, page int, qu
 		}
 
 		averageRating := float32(0)
-		totalSales := int64(0)
+		totalSales := float64(0)
 		if product.SalesData != nil {
 			averageRating = product.SalesData.Average_rating
 			totalSales = product.SalesData.Total_sales
@@ -581,10 +714,16 @@ func (s *productService) SearchProductForGuest(ctx context.Context, page int, qu
 			Description:    product.Description,
 			Price:          product.Price,
 			Stok:           product.Stock,
+			Weight:         product.Weight,
+			Unit:           string(domain.UnitOf(product.Unit)),
+			Min_Quantity:   product.Min_Quantity,
+			Quantity_Step:  product.Quantity_Step,
+			Discount:       product.Discount,
 			Product_id:     product.Product_id,
 			Category:       product.Category,
 			Created_at:     product.Created_at,
 			Images:         product.Images,
+			Variants:       variantRes(product.Variants),
 			Store_Name:     store.Name,
 			City:           store.Address_Details.City,
 			Average_Rating: averageRating,
@@ -593,18 +732,49 @@ func (s *productService) SearchProductForGuest(ctx context.Context, page int, qu
 	}
 
 	return &dto.PagedProducts{
-		Products:  productRes,
-		Page:      page,
-		TotalItem: products.TotalItem,
-		LastPage:  products.LastPage,
+		Products:    productRes,
+		Page:        products.Page,
+		Size:        products.Size,
+		TotalItem:   products.TotalItem,
+		LastPage:    products.LastPage,
+		Next_Cursor: products.Next_Cursor,
 	}, nil
 }
 
-// GetAllProductSorted implements domain.ProductService.
-func (s *productService) GetAllProductSortedForCust(ctx context.Context, sortParams map[string]string, page int) (*dto.PagedProducts, error) {
-	products, err := s.repo.GetAllProductSorted(ctx, sortParams, page)
+// GetCatalogue implements domain.ProductService.
+func (s *productService) GetCatalogue(ctx context.Context, query *dto.CatalogueQuery, page pagination.Request) (*dto.CatalogueRes, error) {
+	if query.Min_Price < 0 || query.Max_Price < 0 || query.Min_Rating < 0 ||
+		(query.Max_Price > 0 && query.Min_Price > query.Max_Price) {
+		return nil, domain.ErrInvalidCatalogueQuery
+	}
+	filter := domain.CatalogueFilter{
+		Min_Price:  query.Min_Price,
+		Max_Price:  query.Max_Price,
+		Min_Rating: query.Min_Rating,
+		In_Stock:   query.In_Stock,
+		City:       strings.TrimSpace(query.City),
+		Store_Id:   query.Store_Id,
+	}
+
+	if query.Category != "" {
+		categories, err := s.categorySvc.Resolve(ctx, query.Category, query.Descendants)
+		if err != nil {
+			return nil, err
+		}
+		filter.Categories = categories
+	}
+
+	if strings.TrimSpace(query.Key) != "" {
+		productIDs, err := s.searchSvc.Search(ctx, query.Key, query.Store_Id)
+		if err != nil {
+			return nil, err
+		}
+		filter.Product_Ids = productIDs
+	}
+
+	products, err := s.repo.GetCatalogue(ctx, filter, page)
 	if err != nil {
-		return nil, errors.New("failed to get all sorted products: " + err.Error())
+		return nil, err
 	}
 
 	productRes := make([]dto.GetProductRes, len(products.Products))
@@ -615,32 +785,199 @@ func (s *productService) GetAllProductSortedForCust(ctx context.Context, sortPar
 		}
 
 		averageRating := float32(0)
-		totalSales := int64(0)
+		totalSales := float64(0)
 		if product.SalesData != nil {
 			averageRating = product.SalesData.Average_rating
 			totalSales = product.SalesData.Total_sales
 		}
 
+		var city string
+		if store.Address_Details != nil {
+			city = store.Address_Details.City
+		}
+
 		productRes[i] = dto.GetProductRes{
 			Name:           product.Name,
 			Description:    product.Description,
 			Price:          product.Price,
 			Stok:           product.Stock,
+			Weight:         product.Weight,
+			Unit:           string(domain.UnitOf(product.Unit)),
+			Min_Quantity:   product.Min_Quantity,
+			Quantity_Step:  product.Quantity_Step,
+			Discount:       product.Discount,
 			Product_id:     product.Product_id,
 			Category:       product.Category,
 			Created_at:     product.Created_at,
 			Images:         product.Images,
+			Variants:       variantRes(product.Variants),
 			Store_Name:     store.Name,
-			City:           store.Address_Details.City,
+			City:           city,
 			Average_Rating: averageRating,
 			Total_Sales:    totalSales,
 		}
 	}
 
-	return &dto.PagedProducts{
-		Products:  productRes,
-		Page:      page,
-		TotalItem: products.TotalItem,
-		LastPage:  products.LastPage,
+	return &dto.CatalogueRes{
+		PagedProducts: dto.PagedProducts{
+			Products:    productRes,
+			Page:        products.Page,
+			Size:        products.Size,
+			TotalItem:   products.TotalItem,
+			LastPage:    products.LastPage,
+			Next_Cursor: products.Next_Cursor,
+		},
+		Facets: products.Facets,
 	}, nil
 }
+
+// recordVariantStock writes the stock the variants of product were set to by
+// the seller as adjustments, a variant that is left out loses its stock.
+func (s *productService) recordVariantStock(ctx context.Context, email, storeID string, product *domain.ProductWithSalesData, variants []domain.Variant) error {
+	before := map[string]float64{}
+	if len(product.Variants) == 0 {
+		before[""] = product.Stock
+	}
+	for _, variant := range product.Variants {
+		before[variant.Variant_Id] = variant.Stock
+	}
+
+	for _, variant := range variants {
+		if diff := variant.Stock - before[variant.Variant_Id]; diff != 0 {
+			err := s.recordStock(ctx, email, storeID, product.Product_id, variant.Variant_Id, domain.MovementAdjustment, diff, "variant updated")
+			if err != nil {
+				return err
+			}
+		}
+		delete(before, variant.Variant_Id)
+	}
+
+	for variantID, stock := range before {
+		if stock != 0 {
+			err := s.recordStock(ctx, email, storeID, product.Product_id, variantID, domain.MovementAdjustment, -stock, "variant removed")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// syncSearch tells the search index about a change of the product, the
+// change is saved already so a failure is only logged.
+func (s *productService) syncSearch(ctx context.Context, productID string) {
+	if err := s.searchSvc.SyncProducts(ctx, productID); err != nil {
+		log.Println("failed to sync product "+productID+" to the search index: ", err)
+	}
+}
+
+// recordStock writes a movement of the stock of a product, in the transaction
+// of ctx that changed the stock.
+func (s *productService) recordStock(ctx context.Context, email, storeID, productID, variantID string,
+	movementType domain.StockMovementType, quantity float64, reason string) error {
+	return s.ledgerSvc.Record(ctx, domain.StockMovement{
+		Store_Id:   storeID,
+		Product_Id: productID,
+		Variant_Id: variantID,
+		Type:       movementType,
+		Quantity:   quantity,
+		Actor:      email,
+		Reason:     reason,
+	})
+}
+
+// buildVariants validates the variants of req and sets req.Price and req.Stok
+// from them, existing keeps the ids of the variants that are updated. Without
+// variants the product needs its own price and stock.
+func buildVariants(req *dto.ProductReq, existing []domain.Variant) ([]domain.Variant, error) {
+	if len(req.Variants) == 0 {
+		if req.Price == 0 || req.Stok == 0 {
+			return nil, errors.New("Invalid request body: price and stock are required")
+		}
+		return nil, nil
+	}
+
+	variants := make([]domain.Variant, len(req.Variants))
+	skus := make(map[string]bool)
+	req.Price, req.Stok = 0, 0
+
+	for i, item := range req.Variants {
+		_, err := govalidator.ValidateStruct(item)
+		if err != nil {
+			return nil, errors.New("Invalid variant: " + err.Error())
+		}
+
+		if skus[item.SKU] {
+			return nil, errors.New("duplicate variant sku " + item.SKU)
+		}
+		skus[item.SKU] = true
+
+		if item.Stock < 0 {
+			return nil, errors.New("variant stock can not be negative")
+		}
+
+		variantID := primitive.NewObjectID().Hex()
+		if item.Variant_Id != "" {
+			if _, err := domain.FindVariant(existing, item.Variant_Id); err != nil {
+				return nil, err
+			}
+			variantID = item.Variant_Id
+		}
+
+		price := util.ToFixed(item.Price, 2)
+		variants[i] = domain.Variant{
+			Variant_Id: variantID,
+			Name:       item.Name,
+			SKU:        item.SKU,
+			Attributes: item.Attributes,
+			Price:      price,
+			Stock:      item.Stock,
+			Images:     item.Images,
+		}
+
+		if req.Price == 0 || price < req.Price {
+			req.Price = price
+		}
+		req.Stok += item.Stock
+	}
+
+	return variants, nil
+}
+
+// quantityRule validates the unit of measure of req, a product without a unit
+// is sold by the piece.
+func quantityRule(req *dto.ProductReq) (domain.QuantityRule, error) {
+	rule := domain.QuantityRule{
+		Unit: domain.UnitOf(req.Unit),
+		Min:  req.Min_Quantity,
+		Step: req.Quantity_Step,
+	}
+
+	if err := rule.Validate(); err != nil {
+		return rule, errors.New("Invalid request body: " + err.Error())
+	}
+
+	return rule, nil
+}
+
+func variantRes(variants []domain.Variant) []dto.VariantRes {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	res := make([]dto.VariantRes, len(variants))
+	for i, variant := range variants {
+		res[i] = dto.VariantRes{
+			Variant_Id: variant.Variant_Id,
+			Name:       variant.Name,
+			SKU:        variant.SKU,
+			Attributes: variant.Attributes,
+			Price:      variant.Price,
+			Stock:      variant.Stock,
+			Images:     variant.Images,
+		}
+	}
+
+	return res
+}
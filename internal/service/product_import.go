@@ -0,0 +1,362 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// maxImportRows keeps one import from holding a store for too long.
+	maxImportRows = 1000
+	// importProgressEvery is how many rows are created between two saves of the job.
+	importProgressEvery = 50
+	importTimeout       = 30 * time.Minute
+)
+
+// importColumns are the CSV columns of an import and an export, images are
+// separated by "|". A product with variants is imported from JSON lines, in
+// CSV it is exported with its lowest price and total stock.
+var importColumns = []string{"name", "description", "price", "stock", "weight", "unit",
+	"min_quantity", "quantity_step", "category", "images"}
+
+// requiredImportColumns must be in the header of a CSV import.
+var requiredImportColumns = []string{"name", "description", "price", "stock", "category", "images"}
+
+type productImportService struct {
+	repo        domain.ImportJobRepository
+	productSvc  domain.ProductService
+	productRepo domain.ProductRepository
+	storeRepo   domain.StoreRepository
+}
+
+func NewProductImportService(repo domain.ImportJobRepository, productSvc domain.ProductService,
+	productRepo domain.ProductRepository, storeRepo domain.StoreRepository) domain.ProductImportService {
+	return &productImportService{
+		repo:        repo,
+		productSvc:  productSvc,
+		productRepo: productRepo,
+		storeRepo:   storeRepo,
+	}
+}
+
+// importRow is a row of an import file, err is set when the row could not be read.
+type importRow struct {
+	line int
+	req  *dto.ProductReq
+	err  error
+}
+
+// Import implements domain.ProductImportService.
+// The file is read before the job starts, so a file that can not be read at
+// all is refused right away while a bad row only fails that row.
+func (s *productImportService) Import(ctx context.Context, email, storeID, format string, file io.Reader) (*dto.ImportJobRes, error) {
+	store, err := s.storeRepo.GetStore(ctx, storeID, email)
+	if err != nil {
+		return nil, errors.New("failed to find store: " + err.Error())
+	}
+
+	if store == nil {
+		return nil, errors.New("store not found")
+	}
+
+	if store.Suspended {
+		return nil, domain.ErrStoreSuspended
+	}
+
+	var rows []importRow
+	switch format {
+	case domain.ImportFormatCSV:
+		rows, err = readCSVRows(file)
+	case domain.ImportFormatJSONL:
+		rows, err = readJSONLRows(file)
+	default:
+		return nil, errors.New("unknown import format " + format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("the file has no products")
+	}
+
+	if len(rows) > maxImportRows {
+		return nil, fmt.Errorf("an import can have at most %d products", maxImportRows)
+	}
+
+	now := time.Now()
+	job := domain.ImportJob{
+		ID:         primitive.NewObjectID(),
+		Job_Id:     primitive.NewObjectID().Hex(),
+		Store_Id:   storeID,
+		Email:      email,
+		Format:     format,
+		Status:     domain.ImportPending,
+		Total_Rows: len(rows),
+		Errors:     []domain.ImportRowError{},
+		Created_At: now,
+		Updated_At: now,
+	}
+
+	_, err = s.repo.Insert(ctx, job)
+	if err != nil {
+		return nil, errors.New("failed to insert import job: " + err.Error())
+	}
+
+	go s.run(job, rows)
+
+	return &dto.ImportJobRes{
+		Job_Id:     job.Job_Id,
+		Total_Rows: job.Total_Rows,
+	}, nil
+}
+
+// run creates the rows of job one by one through ProductService, so every
+// row goes through the checks of a product added by hand.
+func (s *productImportService) run(job domain.ImportJob, rows []importRow) {
+	ctx, cancel := context.WithTimeout(context.Background(), importTimeout)
+	defer cancel()
+
+	created := 0
+	rowErrors := []domain.ImportRowError{}
+	s.saveProgress(ctx, job.Job_Id, domain.ImportRunning, created, rowErrors)
+
+	for i, row := range rows {
+		err := row.err
+		if err == nil {
+			_, err = s.productSvc.CreateProduct(ctx, job.Store_Id, job.Email, row.req)
+		}
+
+		if err != nil {
+			rowError := domain.ImportRowError{Row: row.line, Error: err.Error()}
+			if row.req != nil {
+				rowError.Name = row.req.Name
+			}
+			rowErrors = append(rowErrors, rowError)
+		} else {
+			created++
+		}
+
+		if (i+1)%importProgressEvery == 0 && i+1 < len(rows) {
+			s.saveProgress(ctx, job.Job_Id, domain.ImportRunning, created, rowErrors)
+		}
+	}
+
+	s.saveProgress(ctx, job.Job_Id, domain.ImportDone, created, rowErrors)
+}
+
+func (s *productImportService) saveProgress(ctx context.Context, jobID, status string, created int, rowErrors []domain.ImportRowError) {
+	if err := s.repo.UpdateProgress(ctx, jobID, status, created, rowErrors, time.Now()); err != nil {
+		log.Println("failed to save import job "+jobID+": ", err)
+	}
+}
+
+// GetImportJob implements domain.ProductImportService.
+func (s *productImportService) GetImportJob(ctx context.Context, email, storeID, jobID string) (*domain.ImportJob, error) {
+	if err := s.checkStore(ctx, storeID, email); err != nil {
+		return nil, err
+	}
+
+	job, err := s.repo.FindById(ctx, storeID, jobID)
+	if err != nil {
+		return nil, errors.New("failed to get import job: " + err.Error())
+	}
+
+	return job, nil
+}
+
+// Export implements domain.ProductImportService.
+func (s *productImportService) Export(ctx context.Context, email, storeID string, w io.Writer) error {
+	if err := s.checkStore(ctx, storeID, email); err != nil {
+		return err
+	}
+
+	products, err := s.productRepo.GetAllProductWithNoPage(ctx, storeID)
+	if err != nil {
+		return errors.New("failed to get all product in this store: " + err.Error())
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(importColumns); err != nil {
+		return err
+	}
+
+	for _, product := range *products {
+		err := writer.Write([]string{
+			product.Name,
+			product.Description,
+			formatFloat(product.Price),
+			formatFloat(product.Stock),
+			strconv.Itoa(product.Weight),
+			string(domain.UnitOf(product.Unit)),
+			formatFloat(product.Min_Quantity),
+			formatFloat(product.Quantity_Step),
+			product.Category,
+			strings.Join(product.Images, "|"),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func (s *productImportService) checkStore(ctx context.Context, storeID, email string) error {
+	store, err := s.storeRepo.GetStore(ctx, storeID, email)
+	if err != nil {
+		return errors.New("failed to find store: " + err.Error())
+	}
+
+	if store == nil {
+		return errors.New("store not found")
+	}
+
+	return nil
+}
+
+// readCSVRows reads a CSV with a header of importColumns, in any order.
+func readCSVRows(file io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("the file has no products")
+	}
+	if err != nil {
+		return nil, errors.New("failed to read the csv header: " + err.Error())
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		// spreadsheets save the header with a byte order mark
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range requiredImportColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, errors.New("the csv header has no column " + name)
+		}
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		// a malformed quote leaves the reader out of step with the rows
+		if err != nil {
+			return nil, errors.New("failed to read the csv: " + err.Error())
+		}
+
+		// a quoted field can span lines, the row is where it starts
+		line, _ := reader.FieldPos(0)
+		req, err := csvProduct(record, columns)
+		rows = append(rows, importRow{line: line, req: req, err: err})
+	}
+
+	return rows, nil
+}
+
+func csvProduct(record []string, columns map[string]int) (*dto.ProductReq, error) {
+	value := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	req := dto.ProductReq{
+		Name:        value("name"),
+		Description: value("description"),
+		Unit:        value("unit"),
+		Category:    value("category"),
+	}
+
+	for _, image := range strings.Split(value("images"), "|") {
+		if image = strings.TrimSpace(image); image != "" {
+			req.Images = append(req.Images, image)
+		}
+	}
+
+	var err error
+	for _, field := range []struct {
+		name string
+		to   *float64
+	}{
+		{"price", &req.Price},
+		{"stock", &req.Stok},
+		{"min_quantity", &req.Min_Quantity},
+		{"quantity_step", &req.Quantity_Step},
+	} {
+		if *field.to, err = parseFloat(value(field.name)); err != nil {
+			return &req, errors.New("invalid " + field.name + ": " + value(field.name))
+		}
+	}
+
+	if weight := value("weight"); weight != "" {
+		if req.Weight, err = strconv.Atoi(weight); err != nil {
+			return &req, errors.New("invalid weight: " + weight)
+		}
+	}
+
+	return &req, nil
+}
+
+// readJSONLRows reads one dto.ProductReq a line, blank lines are skipped.
+func readJSONLRows(file io.Reader) ([]importRow, error) {
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var rows []importRow
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var req dto.ProductReq
+		if err := json.Unmarshal([]byte(text), &req); err != nil {
+			rows = append(rows, importRow{line: line, err: errors.New("invalid json: " + err.Error())})
+			continue
+		}
+		rows = append(rows, importRow{line: line, req: &req})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New("failed to read the file: " + err.Error())
+	}
+
+	return rows, nil
+}
+
+func parseFloat(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/IndraSty/GreenBasket/domain"
+)
+
+const (
+	// maxSearchHits is how many of the best matches a search pages through.
+	maxSearchHits  = 500
+	maxSuggestions = 10
+	// reindexBatch is how many documents are indexed at once by Reindex.
+	reindexBatch = 500
+)
+
+type productSearchService struct {
+	index       domain.ProductSearchIndex
+	productRepo domain.ProductRepository
+	storeRepo   domain.StoreRepository
+}
+
+func NewProductSearchService(index domain.ProductSearchIndex, productRepo domain.ProductRepository,
+	storeRepo domain.StoreRepository) domain.ProductSearchService {
+	return &productSearchService{
+		index:       index,
+		productRepo: productRepo,
+		storeRepo:   storeRepo,
+	}
+}
+
+// Search implements domain.ProductSearchService.
+func (s *productSearchService) Search(ctx context.Context, text, storeID string) ([]string, error) {
+	hits, err := s.index.Search(ctx, strings.TrimSpace(text), storeID, maxSearchHits)
+	if err != nil {
+		return nil, errors.New("failed to search products: " + err.Error())
+	}
+
+	productIDs := make([]string, len(hits))
+	for i, hit := range hits {
+		productIDs[i] = hit.Product_Id
+	}
+
+	return productIDs, nil
+}
+
+// Suggest implements domain.ProductSearchService.
+func (s *productSearchService) Suggest(ctx context.Context, prefix string) ([]string, error) {
+	names, err := s.index.Suggest(ctx, prefix, maxSuggestions)
+	if err != nil {
+		return nil, errors.New("failed to suggest products: " + err.Error())
+	}
+
+	return names, nil
+}
+
+// SyncProducts implements domain.ProductSearchService.
+// Products that were deleted or hidden are removed from the index.
+func (s *productSearchService) SyncProducts(ctx context.Context, productIDs ...string) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	docs, err := s.documents(ctx, productIDs...)
+	if err != nil {
+		return err
+	}
+
+	found := map[string]bool{}
+	for _, doc := range docs {
+		found[doc.Product_Id] = true
+	}
+
+	var removed []string
+	for _, productID := range productIDs {
+		if !found[productID] {
+			removed = append(removed, productID)
+		}
+	}
+
+	if err := s.index.Index(ctx, docs...); err != nil {
+		return errors.New("failed to index products: " + err.Error())
+	}
+
+	if err := s.index.Remove(ctx, removed...); err != nil {
+		return errors.New("failed to remove products from the index: " + err.Error())
+	}
+
+	return nil
+}
+
+// SyncStore implements domain.ProductSearchService.
+func (s *productSearchService) SyncStore(ctx context.Context, storeID string) error {
+	products, err := s.productRepo.GetAllProductWithNoPage(ctx, storeID)
+	if err != nil {
+		return errors.New("failed to get all product in this store: " + err.Error())
+	}
+
+	productIDs := make([]string, len(*products))
+	for i, product := range *products {
+		productIDs[i] = product.Product_id
+	}
+
+	return s.SyncProducts(ctx, productIDs...)
+}
+
+// Reindex implements domain.ProductSearchService.
+// A product deleted while the index was not told stays in the index, but it
+// is never found since the products of a search are fetched from the products.
+// Hidden products are taken out of the index.
+func (s *productSearchService) Reindex(ctx context.Context) (int, error) {
+	docs, err := s.documents(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	products, err := s.productRepo.GetAllStock(ctx)
+	if err != nil {
+		return 0, errors.New("failed to get products: " + err.Error())
+	}
+
+	visible := map[string]bool{}
+	for _, doc := range docs {
+		visible[doc.Product_Id] = true
+	}
+
+	var hidden []string
+	for _, product := range *products {
+		if !visible[product.Product_id] {
+			hidden = append(hidden, product.Product_id)
+		}
+	}
+
+	if err := s.index.Remove(ctx, hidden...); err != nil {
+		return 0, errors.New("failed to remove products from the index: " + err.Error())
+	}
+
+	for start := 0; start < len(docs); start += reindexBatch {
+		end := min(start+reindexBatch, len(docs))
+		if err := s.index.Index(ctx, docs[start:end]...); err != nil {
+			return start, errors.New("failed to index products: " + err.Error())
+		}
+	}
+
+	return len(docs), nil
+}
+
+// documents returns the search documents of productIDs, or of every product,
+// with the names of their stores.
+func (s *productSearchService) documents(ctx context.Context, productIDs ...string) ([]domain.SearchDocument, error) {
+	docs, err := s.productRepo.GetSearchDocuments(ctx, productIDs...)
+	if err != nil {
+		return nil, errors.New("failed to get products: " + err.Error())
+	}
+
+	storeNames := map[string]string{}
+	for i, doc := range *docs {
+		name, ok := storeNames[doc.Store_Id]
+		if !ok {
+			// a product can outlive its store, it is indexed without a store name
+			if store, err := s.storeRepo.GetStore(ctx, doc.Store_Id); err == nil {
+				name = store.Name
+			}
+			storeNames[doc.Store_Id] = name
+		}
+		(*docs)[i].Store_Name = name
+	}
+
+	return *docs, nil
+}
@@ -0,0 +1,454 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type refundService struct {
+	repo            domain.RefundRepository
+	orderRepo       domain.OrderRepository
+	sellerOrderRepo domain.SellerOrderRepository
+	sellerRepo      domain.SellerRepository
+	paymentRepo     domain.PaymentRepository
+	productRepo     domain.ProductRepository
+	ledgerSvc       domain.StockLedgerService
+	orderStatusSvc  domain.OrderStatusService
+	gateway         domain.PaymentGateway
+	salesReportSvc  domain.SalesReportService
+	notifSvc        domain.NotificationService
+	transactor      domain.Transactor
+}
+
+func NewRefundService(repo domain.RefundRepository, orderRepo domain.OrderRepository,
+	sellerOrderRepo domain.SellerOrderRepository, sellerRepo domain.SellerRepository,
+	paymentRepo domain.PaymentRepository, productRepo domain.ProductRepository, ledgerSvc domain.StockLedgerService,
+	orderStatusSvc domain.OrderStatusService, gateway domain.PaymentGateway, salesReportSvc domain.SalesReportService,
+	notifSvc domain.NotificationService, transactor domain.Transactor) domain.RefundService {
+	return &refundService{
+		repo:            repo,
+		orderRepo:       orderRepo,
+		sellerOrderRepo: sellerOrderRepo,
+		sellerRepo:      sellerRepo,
+		paymentRepo:     paymentRepo,
+		productRepo:     productRepo,
+		ledgerSvc:       ledgerSvc,
+		orderStatusSvc:  orderStatusSvc,
+		gateway:         gateway,
+		salesReportSvc:  salesReportSvc,
+		notifSvc:        notifSvc,
+		transactor:      transactor,
+	}
+}
+
+// RequestRefund implements domain.RefundService.
+// Only items of a paid order can be refunded, items that are still PENDING
+// are cancelled instead.
+func (s *refundService) RequestRefund(ctx context.Context, email, orderID, productID, variantID string, req *dto.RefundReq) (*dto.RefundRes, error) {
+	order, err := s.orderRepo.GetOrder(ctx, orderID, email)
+	if err != nil {
+		return nil, errors.New("failed to get the order: " + err.Error())
+	}
+
+	payment, err := s.paymentRepo.FindByOrderId(ctx, orderID)
+	if err != nil {
+		return nil, errors.New("failed to find payment: " + err.Error())
+	}
+
+	status := domain.PaymentStatus(payment.Status)
+	if status != domain.PaymentSuccess && status != domain.PaymentPartialRefunded {
+		return nil, errors.New("this order has not yet made payment")
+	}
+
+	var item *domain.OrderItem
+	for i := range order.Items {
+		if order.Items[i].Is(productID, variantID) {
+			item = &order.Items[i]
+		}
+	}
+
+	if item == nil {
+		return nil, errors.New("no item found in the order")
+	}
+
+	// the refund itself is applied by the system once the seller has approved it
+	if _, err := domain.OrderStatus(item.Order_Status).Transition(domain.OrderRefunded, domain.ActorSystem); err != nil {
+		return nil, err
+	}
+
+	refunds, err := s.repo.FindByOrderId(ctx, orderID)
+	if err != nil {
+		return nil, errors.New("failed to get refunds: " + err.Error())
+	}
+
+	for _, refund := range *refunds {
+		if refund.Product_Id == productID && refund.Variant_Id == variantID && !refund.Adjustment && refund.Status != domain.RefundRejected {
+			return nil, errors.New("a refund for this item has already been requested")
+		}
+	}
+
+	// the buyer paid for the quantity ordered, a packed quantity refund gave part
+	// of it back already
+	ordered := item.Quantity
+	var refunded float64
+	for _, refund := range *refunds {
+		if refund.Product_Id == productID && refund.Variant_Id == variantID && refund.Status != domain.RefundRejected {
+			if refund.Adjustment {
+				ordered += refund.Quantity
+			}
+			refunded += refund.Amount
+		}
+	}
+
+	amount := util.ToFixed(item.Price*ordered-refunded, 2)
+	if amount <= 0 {
+		return nil, errors.New("the item has already been refunded")
+	}
+
+	seller, err := s.sellerRepo.FindSellerByStoreId(ctx, item.StoreID)
+	if err != nil {
+		return nil, errors.New("failed to find seller: " + err.Error())
+	}
+
+	createdAt := time.Now()
+	refund := domain.Refund{
+		ID:           primitive.NewObjectID(),
+		Refund_Id:    primitive.NewObjectID().Hex(),
+		Order_id:     orderID,
+		Product_Id:   productID,
+		Variant_Id:   variantID,
+		Store_Id:     item.StoreID,
+		User_Email:   email,
+		Seller_Email: seller.Email,
+		Quantity:     item.Quantity,
+		Amount:       amount,
+		Reason:       req.Reason,
+		Status:       domain.RefundRequested,
+		Created_At:   createdAt,
+		Updated_At:   createdAt,
+	}
+
+	_, err = s.repo.Insert(ctx, refund)
+	if err != nil {
+		return nil, errors.New("failed to insert refund: " + err.Error())
+	}
+
+	s.notify(ctx, seller.Email, "SELLER_REFUND_REQUESTED", &refund)
+
+	return &dto.RefundRes{
+		Refund_Id: refund.Refund_Id,
+		Amount:    refund.Amount,
+		Status:    refund.Status,
+	}, nil
+}
+
+// GetUserRefunds implements domain.RefundService.
+func (s *refundService) GetUserRefunds(ctx context.Context, email string, page pagination.Request) (*pagination.Page[domain.Refund], error) {
+	result, err := s.repo.FindByUserEmail(ctx, email, page)
+	if err != nil {
+		return nil, errors.New("failed to get refunds: " + err.Error())
+	}
+
+	return result, nil
+}
+
+// GetSellerRefunds implements domain.RefundService.
+func (s *refundService) GetSellerRefunds(ctx context.Context, email string, page pagination.Request) (*pagination.Page[domain.Refund], error) {
+	result, err := s.repo.FindBySellerEmail(ctx, email, page)
+	if err != nil {
+		return nil, errors.New("failed to get refunds: " + err.Error())
+	}
+
+	return result, nil
+}
+
+// ApproveRefund implements domain.RefundService.
+// The money is given back through the payment gateway first, the orders, the
+// payment and the sales report only change once the gateway has accepted it.
+func (s *refundService) ApproveRefund(ctx context.Context, email, refundID string) error {
+	refund, err := s.getSellerRefund(ctx, email, refundID)
+	if err != nil {
+		return err
+	}
+
+	// claiming the refund keeps a second approval from refunding the money twice
+	res, err := s.repo.UpdateStatus(ctx, refundID, domain.RefundRequested, domain.RefundApproved, time.Now())
+	if err != nil {
+		return errors.New("failed to update refund status: " + err.Error())
+	}
+
+	if res.ModifiedCount == 0 {
+		return errors.New("refund status is not '" + domain.RefundRequested + "'")
+	}
+
+	err = s.gateway.Refund(ctx, refund.Order_id, refund.Refund_Id, refund.Amount, refund.Reason)
+	if err != nil {
+		if _, err := s.repo.UpdateStatus(ctx, refundID, domain.RefundApproved, domain.RefundRequested, time.Now()); err != nil {
+			log.Println("failed to give back refund status: ", err)
+		}
+		return errors.New("failed to refund payment: " + err.Error())
+	}
+
+	return s.completeRefund(ctx, refund)
+}
+
+// RetryApprovedRefunds implements domain.RefundService.
+// The gateway does not refund a refund key twice, so a refund is sent again
+// whether or not the first attempt reached the gateway.
+func (s *refundService) RetryApprovedRefunds(ctx context.Context, approvedBefore time.Time) (int, error) {
+	refunds, err := s.repo.FindByStatus(ctx, domain.RefundApproved, approvedBefore)
+	if err != nil {
+		return 0, errors.New("failed to get approved refunds: " + err.Error())
+	}
+
+	var finished int
+	for i := range *refunds {
+		refund := &(*refunds)[i]
+
+		err := s.gateway.Refund(ctx, refund.Order_id, refund.Refund_Id, refund.Amount, refund.Reason)
+		if err != nil {
+			log.Println("failed to refund payment of refund "+refund.Refund_Id+": ", err)
+			continue
+		}
+
+		if err := s.completeRefund(ctx, refund); err != nil {
+			log.Println("failed to finish refund "+refund.Refund_Id+": ", err)
+			continue
+		}
+		finished++
+	}
+
+	return finished, nil
+}
+
+// completeRefund applies a refund the gateway has accepted. The money is
+// already back with the buyer, the refund stays APPROVED when this fails so
+// RetryApprovedRefunds picks it up again.
+func (s *refundService) completeRefund(ctx context.Context, refund *domain.Refund) error {
+	err := s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		return s.applyRefund(ctx, refund)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.salesReportSvc.UpdateSalesReport(ctx, refund.Store_Id, refund.Seller_Email)
+	if err != nil {
+		log.Println("failed to update sales report: ", err)
+	}
+
+	s.notify(ctx, refund.User_Email, "USER_REFUND_APPROVED", refund)
+
+	return nil
+}
+
+// RejectRefund implements domain.RefundService.
+func (s *refundService) RejectRefund(ctx context.Context, email, refundID string) error {
+	refund, err := s.getSellerRefund(ctx, email, refundID)
+	if err != nil {
+		return err
+	}
+
+	// the buyer paid for more than was packed, that money is owed either way
+	if refund.Adjustment {
+		return errors.New("a refund of a packed quantity can not be rejected")
+	}
+
+	res, err := s.repo.UpdateStatus(ctx, refundID, domain.RefundRequested, domain.RefundRejected, time.Now())
+	if err != nil {
+		return errors.New("failed to update refund status: " + err.Error())
+	}
+
+	if res.ModifiedCount == 0 {
+		return errors.New("refund status is not '" + domain.RefundRequested + "'")
+	}
+
+	s.notify(ctx, refund.User_Email, "USER_REFUND_REJECTED", refund)
+
+	return nil
+}
+
+func (s *refundService) getSellerRefund(ctx context.Context, email, refundID string) (*domain.Refund, error) {
+	refund, err := s.repo.FindById(ctx, refundID)
+	if err != nil {
+		return nil, errors.New("failed to get refund: " + err.Error())
+	}
+
+	if refund.Seller_Email != email {
+		return nil, errors.New("this refund does not belong to the seller")
+	}
+
+	return refund, nil
+}
+
+// applyRefund marks the item as refunded, takes its price off both orders and
+// moves the payment to PARTIAL_REFUNDED or REFUNDED. The orders of an
+// adjustment refund were already changed when the packed quantity was entered.
+func (s *refundService) applyRefund(ctx context.Context, refund *domain.Refund) error {
+	orderID := refund.Order_id
+
+	if refund.Adjustment {
+		return s.finishRefund(ctx, refund)
+	}
+
+	order, err := s.orderRepo.GetOrder(ctx, orderID)
+	if err != nil {
+		return errors.New("failed to get the order: " + err.Error())
+	}
+
+	var itemStatus string
+	for _, item := range order.Items {
+		if item.Is(refund.Product_Id, refund.Variant_Id) {
+			itemStatus = item.Order_Status
+		}
+	}
+
+	err = s.orderStatusSvc.Transition(ctx, orderID, refund.Product_Id, refund.Variant_Id, domain.OrderRefunded, domain.ActorSystem)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.orderRepo.UpdateTotalPrice(ctx, orderID, -refund.Amount)
+	if err != nil {
+		return errors.New("failed to update total price order: " + err.Error())
+	}
+
+	_, err = s.sellerOrderRepo.UpdateTotalPrice(ctx, refund.Seller_Email, orderID, -refund.Amount)
+	if err != nil {
+		return errors.New("failed to update total price seller order: " + err.Error())
+	}
+
+	// the item has not left the store yet, it can be sold again
+	if itemStatus == string(domain.OrderProcessed) {
+		_, err = s.productRepo.UpdateStockProduct(ctx, refund.Store_Id, refund.Product_Id, refund.Variant_Id, refund.Quantity, time.Now())
+		if err != nil {
+			return errors.New("failed to give back stock product: " + err.Error())
+		}
+
+		err = s.ledgerSvc.Record(ctx, domain.StockMovement{
+			Store_Id:   refund.Store_Id,
+			Product_Id: refund.Product_Id,
+			Variant_Id: refund.Variant_Id,
+			Type:       domain.MovementReturn,
+			Quantity:   refund.Quantity,
+			Actor:      refund.Seller_Email,
+			Reason:     refund.Reason,
+			Ref_Id:     refund.Refund_Id,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.finishRefund(ctx, refund)
+}
+
+// finishRefund moves the payment and the refund once the money is back with the buyer.
+func (s *refundService) finishRefund(ctx context.Context, refund *domain.Refund) error {
+	err := s.updatePaymentStatus(ctx, refund)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.repo.UpdateStatus(ctx, refund.Refund_Id, domain.RefundApproved, domain.RefundRefunded, time.Now())
+	if err != nil {
+		return errors.New("failed to update refund status: " + err.Error())
+	}
+
+	if res.ModifiedCount == 0 {
+		return errors.New("refund status is not '" + domain.RefundApproved + "'")
+	}
+
+	return nil
+}
+
+func (s *refundService) updatePaymentStatus(ctx context.Context, refund *domain.Refund) error {
+	orderID := refund.Order_id
+
+	payment, err := s.paymentRepo.FindByOrderId(ctx, orderID)
+	if err != nil {
+		return errors.New("failed to find payment: " + err.Error())
+	}
+
+	refunds, err := s.repo.FindByOrderId(ctx, orderID)
+	if err != nil {
+		return errors.New("failed to get refunds: " + err.Error())
+	}
+
+	refunded := refund.Amount
+	for _, r := range *refunds {
+		if r.Status == domain.RefundRefunded {
+			refunded += r.Amount
+		}
+	}
+
+	order, err := s.orderRepo.GetOrder(ctx, orderID)
+	if err != nil {
+		return errors.New("failed to get the order: " + err.Error())
+	}
+
+	// the payment also covers the shipping, which is not refunded with the items
+	next := domain.PaymentPartialRefunded
+	if refunded >= payment.Amount-order.Shipping_Fee {
+		next = domain.PaymentRefunded
+	}
+
+	// the refund notification of the gateway may have moved the payment already
+	current := domain.PaymentStatus(payment.Status)
+	if current == next {
+		return nil
+	}
+
+	if _, err := current.Transition(next); err != nil {
+		return err
+	}
+
+	var req dto.UpdatePaymentReq
+	req.Status = string(next)
+
+	res, err := s.paymentRepo.UpdateFromStatus(ctx, orderID, string(current), &req)
+	if err != nil {
+		return errors.New("Failed to update payment: " + err.Error())
+	}
+
+	if res.MatchedCount == 0 {
+		return errors.New("payment status has changed, try again")
+	}
+
+	_, err = s.orderRepo.UpdateOrder(ctx, orderID, &req)
+	if err != nil {
+		return errors.New("Failed to update order: " + err.Error())
+	}
+
+	var reqSO dto.OrderSellerUpdateReq
+	reqSO.Payment_Status = req.Status
+
+	_, err = s.sellerOrderRepo.UpdateOrderSeller(ctx, orderID, &reqSO)
+	if err != nil {
+		return errors.New("Failed to update seller order: " + err.Error())
+	}
+
+	return nil
+}
+
+func (s *refundService) notify(ctx context.Context, email, code string, refund *domain.Refund) {
+	data := map[string]string{
+		"order_id":   refund.Order_id,
+		"product_id": refund.Product_Id,
+		"amount":     fmt.Sprintf("%.2f", refund.Amount),
+	}
+
+	err := s.notifSvc.Insert(ctx, email, code, data)
+	if err != nil {
+		log.Println("failed to insert refund notification: ", err)
+	}
+}
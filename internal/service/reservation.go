@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type reservationService struct {
+	repo        domain.ReservationRepository
+	productRepo domain.ProductRepository
+	batchSvc    domain.BatchService
+	ledgerSvc   domain.StockLedgerService
+	transactor  domain.Transactor
+}
+
+func NewReservationService(repo domain.ReservationRepository, productRepo domain.ProductRepository,
+	batchSvc domain.BatchService, ledgerSvc domain.StockLedgerService, transactor domain.Transactor) domain.ReservationService {
+	return &reservationService{
+		repo:        repo,
+		productRepo: productRepo,
+		batchSvc:    batchSvc,
+		ledgerSvc:   ledgerSvc,
+		transactor:  transactor,
+	}
+}
+
+// ReserveStock implements domain.ReservationService.
+// The reserved quantity is taken from the batches of the product first expiry
+// first out, the reservation keeps the batches so a release gives it back.
+func (s *reservationService) ReserveStock(ctx context.Context, orderID string, items []domain.OrderItem) error {
+	for _, item := range items {
+		err := s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+			return s.reserveItem(ctx, orderID, item)
+		})
+		if err != nil {
+			s.rollback(ctx, orderID)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reserveItem takes the stock of item and records it in the ledger. Without
+// transactions the stock of a failed item is given back here, the items
+// reserved before it are released by the caller.
+func (s *reservationService) reserveItem(ctx context.Context, orderID string, item domain.OrderItem) error {
+	// a product an admin took down since it was added to the cart can not be ordered
+	product, err := s.productRepo.GetProductById(ctx, item.Product_Id, item.StoreID)
+	if err != nil && !errors.Is(err, domain.ErrProductNotFound) {
+		return fmt.Errorf("failed to get product: %w", err)
+	}
+	if err != nil || product.Hidden() {
+		return errors.New("product is no longer available: " + item.Product_Name)
+	}
+
+	updateAT := time.Now()
+	res, err := s.productRepo.ReserveStock(ctx, item.StoreID, item.Product_Id, item.Variant_Id, item.Quantity, updateAT)
+	if err != nil {
+		return fmt.Errorf("failed to reserve stock product: %w", err)
+	}
+
+	if res.ModifiedCount == 0 {
+		return errors.New("product stock is less than quantity for product id: " + item.Product_Id)
+	}
+
+	err = s.record(ctx, domain.MovementReservation, "reserved at checkout", orderID, item.StoreID, item.Product_Id, item.Variant_Id, -item.Quantity)
+	if err != nil {
+		s.giveBack(ctx, orderID, item.StoreID, item.Product_Id, item.Variant_Id, item.Quantity, nil)
+		return err
+	}
+
+	batches, err := s.batchSvc.Allocate(ctx, item.Product_Id, item.Variant_Id, item.Quantity)
+	if err != nil {
+		s.giveBack(ctx, orderID, item.StoreID, item.Product_Id, item.Variant_Id, item.Quantity, nil)
+		return err
+	}
+
+	reservation := domain.Reservation{
+		ID:             primitive.NewObjectID(),
+		Reservation_Id: primitive.NewObjectID().Hex(),
+		Order_id:       orderID,
+		Product_Id:     item.Product_Id,
+		Variant_Id:     item.Variant_Id,
+		Store_Id:       item.StoreID,
+		Quantity:       item.Quantity,
+		Batches:        batches,
+		Status:         "RESERVED",
+		Created_At:     updateAT,
+		Updated_At:     updateAT,
+	}
+
+	_, err = s.repo.Insert(ctx, reservation)
+	if err != nil {
+		// the stock of this item is not tracked by a reservation yet, give it back directly
+		s.giveBack(ctx, orderID, item.StoreID, item.Product_Id, item.Variant_Id, item.Quantity, batches)
+		return fmt.Errorf("failed to insert reservation: %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseStock implements domain.ReservationService.
+func (s *reservationService) ReleaseStock(ctx context.Context, orderID string) error {
+	return s.changeStatus(ctx, orderID, "RELEASED", nil)
+}
+
+// ReleaseItem implements domain.ReservationService.
+func (s *reservationService) ReleaseItem(ctx context.Context, orderID, productID, variantID string) error {
+	return s.changeStatus(ctx, orderID, "RELEASED", func(reservation domain.Reservation) bool {
+		return reservation.Product_Id == productID && reservation.Variant_Id == variantID
+	})
+}
+
+// CommitStock implements domain.ReservationService.
+func (s *reservationService) CommitStock(ctx context.Context, orderID string) error {
+	return s.changeStatus(ctx, orderID, "COMMITTED", nil)
+}
+
+// changeStatus moves the reservations of the order that are still reserved
+// to status, only those matching item when it is not nil.
+func (s *reservationService) changeStatus(ctx context.Context, orderID, status string, item func(domain.Reservation) bool) error {
+	reservations, err := s.repo.FindByOrderId(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to get reservations: %w", err)
+	}
+
+	for _, reservation := range *reservations {
+		if reservation.Status != "RESERVED" {
+			continue
+		}
+
+		if item != nil && !item(reservation) {
+			continue
+		}
+
+		err := s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+			return s.changeReservation(ctx, reservation, status)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// changeReservation moves one reservation to status, a release gives its stock
+// back and records it in the ledger.
+func (s *reservationService) changeReservation(ctx context.Context, reservation domain.Reservation, status string) error {
+	updateAT := time.Now()
+	res, err := s.repo.UpdateStatus(ctx, reservation.Reservation_Id, "RESERVED", status, updateAT)
+	if err != nil {
+		return fmt.Errorf("failed to update reservation status: %w", err)
+	}
+
+	// someone else has released or committed this reservation in the meantime
+	if res.ModifiedCount == 0 || status != "RELEASED" {
+		return nil
+	}
+
+	// a batch withdrawn while the stock was reserved is off sale, its part is not given back
+	withdrawn, err := s.batchSvc.Restore(ctx, reservation.Batches)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.productRepo.UpdateStockProduct(ctx, reservation.Store_Id, reservation.Product_Id, reservation.Variant_Id, reservation.Quantity-withdrawn, updateAT)
+	if err != nil {
+		return fmt.Errorf("failed to give back stock product: %w", err)
+	}
+
+	return s.record(ctx, domain.MovementRelease, "released from the order", reservation.Order_id, reservation.Store_Id,
+		reservation.Product_Id, reservation.Variant_Id, reservation.Quantity-withdrawn)
+}
+
+// giveBack returns the stock of an item that has no reservation.
+func (s *reservationService) giveBack(ctx context.Context, orderID, storeID, productID, variantID string, quantity float64, batches []domain.BatchAllocation) {
+	withdrawn, err := s.batchSvc.Restore(ctx, batches)
+	if err != nil {
+		log.Println("failed to give back batches: ", err)
+	}
+
+	if _, err := s.productRepo.UpdateStockProduct(ctx, storeID, productID, variantID, quantity-withdrawn, time.Now()); err != nil {
+		log.Println("failed to give back stock product: ", err)
+		return
+	}
+
+	err = s.record(ctx, domain.MovementRelease, "checkout failed", orderID, storeID, productID, variantID, quantity-withdrawn)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// record writes a movement of the stock of an order, in the transaction of
+// ctx that moved the stock.
+func (s *reservationService) record(ctx context.Context, movementType domain.StockMovementType, reason, orderID, storeID, productID, variantID string, quantity float64) error {
+	return s.ledgerSvc.Record(ctx, domain.StockMovement{
+		Store_Id:   storeID,
+		Product_Id: productID,
+		Variant_Id: variantID,
+		Type:       movementType,
+		Quantity:   quantity,
+		Actor:      string(domain.ActorSystem),
+		Reason:     reason,
+		Ref_Id:     orderID,
+	})
+}
+
+func (s *reservationService) rollback(ctx context.Context, orderID string) {
+	if err := s.ReleaseStock(ctx, orderID); err != nil {
+		log.Println("failed to release reserved stock: ", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/IndraSty/GreenBasket/domain"
 	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -69,7 +70,7 @@ func (s *reviewService) CreateReview(ctx context.Context, email string, orderId
 
 	for _, item := range order.Items {
 		if item.Product_Id == productID {
-			if item.Order_Status == "FINISHED" {
+			if item.Order_Status == string(domain.OrderFinished) {
 
 				store, err := s.storeRepo.GetStore(ctx, item.StoreID)
 				if err != nil {
@@ -197,7 +198,7 @@ func (s *reviewService) UpdateReview(ctx context.Context, email string, reviewID
 }
 
 // GetAllReviewByProductId implements domain.ReviewService.
-func (s *reviewService) GetAllReviewByProductId(ctx context.Context, productID, sellerEmail string) (*[]dto.GetReviewRes, error) {
+func (s *reviewService) GetAllReviewByProductId(ctx context.Context, productID, sellerEmail string, page pagination.Request) (*pagination.Page[dto.GetReviewRes], error) {
 	product, err := s.productRepo.GetProductById(ctx, productID)
 	if err != nil {
 		return nil, errors.New("Failed to get product by id: " + err.Error())
@@ -207,60 +208,38 @@ func (s *reviewService) GetAllReviewByProductId(ctx context.Context, productID,
 		return nil, errors.New("product not found with this id " + productID)
 	}
 
-	reviews, err := s.repo.GetAllReviewByProductId(ctx, productID, sellerEmail)
+	reviews, err := s.repo.FindByProductId(ctx, productID, sellerEmail, page)
 	if err != nil {
 		return nil, errors.New("Failed to get all reviews by this product id: " + err.Error())
 	}
 
-	reviewRes := make([]dto.GetReviewRes, len(*reviews))
-	for i, review := range *reviews {
-		reviewRes[i] = dto.GetReviewRes{
-			Review_Id:   review.Review_Id,
-			Product_Id:  review.Product_Id,
-			Email:       review.Email,
-			Rating:      review.Rating,
-			Review:      review.Review,
-			Reviewed_At: review.Reviewed_At,
-			Updated_At:  review.Updated_At,
-		}
-	}
-
-	return &reviewRes, nil
+	return reviewPage(reviews), nil
 }
 
 // GetAllReviewBySellerEmail implements domain.ReviewService.
-func (s *reviewService) GetAllReviewBySellerEmail(ctx context.Context, email string) (*[]dto.GetReviewRes, error) {
-	reviews, err := s.repo.GetAllReviewBySellerEmail(ctx, email)
+func (s *reviewService) GetAllReviewBySellerEmail(ctx context.Context, email string, page pagination.Request) (*pagination.Page[dto.GetReviewRes], error) {
+	reviews, err := s.repo.FindBySellerEmail(ctx, email, page)
 	if err != nil {
 		return nil, errors.New("Failed to get all reviews by this seller email: " + err.Error())
 	}
 
-	reviewRes := make([]dto.GetReviewRes, len(*reviews))
-	for i, review := range *reviews {
-		reviewRes[i] = dto.GetReviewRes{
-			Review_Id:   review.Review_Id,
-			Product_Id:  review.Product_Id,
-			Email:       review.Email,
-			Rating:      review.Rating,
-			Review:      review.Review,
-			Reviewed_At: review.Reviewed_At,
-			Updated_At:  review.Updated_At,
-		}
-	}
-
-	return &reviewRes, nil
+	return reviewPage(reviews), nil
 }
 
 // GetAllReviewByUserEmail implements domain.ReviewService.
-func (s *reviewService) GetAllReviewByUserEmail(ctx context.Context, email string) (*[]dto.GetReviewRes, error) {
-	reviews, err := s.repo.GetAllReviewByUserEmail(ctx, email)
+func (s *reviewService) GetAllReviewByUserEmail(ctx context.Context, email string, page pagination.Request) (*pagination.Page[dto.GetReviewRes], error) {
+	reviews, err := s.repo.FindByUserEmail(ctx, email, page)
 	if err != nil {
 		return nil, errors.New("Failed to get all reviews by this user email: " + err.Error())
 	}
 
-	reviewRes := make([]dto.GetReviewRes, len(*reviews))
-	for i, review := range *reviews {
-		reviewRes[i] = dto.GetReviewRes{
+	return reviewPage(reviews), nil
+}
+
+func reviewPage(reviews *pagination.Page[domain.Review]) *pagination.Page[dto.GetReviewRes] {
+	res := make([]dto.GetReviewRes, len(reviews.Items))
+	for i, review := range reviews.Items {
+		res[i] = dto.GetReviewRes{
 			Review_Id:   review.Review_Id,
 			Product_Id:  review.Product_Id,
 			Email:       review.Email,
@@ -271,7 +250,7 @@ func (s *reviewService) GetAllReviewByUserEmail(ctx context.Context, email strin
 		}
 	}
 
-	return &reviewRes, nil
+	return &pagination.Page[dto.GetReviewRes]{Items: res, Next_Cursor: reviews.Next_Cursor}
 }
 
 // UpdateResponSeller implements domain.ReviewService.
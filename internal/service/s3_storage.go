@@ -0,0 +1,177 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/config"
+)
+
+// s3Storage keeps blobs in a bucket of any S3 compatible service, like AWS
+// S3 or MinIO. Requests use path style addressing and are signed with
+// signature version 4.
+type s3Storage struct {
+	client    *http.Client
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	baseURL   string
+}
+
+func NewS3Storage(cnf *config.Config) (domain.BlobStorage, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(cnf.Storage.S3Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, errors.New("invalid s3 endpoint " + cnf.Storage.S3Endpoint)
+	}
+
+	if cnf.Storage.S3Bucket == "" {
+		return nil, errors.New("s3 bucket is not set")
+	}
+
+	baseURL := cnf.Storage.PublicURL
+	if baseURL == "" {
+		baseURL = endpoint.String() + "/" + cnf.Storage.S3Bucket
+	}
+
+	return &s3Storage{
+		client:    &http.Client{Timeout: 30 * time.Second},
+		endpoint:  endpoint,
+		region:    cnf.Storage.S3Region,
+		bucket:    cnf.Storage.S3Bucket,
+		accessKey: cnf.Storage.S3AccessKey,
+		secretKey: cnf.Storage.S3SecretKey,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+// Put implements domain.BlobStorage.
+func (s *s3Storage) Put(ctx context.Context, key, contentType string, data []byte) error {
+	req, err := s.request(ctx, http.MethodPut, key, contentType, data)
+	if err != nil {
+		return err
+	}
+
+	return s.do(req, "failed to put blob: ")
+}
+
+// Delete implements domain.BlobStorage.
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	req, err := s.request(ctx, http.MethodDelete, key, "", nil)
+	if err != nil {
+		return err
+	}
+
+	// S3 answers 204 for a key that does not exist too
+	return s.do(req, "failed to delete blob: ")
+}
+
+// URL implements domain.BlobStorage.
+func (s *s3Storage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+func (s *s3Storage) do(req *http.Request, message string) error {
+	res, err := s.client.Do(req)
+	if err != nil {
+		return errors.New(message + err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return errors.New(message + res.Status + " " + string(body))
+	}
+
+	return nil
+}
+
+// request builds a signed request for key, see
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func (s *s3Storage) request(ctx context.Context, method, key, contentType string, data []byte) (*http.Request, error) {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	path := s.endpoint.Path + "/" + url.PathEscape(s.bucket) + "/" + strings.Join(segments, "/")
+
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint.Scheme+"://"+s.endpoint.Host+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(data)
+
+	headers := map[string]string{
+		"host":                 s.endpoint.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	names := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if contentType != "" {
+		headers["content-type"] = contentType
+		names = append([]string{"content-type"}, names...)
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+		if name != "host" {
+			req.Header.Set(name, headers[name])
+		}
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		path,
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	signingKey = hmacSHA256(signingKey, s.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+
+	return req, nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -9,7 +9,6 @@ import (
 
 	"github.com/IndraSty/GreenBasket/domain"
 	"github.com/IndraSty/GreenBasket/dto"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -35,13 +34,19 @@ func NewSalesRepository(repo domain.SalesReportRepository, sellerOrderRepo domai
 	}
 }
 
-func calculateSalesAndIncome(orders []domain.SellerOrder) (totalSales int32, totalIncome float64) {
+// isPaid reports whether a seller order still holds the buyer's money, a
+// partially refunded order does for the items that were not refunded.
+func isPaid(paymentStatus string) bool {
+	return paymentStatus == string(domain.PaymentSuccess) || paymentStatus == string(domain.PaymentPartialRefunded)
+}
+
+func calculateSalesAndIncome(orders []domain.SellerOrder) (totalSales float64, totalIncome float64) {
 	for _, order := range orders {
-		if order.Payment_Status == "SUCCESS" {
+		if isPaid(order.Payment_Status) {
 			for _, item := range order.Items {
-				if item.Status == "FINISHED" {
-					totalSales += int32(item.Quantity)
-					totalIncome += float64(item.Quantity) * item.Price
+				if item.Status == string(domain.OrderFinished) {
+					totalSales += item.Quantity
+					totalIncome += item.Quantity * item.Price
 				}
 			}
 		} else {
@@ -51,14 +56,24 @@ func calculateSalesAndIncome(orders []domain.SellerOrder) (totalSales int32, tot
 	return
 }
 
-func calculateProductSales(orders []domain.SellerOrder) map[string]int64 {
-	productSalesMap := make(map[string]int64)
+// calculateProductSales returns the units sold per product, and per variant
+// for the products sold in variants.
+func calculateProductSales(orders []domain.SellerOrder) (map[string]float64, map[string]map[string]float64) {
+	productSalesMap := make(map[string]float64)
+	variantSalesMap := make(map[string]map[string]float64)
 
 	for _, order := range orders {
-		if order.Payment_Status == "SUCCESS" {
+		if isPaid(order.Payment_Status) {
 			for _, item := range order.Items {
-				if item.Status == "FINISHED" {
-					productSalesMap[item.Product_Id] += int64(item.Quantity)
+				if item.Status == string(domain.OrderFinished) {
+					productSalesMap[item.Product_Id] += item.Quantity
+
+					if item.Variant_Id != "" {
+						if variantSalesMap[item.Product_Id] == nil {
+							variantSalesMap[item.Product_Id] = make(map[string]float64)
+						}
+						variantSalesMap[item.Product_Id][item.Variant_Id] += item.Quantity
+					}
 				}
 			}
 		} else {
@@ -66,7 +81,46 @@ func calculateProductSales(orders []domain.SellerOrder) map[string]int64 {
 		}
 	}
 
-	return productSalesMap
+	return productSalesMap, variantSalesMap
+}
+
+// variantSales lists every variant of the product, the ones not sold yet too.
+func variantSales(variants []domain.Variant, sold map[string]float64) []domain.Variant_Sales {
+	var sales []domain.Variant_Sales
+	for _, variant := range variants {
+		sales = append(sales, domain.Variant_Sales{
+			Variant_Id:  variant.Variant_Id,
+			Name:        variant.Name,
+			Total_Sales: sold[variant.Variant_Id],
+			Stock:       variant.Stock,
+		})
+	}
+
+	return sales
+}
+
+func productSalesRes(products []domain.Product_Sales) []dto.ProductSalesRes {
+	var productSales []dto.ProductSalesRes
+	for _, item := range products {
+		var variants []dto.VariantSalesRes
+		for _, variant := range item.Variants {
+			variants = append(variants, dto.VariantSalesRes{
+				Variant_Id:  variant.Variant_Id,
+				Name:        variant.Name,
+				Total_Sales: variant.Total_Sales,
+				Stock:       variant.Stock,
+			})
+		}
+
+		productSales = append(productSales, dto.ProductSalesRes{
+			Product_Id:  item.Product_Id,
+			Total_Sales: item.Total_Sales,
+			Stock:       item.Stock,
+			Variants:    variants,
+		})
+	}
+
+	return productSales
 }
 
 func CalculateAverageRatingProduct(reviews []domain.Review, productId string) float32 {
@@ -121,7 +175,6 @@ func (s *salesReportService) updateRedisSR(ctx context.Context, email, storeID s
 }
 
 func (s *salesReportService) getSalesReportWithNoAct(ctx context.Context, email string, storeID string) (*dto.SalesReportRes, error) {
-	var productSales []dto.ProductSalesRes
 	_, err := s.storeRepo.GetStore(ctx, storeID, email)
 	if err != nil {
 		return nil, errors.New("failed to get store by email and id: " + err.Error())
@@ -132,16 +185,7 @@ func (s *salesReportService) getSalesReportWithNoAct(ctx context.Context, email
 		return nil, errors.New("failed to get sales report: " + err.Error())
 	}
 
-	for _, item := range result.Products {
-		result := dto.ProductSalesRes{
-			Product_Id:  item.Product_Id,
-			Total_Sales: item.Total_Sales,
-			Stock:       item.Stock,
-		}
-
-		productSales = append(productSales, result)
-
-	}
+	productSales := productSalesRes(result.Products)
 
 	return &dto.SalesReportRes{
 		Store_Id:      result.Store_Id,
@@ -170,7 +214,7 @@ func (s *salesReportService) UpdateSalesReport(ctx context.Context, storeID stri
 	}
 
 	totalSales, totalIncome := calculateSalesAndIncome(*orders)
-	productSalesMap := calculateProductSales(*orders)
+	productSalesMap, variantSalesMap := calculateProductSales(*orders)
 
 	var productSales []domain.Product_Sales
 	for productID, totalProdSales := range productSalesMap {
@@ -189,24 +233,21 @@ func (s *salesReportService) UpdateSalesReport(ctx context.Context, storeID stri
 		productSales = append(productSales, domain.Product_Sales{
 			Product_Id:     productID,
 			Total_Sales:    totalProdSales,
-			Stock:          int32(product.Stock),
+			Stock:          product.Stock,
 			Average_Rating: averageRating,
+			Variants:       variantSales(product.Variants, variantSalesMap[productID]),
 		})
 	}
 
-	var update primitive.D
-	if totalSales != 0 {
-		update = append(update, bson.E{Key: "total_sales", Value: totalSales})
-	}
-	if totalIncome != 0.0 {
-		update = append(update, bson.E{Key: "total_income", Value: totalIncome})
-	}
-	if len(productSales) > 0 {
-		update = append(update, bson.E{Key: "products", Value: productSales})
+	// a refund can bring the totals back down, so they are always written
+	if productSales == nil {
+		productSales = []domain.Product_Sales{}
 	}
 
-	if len(update) == 0 {
-		return errors.New("no updates to be made")
+	update := primitive.D{
+		{Key: "total_sales", Value: totalSales},
+		{Key: "total_income", Value: totalIncome},
+		{Key: "products", Value: productSales},
 	}
 
 	result, err := s.repo.Update(ctx, storeID, update)
@@ -239,7 +280,6 @@ func (s *salesReportService) GetSalesReport(ctx context.Context, email string, s
 		return &data, nil
 	}
 
-	var productSales []dto.ProductSalesRes
 	_, err = s.storeRepo.GetStore(ctx, storeID, email)
 	if err != nil {
 		return nil, errors.New("failed to get store by email and id: " + err.Error())
@@ -254,16 +294,7 @@ func (s *salesReportService) GetSalesReport(ctx context.Context, email string, s
 		return nil, errors.New("this seller has no store")
 	}
 
-	for _, item := range result.Products {
-		result := dto.ProductSalesRes{
-			Product_Id:  item.Product_Id,
-			Total_Sales: item.Total_Sales,
-			Stock:       item.Stock,
-		}
-
-		productSales = append(productSales, result)
-
-	}
+	productSales := productSalesRes(result.Products)
 
 	data := dto.SalesReportRes{
 		Store_Id:      result.Store_Id,
@@ -15,20 +15,24 @@ import (
 )
 
 type sellerService struct {
-	repo      domain.SellerRepository
-	tokenSvc  domain.TokenService
-	cacheRepo domain.CacheRepository
-	emailSvc  domain.EmailService
+	repo            domain.SellerRepository
+	storeRepo       domain.StoreRepository
+	tokenSvc        domain.TokenService
+	cacheRepo       domain.CacheRepository
+	revocationStore domain.TokenRevocationStore
+	emailSvc        domain.EmailService
 }
 
-func NewSellerService(repo domain.SellerRepository, tokenSvc domain.TokenService,
-	cacheRepo domain.CacheRepository,
+func NewSellerService(repo domain.SellerRepository, storeRepo domain.StoreRepository, tokenSvc domain.TokenService,
+	cacheRepo domain.CacheRepository, revocationStore domain.TokenRevocationStore,
 	emailSvc domain.EmailService) domain.SellerService {
 	return &sellerService{
-		repo:      repo,
-		tokenSvc:  tokenSvc,
-		cacheRepo: cacheRepo,
-		emailSvc:  emailSvc,
+		repo:            repo,
+		storeRepo:       storeRepo,
+		tokenSvc:        tokenSvc,
+		cacheRepo:       cacheRepo,
+		revocationStore: revocationStore,
+		emailSvc:        emailSvc,
 	}
 }
 
@@ -72,7 +76,7 @@ func (s *sellerService) RegisterSeller(ctx context.Context, req *dto.SellerRegis
 		Email:         req.Email,
 		Password:      password,
 		Phone:         req.Phone,
-		Role:          "Seller",
+		Role:          domain.RoleSeller,
 		Created_At:    time.Now(),
 		Updated_At:    time.Now(),
 		EmailVerified: false,
@@ -132,7 +136,30 @@ func (s *sellerService) AuthenticateSeller(ctx context.Context, req *dto.SellerA
 		return nil, errors.New("email or password incorrect")
 	}
 
-	acc_token, refreshToken, _ := s.tokenSvc.GenerateAllTokens(seller.Email, seller.First_Name, seller.Last_Name, seller.Seller_Id)
+	if seller.Suspended {
+		return nil, errors.New("your account is suspended")
+	}
+
+	staff, err := sellerAccess(ctx, s.storeRepo, seller)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenVersion, err := s.revocationStore.TokenVersion(domain.AccountSeller, seller.Seller_Id)
+	if err != nil {
+		return nil, errors.New("failed to get token version: " + err.Error())
+	}
+
+	acc_token, refreshToken, err := s.tokenSvc.GenerateAllTokens(seller.Email, seller.First_Name, seller.Last_Name, seller.Seller_Id, domain.AccountSeller, seller.Role, staff, tokenVersion)
+	if err != nil {
+		return nil, errors.New("failed to generate tokens: " + err.Error())
+	}
+
+	// the stored refresh token is the only one of its family that can be exchanged
+	_, err = s.repo.UpdateSeller(ctx, seller.Email, bson.D{{Key: "refresh_token", Value: refreshToken}})
+	if err != nil {
+		return nil, errors.New("failed to update refresh token: " + err.Error())
+	}
 
 	return &dto.SellerAuthRes{
 		Access_Token:  acc_token,
@@ -249,3 +276,145 @@ func (s *sellerService) UpdateSeller(ctx context.Context, email string, req *dto
 	}, nil
 
 }
+
+// AddStaff implements domain.SellerService.
+func (s *sellerService) AddStaff(ctx context.Context, email, storeID string, req *dto.SellerRegisterReq) (*dto.SellerRegisterRes, error) {
+	_, err := govalidator.ValidateStruct(req)
+	if err != nil {
+		return nil, errors.New("Invalid request body: " + err.Error())
+	}
+
+	passwordErr := util.ValidatePassword(req.Password)
+	if passwordErr != "" {
+		return nil, errors.New(passwordErr)
+	}
+
+	_, err = s.storeRepo.GetStore(ctx, storeID, email)
+	if err != nil {
+		return nil, errors.New("failed to find store: " + err.Error())
+	}
+
+	emailExist, err := s.repo.CheckEmailExists(ctx, req.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if emailExist {
+		return nil, errors.New("email already registered")
+	}
+
+	phoneExists, err := s.repo.CheckPhoneExists(ctx, req.Phone)
+	if err != nil {
+		return nil, err
+	}
+	if phoneExists {
+		return nil, errors.New("phone number already registered")
+	}
+
+	id := primitive.NewObjectID()
+
+	// the seller vouches for the email of its staff
+	staff := domain.Seller{
+		ID:            id,
+		First_Name:    req.First_Name,
+		Last_Name:     req.Last_Name,
+		Email:         req.Email,
+		Password:      util.HashPassword(req.Password),
+		Phone:         req.Phone,
+		Role:          domain.RoleSellerStaff,
+		Created_At:    time.Now(),
+		Updated_At:    time.Now(),
+		EmailVerified: true,
+		Seller_Id:     id.Hex(),
+		Staff_Of:      storeID,
+	}
+
+	insertResult, err := s.repo.CreateSeller(ctx, staff)
+	if err != nil {
+		return nil, errors.New("failed to create staff: " + err.Error())
+	}
+
+	return &dto.SellerRegisterRes{
+		InsertId: insertResult,
+		Message:  []string{"Staff created successfully!"},
+	}, nil
+}
+
+// GetStaff implements domain.SellerService.
+func (s *sellerService) GetStaff(ctx context.Context, email, storeID string) ([]dto.StaffRes, error) {
+	_, err := s.storeRepo.GetStore(ctx, storeID, email)
+	if err != nil {
+		return nil, errors.New("failed to find store: " + err.Error())
+	}
+
+	staff, err := s.repo.FindStaff(ctx, storeID)
+	if err != nil {
+		return nil, errors.New("failed to get staff of the store: " + err.Error())
+	}
+
+	res := make([]dto.StaffRes, 0, len(staff))
+	for _, seller := range staff {
+		res = append(res, dto.StaffRes{
+			Seller_Id:  seller.Seller_Id,
+			First_Name: seller.First_Name,
+			Last_Name:  seller.Last_Name,
+			Email:      seller.Email,
+			Phone:      seller.Phone,
+			Created_At: seller.Created_At,
+		})
+	}
+
+	return res, nil
+}
+
+// RemoveStaff implements domain.SellerService.
+func (s *sellerService) RemoveStaff(ctx context.Context, email, storeID, sellerID string) error {
+	_, err := s.storeRepo.GetStore(ctx, storeID, email)
+	if err != nil {
+		return errors.New("failed to find store: " + err.Error())
+	}
+
+	res, err := s.repo.DeleteStaff(ctx, storeID, sellerID)
+	if err != nil {
+		return errors.New("failed to delete staff: " + err.Error())
+	}
+
+	if res.DeletedCount == 0 {
+		return errors.New("staff not found")
+	}
+
+	_, err = s.revocationStore.BumpTokenVersion(domain.AccountSeller, sellerID)
+	if err != nil {
+		return errors.New("failed to revoke staff tokens: " + err.Error())
+	}
+
+	return nil
+}
+
+// sellerAccess refuses a seller working for a suspended store and returns the
+// scope of a staff account, nil for a seller owning its stores.
+func sellerAccess(ctx context.Context, storeRepo domain.StoreRepository, seller *domain.Seller) (*domain.StaffScope, error) {
+	if seller.Role != domain.RoleSellerStaff {
+		stores, err := storeRepo.GetStoresByEmail(ctx, seller.Email)
+		if err != nil {
+			return nil, errors.New("failed to get stores of the seller: " + err.Error())
+		}
+
+		if domain.AnySuspended(stores) {
+			return nil, domain.ErrStoreSuspended
+		}
+
+		return nil, nil
+	}
+
+	store, err := storeRepo.GetStore(ctx, seller.Staff_Of)
+	if err != nil {
+		return nil, errors.New("failed to find store of the staff: " + err.Error())
+	}
+
+	if store.Suspended {
+		return nil, domain.ErrStoreSuspended
+	}
+
+	return &domain.StaffScope{Store_Id: store.Store_Id, Store_Email: store.Email}, nil
+}
@@ -9,27 +9,37 @@ import (
 
 	"github.com/IndraSty/GreenBasket/domain"
 	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
 )
 
 type sellerOrderService struct {
-	repo        domain.SellerOrderRepository
-	sellerRepo  domain.SellerRepository
-	orderRepo   domain.OrderRepository
-	productRepo domain.ProductRepository
-	notifSvc    domain.NotificationService
-	cacheRepo   domain.CacheRepository
+	repo           domain.SellerOrderRepository
+	sellerRepo     domain.SellerRepository
+	orderRepo      domain.OrderRepository
+	productRepo    domain.ProductRepository
+	paymentRepo    domain.PaymentRepository
+	reservationSvc domain.ReservationService
+	orderStatusSvc domain.OrderStatusService
+	notifSvc       domain.NotificationService
+	cacheRepo      domain.CacheRepository
+	transactor     domain.Transactor
 }
 
 func NewSellerOrderService(repo domain.SellerOrderRepository, sellerRepo domain.SellerRepository,
-	orderRepo domain.OrderRepository, productRepo domain.ProductRepository,
-	notifSvc domain.NotificationService, cacheRepo domain.CacheRepository) domain.SellerOrderService {
+	orderRepo domain.OrderRepository, productRepo domain.ProductRepository, paymentRepo domain.PaymentRepository,
+	reservationSvc domain.ReservationService, orderStatusSvc domain.OrderStatusService,
+	notifSvc domain.NotificationService, cacheRepo domain.CacheRepository, transactor domain.Transactor) domain.SellerOrderService {
 	return &sellerOrderService{
-		repo:        repo,
-		sellerRepo:  sellerRepo,
-		orderRepo:   orderRepo,
-		productRepo: productRepo,
-		notifSvc:    notifSvc,
-		cacheRepo:   cacheRepo,
+		repo:           repo,
+		sellerRepo:     sellerRepo,
+		orderRepo:      orderRepo,
+		productRepo:    productRepo,
+		paymentRepo:    paymentRepo,
+		reservationSvc: reservationSvc,
+		orderStatusSvc: orderStatusSvc,
+		notifSvc:       notifSvc,
+		cacheRepo:      cacheRepo,
+		transactor:     transactor,
 	}
 }
 
@@ -47,42 +57,24 @@ func (s *sellerOrderService) setRedisSO(item any, name, email string) error {
 	return nil
 }
 
-func (s *sellerOrderService) delRedisSO(email string, name ...string) error {
-	err := s.cacheRepo.Del(name[0] + email)
+func (s *sellerOrderService) delRedisSO(email, name string) error {
+	err := s.cacheRepo.Del(name + email)
 	if err != nil {
 		return errors.New("failed to delete seller order data in cache: " + err.Error())
 	}
 
-	if len(name[1]) != 0 {
-		err = s.cacheRepo.Del(name[1] + email)
-		if err != nil {
-			return errors.New("failed to delete all seller order data in cache: " + err.Error())
-		}
-	}
-
 	return nil
 }
 
-func (s *sellerOrderService) updateRedisSO(ctx context.Context, email, orderID string, name ...string) error {
+func (s *sellerOrderService) updateRedisSO(ctx context.Context, email, orderID, name string) error {
 	data1, err := s.getSellerOrdersWithNoAct(ctx, email, orderID)
 	if err != nil {
 		return errors.New("failed to get seller order data: " + err.Error())
 	}
 
-	err = s.setRedisSO(*data1, name[0], email)
+	err = s.setRedisSO(*data1, name, email)
 	if err != nil {
-		return errors.New("failed to set user seller order data1 in cache: " + err.Error())
-	}
-
-	if len(name[1]) != 0 {
-		data2, err := s.getAllSellerOrdersWithNoAct(ctx, email)
-		if err != nil {
-			return errors.New("failed to get seller order data: " + err.Error())
-		}
-		err = s.setRedisSO(*data2, name[1], email)
-		if err != nil {
-			return errors.New("failed to set seller order data2 in cache: " + err.Error())
-		}
+		return errors.New("failed to set user seller order data in cache: " + err.Error())
 	}
 
 	return nil
@@ -102,46 +94,18 @@ func (s *sellerOrderService) getSellerOrdersWithNoAct(ctx context.Context, email
 	return result, nil
 }
 
-func (s *sellerOrderService) getAllSellerOrdersWithNoAct(ctx context.Context, email string) (*[]domain.SellerOrder, error) {
-	_, err := s.sellerRepo.FindSellerByEmail(ctx, email)
-	if err != nil {
-		return nil, errors.New("failed to find user: " + err.Error())
-	}
-
-	result, err := s.repo.GetAllSellerOrders(ctx, email)
-	if err != nil {
-		return nil, errors.New("failed to get all orders: " + err.Error())
-	}
-
-	return result, nil
-}
-
 // GetAllSellerOrders implements domain.SellerOrderService.
-func (s *sellerOrderService) GetAllSellerOrders(ctx context.Context, email string) (*[]domain.SellerOrder, error) {
-	val, err := s.cacheRepo.Get("all_seller-order:" + email)
-	if err == nil {
-		var data []domain.SellerOrder
-		err := json.Unmarshal(val, &data)
-		if err != nil {
-			return nil, errors.New("failed to unmarshal seller order data: " + err.Error())
-		}
-	}
-
-	_, err = s.sellerRepo.FindSellerByEmail(ctx, email)
+func (s *sellerOrderService) GetAllSellerOrders(ctx context.Context, email string, page pagination.Request) (*pagination.Page[domain.SellerOrder], error) {
+	_, err := s.sellerRepo.FindSellerByEmail(ctx, email)
 	if err != nil {
 		return nil, errors.New("failed to find seller: " + err.Error())
 	}
 
-	result, err := s.repo.GetAllSellerOrders(ctx, email)
+	result, err := s.repo.FindByEmail(ctx, email, page)
 	if err != nil {
 		return nil, errors.New("failed to get all orders: " + err.Error())
 	}
 
-	err = s.setRedisSO(*result, "all_seller-order:", email)
-	if err != nil {
-		return nil, err
-	}
-
 	return result, nil
 }
 
@@ -179,8 +143,8 @@ func (s *sellerOrderService) GetSellerOrderByEmailAndId(ctx context.Context, ema
 }
 
 // UpdateSellerAndUserOrder implements domain.SellerOrderService.
-func (s *sellerOrderService) UpdateSellerAndUserOrderStatus(ctx context.Context, email, orderID, productID string, req *dto.OrderStatusUpdateReq) error {
-	err := s.delRedisSO(email, "seller-order:", "all_seller-order:")
+func (s *sellerOrderService) UpdateSellerAndUserOrderStatus(ctx context.Context, email, orderID, productID, variantID string, req *dto.OrderStatusUpdateReq) error {
+	err := s.delRedisSO(email, "seller-order:")
 	if err != nil {
 		return err
 	}
@@ -200,46 +164,64 @@ func (s *sellerOrderService) UpdateSellerAndUserOrderStatus(ctx context.Context,
 		return errors.New("failed to get the user order: " + err.Error())
 	}
 
-	if sellerOrder.Payment_Status != "SUCCESS" {
+	if !isPaid(sellerOrder.Payment_Status) {
 		return errors.New("this order has not yet made payment")
 	}
 
-	_, err = s.repo.UpdateStatusOrderSeller(ctx, orderID, productID, req)
-	if err != nil {
-		return errors.New("failed to update the status seller order: " + err.Error())
+	var item *domain.SellerOrderItem
+	for i := range sellerOrder.Items {
+		if sellerOrder.Items[i].Is(productID, variantID) {
+			item = &sellerOrder.Items[i]
+		}
+	}
+
+	if item == nil {
+		return errors.New("no item found in the seller order")
+	}
+
+	next := domain.OrderStatus(req.Status)
+	if next == domain.OrderShipped {
+		if err := s.readyToShip(ctx, item); err != nil {
+			return err
+		}
 	}
 
-	_, err = s.orderRepo.UpdateStatusOrder(ctx, orderID, productID, req)
+	err = s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		return s.orderStatusSvc.Transition(ctx, orderID, productID, variantID, next, domain.ActorSeller)
+	})
 	if err != nil {
-		return errors.New("failed to update the status user order: " + err.Error())
+		return err
 	}
 
-	if req.Status == "SHIPPED" {
+	// the stock of the item has already been taken by the reservation made at checkout
+	if next == domain.OrderShipped {
 		for _, item := range order.Items {
-			if item.Product_Id == productID {
-				updateAT := time.Now()
-				res, err := s.productRepo.UpdateStockProduct(ctx, item.StoreID, item.Product_Id, -item.Quantity, updateAT)
+			if item.Is(productID, variantID) {
+				go s.notificationProductShipped(order.Email, productID, item.StoreID)
+
+				product, err := s.productRepo.GetProductById(ctx, productID)
 				if err != nil {
-					return errors.New("failed to update stock product: " + err.Error())
+					return errors.New("failed to get product by id: " + err.Error())
 				}
 
-				go s.notificationProductShipped(order.Email, productID, item.StoreID)
-
-				if res.ModifiedCount == 1 {
-					product, err := s.productRepo.GetProductById(ctx, productID)
+				// a variant runs out on its own stock
+				stock := product.Stock
+				if variantID != "" {
+					variant, err := domain.FindVariant(product.Variants, variantID)
 					if err != nil {
-						return errors.New("failed to get product by id: " + err.Error())
-					}
-					if product.Stock <= 2 {
-						go s.notificationStockProduct(email, productID)
+						return err
 					}
+					stock = variant.Stock
+				}
+				if stock <= 2 {
+					go s.notificationStockProduct(email, productID)
 				}
 			}
 		}
 	}
 
 	defer func() {
-		if err := s.updateRedisSO(ctx, email, orderID, "seller-order:", "all_seller-order:"); err != nil {
+		if err := s.updateRedisSO(ctx, email, orderID, "seller-order:"); err != nil {
 			log.Println("failed to update seller order in cache: ", err)
 		}
 	}()
@@ -247,9 +229,36 @@ func (s *sellerOrderService) UpdateSellerAndUserOrderStatus(ctx context.Context,
 	return nil
 }
 
+// readyToShip refuses to ship a weighed item before its packed quantity is
+// entered and the extra charge of a heavier pack is paid.
+func (s *sellerOrderService) readyToShip(ctx context.Context, item *domain.SellerOrderItem) error {
+	if !domain.UnitOf(item.Unit).Weighed() {
+		return nil
+	}
+
+	if item.Ordered_Quantity == 0 {
+		return errors.New("enter the packed quantity of this item before it is shipped")
+	}
+
+	if item.Charge_Id == "" {
+		return nil
+	}
+
+	charge, err := s.paymentRepo.FindByOrderId(ctx, item.Charge_Id)
+	if err != nil {
+		return errors.New("failed to get the extra charge: " + err.Error())
+	}
+
+	if charge.Status != string(domain.PaymentSuccess) {
+		return errors.New("the extra charge of the packed quantity has not yet been paid")
+	}
+
+	return nil
+}
+
 // CancelOrder implements domain.SellerOrderService.
-func (s *sellerOrderService) CancelOrder(ctx context.Context, email string, orderID string, productID string) error {
-	err := s.delRedisSO(email, "seller-order:", "all_seller-order:")
+func (s *sellerOrderService) CancelOrder(ctx context.Context, email, orderID, productID, variantID string) error {
+	err := s.delRedisSO(email, "seller-order:")
 	if err != nil {
 		return err
 	}
@@ -259,38 +268,27 @@ func (s *sellerOrderService) CancelOrder(ctx context.Context, email string, orde
 		return errors.New("failed to get the order: " + err.Error())
 	}
 
-	for _, item := range order.Items {
-		if item.Product_Id == productID {
-			if item.Status != "PENDING" {
-				return errors.New("order status is not 'PENDING'")
-			}
+	var item *domain.SellerOrderItem
+	for i := range order.Items {
+		if order.Items[i].Is(productID, variantID) {
+			item = &order.Items[i]
 		}
 	}
 
-	res, err := s.repo.DeleteItem(ctx, email, orderID, productID)
-	if err != nil {
-		return errors.New("failed to delete item: " + err.Error())
-	}
-
-	if res.ModifiedCount == 0 {
-		return errors.New("no item deleted")
+	if item == nil {
+		return errors.New("no item found in the seller order")
 	}
 
-	for _, item := range order.Items {
-		if item.Product_Id == productID {
-			res, err := s.orderRepo.DeleteItem(ctx, orderID, productID)
-			if err != nil {
-				return errors.New("failed to delete item: " + err.Error())
-			}
-
-			if res.ModifiedCount == 0 {
-				return errors.New("no item deleted")
-			}
-		}
+	err = s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		return cancelOrderItem(ctx, s.orderStatusSvc, s.orderRepo, s.repo, s.reservationSvc,
+			email, orderID, productID, variantID, item.Price*item.Quantity, domain.ActorSeller)
+	})
+	if err != nil {
+		return err
 	}
 
 	defer func() {
-		if err := s.updateRedisSO(ctx, email, orderID, "seller-order:", "all_seller-order"); err != nil {
+		if err := s.updateRedisSO(ctx, email, orderID, "seller-order:"); err != nil {
 			log.Println("failed to update seller order in cache: ", err)
 		}
 	}()
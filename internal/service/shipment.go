@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+)
+
+type shipmentService struct {
+	repo            domain.ShipmentRepository
+	orderRepo       domain.OrderRepository
+	sellerOrderRepo domain.SellerOrderRepository
+	orderStatusSvc  domain.OrderStatusService
+	tracker         domain.CarrierTracker
+	notifSvc        domain.NotificationService
+	cacheRepo       domain.CacheRepository
+}
+
+func NewShipmentService(repo domain.ShipmentRepository, orderRepo domain.OrderRepository,
+	sellerOrderRepo domain.SellerOrderRepository, orderStatusSvc domain.OrderStatusService,
+	tracker domain.CarrierTracker, notifSvc domain.NotificationService, cacheRepo domain.CacheRepository) domain.ShipmentService {
+	return &shipmentService{
+		repo:            repo,
+		orderRepo:       orderRepo,
+		sellerOrderRepo: sellerOrderRepo,
+		orderStatusSvc:  orderStatusSvc,
+		tracker:         tracker,
+		notifSvc:        notifSvc,
+		cacheRepo:       cacheRepo,
+	}
+}
+
+// AddShipment implements domain.ShipmentService.
+// Adding the tracking number of a PROCESSED item ships it.
+func (s *shipmentService) AddShipment(ctx context.Context, email, orderID, productID, variantID string, req *dto.ShipmentReq) error {
+	if req.Carrier == "" || req.Tracking_Number == "" {
+		return errors.New("carrier and tracking number are required")
+	}
+
+	item, err := s.getSellerItem(ctx, email, orderID, productID, variantID)
+	if err != nil {
+		return err
+	}
+
+	if item.Shipment != nil {
+		return errors.New("tracking has already been added to this item")
+	}
+
+	if item.Status != string(domain.OrderShipped) {
+		err = s.orderStatusSvc.Transition(ctx, orderID, productID, variantID, domain.OrderShipped, domain.ActorSeller)
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	shipment := domain.Shipment{
+		Carrier:            req.Carrier,
+		Tracking_Number:    req.Tracking_Number,
+		Estimated_Delivery: req.Estimated_Delivery,
+		Events:             []domain.TrackingEvent{},
+		Created_At:         now,
+		Updated_At:         now,
+	}
+
+	err = s.saveShipment(ctx, email, orderID, productID, variantID, shipment)
+	if err != nil {
+		return err
+	}
+
+	s.notify(ctx, item.User_Email, "USER_SHIPMENT_UPDATED", orderID, productID, &shipment)
+
+	return nil
+}
+
+// UpdateShipment implements domain.ShipmentService.
+// A new carrier or tracking number starts the timeline over.
+func (s *shipmentService) UpdateShipment(ctx context.Context, email, orderID, productID, variantID string, req *dto.ShipmentReq) error {
+	item, err := s.getSellerItem(ctx, email, orderID, productID, variantID)
+	if err != nil {
+		return err
+	}
+
+	if item.Shipment == nil {
+		return errors.New("no tracking found for this item, add it first")
+	}
+
+	shipment := *item.Shipment
+	if shipment.Delivered_At != nil {
+		return errors.New("this item has already been delivered")
+	}
+
+	if (req.Carrier != "" && req.Carrier != shipment.Carrier) ||
+		(req.Tracking_Number != "" && req.Tracking_Number != shipment.Tracking_Number) {
+		shipment.Events = []domain.TrackingEvent{}
+	}
+
+	if req.Carrier != "" {
+		shipment.Carrier = req.Carrier
+	}
+	if req.Tracking_Number != "" {
+		shipment.Tracking_Number = req.Tracking_Number
+	}
+	if !req.Estimated_Delivery.IsZero() {
+		shipment.Estimated_Delivery = req.Estimated_Delivery
+	}
+	shipment.Updated_At = time.Now()
+
+	err = s.saveShipment(ctx, email, orderID, productID, variantID, shipment)
+	if err != nil {
+		return err
+	}
+
+	s.notify(ctx, item.User_Email, "USER_SHIPMENT_UPDATED", orderID, productID, &shipment)
+
+	return nil
+}
+
+// GetOrderShipments implements domain.ShipmentService.
+func (s *shipmentService) GetOrderShipments(ctx context.Context, email, orderID string) (*[]domain.ItemShipment, error) {
+	order, err := s.orderRepo.GetOrder(ctx, orderID, email)
+	if err != nil {
+		return nil, errors.New("failed to get the order: " + err.Error())
+	}
+
+	sellerOrders, err := s.sellerOrderRepo.GetSellerOrderById(ctx, orderID)
+	if err != nil {
+		return nil, errors.New("failed to get seller orders: " + err.Error())
+	}
+
+	type itemKey struct{ productID, variantID string }
+	shipments := map[itemKey]*domain.Shipment{}
+	for _, sellerOrder := range *sellerOrders {
+		for _, item := range sellerOrder.Items {
+			shipments[itemKey{item.Product_Id, item.Variant_Id}] = item.Shipment
+		}
+	}
+
+	result := []domain.ItemShipment{}
+	for _, item := range order.Items {
+		result = append(result, domain.ItemShipment{
+			Product_Id:   item.Product_Id,
+			Variant_Id:   item.Variant_Id,
+			Product_Name: item.Product_Name,
+			Status:       item.Order_Status,
+			Shipment:     shipments[itemKey{item.Product_Id, item.Variant_Id}],
+		})
+	}
+
+	return &result, nil
+}
+
+// RefreshTracking implements domain.ShipmentService.
+// A shipment the carrier can not track is logged and tried again on the next run.
+func (s *shipmentService) RefreshTracking(ctx context.Context) (int, error) {
+	orders, err := s.repo.FindInTransit(ctx)
+	if err != nil {
+		return 0, errors.New("failed to find shipments in transit: " + err.Error())
+	}
+
+	var updated int
+	for _, order := range *orders {
+		for _, item := range order.Items {
+			if item.Shipment == nil || item.Shipment.Delivered_At != nil {
+				continue
+			}
+
+			changed, err := s.refreshShipment(ctx, &order, &item)
+			if err != nil {
+				log.Println("failed to refresh tracking of order", order.Order_id, "product", item.Product_Id, ": ", err)
+				continue
+			}
+
+			if changed {
+				updated++
+			}
+		}
+	}
+
+	return updated, nil
+}
+
+func (s *shipmentService) refreshShipment(ctx context.Context, order *domain.SellerOrder, item *domain.SellerOrderItem) (bool, error) {
+	shipment := *item.Shipment
+
+	info, err := s.tracker.Track(ctx, shipment.Carrier, shipment.Tracking_Number)
+	if err != nil {
+		return false, err
+	}
+
+	if len(info.Events) == len(shipment.Events) && info.Delivered_At == nil &&
+		(info.Estimated_Delivery.IsZero() || info.Estimated_Delivery.Equal(shipment.Estimated_Delivery)) {
+		return false, nil
+	}
+
+	shipment.Events = info.Events
+	shipment.Delivered_At = info.Delivered_At
+	if !info.Estimated_Delivery.IsZero() {
+		shipment.Estimated_Delivery = info.Estimated_Delivery
+	}
+	shipment.Updated_At = time.Now()
+
+	err = s.saveShipment(ctx, order.Email, order.Order_id, item.Product_Id, item.Variant_Id, shipment)
+	if err != nil {
+		return false, err
+	}
+
+	if shipment.Delivered_At != nil {
+		s.notify(ctx, item.User_Email, "USER_SHIPMENT_DELIVERED", order.Order_id, item.Product_Id, &shipment)
+	}
+
+	return true, nil
+}
+
+func (s *shipmentService) getSellerItem(ctx context.Context, email, orderID, productID, variantID string) (*domain.SellerOrderItem, error) {
+	sellerOrder, err := s.sellerOrderRepo.GetSellerOrderByEmailAndId(ctx, email, orderID)
+	if err != nil {
+		return nil, errors.New("failed to get the order: " + err.Error())
+	}
+
+	for i := range sellerOrder.Items {
+		if sellerOrder.Items[i].Is(productID, variantID) {
+			return &sellerOrder.Items[i], nil
+		}
+	}
+
+	return nil, errors.New("no item found in the seller order")
+}
+
+func (s *shipmentService) saveShipment(ctx context.Context, email, orderID, productID, variantID string, shipment domain.Shipment) error {
+	res, err := s.repo.UpdateShipment(ctx, email, orderID, productID, variantID, shipment)
+	if err != nil {
+		return errors.New("failed to update shipment: " + err.Error())
+	}
+
+	if res.MatchedCount == 0 {
+		return errors.New("no item found in the seller order")
+	}
+
+	// the seller order is cached with its items
+	if err := s.cacheRepo.Del("seller-order:" + email); err != nil {
+		log.Println("failed to delete seller order in cache: ", err)
+	}
+
+	return nil
+}
+
+func (s *shipmentService) notify(ctx context.Context, email, code, orderID, productID string, shipment *domain.Shipment) {
+	data := map[string]string{
+		"order_id":        orderID,
+		"product_id":      productID,
+		"carrier":         shipment.Carrier,
+		"tracking_number": shipment.Tracking_Number,
+	}
+
+	err := s.notifSvc.Insert(ctx, email, code, data)
+	if err != nil {
+		log.Println("failed to insert shipment notification: ", err)
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/IndraSty/GreenBasket/domain"
+)
+
+type shippingService struct {
+	provider    domain.ShippingRateProvider
+	productRepo domain.ProductRepository
+	storeRepo   domain.StoreRepository
+	userRepo    domain.UserRepository
+	cartRepo    domain.CartRepository
+}
+
+func NewShippingService(provider domain.ShippingRateProvider, productRepo domain.ProductRepository,
+	storeRepo domain.StoreRepository, userRepo domain.UserRepository, cartRepo domain.CartRepository) domain.ShippingService {
+	return &shippingService{
+		provider:    provider,
+		productRepo: productRepo,
+		storeRepo:   storeRepo,
+		userRepo:    userRepo,
+		cartRepo:    cartRepo,
+	}
+}
+
+// Quote implements domain.ShippingService.
+// Every store ships its items in one parcel from the city of the store. A
+// parcel that can not be shipped returns domain.ErrNoShippingRate as is.
+func (s *shippingService) Quote(ctx context.Context, destination domain.Address, items []domain.OrderItem) (*domain.ShippingQuote, error) {
+	quote := domain.ShippingQuote{
+		Destination_City: destination.City,
+		Stores:           []domain.StoreShipping{},
+	}
+
+	parcels := map[string]*domain.StoreShipping{}
+	var storeIDs []string
+
+	for _, item := range items {
+		product, err := s.productRepo.GetProductById(ctx, item.Product_Id)
+		if err != nil {
+			return nil, errors.New("failed to get product by id: " + err.Error())
+		}
+
+		parcel, ok := parcels[item.StoreID]
+		if !ok {
+			store, err := s.storeRepo.GetStore(ctx, item.StoreID)
+			if err != nil {
+				return nil, errors.New("failed to find store: " + err.Error())
+			}
+
+			if store.Address_Details == nil {
+				return nil, errors.New("store " + store.Name + " doesn't have an address")
+			}
+
+			parcel = &domain.StoreShipping{
+				Store_Id:    item.StoreID,
+				Store_Name:  store.Name,
+				Origin_City: store.Address_Details.City,
+			}
+			parcels[item.StoreID] = parcel
+			storeIDs = append(storeIDs, item.StoreID)
+		}
+
+		// a weighed item may be a fraction of a unit, its weight is rounded up to the gram
+		parcel.Weight += int(math.Ceil(float64(product.Weight) * item.Quantity))
+		quote.Subtotal += item.Price * item.Quantity
+	}
+
+	for _, storeID := range storeIDs {
+		parcel := parcels[storeID]
+
+		fee, err := s.provider.Rate(ctx, parcel.Origin_City, destination.City, parcel.Weight)
+		if errors.Is(err, domain.ErrNoShippingRate) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, errors.New("failed to get shipping rate from " + parcel.Origin_City + " to " + destination.City + ": " + err.Error())
+		}
+
+		parcel.Fee = fee
+		quote.Shipping_Fee += fee
+		quote.Stores = append(quote.Stores, *parcel)
+	}
+
+	quote.Total = quote.Subtotal + quote.Shipping_Fee
+
+	return &quote, nil
+}
+
+// QuoteCart implements domain.ShippingService.
+func (s *shippingService) QuoteCart(ctx context.Context, email string) (*domain.ShippingQuote, error) {
+	user, err := s.userRepo.FindUserByEmail(ctx, email)
+	if err != nil {
+		return nil, errors.New("failed to find user: " + err.Error())
+	}
+
+	if user.Address_Details == nil {
+		return nil, errors.New("user doesn't have an addresses")
+	}
+
+	cart, err := s.cartRepo.GetUserCart(ctx, email)
+	if err != nil {
+		return nil, errors.New("failed to get user cart: " + err.Error())
+	}
+
+	var items []domain.OrderItem
+	for _, item := range cart.Items {
+		if item.Selected {
+			items = append(items, domain.OrderItem{
+				Product_Id: item.Product_Id,
+				StoreID:    item.StoreID,
+				Quantity:   item.Quantity,
+				Price:      item.Price,
+			})
+		}
+	}
+
+	if len(items) == 0 {
+		return nil, errors.New("no items selected in the cart")
+	}
+
+	return s.Quote(ctx, *user.Address_Details, items)
+}
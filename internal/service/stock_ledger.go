@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
+	"github.com/asaskevich/govalidator"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type stockLedgerService struct {
+	repo        domain.StockMovementRepository
+	productRepo domain.ProductRepository
+	storeRepo   domain.StoreRepository
+	transactor  domain.Transactor
+}
+
+func NewStockLedgerService(repo domain.StockMovementRepository, productRepo domain.ProductRepository,
+	storeRepo domain.StoreRepository, transactor domain.Transactor) domain.StockLedgerService {
+	return &stockLedgerService{
+		repo:        repo,
+		productRepo: productRepo,
+		storeRepo:   storeRepo,
+		transactor:  transactor,
+	}
+}
+
+// Record implements domain.StockLedgerService.
+func (s *stockLedgerService) Record(ctx context.Context, movement domain.StockMovement) error {
+	movement.ID = primitive.NewObjectID()
+	movement.Movement_Id = movement.ID.Hex()
+	if movement.Created_At.IsZero() {
+		movement.Created_At = time.Now()
+	}
+
+	_, err := s.repo.Insert(ctx, movement)
+	if err != nil {
+		return fmt.Errorf("failed to record stock movement: %w", err)
+	}
+
+	return nil
+}
+
+// AdjustStock implements domain.StockLedgerService.
+func (s *stockLedgerService) AdjustStock(ctx context.Context, email, storeID, productID string, req *dto.StockAdjustmentReq) error {
+	_, err := govalidator.ValidateStruct(req)
+	if err != nil {
+		return errors.New("Invalid request body: " + err.Error())
+	}
+
+	if err := s.checkStore(ctx, storeID, email); err != nil {
+		return err
+	}
+
+	product, err := s.productRepo.GetProductById(ctx, productID, storeID)
+	if err != nil {
+		return errors.New("failed to get product: " + err.Error())
+	}
+
+	if len(product.Variants) > 0 {
+		if _, err := domain.FindVariant(product.Variants, req.Variant_Id); err != nil {
+			return err
+		}
+	} else if req.Variant_Id != "" {
+		return errors.New("product has no variants")
+	}
+
+	return s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		updateAt := time.Now()
+		if req.Quantity < 0 {
+			// taking stock out must leave the stock at 0 or more
+			res, err := s.productRepo.ReserveStock(ctx, storeID, productID, req.Variant_Id, -req.Quantity, updateAt)
+			if err != nil {
+				return errors.New("failed to update stock product: " + err.Error())
+			}
+
+			if res.ModifiedCount == 0 {
+				return errors.New("product stock is less than the quantity taken out")
+			}
+		} else {
+			_, err := s.productRepo.UpdateStockProduct(ctx, storeID, productID, req.Variant_Id, req.Quantity, updateAt)
+			if err != nil {
+				return errors.New("failed to update stock product: " + err.Error())
+			}
+		}
+
+		return s.Record(ctx, domain.StockMovement{
+			Store_Id:   storeID,
+			Product_Id: productID,
+			Variant_Id: req.Variant_Id,
+			Type:       domain.MovementAdjustment,
+			Quantity:   req.Quantity,
+			Actor:      email,
+			Reason:     req.Reason,
+			Created_At: updateAt,
+		})
+	})
+}
+
+// GetMovements implements domain.StockLedgerService.
+func (s *stockLedgerService) GetMovements(ctx context.Context, email, storeID, productID string, page pagination.Request) (*pagination.Page[domain.StockMovement], error) {
+	if err := s.checkStore(ctx, storeID, email); err != nil {
+		return nil, err
+	}
+
+	movements, err := s.repo.FindByProductId(ctx, storeID, productID, page)
+	if err != nil {
+		return nil, errors.New("failed to get stock movements: " + err.Error())
+	}
+
+	return movements, nil
+}
+
+// Reconcile implements domain.StockLedgerService.
+func (s *stockLedgerService) Reconcile(ctx context.Context, opening, fix bool) (*[]dto.StockDrift, error) {
+	totals, err := s.repo.Totals(ctx)
+	if err != nil {
+		return nil, errors.New("failed to sum stock movements: " + err.Error())
+	}
+
+	ledger := map[string]float64{}
+	tracked := map[string]bool{}
+	for _, total := range *totals {
+		ledger[total.Product_Id+"/"+total.Variant_Id] = total.Quantity
+		tracked[total.Product_Id] = true
+	}
+
+	products, err := s.productRepo.GetAllStock(ctx)
+	if err != nil {
+		return nil, errors.New("failed to get products: " + err.Error())
+	}
+
+	drifts := []dto.StockDrift{}
+	for _, product := range *products {
+		// the stock of a product with variants belongs to its variants
+		stocks := []domain.Variant{{Stock: product.Stock}}
+		if len(product.Variants) > 0 {
+			stocks = product.Variants
+		}
+
+		if opening && !tracked[product.Product_id] {
+			for _, stock := range stocks {
+				err := s.Record(ctx, domain.StockMovement{
+					Store_Id:   product.Store_id,
+					Product_Id: product.Product_id,
+					Variant_Id: stock.Variant_Id,
+					Type:       domain.MovementAdjustment,
+					Quantity:   stock.Stock,
+					Actor:      string(domain.ActorSystem),
+					Reason:     "opening balance",
+				})
+				if err != nil {
+					return nil, err
+				}
+				ledger[product.Product_id+"/"+stock.Variant_Id] = stock.Stock
+			}
+		}
+
+		for _, stock := range stocks {
+			variantID := stock.Variant_Id
+			balance := ledger[product.Product_id+"/"+variantID]
+			// quantities are floats, a sale of 0.1 and 0.2 kg is not exactly 0.3 kg
+			if math.Abs(stock.Stock-balance) < 1e-9 {
+				continue
+			}
+
+			drifts = append(drifts, dto.StockDrift{
+				Store_Id:   product.Store_id,
+				Product_Id: product.Product_id,
+				Variant_Id: variantID,
+				Stock:      stock.Stock,
+				Ledger:     balance,
+			})
+
+			if fix {
+				_, err := s.productRepo.UpdateStockProduct(ctx, product.Store_id, product.Product_id, variantID, balance-stock.Stock, time.Now())
+				if err != nil {
+					return nil, errors.New("failed to fix stock product: " + err.Error())
+				}
+			}
+		}
+	}
+
+	return &drifts, nil
+}
+
+func (s *stockLedgerService) checkStore(ctx context.Context, storeID, email string) error {
+	store, err := s.storeRepo.GetStore(ctx, storeID, email)
+	if err != nil {
+		return errors.New("failed to find store: " + err.Error())
+	}
+
+	if store == nil {
+		return errors.New("store not found")
+	}
+
+	return nil
+}
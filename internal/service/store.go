@@ -19,15 +19,20 @@ type storeService struct {
 	sellerRepo  domain.SellerRepository
 	salesReport domain.SalesReportRepository
 	cacheRepo   domain.CacheRepository
+	uploadSvc   domain.UploadService
+	searchSvc   domain.ProductSearchService
 }
 
 func NewStoreService(storeRepo domain.StoreRepository, sellerRepo domain.SellerRepository,
-	salesReport domain.SalesReportRepository, cacheRepo domain.CacheRepository) domain.StoreService {
+	salesReport domain.SalesReportRepository, cacheRepo domain.CacheRepository, uploadSvc domain.UploadService,
+	searchSvc domain.ProductSearchService) domain.StoreService {
 	return &storeService{
 		storeRepo:   storeRepo,
 		sellerRepo:  sellerRepo,
 		salesReport: salesReport,
 		cacheRepo:   cacheRepo,
+		uploadSvc:   uploadSvc,
+		searchSvc:   searchSvc,
 	}
 }
 
@@ -165,6 +170,10 @@ func (s *storeService) DeleteStore(ctx context.Context, email, storeID string) (
 		return nil, errors.New("no item was deleted")
 	}
 
+	if err := s.uploadSvc.Release(ctx, store.Logo, store.Banner); err != nil {
+		log.Println("failed to release images of store "+storeID+": ", err)
+	}
+
 	err = s.delRedisStore(email)
 	if err != nil {
 		return nil, err
@@ -265,6 +274,13 @@ func (s *storeService) UpdateStore(ctx context.Context, email, storeID string, r
 		return nil, errors.New("no store was updated")
 	}
 
+	// the products are found by the name of their store too
+	if req.Name != "" && req.Name != store.Name {
+		if err := s.searchSvc.SyncStore(ctx, storeID); err != nil {
+			log.Println("failed to sync products of store "+storeID+" to the search index: ", err)
+		}
+	}
+
 	defer func() {
 		if err := s.updateRedisStore(ctx, email, storeID); err != nil {
 			log.Println("failed to update store data in cache: ", err)
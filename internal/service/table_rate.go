@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/IndraSty/GreenBasket/domain"
+)
+
+type tableRateProvider struct {
+	repo domain.ShippingRateRepository
+}
+
+// NewTableRateProvider prices parcels from the Shipping_Rates table.
+func NewTableRateProvider(repo domain.ShippingRateRepository) domain.ShippingRateProvider {
+	return &tableRateProvider{
+		repo: repo,
+	}
+}
+
+// Rate implements domain.ShippingRateProvider.
+// The parcel takes the lightest weight bracket it fits in.
+func (p *tableRateProvider) Rate(ctx context.Context, origin, destination string, weight int) (float64, error) {
+	rates, err := p.repo.FindRates(ctx, strings.TrimSpace(origin), strings.TrimSpace(destination))
+	if err != nil {
+		return 0, errors.New("failed to find shipping rates: " + err.Error())
+	}
+
+	for _, rate := range *rates {
+		if weight <= rate.Max_Weight {
+			return rate.Fee, nil
+		}
+	}
+
+	return 0, domain.ErrNoShippingRate
+}
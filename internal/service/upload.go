@@ -0,0 +1,266 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxImagePixels refuses images that are small files but huge once decoded.
+const maxImagePixels = 40_000_000
+
+// imageExtensions are the image types accepted, by sniffed content type.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type uploadService struct {
+	repo          domain.UploadRepository
+	productRepo   domain.ProductRepository
+	storeRepo     domain.StoreRepository
+	storage       domain.BlobStorage
+	maxSize       int64
+	thumbnailSize int
+}
+
+func NewUploadService(repo domain.UploadRepository, productRepo domain.ProductRepository, storeRepo domain.StoreRepository,
+	storage domain.BlobStorage, maxSize int64, thumbnailSize int) domain.UploadService {
+	return &uploadService{
+		repo:          repo,
+		productRepo:   productRepo,
+		storeRepo:     storeRepo,
+		storage:       storage,
+		maxSize:       maxSize,
+		thumbnailSize: thumbnailSize,
+	}
+}
+
+// UploadImage implements domain.UploadService.
+// The type is sniffed from the content, the name and type sent by the client
+// are not trusted.
+func (s *uploadService) UploadImage(ctx context.Context, email string, file io.Reader) (*dto.UploadRes, error) {
+	data, err := io.ReadAll(io.LimitReader(file, s.maxSize+1))
+	if err != nil {
+		return nil, errors.New("failed to read the file: " + err.Error())
+	}
+
+	if int64(len(data)) > s.maxSize {
+		return nil, domain.ErrImageTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, domain.ErrUnsupportedImage
+	}
+
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, domain.ErrUnsupportedImage
+	}
+
+	if imageConfig.Width*imageConfig.Height > maxImagePixels {
+		return nil, domain.ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, domain.ErrUnsupportedImage
+	}
+
+	thumbnail, thumbnailType, err := encodeThumbnail(img, contentType, s.thumbnailSize)
+	if err != nil {
+		return nil, errors.New("failed to make thumbnail: " + err.Error())
+	}
+
+	id := primitive.NewObjectID()
+	upload := domain.Upload{
+		ID:            id,
+		Upload_Id:     id.Hex(),
+		Email:         email,
+		Key:           "images/" + id.Hex() + ext,
+		Thumbnail_Key: "thumbnails/" + id.Hex() + imageExtensions[thumbnailType],
+		Content_Type:  contentType,
+		Size:          int64(len(data)),
+		Width:         imageConfig.Width,
+		Height:        imageConfig.Height,
+		Created_At:    time.Now(),
+	}
+	upload.Url = s.storage.URL(upload.Key)
+	upload.Thumbnail_Url = s.storage.URL(upload.Thumbnail_Key)
+
+	if err := s.storage.Put(ctx, upload.Key, contentType, data); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.Put(ctx, upload.Thumbnail_Key, thumbnailType, thumbnail); err != nil {
+		s.deleteBlobs(ctx, upload.Key)
+		return nil, err
+	}
+
+	_, err = s.repo.Insert(ctx, upload)
+	if err != nil {
+		s.deleteBlobs(ctx, upload.Key, upload.Thumbnail_Key)
+		return nil, errors.New("failed to insert upload: " + err.Error())
+	}
+
+	return &dto.UploadRes{
+		Upload_Id:     upload.Upload_Id,
+		Url:           upload.Url,
+		Thumbnail_Url: upload.Thumbnail_Url,
+		Content_Type:  upload.Content_Type,
+		Size:          upload.Size,
+		Width:         upload.Width,
+		Height:        upload.Height,
+	}, nil
+}
+
+// Release implements domain.UploadService.
+func (s *uploadService) Release(ctx context.Context, urls ...string) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	uploads, err := s.repo.FindByUrls(ctx, urls)
+	if err != nil {
+		return errors.New("failed to find uploads: " + err.Error())
+	}
+
+	var releaseErr error
+	for _, upload := range *uploads {
+		used, err := s.inUse(ctx, upload.Url, upload.Thumbnail_Url)
+		if err != nil {
+			releaseErr = err
+			continue
+		}
+
+		if used {
+			continue
+		}
+
+		// the record goes last, a blob left behind by a failure is found again next time
+		if err := s.deleteBlobs(ctx, upload.Key, upload.Thumbnail_Key); err != nil {
+			releaseErr = err
+			continue
+		}
+
+		if err := s.repo.Delete(ctx, upload.Upload_Id); err != nil {
+			releaseErr = errors.New("failed to delete upload: " + err.Error())
+		}
+	}
+
+	return releaseErr
+}
+
+func (s *uploadService) inUse(ctx context.Context, urls ...string) (bool, error) {
+	for _, url := range urls {
+		products, err := s.productRepo.CountByImage(ctx, url)
+		if err != nil {
+			return false, errors.New("failed to count products with image: " + err.Error())
+		}
+
+		stores, err := s.storeRepo.CountByImage(ctx, url)
+		if err != nil {
+			return false, errors.New("failed to count stores with image: " + err.Error())
+		}
+
+		if products+stores > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (s *uploadService) deleteBlobs(ctx context.Context, keys ...string) error {
+	var deleteErr error
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Println("failed to delete blob "+key+": ", err)
+			deleteErr = err
+		}
+	}
+
+	return deleteErr
+}
+
+// encodeThumbnail scales img down to fit in a size by size square and
+// encodes it as a jpeg, or as a png when the image can be transparent.
+func encodeThumbnail(img image.Image, contentType string, size int) ([]byte, string, error) {
+	thumbnail := resizeImage(img, size)
+
+	var buf bytes.Buffer
+	if contentType == "image/jpeg" {
+		if err := jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 80}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+
+	if err := png.Encode(&buf, thumbnail); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/png", nil
+}
+
+// resizeImage scales img down to fit in a size by size square, every pixel of
+// the result is the average of the pixels it covers. Smaller images are
+// returned as they are.
+func resizeImage(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if size <= 0 || (width <= size && height <= size) {
+		return img
+	}
+
+	toWidth, toHeight := size, height*size/width
+	if height > width {
+		toWidth, toHeight = width*size/height, size
+	}
+	toWidth, toHeight = max(toWidth, 1), max(toHeight, 1)
+
+	src := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, toWidth, toHeight))
+	for y := 0; y < toHeight; y++ {
+		fromY, toY := y*height/toHeight, max((y+1)*height/toHeight, y*height/toHeight+1)
+		for x := 0; x < toWidth; x++ {
+			fromX, toX := x*width/toWidth, max((x+1)*width/toWidth, x*width/toWidth+1)
+
+			var sum [4]int
+			for sy := fromY; sy < toY; sy++ {
+				row := src.Pix[sy*src.Stride+fromX*4 : sy*src.Stride+toX*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+
+			count := (toY - fromY) * (toX - fromX)
+			i := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[i+c] = uint8(sum[c] / count)
+			}
+		}
+	}
+
+	return dst
+}
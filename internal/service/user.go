@@ -85,7 +85,7 @@ func (us *userService) RegisterUser(ctx context.Context, req *dto.UserRegisterRe
 		Email:         req.Email,
 		Password:      password,
 		Phone:         req.Phone,
-		Role:          "User",
+		Role:          domain.RoleUser,
 		Created_At:    time.Now(),
 		Updated_At:    time.Now(),
 		EmailVerified: false,
@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/asaskevich/govalidator"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type weightAdjustmentService struct {
+	orderRepo       domain.OrderRepository
+	sellerOrderRepo domain.SellerOrderRepository
+	productRepo     domain.ProductRepository
+	reservationRepo domain.ReservationRepository
+	refundRepo      domain.RefundRepository
+	refundSvc       domain.RefundService
+	paymentSvc      domain.PaymentService
+	batchSvc        domain.BatchService
+	ledgerSvc       domain.StockLedgerService
+	notifSvc        domain.NotificationService
+	cacheRepo       domain.CacheRepository
+	transactor      domain.Transactor
+}
+
+func NewWeightAdjustmentService(orderRepo domain.OrderRepository, sellerOrderRepo domain.SellerOrderRepository,
+	productRepo domain.ProductRepository, reservationRepo domain.ReservationRepository, refundRepo domain.RefundRepository, refundSvc domain.RefundService,
+	paymentSvc domain.PaymentService, batchSvc domain.BatchService, ledgerSvc domain.StockLedgerService,
+	notifSvc domain.NotificationService, cacheRepo domain.CacheRepository, transactor domain.Transactor) domain.WeightAdjustmentService {
+	return &weightAdjustmentService{
+		orderRepo:       orderRepo,
+		sellerOrderRepo: sellerOrderRepo,
+		productRepo:     productRepo,
+		reservationRepo: reservationRepo,
+		refundRepo:      refundRepo,
+		refundSvc:       refundSvc,
+		paymentSvc:      paymentSvc,
+		batchSvc:        batchSvc,
+		ledgerSvc:       ledgerSvc,
+		notifSvc:        notifSvc,
+		cacheRepo:       cacheRepo,
+		transactor:      transactor,
+	}
+}
+
+// AdjustPackedQuantity implements domain.WeightAdjustmentService.
+// The stock follows the packed quantity. A lighter pack is refunded right away
+// through RefundService, when the gateway fails the adjustment refund stays
+// REQUESTED and the seller approves it again from the refunds. A heavier pack
+// is charged to the buyer as an extra payment of the order.
+func (s *weightAdjustmentService) AdjustPackedQuantity(ctx context.Context, email, orderID, productID, variantID string, req *dto.PackedQuantityReq) (*dto.PackedQuantityRes, error) {
+	_, err := govalidator.ValidateStruct(req)
+	if err != nil {
+		return nil, errors.New("Invalid request body: " + err.Error())
+	}
+
+	if req.Quantity <= 0 {
+		return nil, errors.New("packed quantity must be more than 0")
+	}
+
+	sellerOrder, err := s.sellerOrderRepo.GetSellerOrderByEmailAndId(ctx, email, orderID)
+	if err != nil {
+		return nil, errors.New("failed to get the order: " + err.Error())
+	}
+
+	var item *domain.SellerOrderItem
+	for i := range sellerOrder.Items {
+		if sellerOrder.Items[i].Is(productID, variantID) {
+			item = &sellerOrder.Items[i]
+		}
+	}
+
+	if item == nil {
+		return nil, errors.New("no item found in the seller order")
+	}
+
+	if !domain.UnitOf(item.Unit).Weighed() {
+		return nil, errors.New("only an item sold by weight has a packed quantity")
+	}
+
+	if item.Ordered_Quantity != 0 {
+		return nil, errors.New("the packed quantity of this item has already been entered")
+	}
+
+	if item.Status != string(domain.OrderProcessed) {
+		return nil, errors.New("the packed quantity can only be entered while the item is " + string(domain.OrderProcessed))
+	}
+
+	order, err := s.orderRepo.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, errors.New("failed to get the user order: " + err.Error())
+	}
+
+	var storeID string
+	for _, orderItem := range order.Items {
+		if orderItem.Is(productID, variantID) {
+			storeID = orderItem.StoreID
+		}
+	}
+
+	ordered := item.Quantity
+	packed := req.Quantity
+	amount := util.ToFixed((packed-ordered)*item.Price, 2)
+
+	res := dto.PackedQuantityRes{
+		Ordered_Quantity: ordered,
+		Packed_Quantity:  packed,
+		Amount:           amount,
+	}
+
+	if packed > ordered {
+		if err := s.checkStock(ctx, storeID, productID, item.Variant_Id, packed-ordered); err != nil {
+			return nil, err
+		}
+	}
+
+	// the charge is opened before the order changes, a charge of an adjustment
+	// that did not go through is never paid and expires at the gateway
+	if amount > 0 {
+		res.Charge_Id = orderID + "-" + primitive.NewObjectID().Hex()
+		payment, err := s.paymentSvc.InitializePayment(ctx, &dto.PaymentReq{
+			OrderID:         res.Charge_Id,
+			UserID:          order.Email,
+			Parent_Order_Id: orderID,
+			Amount:          amount,
+		})
+		if err != nil {
+			return nil, errors.New("failed to charge the difference: " + err.Error())
+		}
+		res.Snap_Url = payment.Snap_Url
+	}
+
+	var refund *domain.Refund
+	if amount < 0 {
+		createdAt := time.Now()
+		refund = &domain.Refund{
+			ID:           primitive.NewObjectID(),
+			Refund_Id:    primitive.NewObjectID().Hex(),
+			Order_id:     orderID,
+			Product_Id:   productID,
+			Variant_Id:   variantID,
+			Store_Id:     storeID,
+			User_Email:   order.Email,
+			Seller_Email: sellerOrder.Email,
+			Quantity:     ordered - packed,
+			Amount:       -amount,
+			Reason:       fmt.Sprintf("packed %g %s of the %g %s ordered", packed, item.Unit, ordered, item.Unit),
+			Status:       domain.RefundRequested,
+			Adjustment:   true,
+			Created_At:   createdAt,
+			Updated_At:   createdAt,
+		}
+		res.Refund_Id = refund.Refund_Id
+	}
+
+	err = s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		return s.applyPackedQuantity(ctx, sellerOrder.Email, orderID, storeID, item, packed, amount, res.Charge_Id, refund)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.clearCache(sellerOrder.Email, order.Email)
+
+	if refund != nil {
+		if err := s.refundSvc.ApproveRefund(ctx, sellerOrder.Email, refund.Refund_Id); err != nil {
+			log.Println("failed to refund packed quantity of order "+orderID+": ", err)
+		}
+	}
+
+	if res.Snap_Url != "" {
+		data := map[string]string{
+			"order_id":   orderID,
+			"product_id": productID,
+			"amount":     fmt.Sprintf("%.2f", amount),
+			"snap_url":   res.Snap_Url,
+		}
+
+		if err := s.notifSvc.Insert(ctx, order.Email, "USER_EXTRA_CHARGE", data); err != nil {
+			log.Println("failed to insert extra charge notification: ", err)
+		}
+	}
+
+	return &res, nil
+}
+
+// applyPackedQuantity prices the item at the packed quantity on both orders and
+// moves the difference of the stock. The seller order item keeps chargeID so
+// the item waits for the charge before it is shipped.
+func (s *weightAdjustmentService) applyPackedQuantity(ctx context.Context, sellerEmail, orderID, storeID string,
+	item *domain.SellerOrderItem, packed, amount float64, chargeID string, refund *domain.Refund) error {
+	productID := item.Product_Id
+	ordered := item.Quantity
+	updateAt := time.Now()
+
+	res, err := s.orderRepo.UpdateItemQuantity(ctx, orderID, productID, item.Variant_Id, ordered, packed, updateAt)
+	if err != nil {
+		return errors.New("failed to update item quantity order: " + err.Error())
+	}
+
+	// another request has entered the packed quantity since we read the item
+	if res.ModifiedCount == 0 {
+		return errors.New("the packed quantity of this item has already been entered")
+	}
+
+	res, err = s.sellerOrderRepo.UpdateItemQuantity(ctx, orderID, productID, item.Variant_Id, ordered, packed, chargeID, updateAt)
+	if err != nil {
+		return errors.New("failed to update item quantity seller order: " + err.Error())
+	}
+
+	if res.ModifiedCount == 0 {
+		return errors.New("the packed quantity of this item has already been entered")
+	}
+
+	_, err = s.orderRepo.UpdateTotalPrice(ctx, orderID, amount)
+	if err != nil {
+		return errors.New("failed to update total price order: " + err.Error())
+	}
+
+	_, err = s.sellerOrderRepo.UpdateTotalPrice(ctx, sellerEmail, orderID, amount)
+	if err != nil {
+		return errors.New("failed to update total price seller order: " + err.Error())
+	}
+
+	reservation, err := s.findReservation(ctx, orderID, productID, item.Variant_Id)
+	if err != nil {
+		return err
+	}
+
+	batches := reservation.Batches
+	moved := ordered - packed
+	if packed > ordered {
+		res, err = s.productRepo.ReserveStock(ctx, storeID, productID, item.Variant_Id, packed-ordered, updateAt)
+		if err != nil {
+			return errors.New("failed to reserve stock product: " + err.Error())
+		}
+
+		if res.ModifiedCount == 0 {
+			return errors.New("product stock is less than the packed quantity")
+		}
+
+		extra, err := s.batchSvc.Allocate(ctx, productID, item.Variant_Id, packed-ordered)
+		if err != nil {
+			return err
+		}
+		batches = append(batches, extra...)
+	}
+
+	if packed < ordered {
+		var unpacked []domain.BatchAllocation
+		batches, unpacked = splitAllocations(batches, ordered-packed)
+
+		// a batch withdrawn since checkout is off sale, its part is not given back
+		withdrawn, err := s.batchSvc.Restore(ctx, unpacked)
+		if err != nil {
+			return err
+		}
+		moved -= withdrawn
+
+		_, err = s.productRepo.UpdateStockProduct(ctx, storeID, productID, item.Variant_Id, moved, updateAt)
+		if err != nil {
+			return errors.New("failed to give back stock product: " + err.Error())
+		}
+	}
+
+	_, err = s.reservationRepo.UpdateBatches(ctx, reservation.Reservation_Id, packed, batches, updateAt)
+	if err != nil {
+		return errors.New("failed to update reservation: " + err.Error())
+	}
+
+	err = s.ledgerSvc.Record(ctx, domain.StockMovement{
+		Store_Id:   storeID,
+		Product_Id: productID,
+		Variant_Id: item.Variant_Id,
+		Type:       domain.MovementShipment,
+		Quantity:   moved,
+		Actor:      sellerEmail,
+		Reason:     fmt.Sprintf("packed %g %s of the %g %s ordered", packed, item.Unit, ordered, item.Unit),
+		Ref_Id:     orderID,
+		Created_At: updateAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	if refund != nil {
+		_, err = s.refundRepo.Insert(ctx, *refund)
+		if err != nil {
+			return errors.New("failed to insert refund: " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// findReservation returns the reservation that holds the stock of the item, a
+// released reservation no longer does.
+func (s *weightAdjustmentService) findReservation(ctx context.Context, orderID, productID, variantID string) (*domain.Reservation, error) {
+	reservations, err := s.reservationRepo.FindByOrderId(ctx, orderID)
+	if err != nil {
+		return nil, errors.New("failed to get reservations: " + err.Error())
+	}
+
+	for _, reservation := range *reservations {
+		if reservation.Product_Id == productID && reservation.Variant_Id == variantID && reservation.Status != "RELEASED" {
+			return &reservation, nil
+		}
+	}
+
+	return nil, errors.New("no reservation found for the item")
+}
+
+// splitAllocations takes quantity off the batches that expire last and returns
+// the allocations that are kept and the ones taken off. Quantity beyond the
+// batches came from stock that is not tracked in a batch.
+func splitAllocations(allocations []domain.BatchAllocation, quantity float64) (kept, taken []domain.BatchAllocation) {
+	kept = append(kept, allocations...)
+	for i := len(kept) - 1; i >= 0 && quantity > 0; i-- {
+		take := math.Min(quantity, kept[i].Quantity)
+		taken = append(taken, domain.BatchAllocation{Batch_Id: kept[i].Batch_Id, Quantity: take})
+		kept[i].Quantity -= take
+		quantity -= take
+
+		if kept[i].Quantity == 0 {
+			kept = kept[:i]
+		}
+	}
+
+	return kept, taken
+}
+
+func (s *weightAdjustmentService) checkStock(ctx context.Context, storeID, productID, variantID string, quantity float64) error {
+	product, err := s.productRepo.GetProductById(ctx, productID, storeID)
+	if err != nil {
+		return errors.New("failed to get product: " + err.Error())
+	}
+
+	stock := product.Stock
+	if variantID != "" {
+		variant, err := domain.FindVariant(product.Variants, variantID)
+		if err != nil {
+			return err
+		}
+		stock = variant.Stock
+	}
+
+	if quantity > stock {
+		return errors.New("product stock is less than the packed quantity")
+	}
+
+	return nil
+}
+
+func (s *weightAdjustmentService) clearCache(sellerEmail, userEmail string) {
+	// both orders are cached with their items
+	for _, key := range []string{"seller-order:" + sellerEmail, "user-order:" + userEmail} {
+		if err := s.cacheRepo.Del(key); err != nil {
+			log.Println("failed to delete order in cache: ", err)
+		}
+	}
+}
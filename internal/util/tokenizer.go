@@ -2,6 +2,7 @@ package util
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -23,21 +24,63 @@ func NewTokenService(cnf *config.Config) domain.TokenService {
 	return &tokenService{cnf: cnf}
 }
 
-func (ts *tokenService) GenerateAllTokens(email string, firstname string, lastname string, uid string) (signedToken string, signedRefreshToken string, err error) {
+// accessTokenTTL and refreshTokenTTL are how long the tokens are valid after they are issued.
+const (
+	accessTokenTTL  = 5 * time.Minute
+	refreshTokenTTL = 168 * time.Hour
+)
+
+func (ts *tokenService) GenerateAllTokens(email string, firstname string, lastname string, uid string, account string, role string, staff *domain.StaffScope, tokenVersion int) (signedToken string, signedRefreshToken string, err error) {
+	refreshClaims := &domain.SignedDetails{
+		Email:         email,
+		Uid:           uid,
+		Account:       account,
+		Family:        primitive.NewObjectID().Hex(),
+		Token_Version: tokenVersion,
+	}
+
+	return ts.generateTokens(refreshClaims, firstname, lastname, role, staff)
+}
+
+func (ts *tokenService) RotateTokens(refreshClaims *domain.SignedDetails, firstname string, lastname string, role string, staff *domain.StaffScope) (signedToken string, signedRefreshToken string, err error) {
+	if refreshClaims.Family == "" {
+		return "", "", errors.New("token is not a refresh token")
+	}
+
+	return ts.generateTokens(&domain.SignedDetails{
+		Email:         refreshClaims.Email,
+		Uid:           refreshClaims.Uid,
+		Account:       refreshClaims.Account,
+		Family:        refreshClaims.Family,
+		Token_Version: refreshClaims.Token_Version,
+	}, firstname, lastname, role, staff)
+}
+
+func (ts *tokenService) generateTokens(refreshClaims *domain.SignedDetails, firstname string, lastname string, role string, staff *domain.StaffScope) (string, string, error) {
+	now := time.Now().Local()
+
+	// the id of the access token is what a logout revokes
 	claims := &domain.SignedDetails{
-		Email:      email,
-		First_Name: firstname,
-		Last_Name:  lastname,
-		Uid:        uid,
+		Email:         refreshClaims.Email,
+		First_Name:    firstname,
+		Last_Name:     lastname,
+		Uid:           refreshClaims.Uid,
+		Account:       refreshClaims.Account,
+		Role:          role,
+		Token_Version: refreshClaims.Token_Version,
+		Staff:         staff,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Minute * time.Duration(5)).Unix(),
+			Id:        primitive.NewObjectID().Hex(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(accessTokenTTL).Unix(),
 		},
 	}
 
-	refreshClaims := &domain.SignedDetails{
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(168)).Unix(),
-		},
+	// the id keeps two refresh tokens issued in the same second apart
+	refreshClaims.StandardClaims = jwt.StandardClaims{
+		Id:        primitive.NewObjectID().Hex(),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(refreshTokenTTL).Unix(),
 	}
 
 	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(ts.cnf.Token.Secret_Key))
@@ -47,11 +90,10 @@ func (ts *tokenService) GenerateAllTokens(email string, firstname string, lastna
 
 	refreshToken, err := jwt.NewWithClaims(jwt.SigningMethodHS384, refreshClaims).SignedString([]byte(ts.cnf.Token.Secret_Key))
 	if err != nil {
-		log.Panic(err)
-		return
+		return "", "", err
 	}
 
-	return token, refreshToken, err
+	return token, refreshToken, nil
 }
 
 func (ts *tokenService) UpdateRefreshToken(signedRefreshToken string, userId string, usercol *mongo.Collection) {
@@ -87,22 +129,62 @@ func (ts *tokenService) ValidateToken(signedToken string) (claims *domain.Signed
 		signedToken,
 		&domain.SignedDetails{},
 		func(t *jwt.Token) (interface{}, error) {
+			// access tokens are only signed with HS256, refresh tokens use HS384
+			if t.Method != jwt.SigningMethodHS256 {
+				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+			}
 			return []byte(ts.cnf.Token.Secret_Key), nil
 		},
 	)
 
-	// check token is invalid
+	// check token is expired
+	var validationErr *jwt.ValidationError
+	if errors.As(err, &validationErr) && validationErr.Errors&jwt.ValidationErrorExpired != 0 {
+		msg = fmt.Sprintf("token is expired: %v", err)
+		return nil, msg
+	}
+
+	// check token is invalid, a token signed with another key or method never
+	// gets here
+	if err != nil || !token.Valid {
+		msg = fmt.Sprintf("token is invalid: %v", err)
+		return nil, msg
+	}
+
 	claims, ok := token.Claims.(*domain.SignedDetails)
 	if !ok {
-		msg = fmt.Sprintf("token is invalid: %v", err)
-		return
+		msg = "token is invalid"
+		return nil, msg
 	}
 
-	// check token is expired
-	if claims.ExpiresAt < time.Now().Local().Unix() {
-		msg = fmt.Sprintf("token is expired: %v", err)
-		return
+	// a refresh token carries the email too, it must not open the api
+	if claims.Family != "" {
+		msg = "token is not an access token"
+		return nil, msg
 	}
 
 	return claims, msg
 }
+
+func (ts *tokenService) ValidateRefreshToken(signedRefreshToken string) (*domain.SignedDetails, error) {
+	token, err := jwt.ParseWithClaims(
+		signedRefreshToken,
+		&domain.SignedDetails{},
+		func(t *jwt.Token) (interface{}, error) {
+			if t.Method != jwt.SigningMethodHS384 {
+				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+			}
+			return []byte(ts.cnf.Token.Secret_Key), nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*domain.SignedDetails)
+	if !ok || !token.Valid || claims.Family == "" {
+		return nil, errors.New("token is not a refresh token")
+	}
+
+	return claims, nil
+}
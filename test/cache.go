@@ -0,0 +1,119 @@
+package test
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+)
+
+// MemoryCache is an in-memory domain.CacheRepository so the service tests
+// can run without a redis server.
+type MemoryCache struct {
+	mu      sync.Mutex
+	items   map[string][]byte
+	expires map[string]time.Time
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		items:   map[string][]byte{},
+		expires: map[string]time.Time{},
+	}
+}
+
+func (c *MemoryCache) Get(key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	val, ok := c.get(key)
+	if !ok {
+		return nil, domain.ErrCacheMiss
+	}
+	return val, nil
+}
+
+func (c *MemoryCache) Set(key string, entry []byte, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(key, entry, expiration)
+	return nil
+}
+
+func (c *MemoryCache) SetNX(key string, entry []byte, expiration time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.get(key); ok {
+		return false, nil
+	}
+
+	c.set(key, entry, expiration)
+	return true, nil
+}
+
+func (c *MemoryCache) ExpireIfEqual(key string, entry []byte, expiration time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if val, ok := c.get(key); !ok || string(val) != string(entry) {
+		return false, nil
+	}
+
+	if expiration <= 0 {
+		delete(c.items, key)
+		delete(c.expires, key)
+		return true, nil
+	}
+
+	c.expires[key] = time.Now().Add(expiration)
+	return true, nil
+}
+
+func (c *MemoryCache) Incr(key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var n int64
+	if val, ok := c.get(key); ok {
+		var err error
+		if n, err = strconv.ParseInt(string(val), 10, 64); err != nil {
+			return 0, err
+		}
+	}
+
+	n++
+	// like redis, incr keeps the expiration of the key
+	c.items[key] = []byte(strconv.FormatInt(n, 10))
+	return n, nil
+}
+
+func (c *MemoryCache) Del(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.items, key)
+	delete(c.expires, key)
+	return nil
+}
+
+func (c *MemoryCache) get(key string) ([]byte, bool) {
+	if at, ok := c.expires[key]; ok && time.Now().After(at) {
+		delete(c.items, key)
+		delete(c.expires, key)
+	}
+
+	val, ok := c.items[key]
+	return val, ok
+}
+
+// set keeps entry forever for a zero expiration, like redis does.
+func (c *MemoryCache) set(key string, entry []byte, expiration time.Duration) {
+	c.items[key] = entry
+	delete(c.expires, key)
+	if expiration > 0 {
+		c.expires[key] = time.Now().Add(expiration)
+	}
+}
@@ -0,0 +1,127 @@
+package pagination_test
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/internal/pagination"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var spec = pagination.Spec{
+	Keys:        map[string]string{"price": "price", "created_at": "created_at", "id": "item_id"},
+	Default:     "-created_at",
+	Tiebreaker:  "item_id",
+	Types:       map[string]bsontype.Type{"price": bsontype.Double, "created_at": bsontype.DateTime, "item_id": bsontype.String},
+	DefaultSize: 2,
+	MaxSize:     3,
+}
+
+type item struct {
+	Item_Id    string    `bson:"item_id"`
+	Price      float64   `bson:"price"`
+	Created_At time.Time `bson:"created_at"`
+}
+
+func TestParse(t *testing.T) {
+	page, err := spec.Parse("", "", "", "")
+	require.NoError(t, err)
+	require.Equal(t, []pagination.Key{{Field: "created_at", Desc: true}, {Field: "item_id", Desc: true}}, page.Sort)
+	require.Equal(t, 2, page.Size)
+	require.Equal(t, 1, page.Page)
+
+	page, err = spec.Parse("price, -created_at", "10", "3", "")
+	require.NoError(t, err)
+	require.Equal(t, bson.D{{Key: "price", Value: 1}, {Key: "created_at", Value: -1}, {Key: "item_id", Value: 1}}, page.SortDoc())
+	require.Equal(t, 3, page.Size)
+	require.Equal(t, 6, page.Skip())
+
+	// nothing is sorted after the tiebreaker
+	page, err = spec.Parse("-id,price", "", "x", "")
+	require.NoError(t, err)
+	require.Equal(t, []pagination.Key{{Field: "item_id", Desc: true}}, page.Sort)
+	require.Equal(t, 1, page.Page)
+
+	for _, sort := range []string{"stock", "price,price", "price,", "$where"} {
+		_, err = spec.Parse(sort, "", "", "")
+		require.ErrorIs(t, err, pagination.ErrInvalidSort, sort)
+	}
+
+	_, err = spec.Parse("", "0", "", "")
+	require.ErrorIs(t, err, pagination.ErrInvalidSize)
+
+	_, err = spec.Parse("", "", "", "not a cursor")
+	require.ErrorIs(t, err, pagination.ErrInvalidCursor)
+}
+
+func TestCursor(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	docs := []interface{}{
+		item{Item_Id: "a", Price: 10, Created_At: day},
+		item{Item_Id: "b", Price: 10, Created_At: day.Add(time.Hour)},
+		item{Item_Id: "c", Price: 20, Created_At: day},
+	}
+
+	page, err := spec.Parse("price,-created_at", "", "", "")
+	require.NoError(t, err)
+
+	cur, err := mongo.NewCursorFromDocuments(docs, nil, nil)
+	require.NoError(t, err)
+	items, next, err := pagination.Collect[item](ctx, cur, page)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.NotEmpty(t, next)
+
+	page, err = spec.Parse("price,-created_at", "", "", next)
+	require.NoError(t, err)
+	require.Equal(t, 0, page.Skip())
+	require.Equal(t, bson.M{"$or": []bson.M{
+		{"price": bson.M{"$gt": 10.0}},
+		{"price": 10.0, "created_at": bson.M{"$lt": primitive.NewDateTimeFromTime(day.Add(time.Hour))}},
+		{"price": 10.0, "created_at": primitive.NewDateTimeFromTime(day.Add(time.Hour)), "item_id": bson.M{"$gt": "b"}},
+	}}, page.AfterFilter())
+
+	// the last page has no cursor
+	cur, err = mongo.NewCursorFromDocuments(docs[2:], nil, nil)
+	require.NoError(t, err)
+	items, next, err = pagination.Collect[item](ctx, cur, page)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Empty(t, next)
+
+	// a cursor only pages the sort it was made for
+	first, err := spec.Parse("price,-created_at", "", "", "")
+	require.NoError(t, err)
+	cur, err = mongo.NewCursorFromDocuments(docs, nil, nil)
+	require.NoError(t, err)
+	_, next, err = pagination.Collect[item](ctx, cur, first)
+	require.NoError(t, err)
+	_, err = spec.Parse("-price", "", "", next)
+	require.ErrorIs(t, err, pagination.ErrInvalidCursor)
+
+	// a cursor value must have the type of its field
+	cursor := func(values ...interface{}) string {
+		data, err := bson.Marshal(bson.M{"o": "price,-created_at", "v": values})
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	page, err = spec.Parse("price,-created_at", "", "", cursor(int32(10), day, "b"))
+	require.NoError(t, err)
+	require.Equal(t, bson.A{int32(10), primitive.NewDateTimeFromTime(day), "b"}, page.After)
+	for _, values := range [][]interface{}{
+		{bson.M{"$gt": ""}, day, "b"},
+		{10.0, "2024-05-01", "b"},
+		{10.0, day, bson.A{"b"}},
+		{10.0, day, nil},
+	} {
+		_, err = spec.Parse("price,-created_at", "", "", cursor(values...))
+		require.ErrorIs(t, err, pagination.ErrInvalidCursor, values)
+	}
+}
@@ -134,6 +134,39 @@ func (suite *CartRepositoryTestSuite) TestGetAllUserCartEmpty() {
 	suite.Require().NotNil(cartItems)
 }
 
+func (suite *CartRepositoryTestSuite) TestFindItemsPages() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	email := "paged@gmail.com"
+	addedAt := time.Now().UTC().Truncate(time.Millisecond)
+	// two variants of one product share the product id and the added time
+	items := []domain.CartItem{
+		{Product_Id: "apple", Variant_Id: "small", AddedAt: addedAt, Price: 5000},
+		{Product_Id: "apple", Variant_Id: "large", AddedAt: addedAt, Price: 9000},
+		{Product_Id: "pear", AddedAt: addedAt.Add(time.Minute), Price: 7000},
+	}
+	err := suite.repo.CreateCart(ctx, &domain.Cart{ID: primitive.NewObjectID(), Email: email, Items: items})
+	suite.Require().NoError(err)
+
+	page, err := domain.CartItemPages.Parse("", "2", "", "")
+	suite.Require().NoError(err)
+	first, err := suite.repo.FindItems(ctx, email, page)
+	suite.Require().NoError(err)
+	suite.Require().Len(first.Items, 2)
+	suite.Require().Equal("pear", first.Items[0].Product_Id)
+	suite.Require().Equal("small", first.Items[1].Variant_Id)
+	suite.Require().NotEmpty(first.Next_Cursor)
+
+	page, err = domain.CartItemPages.Parse("", "2", "", first.Next_Cursor)
+	suite.Require().NoError(err)
+	last, err := suite.repo.FindItems(ctx, email, page)
+	suite.Require().NoError(err)
+	suite.Require().Len(last.Items, 1)
+	suite.Require().Equal("large", last.Items[0].Variant_Id)
+	suite.Require().Empty(last.Next_Cursor)
+}
+
 func TestCartRepositoryTestSuite(t *testing.T) {
 	suite.Run(t, new(CartRepositoryTestSuite))
 }
@@ -225,7 +225,7 @@ func (suite *OrderRepositoryTestSuite) TestDeleteItemOrderSuccess() {
 
 	suite.Require().NoError(err)
 
-	result, err := suite.repo.DeleteItem(ctx, orderID, productID)
+	result, err := suite.repo.DeleteItem(ctx, orderID, productID, "")
 
 	suite.Require().NoError(err)
 	suite.Require().NotNil(result)
@@ -239,7 +239,7 @@ func (suite *OrderRepositoryTestSuite) TestDeleteItemOrderFailed() {
 	orderID := "uniqueorderid"
 	productID := "uniqueproductid"
 
-	result, err := suite.repo.DeleteItem(ctx, orderID, productID)
+	result, err := suite.repo.DeleteItem(ctx, orderID, productID, "")
 
 	suite.Require().NoError(err)
 	suite.Require().NotNil(result)
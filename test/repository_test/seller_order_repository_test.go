@@ -226,7 +226,7 @@ func (suite *SellerOrderRepositoryTestSuite) TestDeleteSellerOrderItemSuccess()
 
 	suite.Require().NoError(err)
 
-	result, err := suite.repo.DeleteItem(ctx, email, orderID, productID)
+	result, err := suite.repo.DeleteItem(ctx, email, orderID, productID, "")
 
 	suite.Require().NoError(err)
 	suite.Require().NotNil(result)
@@ -240,7 +240,7 @@ func (suite *SellerOrderRepositoryTestSuite) TestDeleteSellerOrderItemFailed() {
 	orderID := "uniqueorderid"
 	productID := "uniqueproductid"
 
-	result, err := suite.repo.DeleteItem(ctx, email, orderID, productID)
+	result, err := suite.repo.DeleteItem(ctx, email, orderID, productID, "")
 
 	suite.Require().NoError(err)
 	suite.Require().NotNil(result)
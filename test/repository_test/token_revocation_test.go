@@ -0,0 +1,134 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenRevocationStoreRevoke(t *testing.T) {
+	store := repository.NewTokenRevocationStore(test.NewMemoryCache(), test.NewMemoryTokenVersions())
+
+	err := store.Revoke("revoked-jti", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+
+	// a token that expired already is not kept
+	err = store.Revoke("expired-jti", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	revoked, err := store.IsRevoked("revoked-jti")
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = store.IsRevoked("expired-jti")
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	revoked, err = store.IsRevoked("")
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
+
+func TestTokenRevocationStoreVersion(t *testing.T) {
+	store := repository.NewTokenRevocationStore(test.NewMemoryCache(), test.NewMemoryTokenVersions())
+
+	version, err := store.TokenVersion(domain.AccountUser, "uid")
+	require.NoError(t, err)
+	require.Equal(t, 0, version)
+
+	version, err = store.BumpTokenVersion(domain.AccountUser, "uid")
+	require.NoError(t, err)
+	require.Equal(t, 1, version)
+
+	// a seller with the same id has a version of its own
+	version, err = store.TokenVersion(domain.AccountSeller, "uid")
+	require.NoError(t, err)
+	require.Equal(t, 0, version)
+
+	version, err = store.TokenVersion(domain.AccountUser, "uid")
+	require.NoError(t, err)
+	require.Equal(t, 1, version)
+}
+
+func TestTokenRevocationStoreVersionOutlivesCache(t *testing.T) {
+	versions := test.NewMemoryTokenVersions()
+	store := repository.NewTokenRevocationStore(test.NewMemoryCache(), versions)
+
+	_, err := store.BumpTokenVersion(domain.AccountUser, "uid")
+	require.NoError(t, err)
+	_, err = store.BumpTokenVersion(domain.AccountUser, "uid")
+	require.NoError(t, err)
+
+	// the cache was flushed, the version is loaded back from the account
+	store = repository.NewTokenRevocationStore(test.NewMemoryCache(), versions)
+	version, err := store.TokenVersion(domain.AccountUser, "uid")
+	require.NoError(t, err)
+	require.Equal(t, 2, version)
+
+	version, err = store.BumpTokenVersion(domain.AccountUser, "uid")
+	require.NoError(t, err)
+	require.Equal(t, 3, version)
+}
+
+func TestTokenRevocationStoreConcurrentBumps(t *testing.T) {
+	versions := test.NewMemoryTokenVersions()
+	store := repository.NewTokenRevocationStore(test.NewMemoryCache(), versions)
+
+	var wg sync.WaitGroup
+	bumped := make(chan int, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			version, err := store.BumpTokenVersion(domain.AccountSeller, "uid")
+			require.NoError(t, err)
+			bumped <- version
+		}()
+	}
+	wg.Wait()
+	close(bumped)
+
+	// every bump got a version of its own
+	seen := map[int]bool{}
+	for version := range bumped {
+		require.False(t, seen[version], version)
+		seen[version] = true
+	}
+
+	version, err := store.TokenVersion(domain.AccountSeller, "uid")
+	require.NoError(t, err)
+	require.Equal(t, 20, version)
+
+	saved, err := versions.TokenVersion(context.Background(), domain.AccountSeller, "uid")
+	require.NoError(t, err)
+	require.Equal(t, 20, saved)
+}
+
+// brokenCache can not be read, like a redis server that is down.
+type brokenCache struct {
+	*test.MemoryCache
+}
+
+func (brokenCache) Get(key string) ([]byte, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestTokenRevocationStoreFailsClosed(t *testing.T) {
+	store := repository.NewTokenRevocationStore(brokenCache{test.NewMemoryCache()}, test.NewMemoryTokenVersions())
+
+	_, err := store.IsRevoked("jti")
+	require.Error(t, err)
+
+	_, err = store.TokenVersion(domain.AccountUser, "uid")
+	require.Error(t, err)
+
+	_, err = store.BumpTokenVersion(domain.AccountUser, "uid")
+	require.Error(t, err)
+}
@@ -0,0 +1,252 @@
+package routes_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"github.com/IndraSty/GreenBasket/internal/delivery"
+	"github.com/IndraSty/GreenBasket/internal/middlewares"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/routes"
+	"github.com/IndraSty/GreenBasket/internal/sse"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/IndraSty/GreenBasket/test"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRoutes serves the routes with handlers that have no services, a request
+// that gets past the middlewares ends in a recovered panic instead of a 403.
+func setupRoutes() (*gin.Engine, domain.TokenService) {
+	gin.SetMode(gin.TestMode)
+
+	tokenSvc := util.NewTokenService(&config.Config{Token: config.Token{Secret_Key: "test-secret-key"}})
+	app := gin.New()
+	app.Use(gin.RecoveryWithWriter(io.Discard))
+
+	routeConfig := routes.RouteConfig{
+		App:                        app,
+		Middlewares:                middlewares.NewMiddleware(tokenSvc, repository.NewTokenRevocationStore(test.NewMemoryCache(), test.NewMemoryTokenVersions())),
+		UserHandler:                &delivery.UserHandler{},
+		SellerHandler:              &delivery.SellerHandler{},
+		StoreHandler:               &delivery.StoreHandler{},
+		ProductHandler:             &delivery.ProductHandler{},
+		PaymentHandler:             &delivery.PaymentHandler{},
+		OrderHandler:               &delivery.OrderHandler{},
+		SellerOrderHandler:         &delivery.SellerOrderHandler{},
+		NotificationHandler:        &delivery.NotificationHandler{},
+		PaymentNotificationHandler: &delivery.PaymentNotificationHandler{},
+		ContactHandler:             &delivery.ContactHandler{},
+		CartHandler:                &delivery.CartHandler{},
+		GuestCartHandler:           &delivery.GuestCartHandler{},
+		AddressHandler:             &delivery.AddressHandler{},
+		ReviewHandler:              &delivery.ReviewHandler{},
+		SalesReportHandler:         &delivery.SalesReportHandler{},
+		RefundHandler:              &delivery.RefundHandler{},
+		ShipmentHandler:            &delivery.ShipmentHandler{},
+		WeightAdjustmentHandler:    &delivery.WeightAdjustmentHandler{},
+		BatchHandler:               &delivery.BatchHandler{},
+		StockLedgerHandler:         &delivery.StockLedgerHandler{},
+		ProductImportHandler:       &delivery.ProductImportHandler{},
+		UploadHandler:              &delivery.UploadHandler{},
+		SearchHandler:              &delivery.SearchHandler{},
+		ShippingHandler:            &delivery.ShippingHandler{},
+		AdminHandler:               &delivery.AdminHandler{},
+		CategoryHandler:            &delivery.CategoryHandler{},
+		AuthHandler:                &delivery.AuthHandler{},
+		NotificationSSE:            &sse.NotificationSSE{},
+	}
+	routeConfig.Setup()
+
+	return app, tokenSvc
+}
+
+func TestCrossRoleAccess(t *testing.T) {
+	app, tokenSvc := setupRoutes()
+
+	accounts := map[string]string{
+		domain.RoleUser:        domain.AccountUser,
+		domain.RoleAdmin:       domain.AccountUser,
+		domain.RoleSeller:      domain.AccountSeller,
+		domain.RoleSellerStaff: domain.AccountSeller,
+	}
+
+	tests := []struct {
+		name    string
+		role    string
+		method  string
+		path    string
+		allowed bool
+	}{
+		{"user on seller profile", domain.RoleUser, http.MethodGet, "/api/sellers/current", false},
+		{"user on seller orders", domain.RoleUser, http.MethodGet, "/api/sellers/current/orders", false},
+		{"user on cart", domain.RoleUser, http.MethodGet, "/api/users/current/cart", true},
+		{"seller on user profile", domain.RoleSeller, http.MethodGet, "/api/users/current", false},
+		{"seller on user cart", domain.RoleSeller, http.MethodGet, "/api/users/current/cart", false},
+		{"seller on sales report", domain.RoleSeller, http.MethodGet, "/api/sellers/current/stores/store/report", true},
+		{"staff on sales report", domain.RoleSellerStaff, http.MethodGet, "/api/sellers/current/stores/store/report", false},
+		{"staff on store", domain.RoleSellerStaff, http.MethodPost, "/api/sellers/current/stores", false},
+		{"staff on products", domain.RoleSellerStaff, http.MethodGet, "/api/sellers/current/stores/store/products", true},
+		{"staff on seller orders", domain.RoleSellerStaff, http.MethodGet, "/api/sellers/current/orders", true},
+		{"staff on packed quantity", domain.RoleSellerStaff, http.MethodPost, "/api/sellers/current/orders/order/packed-quantity", true},
+		{"user on packed quantity", domain.RoleUser, http.MethodPost, "/api/sellers/current/orders/order/packed-quantity", false},
+		{"staff on product batches", domain.RoleSellerStaff, http.MethodGet, "/api/sellers/current/stores/store/product/batches", true},
+		{"user on expiring stock", domain.RoleUser, http.MethodGet, "/api/sellers/current/stores/store/batches/expiring", false},
+		{"staff on stock movements", domain.RoleSellerStaff, http.MethodGet, "/api/sellers/current/stores/store/product/stock/movements", true},
+		{"staff on product export", domain.RoleSellerStaff, http.MethodGet, "/api/sellers/current/stores/store/products/export", true},
+		{"staff on another store's products", domain.RoleSellerStaff, http.MethodGet, "/api/sellers/current/stores/other/products", false},
+		{"staff on another store's stock", domain.RoleSellerStaff, http.MethodPost, "/api/sellers/current/stores/other/product/stock", false},
+		{"staff on store settings", domain.RoleSellerStaff, http.MethodPut, "/api/sellers/current/stores/store", false},
+		{"staff on store staff", domain.RoleSellerStaff, http.MethodPost, "/api/sellers/current/stores/store/staff", false},
+		{"seller on store staff", domain.RoleSeller, http.MethodPost, "/api/sellers/current/stores/store/staff", true},
+		{"admin on user profile", domain.RoleAdmin, http.MethodGet, "/api/users/current", true},
+		{"admin on cart", domain.RoleAdmin, http.MethodGet, "/api/users/current/cart", false},
+		{"admin on seller orders", domain.RoleAdmin, http.MethodGet, "/api/sellers/current/orders", false},
+		{"no role on user profile", "", http.MethodGet, "/api/users/current", false},
+		{"admin on admin users", domain.RoleAdmin, http.MethodGet, "/api/admin/users", true},
+		{"user on admin users", domain.RoleUser, http.MethodGet, "/api/admin/users", false},
+		{"seller on admin orders", domain.RoleSeller, http.MethodGet, "/api/admin/orders", false},
+		{"admin on create category", domain.RoleAdmin, http.MethodPost, "/api/admin/categories", true},
+		{"user on delete category", domain.RoleUser, http.MethodDelete, "/api/admin/categories/fruits", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := accounts[tt.role]
+			if account == "" {
+				account = domain.AccountUser
+			}
+
+			var staff *domain.StaffScope
+			if tt.role == domain.RoleSellerStaff {
+				staff = &domain.StaffScope{Store_Id: "store", Store_Email: "owner@mail.com"}
+			}
+
+			accessToken, _, err := tokenSvc.GenerateAllTokens("test@mail.com", "Test", "Account", "uid", account, tt.role, staff, 0)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+			rec := httptest.NewRecorder()
+			app.ServeHTTP(rec, req)
+
+			if tt.allowed {
+				require.NotContains(t, []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound}, rec.Code)
+			} else {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			}
+		})
+	}
+}
+
+func TestStaffActsForStoreOwner(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tokenSvc := util.NewTokenService(&config.Config{Token: config.Token{Secret_Key: "test-secret-key"}})
+	m := middlewares.NewMiddleware(tokenSvc, repository.NewTokenRevocationStore(test.NewMemoryCache(), test.NewMemoryTokenVersions()))
+	app := gin.New()
+	app.GET("/stores/:store_id", m.SellerAuthMiddleware(), m.ScopeStaff(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("email"))
+	})
+
+	tests := []struct {
+		name  string
+		role  string
+		staff *domain.StaffScope
+		path  string
+		code  int
+		email string
+	}{
+		{"seller", domain.RoleSeller, nil, "/stores/store", http.StatusOK, "test@mail.com"},
+		{"staff of the store", domain.RoleSellerStaff, &domain.StaffScope{Store_Id: "store", Store_Email: "owner@mail.com"},
+			"/stores/store", http.StatusOK, "owner@mail.com"},
+		{"staff of another store", domain.RoleSellerStaff, &domain.StaffScope{Store_Id: "other", Store_Email: "other@mail.com"},
+			"/stores/store", http.StatusForbidden, ""},
+		{"staff without a store", domain.RoleSellerStaff, nil, "/stores/store", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accessToken, _, err := tokenSvc.GenerateAllTokens("test@mail.com", "Test", "Account", "uid", domain.AccountSeller, tt.role, tt.staff, 0)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+			rec := httptest.NewRecorder()
+			app.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.code, rec.Code)
+			if tt.code == http.StatusOK {
+				require.Equal(t, tt.email, rec.Body.String())
+			}
+		})
+	}
+}
+
+// forgedTokens are access tokens with claims that were not signed by the
+// server, with another key or another signing method.
+func forgedTokens(t *testing.T, claims *domain.SignedDetails) map[string]string {
+	otherKey, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("another-secret-key"))
+	require.NoError(t, err)
+
+	otherMethod, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString([]byte("test-secret-key"))
+	require.NoError(t, err)
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	return map[string]string{"another key": otherKey, "another method": otherMethod, "no signature": unsigned}
+}
+
+func TestForgedToken(t *testing.T) {
+	app, _ := setupRoutes()
+
+	tests := []struct {
+		name    string
+		role    string
+		account string
+		path    string
+	}{
+		{"user profile", domain.RoleUser, domain.AccountUser, "/api/users/current"},
+		{"seller profile", domain.RoleSeller, domain.AccountSeller, "/api/sellers/current"},
+		{"admin users", domain.RoleAdmin, domain.AccountUser, "/api/admin/users"},
+		{"admin orders", domain.RoleAdmin, domain.AccountUser, "/api/admin/orders"},
+	}
+
+	for _, tt := range tests {
+		claims := &domain.SignedDetails{
+			Email:   "test@mail.com",
+			Uid:     "uid",
+			Account: tt.account,
+			Role:    tt.role,
+			StandardClaims: jwt.StandardClaims{
+				Id:        "forged",
+				ExpiresAt: time.Now().Add(time.Hour).Unix(),
+			},
+		}
+
+		for forgery, token := range forgedTokens(t, claims) {
+			t.Run(tt.name+" with "+forgery, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+				req.Header.Set("Authorization", "Bearer "+token)
+				rec := httptest.NewRecorder()
+				app.ServeHTTP(rec, req)
+
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			})
+		}
+	}
+}
+
+func TestRolePermissions(t *testing.T) {
+	require.ElementsMatch(t, []string{domain.RoleSeller, domain.RoleSellerStaff}, domain.RolesWith(domain.PermissionManageProduct))
+	require.ElementsMatch(t, []string{domain.RoleUser}, domain.RolesWith(domain.PermissionShop))
+	require.ElementsMatch(t, []string{domain.RoleAdmin}, domain.RolesWith(domain.PermissionAdmin))
+	require.Len(t, domain.RolesWith(domain.PermissionAccount), 4)
+}
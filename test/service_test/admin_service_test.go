@@ -0,0 +1,197 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/IndraSty/GreenBasket/test"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AdminServiceTestSuite struct {
+	ServiceTestSuite
+	svc             domain.AdminService
+	auditRepo       domain.AuditLogRepository
+	userRepo        domain.UserRepository
+	sellerRepo      domain.SellerRepository
+	storeRepo       domain.StoreRepository
+	productRepo     domain.ProductRepository
+	searchSvc       domain.ProductSearchService
+	revocationStore domain.TokenRevocationStore
+}
+
+const adminEmail = "admin@greenbasket.com"
+
+func (suite *AdminServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	cacheRepo := test.NewMemoryCache()
+	suite.auditRepo = repository.NewAuditLogRepository(suite.Client)
+	suite.userRepo = repository.NewUserRepository(suite.Client)
+	suite.sellerRepo = repository.NewSellerRepository(suite.Client)
+	suite.storeRepo = repository.NewStoreRepository(suite.Client)
+	suite.productRepo = repository.NewProductRepository(suite.Client)
+	suite.revocationStore = repository.NewTokenRevocationStore(cacheRepo, repository.NewTokenVersionRepository(suite.Client))
+	suite.searchSvc = service.NewProductSearchService(repository.NewMemorySearchIndex(), suite.productRepo, suite.storeRepo)
+	suite.svc = service.NewAdminService(suite.auditRepo, suite.userRepo, suite.sellerRepo, suite.storeRepo, suite.productRepo,
+		repository.NewReviewRepository(suite.Client), repository.NewOrderRepository(suite.Client), suite.revocationStore, cacheRepo,
+		suite.searchSvc)
+}
+
+func (suite *AdminServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *AdminServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+}
+
+func (suite *AdminServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+// seedSellerProduct creates a seller with a store selling one product and
+// returns the seller id, the store id and the product id.
+func (suite *AdminServiceTestSuite) seedSellerProduct(ctx context.Context) (sellerID, storeID, productID string) {
+	storeID = primitive.NewObjectID().Hex()
+	sellerID, _ = suite.seedSeller(ctx, storeID)
+	suite.seedStore(ctx, domain.Store{Store_Id: storeID})
+	productID = suite.seedProduct(ctx, domain.Products{Store_id: storeID, Stock: 10})
+	suite.Require().NoError(suite.searchSvc.SyncProducts(ctx, productID))
+
+	return sellerID, storeID, productID
+}
+
+// guestCount is 1 when guests see the product and 0 when it is hidden.
+func (suite *AdminServiceTestSuite) guestCount(ctx context.Context, productID string) int {
+	products, err := suite.productRepo.GetAllProductByIds(ctx, []string{productID}, domain.ProductPages.Offset(1))
+	suite.Require().NoError(err)
+	return len(products.Products)
+}
+
+// searchCount is how many products of the store are found by a search for
+// their name, hidden products must not be found.
+func (suite *AdminServiceTestSuite) searchCount(ctx context.Context, storeID string) int {
+	productIDs, err := suite.searchSvc.Search(ctx, "product", storeID)
+	suite.Require().NoError(err)
+	return len(productIDs)
+}
+
+func (suite *AdminServiceTestSuite) TestSuspendSellerHidesProducts() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	sellerID, storeID, productID := suite.seedSellerProduct(ctx)
+	suite.Require().Equal(1, suite.guestCount(ctx, productID))
+	suite.Require().Equal(1, suite.searchCount(ctx, storeID))
+
+	err := suite.svc.SuspendSeller(ctx, adminEmail, sellerID, &dto.SuspendReq{Suspended: true, Reason: "fraud"})
+	suite.Require().NoError(err)
+	suite.Require().Equal(0, suite.guestCount(ctx, productID))
+	suite.Require().Equal(0, suite.searchCount(ctx, storeID))
+
+	seller, err := suite.sellerRepo.FindSellerById(ctx, sellerID)
+	suite.Require().NoError(err)
+	suite.Require().True(seller.Suspended)
+
+	version, err := suite.revocationStore.TokenVersion(domain.AccountSeller, sellerID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(1, version)
+
+	err = suite.svc.SuspendSeller(ctx, adminEmail, sellerID, &dto.SuspendReq{Suspended: false, Reason: "appeal accepted"})
+	suite.Require().NoError(err)
+	suite.Require().Equal(1, suite.guestCount(ctx, productID))
+	suite.Require().Equal(1, suite.searchCount(ctx, storeID))
+
+	logs, err := suite.auditRepo.FindAll(ctx, domain.AdminPages.Offset(1), sellerID)
+	suite.Require().NoError(err)
+	suite.Require().Len(logs.Items, 2)
+	suite.Require().Equal(domain.AuditUnsuspendSeller, logs.Items[0].Action)
+	suite.Require().Equal(domain.AuditSuspendSeller, logs.Items[1].Action)
+	suite.Require().Equal(adminEmail, logs.Items[1].Admin_Email)
+	suite.Require().Equal("fraud", logs.Items[1].Reason)
+}
+
+func (suite *AdminServiceTestSuite) TestStoreStaysHiddenWhileSellerSuspended() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	sellerID, storeID, productID := suite.seedSellerProduct(ctx)
+
+	err := suite.svc.SuspendStore(ctx, adminEmail, storeID, &dto.SuspendReq{Suspended: true, Reason: "counterfeit goods"})
+	suite.Require().NoError(err)
+	suite.Require().Equal(0, suite.guestCount(ctx, productID))
+	suite.Require().Equal(0, suite.searchCount(ctx, storeID))
+
+	version, err := suite.revocationStore.TokenVersion(domain.AccountSeller, sellerID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(1, version)
+
+	err = suite.svc.SuspendSeller(ctx, adminEmail, sellerID, &dto.SuspendReq{Suspended: true, Reason: "fraud"})
+	suite.Require().NoError(err)
+
+	err = suite.svc.SuspendStore(ctx, adminEmail, storeID, &dto.SuspendReq{Suspended: false, Reason: "goods removed"})
+	suite.Require().NoError(err)
+	suite.Require().Equal(0, suite.guestCount(ctx, productID))
+	suite.Require().Equal(0, suite.searchCount(ctx, storeID))
+
+	err = suite.svc.SuspendSeller(ctx, adminEmail, sellerID, &dto.SuspendReq{Suspended: false, Reason: "appeal accepted"})
+	suite.Require().NoError(err)
+	suite.Require().Equal(1, suite.guestCount(ctx, productID))
+}
+
+func (suite *AdminServiceTestSuite) TestUnpublishProduct() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	_, storeID, productID := suite.seedSellerProduct(ctx)
+	suite.Require().Equal(1, suite.guestCount(ctx, productID))
+
+	err := suite.svc.UnpublishProduct(ctx, adminEmail, productID, &dto.UnpublishReq{Unpublished: true, Reason: "prohibited item"})
+	suite.Require().NoError(err)
+	suite.Require().Equal(0, suite.guestCount(ctx, productID))
+	suite.Require().Equal(0, suite.searchCount(ctx, storeID))
+
+	// without a reason nothing changes
+	err = suite.svc.UnpublishProduct(ctx, adminEmail, productID, &dto.UnpublishReq{Unpublished: false})
+	suite.Require().Error(err)
+	suite.Require().Equal(0, suite.guestCount(ctx, productID))
+}
+
+func (suite *AdminServiceTestSuite) TestSuspendUserRevokesSessions() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	userID := primitive.NewObjectID().Hex()
+	email := userID + "@buyer.com"
+	_, err := suite.userRepo.CreateUser(ctx, domain.User{
+		ID:            primitive.NewObjectID(),
+		Email:         email,
+		User_Id:       userID,
+		Role:          domain.RoleUser,
+		Refresh_Token: "refresh-token",
+	})
+	suite.Require().NoError(err)
+
+	err = suite.svc.SuspendUser(ctx, adminEmail, userID, &dto.SuspendReq{Suspended: true, Reason: "spam reviews"})
+	suite.Require().NoError(err)
+
+	user, err := suite.userRepo.FindUserById(ctx, userID)
+	suite.Require().NoError(err)
+	suite.Require().True(user.Suspended)
+	suite.Require().Empty(user.Refresh_Token)
+
+	version, err := suite.revocationStore.TokenVersion(domain.AccountUser, userID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(1, version)
+}
+
+func TestAdminServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(AdminServiceTestSuite))
+}
@@ -0,0 +1,224 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/IndraSty/GreenBasket/internal/util"
+	"github.com/IndraSty/GreenBasket/test"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuthServiceTestSuite struct {
+	ServiceTestSuite
+	svc             domain.AuthService
+	tokenSvc        domain.TokenService
+	userRepo        domain.UserRepository
+	sellerRepo      domain.SellerRepository
+	revocationStore domain.TokenRevocationStore
+}
+
+func (suite *AuthServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	cnf := &config.Config{Token: config.Token{Secret_Key: "test-secret-key"}}
+	suite.tokenSvc = util.NewTokenService(cnf)
+	suite.userRepo = repository.NewUserRepository(suite.Client)
+	suite.sellerRepo = repository.NewSellerRepository(suite.Client)
+	suite.revocationStore = repository.NewTokenRevocationStore(test.NewMemoryCache(), repository.NewTokenVersionRepository(suite.Client))
+	suite.svc = service.NewAuthService(suite.userRepo, suite.sellerRepo, repository.NewStoreRepository(suite.Client), test.NewMemoryCache(), suite.revocationStore, suite.tokenSvc, nil)
+}
+
+func (suite *AuthServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *AuthServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+}
+
+func (suite *AuthServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+// login creates a user holding the refresh token of a fresh login, like AuthenticateUser does.
+func (suite *AuthServiceTestSuite) login(ctx context.Context) (email, accessToken, refreshToken string) {
+	email = primitive.NewObjectID().Hex() + "@buyer.com"
+	userID := primitive.NewObjectID().Hex()
+
+	_, err := suite.userRepo.CreateUser(ctx, domain.User{
+		ID:         primitive.NewObjectID(),
+		First_Name: "Test",
+		Last_Name:  "User",
+		Email:      email,
+		User_Id:    userID,
+		Role:       domain.RoleUser,
+	})
+	suite.Require().NoError(err)
+
+	accessToken, refreshToken, err = suite.tokenSvc.GenerateAllTokens(email, "Test", "User", userID, domain.AccountUser, domain.RoleUser, nil, 0)
+	suite.Require().NoError(err)
+
+	_, err = suite.userRepo.UpdateUser(ctx, email, bson.D{{Key: "refresh_token", Value: refreshToken}})
+	suite.Require().NoError(err)
+
+	return email, accessToken, refreshToken
+}
+
+func (suite *AuthServiceTestSuite) TestRefreshRotatesToken() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	email, _, refreshToken := suite.login(ctx)
+
+	res, err := suite.svc.RefreshToken(ctx, refreshToken)
+	suite.Require().NoError(err)
+	suite.Require().NotEqual(refreshToken, res.Refresh_Token)
+
+	claims, msg := suite.tokenSvc.ValidateToken(res.Access_Token)
+	suite.Require().Empty(msg)
+	suite.Require().Equal(email, claims.Email)
+
+	user, err := suite.userRepo.FindUserByEmail(ctx, email)
+	suite.Require().NoError(err)
+	suite.Require().Equal(res.Refresh_Token, user.Refresh_Token)
+
+	// the rotated token can be exchanged in turn
+	_, err = suite.svc.RefreshToken(ctx, res.Refresh_Token)
+	suite.Require().NoError(err)
+}
+
+func (suite *AuthServiceTestSuite) TestRefreshReuseRevokesFamily() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	email, _, refreshToken := suite.login(ctx)
+
+	res, err := suite.svc.RefreshToken(ctx, refreshToken)
+	suite.Require().NoError(err)
+
+	_, err = suite.svc.RefreshToken(ctx, refreshToken)
+	suite.Require().ErrorIs(err, domain.ErrRefreshTokenReused)
+
+	// the legitimate holder of the newest token is logged out too
+	_, err = suite.svc.RefreshToken(ctx, res.Refresh_Token)
+	suite.Require().ErrorIs(err, domain.ErrInvalidRefreshToken)
+
+	user, err := suite.userRepo.FindUserByEmail(ctx, email)
+	suite.Require().NoError(err)
+	suite.Require().Empty(user.Refresh_Token)
+}
+
+func (suite *AuthServiceTestSuite) TestRefreshAfterNewLogin() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	email, _, oldRefreshToken := suite.login(ctx)
+
+	_, newRefreshToken, err := suite.tokenSvc.GenerateAllTokens(email, "Test", "User", "uid", domain.AccountUser, domain.RoleUser, nil, 0)
+	suite.Require().NoError(err)
+	_, err = suite.userRepo.UpdateUser(ctx, email, bson.D{{Key: "refresh_token", Value: newRefreshToken}})
+	suite.Require().NoError(err)
+
+	// a token of the previous login is simply invalid, the new login keeps working
+	_, err = suite.svc.RefreshToken(ctx, oldRefreshToken)
+	suite.Require().ErrorIs(err, domain.ErrInvalidRefreshToken)
+
+	_, err = suite.svc.RefreshToken(ctx, newRefreshToken)
+	suite.Require().NoError(err)
+}
+
+func (suite *AuthServiceTestSuite) TestRefreshSeller() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	email := primitive.NewObjectID().Hex() + "@seller.com"
+	_, err := suite.sellerRepo.CreateSeller(ctx, domain.Seller{
+		ID:        primitive.NewObjectID(),
+		Email:     email,
+		Seller_Id: primitive.NewObjectID().Hex(),
+		Role:      domain.RoleSeller,
+	})
+	suite.Require().NoError(err)
+
+	_, refreshToken, err := suite.tokenSvc.GenerateAllTokens(email, "Test", "Seller", "uid", domain.AccountSeller, domain.RoleSeller, nil, 0)
+	suite.Require().NoError(err)
+	_, err = suite.sellerRepo.UpdateSeller(ctx, email, bson.D{{Key: "refresh_token", Value: refreshToken}})
+	suite.Require().NoError(err)
+
+	res, err := suite.svc.RefreshToken(ctx, refreshToken)
+	suite.Require().NoError(err)
+
+	seller, err := suite.sellerRepo.FindSellerByEmail(ctx, email)
+	suite.Require().NoError(err)
+	suite.Require().Equal(res.Refresh_Token, seller.Refresh_Token)
+}
+
+func (suite *AuthServiceTestSuite) TestTokensAreNotInterchangeable() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	_, accessToken, refreshToken := suite.login(ctx)
+
+	_, err := suite.svc.RefreshToken(ctx, accessToken)
+	suite.Require().ErrorIs(err, domain.ErrInvalidRefreshToken)
+
+	_, msg := suite.tokenSvc.ValidateToken(refreshToken)
+	suite.Require().NotEmpty(msg)
+}
+
+func (suite *AuthServiceTestSuite) TestLogoutRevokesAccessToken() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	email, accessToken, refreshToken := suite.login(ctx)
+
+	claims, msg := suite.tokenSvc.ValidateToken(accessToken)
+	suite.Require().Empty(msg)
+	suite.Require().NotEmpty(claims.Id)
+
+	err := suite.svc.Logout(ctx, claims)
+	suite.Require().NoError(err)
+
+	revoked, err := suite.revocationStore.IsRevoked(claims.Id)
+	suite.Require().NoError(err)
+	suite.Require().True(revoked)
+
+	user, err := suite.userRepo.FindUserByEmail(ctx, email)
+	suite.Require().NoError(err)
+	suite.Require().Empty(user.Refresh_Token)
+
+	_, err = suite.svc.RefreshToken(ctx, refreshToken)
+	suite.Require().ErrorIs(err, domain.ErrInvalidRefreshToken)
+}
+
+func (suite *AuthServiceTestSuite) TestLogoutAllSessions() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	_, accessToken, refreshToken := suite.login(ctx)
+
+	claims, msg := suite.tokenSvc.ValidateToken(accessToken)
+	suite.Require().Empty(msg)
+
+	err := suite.svc.LogoutAllSessions(ctx, claims)
+	suite.Require().NoError(err)
+
+	version, err := suite.revocationStore.TokenVersion(domain.AccountUser, claims.Uid)
+	suite.Require().NoError(err)
+	suite.Require().Greater(version, claims.Token_Version)
+
+	_, err = suite.svc.RefreshToken(ctx, refreshToken)
+	suite.Require().ErrorIs(err, domain.ErrInvalidRefreshToken)
+}
+
+func TestAuthServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(AuthServiceTestSuite))
+}
@@ -0,0 +1,182 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type BatchServiceTestSuite struct {
+	ServiceTestSuite
+	svc            domain.BatchService
+	reservationSvc domain.ReservationService
+	productRepo    domain.ProductRepository
+	storeRepo      domain.StoreRepository
+	sellerRepo     domain.SellerRepository
+}
+
+func (suite *BatchServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	suite.productRepo = repository.NewProductRepository(suite.Client)
+	suite.storeRepo = repository.NewStoreRepository(suite.Client)
+	suite.sellerRepo = repository.NewSellerRepository(suite.Client)
+	transactor := repository.NewTransactor(suite.Client, repository.TxModeAuto)
+
+	hub := &dto.Hub{NotificationChannel: map[string]chan dto.NotificationRes{}}
+	notificationSvc := service.NewNotificationService(repository.NewNotificationRepository(suite.Client), repository.NewTemplateRepository(suite.Client), hub)
+
+	ledgerSvc := service.NewStockLedgerService(repository.NewStockMovementRepository(suite.Client), suite.productRepo,
+		suite.storeRepo, transactor)
+	suite.svc = service.NewBatchService(repository.NewBatchRepository(suite.Client), suite.productRepo, suite.storeRepo,
+		suite.sellerRepo, ledgerSvc, notificationSvc, transactor, config.Inventory{
+			WithdrawBefore:  24 * time.Hour,
+			DiscountBefore:  72 * time.Hour,
+			DiscountPercent: 30,
+			AlertBefore:     72 * time.Hour,
+		})
+	suite.reservationSvc = service.NewReservationService(repository.NewReservationRepository(suite.Client), suite.productRepo, suite.svc, ledgerSvc, transactor)
+}
+
+func (suite *BatchServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *BatchServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+}
+
+func (suite *BatchServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+func (suite *BatchServiceTestSuite) addBatch(ctx context.Context, seller, storeID, productID string, quantity float64, expiresIn time.Duration) string {
+	res, err := suite.svc.AddBatch(ctx, seller, storeID, productID, &dto.BatchReq{
+		Quantity:   quantity,
+		Expires_At: time.Now().Add(expiresIn),
+	})
+	suite.Require().NoError(err)
+
+	return res.Batch_Id
+}
+
+func (suite *BatchServiceTestSuite) batches(ctx context.Context, seller, storeID, productID string) map[string]domain.Batch {
+	batches, err := suite.svc.GetBatches(ctx, seller, storeID, productID)
+	suite.Require().NoError(err)
+
+	byID := map[string]domain.Batch{}
+	for _, batch := range *batches {
+		byID[batch.Batch_Id] = batch
+	}
+
+	return byID
+}
+
+func (suite *BatchServiceTestSuite) stock(ctx context.Context, productID string) *domain.ProductWithSalesData {
+	product, err := suite.productRepo.GetProductById(ctx, productID)
+	suite.Require().NoError(err)
+
+	return product
+}
+
+func (suite *BatchServiceTestSuite) TestReserveStockTakesFirstExpiryFirst() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID, productID := suite.seedShop(ctx, domain.Products{Stock: 2})
+	seller := sellerEmail(storeID)
+	later := suite.addBatch(ctx, seller, storeID, productID, 5, 10*24*time.Hour)
+	sooner := suite.addBatch(ctx, seller, storeID, productID, 5, 5*24*time.Hour)
+	suite.Require().Equal(12.0, suite.stock(ctx, productID).Stock)
+
+	orderID := primitive.NewObjectID().Hex()
+	err := suite.reservationSvc.ReserveStock(ctx, orderID, []domain.OrderItem{{
+		Product_Id: productID,
+		StoreID:    storeID,
+		Quantity:   7,
+	}})
+	suite.Require().NoError(err)
+
+	batches := suite.batches(ctx, seller, storeID, productID)
+	suite.Require().Equal(0.0, batches[sooner].Quantity)
+	suite.Require().Equal(3.0, batches[later].Quantity)
+	suite.Require().Equal(5.0, suite.stock(ctx, productID).Stock)
+
+	err = suite.reservationSvc.ReleaseStock(ctx, orderID)
+	suite.Require().NoError(err)
+
+	batches = suite.batches(ctx, seller, storeID, productID)
+	suite.Require().Equal(5.0, batches[sooner].Quantity)
+	suite.Require().Equal(5.0, batches[later].Quantity)
+	suite.Require().Equal(12.0, suite.stock(ctx, productID).Stock)
+}
+
+func (suite *BatchServiceTestSuite) TestExpiryPolicyWithdrawsAndDiscounts() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID, productID := suite.seedShop(ctx, domain.Products{Stock: 2})
+	seller := sellerEmail(storeID)
+	expiring := suite.addBatch(ctx, seller, storeID, productID, 3, 12*time.Hour)
+	nearExpiry := suite.addBatch(ctx, seller, storeID, productID, 4, 48*time.Hour)
+	suite.addBatch(ctx, seller, storeID, productID, 5, 10*24*time.Hour)
+
+	withdrawn, err := suite.svc.ApplyExpiryPolicy(ctx, time.Now())
+	suite.Require().NoError(err)
+	suite.Require().Equal(1, withdrawn)
+
+	batches := suite.batches(ctx, seller, storeID, productID)
+	suite.Require().Equal(domain.BatchWithdrawn, batches[expiring].Status)
+	suite.Require().Equal(domain.BatchActive, batches[nearExpiry].Status)
+	suite.Require().True(batches[nearExpiry].Alerted)
+
+	product := suite.stock(ctx, productID)
+	suite.Require().Equal(11.0, product.Stock)
+	suite.Require().Equal(30.0, product.Discount)
+	suite.Require().Equal(7000.0, product.SalePrice(product.Price))
+
+	expiringStock, err := suite.svc.GetExpiringStock(ctx, seller, storeID, 3)
+	suite.Require().NoError(err)
+	suite.Require().Len(*expiringStock, 1)
+	suite.Require().Equal(nearExpiry, (*expiringStock)[0].Batch_Id)
+	suite.Require().Equal(2, (*expiringStock)[0].Days_Left)
+}
+
+func (suite *BatchServiceTestSuite) TestReleaseAfterWithdrawal() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID, productID := suite.seedShop(ctx, domain.Products{Stock: 2})
+	seller := sellerEmail(storeID)
+	expiring := suite.addBatch(ctx, seller, storeID, productID, 3, 12*time.Hour)
+
+	orderID := primitive.NewObjectID().Hex()
+	err := suite.reservationSvc.ReserveStock(ctx, orderID, []domain.OrderItem{{
+		Product_Id: productID,
+		StoreID:    storeID,
+		Quantity:   2,
+	}})
+	suite.Require().NoError(err)
+
+	_, err = suite.svc.ApplyExpiryPolicy(ctx, time.Now())
+	suite.Require().NoError(err)
+	suite.Require().Equal(2.0, suite.stock(ctx, productID).Stock)
+
+	// the reserved part of the withdrawn batch is not put back on sale
+	err = suite.reservationSvc.ReleaseStock(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(2.0, suite.stock(ctx, productID).Stock)
+	suite.Require().Equal(1.0, suite.batches(ctx, seller, storeID, productID)[expiring].Quantity)
+}
+
+func TestBatchServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(BatchServiceTestSuite))
+}
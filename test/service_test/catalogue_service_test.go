@@ -0,0 +1,244 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/pagination"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CatalogueServiceTestSuite struct {
+	ServiceTestSuite
+	svc             domain.ProductService
+	searchSvc       domain.ProductSearchService
+	categorySvc     domain.CategoryService
+	productRepo     domain.ProductRepository
+	storeRepo       domain.StoreRepository
+	salesReportRepo domain.SalesReportRepository
+}
+
+func (suite *CatalogueServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	suite.productRepo = repository.NewProductRepository(suite.Client)
+	suite.storeRepo = repository.NewStoreRepository(suite.Client)
+	suite.salesReportRepo = repository.NewSalesReportRepository(suite.Client)
+
+	suite.categorySvc = service.NewCategoryService(repository.NewCategoryRepository(suite.Client), suite.productRepo,
+		repository.NewAuditLogRepository(suite.Client))
+	suite.searchSvc = service.NewProductSearchService(repository.NewMemorySearchIndex(), suite.productRepo, suite.storeRepo)
+	suite.svc = service.NewProductService(suite.productRepo, suite.storeRepo, repository.NewSellerRepository(suite.Client), suite.salesReportRepo,
+		nil, suite.categorySvc, nil, nil, suite.searchSvc, repository.NewTransactor(suite.Client, repository.TxModeAuto))
+}
+
+func (suite *CatalogueServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *CatalogueServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+}
+
+func (suite *CatalogueServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+type catalogueProduct struct {
+	name     string
+	category string
+	price    float64
+	stock    float64
+	rating   float32
+}
+
+// seedRatedStore creates a store in city with products and the sales report
+// holding their ratings, and indexes them for search.
+func (suite *CatalogueServiceTestSuite) seedRatedStore(ctx context.Context, city string, products []catalogueProduct) (storeID string, productIDs []string) {
+	storeID = suite.seedStore(ctx, domain.Store{Address_Details: &domain.Address{City: city}})
+
+	report := domain.Sales_Report{ID: primitive.NewObjectID(), Store_Id: storeID, Email: sellerEmail(storeID)}
+	for _, product := range products {
+		productID := suite.seedProduct(ctx, domain.Products{
+			Name:        product.name,
+			Description: product.name,
+			Category:    product.category,
+			Price:       product.price,
+			Stock:       product.stock,
+			Store_id:    storeID,
+		})
+
+		report.Products = append(report.Products, domain.Product_Sales{Product_Id: productID, Average_Rating: product.rating})
+		productIDs = append(productIDs, productID)
+	}
+
+	_, err := suite.salesReportRepo.Insert(ctx, report)
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(suite.searchSvc.SyncStore(ctx, storeID))
+
+	return storeID, productIDs
+}
+
+func (suite *CatalogueServiceTestSuite) seedCategories(ctx context.Context) {
+	for _, req := range []dto.CategoryReq{
+		{Names: map[string]string{"en": "Vegetables"}},
+		{Names: map[string]string{"en": "Fruits"}},
+		{Names: map[string]string{"en": "Citrus"}, Parent: "fruits"},
+	} {
+		_, err := suite.categorySvc.CreateCategory(ctx, adminEmail, &req)
+		suite.Require().NoError(err)
+	}
+}
+
+// sorted is the first page of a product list in sort.
+func (suite *CatalogueServiceTestSuite) sorted(sort string) pagination.Request {
+	page, err := domain.ProductSearchPages.Parse(sort, "", "", "")
+	suite.Require().NoError(err)
+	return page
+}
+
+func productIdsOf(products []dto.GetProductRes) []string {
+	productIDs := make([]string, len(products))
+	for i, product := range products {
+		productIDs[i] = product.Product_id
+	}
+	return productIDs
+}
+
+func (suite *CatalogueServiceTestSuite) TestCombinedFiltersAndFacets() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	suite.seedCategories(ctx)
+	_, bandung := suite.seedRatedStore(ctx, "Bandung", []catalogueProduct{
+		{name: "Lemon", category: "citrus", price: 12000, stock: 10, rating: 4.5},
+		{name: "Orange", category: "citrus", price: 30000, stock: 0, rating: 4.8},
+		{name: "Apple", category: "fruits", price: 8000, stock: 5, rating: 3.2},
+		{name: "Carrot", category: "vegetables", price: 5000, stock: 20, rating: 2},
+	})
+	_, jakarta := suite.seedRatedStore(ctx, "Jakarta", []catalogueProduct{
+		{name: "Lime", category: "citrus", price: 15000, stock: 3, rating: 4.1},
+	})
+
+	res, err := suite.svc.GetCatalogue(ctx, &dto.CatalogueQuery{
+		Category:    "fruits",
+		Descendants: true,
+		In_Stock:    true,
+		City:        "bandung",
+	}, suite.sorted("price"))
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{bandung[2], bandung[0]}, productIdsOf(res.Products))
+	suite.Require().Equal(2, res.TotalItem)
+	suite.Require().Equal(1, res.LastPage)
+	suite.Require().Equal("Bandung", res.Products[0].City)
+
+	suite.Require().Equal([]dto.FacetCount{{Value: "citrus", Count: 1}, {Value: "fruits", Count: 1}}, res.Facets.Categories)
+	suite.Require().Equal(dto.PriceFacet{Min: 0, Max: 10000, Count: 1}, res.Facets.Prices[0])
+	suite.Require().Equal(dto.PriceFacet{Min: 10000, Max: 25000, Count: 1}, res.Facets.Prices[1])
+	suite.Require().Equal(dto.PriceFacet{Min: 250000, Max: 0, Count: 0}, res.Facets.Prices[len(res.Facets.Prices)-1])
+	suite.Require().Equal([]dto.RatingFacet{{Min: 4, Count: 1}, {Min: 3, Count: 2}, {Min: 2, Count: 2}, {Min: 1, Count: 2}},
+		res.Facets.Ratings)
+
+	res, err = suite.svc.GetCatalogue(ctx, &dto.CatalogueQuery{
+		Min_Price:  10000,
+		Max_Price:  20000,
+		Min_Rating: 4,
+	}, suite.sorted("-average_rating"))
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{bandung[0], jakarta[0]}, productIdsOf(res.Products))
+}
+
+func (suite *CatalogueServiceTestSuite) TestSearchKeepsRelevance() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	suite.seedCategories(ctx)
+	storeID, productIDs := suite.seedRatedStore(ctx, "Bandung", []catalogueProduct{
+		{name: "Lemon Tea Leaves", category: "vegetables", price: 20000, stock: 1},
+		{name: "Lemon", category: "citrus", price: 12000, stock: 1},
+		{name: "Carrot", category: "vegetables", price: 5000, stock: 1},
+	})
+
+	res, err := suite.svc.GetCatalogue(ctx, &dto.CatalogueQuery{Key: "lemon", Store_Id: storeID}, suite.sorted(""))
+	suite.Require().NoError(err)
+	suite.Require().ElementsMatch([]string{productIDs[0], productIDs[1]}, productIdsOf(res.Products))
+	suite.Require().Equal(2, res.TotalItem)
+
+	res, err = suite.svc.GetCatalogue(ctx, &dto.CatalogueQuery{Key: "lemon"}, suite.sorted("-price"))
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{productIDs[0], productIDs[1]}, productIdsOf(res.Products))
+
+	res, err = suite.svc.GetCatalogue(ctx, &dto.CatalogueQuery{Key: "durian"}, suite.sorted(""))
+	suite.Require().NoError(err)
+	suite.Require().Empty(res.Products)
+	suite.Require().Empty(res.Facets.Categories)
+}
+
+func (suite *CatalogueServiceTestSuite) TestCursorPaging() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var products []catalogueProduct
+	for i := 0; i < 12; i++ {
+		// two products share every price, the tiebreaker orders them
+		products = append(products, catalogueProduct{name: "Carrot", category: "vegetables", price: float64(1000 * (i/2 + 1)), stock: 1})
+	}
+	_, productIDs := suite.seedRatedStore(ctx, "Bandung", products)
+
+	var seen []string
+	var prices []float64
+	cursor := ""
+	for pages := 0; ; pages++ {
+		suite.Require().Less(pages, 3)
+
+		page, err := domain.ProductPages.Parse("-price", "5", "", cursor)
+		suite.Require().NoError(err)
+
+		res, err := suite.svc.GetAllProductForGuest(ctx, page)
+		suite.Require().NoError(err)
+		suite.Require().Equal(12, res.TotalItem)
+		suite.Require().Equal(3, res.LastPage)
+
+		for _, product := range res.Products {
+			seen = append(seen, product.Product_id)
+			prices = append(prices, product.Price)
+		}
+		if res.Next_Cursor == "" {
+			break
+		}
+		cursor = res.Next_Cursor
+	}
+
+	suite.Require().ElementsMatch(productIDs, seen)
+	suite.Require().IsNonIncreasing(prices)
+
+	// a cursor only pages the sort it was made for
+	page, err := domain.ProductPages.Parse("-price", "5", "", "")
+	suite.Require().NoError(err)
+	res, err := suite.svc.GetAllProductForGuest(ctx, page)
+	suite.Require().NoError(err)
+	_, err = domain.ProductPages.Parse("price", "5", "", res.Next_Cursor)
+	suite.Require().ErrorIs(err, pagination.ErrInvalidCursor)
+}
+
+func (suite *CatalogueServiceTestSuite) TestInvalidQuery() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	_, err := suite.svc.GetCatalogue(ctx, &dto.CatalogueQuery{Min_Price: 20000, Max_Price: 10000}, suite.sorted(""))
+	suite.Require().ErrorIs(err, domain.ErrInvalidCatalogueQuery)
+
+	_, err = suite.svc.GetCatalogue(ctx, &dto.CatalogueQuery{Category: "durian"}, suite.sorted(""))
+	suite.Require().ErrorIs(err, domain.ErrCategoryNotFound)
+}
+
+func TestCatalogueServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(CatalogueServiceTestSuite))
+}
@@ -0,0 +1,149 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CategoryServiceTestSuite struct {
+	ServiceTestSuite
+	svc         domain.CategoryService
+	productRepo domain.ProductRepository
+	auditRepo   domain.AuditLogRepository
+}
+
+func (suite *CategoryServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	suite.productRepo = repository.NewProductRepository(suite.Client)
+	suite.auditRepo = repository.NewAuditLogRepository(suite.Client)
+	suite.svc = service.NewCategoryService(repository.NewCategoryRepository(suite.Client), suite.productRepo, suite.auditRepo)
+}
+
+func (suite *CategoryServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *CategoryServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+}
+
+func (suite *CategoryServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+// seedTree creates fruits > citrus > lemons and vegetables.
+func (suite *CategoryServiceTestSuite) seedTree(ctx context.Context) {
+	for _, req := range []dto.CategoryReq{
+		{Names: map[string]string{"en": "Vegetables", "id": "Sayuran"}, Sort_Order: 2},
+		{Names: map[string]string{"en": "Fruits", "id": "Buah"}, Sort_Order: 1},
+		{Names: map[string]string{"en": "Citrus"}, Parent: "fruits"},
+		{Slug: "lemons", Names: map[string]string{"en": "Lemon & Lime"}, Parent: "citrus"},
+	} {
+		_, err := suite.svc.CreateCategory(ctx, adminEmail, &req)
+		suite.Require().NoError(err)
+	}
+}
+
+func (suite *CategoryServiceTestSuite) TestGetTree() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	suite.seedTree(ctx)
+
+	tree, err := suite.svc.GetTree(ctx, "id")
+	suite.Require().NoError(err)
+	suite.Require().Len(tree, 2)
+
+	suite.Require().Equal("fruits", tree[0].Slug)
+	suite.Require().Equal("Buah", tree[0].Name)
+	suite.Require().Equal("citrus", tree[0].Children[0].Slug)
+	// no indonesian name, the english one is used
+	suite.Require().Equal("Citrus", tree[0].Children[0].Name)
+	suite.Require().Equal("lemons", tree[0].Children[0].Children[0].Slug)
+	suite.Require().Equal("vegetables", tree[1].Slug)
+}
+
+func (suite *CategoryServiceTestSuite) TestResolve() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	suite.seedTree(ctx)
+
+	slugs, err := suite.svc.Resolve(ctx, "fruits", true)
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{"fruits", "citrus", "lemons"}, slugs)
+
+	// the display name products used before still resolves
+	slugs, err = suite.svc.Resolve(ctx, "Fruits", false)
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{"fruits"}, slugs)
+
+	_, err = suite.svc.Resolve(ctx, "Toys", true)
+	suite.Require().ErrorIs(err, domain.ErrCategoryNotFound)
+}
+
+func (suite *CategoryServiceTestSuite) TestUpdateRefusesCycle() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	suite.seedTree(ctx)
+
+	err := suite.svc.UpdateCategory(ctx, adminEmail, "fruits", &dto.CategoryReq{
+		Names:  map[string]string{"en": "Fruits"},
+		Parent: "lemons",
+	})
+	suite.Require().Error(err)
+
+	err = suite.svc.UpdateCategory(ctx, adminEmail, "citrus", &dto.CategoryReq{
+		Names:  map[string]string{"en": "Citrus"},
+		Parent: "vegetables",
+	})
+	suite.Require().NoError(err)
+
+	slugs, err := suite.svc.Resolve(ctx, "vegetables", true)
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{"vegetables", "citrus", "lemons"}, slugs)
+}
+
+func (suite *CategoryServiceTestSuite) TestDeleteCategory() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	suite.seedTree(ctx)
+
+	// still has subcategories
+	err := suite.svc.DeleteCategory(ctx, adminEmail, "citrus")
+	suite.Require().Error(err)
+
+	_, err = suite.productRepo.CreateProduct(ctx, domain.Products{
+		ID:         primitive.NewObjectID(),
+		Name:       "lemon",
+		Product_id: primitive.NewObjectID().Hex(),
+		Category:   "lemons",
+	})
+	suite.Require().NoError(err)
+
+	// still has products
+	err = suite.svc.DeleteCategory(ctx, adminEmail, "lemons")
+	suite.Require().Error(err)
+
+	err = suite.svc.DeleteCategory(ctx, adminEmail, "vegetables")
+	suite.Require().NoError(err)
+
+	logs, err := suite.auditRepo.FindAll(ctx, domain.AdminPages.Offset(1), "vegetables")
+	suite.Require().NoError(err)
+	suite.Require().Len(logs.Items, 2)
+}
+
+func TestCategoryServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(CategoryServiceTestSuite))
+}
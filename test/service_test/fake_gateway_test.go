@@ -0,0 +1,170 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type FakeGatewayTestSuite struct {
+	ServiceTestSuite
+	gateway         domain.FakePaymentGateway
+	paymentSvc      domain.PaymentService
+	notifSvc        domain.PaymentNotificationService
+	paymentRepo     domain.PaymentRepository
+	orderRepo       domain.OrderRepository
+	sellerOrderRepo domain.SellerOrderRepository
+	productRepo     domain.ProductRepository
+	reservationSvc  domain.ReservationService
+}
+
+func (suite *FakeGatewayTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	cnf := &config.Config{Server: config.Server{Host: "localhost", Port: "8080"}}
+	suite.gateway = service.NewFakeGateway(cnf)
+	suite.paymentRepo = repository.NewPaymentRepository(suite.Client)
+	suite.orderRepo = repository.NewOrderRepository(suite.Client)
+	suite.productRepo = repository.NewProductRepository(suite.Client)
+	ledgerSvc := service.NewStockLedgerService(repository.NewStockMovementRepository(suite.Client), suite.productRepo,
+		repository.NewStoreRepository(suite.Client), repository.NewTransactor(suite.Client, repository.TxModeAuto))
+	batchSvc := service.NewBatchService(repository.NewBatchRepository(suite.Client), suite.productRepo,
+		repository.NewStoreRepository(suite.Client), repository.NewSellerRepository(suite.Client), ledgerSvc, nil,
+		repository.NewTransactor(suite.Client, repository.TxModeAuto), config.Inventory{})
+	suite.reservationSvc = service.NewReservationService(repository.NewReservationRepository(suite.Client), suite.productRepo, batchSvc, ledgerSvc,
+		repository.NewTransactor(suite.Client, repository.TxModeAuto))
+
+	hub := &dto.Hub{NotificationChannel: map[string]chan dto.NotificationRes{}}
+	notificationSvc := service.NewNotificationService(repository.NewNotificationRepository(suite.Client), repository.NewTemplateRepository(suite.Client), hub)
+
+	suite.paymentSvc = service.NewPaymentService(notificationSvc, suite.paymentRepo, repository.NewUserRepository(suite.Client), suite.orderRepo, suite.gateway)
+	suite.sellerOrderRepo = repository.NewSellerOrderRepository(suite.Client)
+	orderStatusSvc := service.NewOrderStatusService(suite.orderRepo, suite.sellerOrderRepo)
+	suite.notifSvc = service.NewPaymentNotificationService(suite.gateway, suite.paymentRepo, suite.orderRepo,
+		suite.sellerOrderRepo, repository.NewPaymentEventRepository(suite.Client), suite.reservationSvc, orderStatusSvc,
+		notificationSvc, repository.NewTransactor(suite.Client, repository.TxModeAuto))
+}
+
+func (suite *FakeGatewayTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *FakeGatewayTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+}
+
+func (suite *FakeGatewayTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+// checkout creates an order that reserves 2 units out of a stock of 5 and
+// initializes its payment at the fake gateway.
+func (suite *FakeGatewayTestSuite) checkout(ctx context.Context) (orderID, storeID, productID string) {
+	storeID = primitive.NewObjectID().Hex()
+	productID = suite.seedProduct(ctx, domain.Products{Store_id: storeID, Stock: 5})
+
+	items := []domain.OrderItem{orderItem(storeID, productID, 2, domain.OrderPending)}
+	orderID = suite.seedOrder(ctx, domain.Orders{Items: items})
+
+	err := suite.reservationSvc.ReserveStock(ctx, orderID, items)
+	suite.Require().NoError(err)
+
+	res, err := suite.paymentSvc.InitializePayment(ctx, &dto.PaymentReq{
+		OrderID: orderID,
+		UserID:  buyerEmail,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal("http://localhost:8080/api/fake-gateway/charges/"+orderID, res.Snap_Url)
+
+	return orderID, storeID, productID
+}
+
+func (suite *FakeGatewayTestSuite) TestSimulateSettlement() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, storeID, productID := suite.checkout(ctx)
+
+	payload, err := suite.gateway.Simulate(ctx, orderID, "settlement")
+	suite.Require().NoError(err)
+
+	success, err := suite.notifSvc.HandleNotification(ctx, payload)
+	suite.Require().NoError(err)
+	suite.Require().True(success)
+
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentSuccess), payment.Status)
+
+	product, err := suite.productRepo.GetProductById(ctx, productID, storeID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(3), product.Stock)
+
+	status, err := suite.gateway.CheckStatus(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(domain.PaymentSuccess, status.Status)
+}
+
+func (suite *FakeGatewayTestSuite) TestSimulateExpiry() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, storeID, productID := suite.checkout(ctx)
+
+	payload, err := suite.gateway.Simulate(ctx, orderID, "expire")
+	suite.Require().NoError(err)
+
+	success, err := suite.notifSvc.HandleNotification(ctx, payload)
+	suite.Require().NoError(err)
+	suite.Require().False(success)
+
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentExpired), payment.Status)
+
+	product, err := suite.productRepo.GetProductById(ctx, productID, storeID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(5), product.Stock)
+}
+
+func (suite *FakeGatewayTestSuite) TestChargeIsTheOrderTotal() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, _, _ := suite.checkout(ctx)
+
+	charge, err := suite.gateway.GetCharge(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(20000.0, charge.Amount)
+
+	// only the buyer of an order can pay it
+	items := []domain.OrderItem{orderItem(primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex(), 1, domain.OrderPending)}
+	otherID := suite.seedOrder(ctx, domain.Orders{Items: items})
+
+	_, err = suite.paymentSvc.InitializePayment(ctx, &dto.PaymentReq{OrderID: otherID, UserID: "someone@buyer.com"})
+	suite.Require().Error(err)
+
+	_, err = suite.gateway.GetCharge(ctx, otherID)
+	suite.Require().Error(err)
+}
+
+func (suite *FakeGatewayTestSuite) TestSimulateUnknownCharge() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	payload, err := suite.gateway.Simulate(ctx, primitive.NewObjectID().Hex(), "settlement")
+	suite.Require().Error(err)
+	suite.Require().Nil(payload)
+}
+
+func TestFakeGatewayTestSuite(t *testing.T) {
+	suite.Run(t, new(FakeGatewayTestSuite))
+}
@@ -0,0 +1,253 @@
+package service_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/test"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// buyerEmail is the buyer of the orders seeded without an email.
+const buyerEmail = "testemail@gmail.com"
+
+// ServiceTestSuite is the MongoTestSuite of the service tests. Its seed
+// helpers write the documents a service works on straight through the
+// repositories, a field left empty gets a default.
+type ServiceTestSuite struct {
+	test.MongoTestSuite
+}
+
+// sellerEmail is the email of the seller owning the store storeID.
+func sellerEmail(storeID string) string {
+	return storeID + "@seller.com"
+}
+
+// seedSeller creates the seller owning the store storeID and returns its
+// seller id and email.
+func (suite *ServiceTestSuite) seedSeller(ctx context.Context, storeID string) (sellerID, email string) {
+	sellerID = primitive.NewObjectID().Hex()
+	email = sellerEmail(storeID)
+
+	_, err := repository.NewSellerRepository(suite.Client).CreateSeller(ctx, domain.Seller{
+		ID:        primitive.NewObjectID(),
+		Email:     email,
+		Seller_Id: sellerID,
+		Store_Id:  storeID,
+		Role:      domain.RoleSeller,
+	})
+	suite.Require().NoError(err)
+
+	return sellerID, email
+}
+
+// seedStore creates store, by default a new store of the seller
+// sellerEmail(storeID), and returns its id.
+func (suite *ServiceTestSuite) seedStore(ctx context.Context, store domain.Store) string {
+	store.ID = primitive.NewObjectID()
+	if store.Store_Id == "" {
+		store.Store_Id = primitive.NewObjectID().Hex()
+	}
+	if store.Name == "" {
+		store.Name = "store " + store.Store_Id
+	}
+	if store.Email == "" {
+		store.Email = sellerEmail(store.Store_Id)
+	}
+
+	_, err := repository.NewStoreRepository(suite.Client).CreateStore(ctx, store)
+	suite.Require().NoError(err)
+
+	return store.Store_Id
+}
+
+// seedProduct creates product, by default priced at 10000, and returns its id.
+func (suite *ServiceTestSuite) seedProduct(ctx context.Context, product domain.Products) string {
+	product.ID = primitive.NewObjectID()
+	if product.Product_id == "" {
+		product.Product_id = primitive.NewObjectID().Hex()
+	}
+	if product.Name == "" {
+		product.Name = "product " + product.Product_id
+	}
+	if product.Price == 0 {
+		product.Price = 10000
+	}
+	product.Created_at = time.Now()
+	product.Updated_at = time.Now()
+
+	_, err := repository.NewProductRepository(suite.Client).CreateProduct(ctx, product)
+	suite.Require().NoError(err)
+
+	return product.Product_id
+}
+
+// seedShop creates a seller with a store in its own city that ships to Jakarta
+// for 9000 and sells product, and returns the store id and the product id.
+func (suite *ServiceTestSuite) seedShop(ctx context.Context, product domain.Products) (storeID, productID string) {
+	storeID = primitive.NewObjectID().Hex()
+	city := "city " + storeID
+
+	suite.seedSeller(ctx, storeID)
+	suite.seedStore(ctx, domain.Store{Store_Id: storeID, Address_Details: &domain.Address{City: city}})
+	suite.seedRate(ctx, city, "Jakarta", 100000, 9000)
+
+	product.Store_id = storeID
+	return storeID, suite.seedProduct(ctx, product)
+}
+
+// seedVariants splits the stock of the product into a small pack of 5 and a
+// large pack of 15.
+func (suite *ServiceTestSuite) seedVariants(ctx context.Context, storeID, productID string) (small, large string) {
+	small, large = primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex()
+
+	_, err := repository.NewProductRepository(suite.Client).UpdateProduct(ctx, storeID, productID, bson.D{
+		{Key: "stock", Value: 20},
+		{Key: "variants", Value: []domain.Variant{
+			{Variant_Id: small, Name: "250g", SKU: "P-250", Price: 10000, Stock: 5},
+			{Variant_Id: large, Name: "1kg", SKU: "P-1000", Price: 35000, Stock: 15},
+		}},
+	})
+	suite.Require().NoError(err)
+
+	return small, large
+}
+
+// seedRate creates a shipping rate from origin to destination.
+func (suite *ServiceTestSuite) seedRate(ctx context.Context, origin, destination string, maxWeight int, fee float64) {
+	_, err := repository.NewShippingRateRepository(suite.Client).Insert(ctx, domain.ShippingRate{
+		ID:               primitive.NewObjectID(),
+		Origin_City:      origin,
+		Destination_City: destination,
+		Max_Weight:       maxWeight,
+		Fee:              fee,
+	})
+	suite.Require().NoError(err)
+}
+
+// seedBuyer creates a user living in Jakarta with a cart holding items and
+// returns the email of the user.
+func (suite *ServiceTestSuite) seedBuyer(ctx context.Context, items ...domain.CartItem) string {
+	email := primitive.NewObjectID().Hex() + "@buyer.com"
+
+	_, err := repository.NewUserRepository(suite.Client).CreateUser(ctx, domain.User{
+		ID:              primitive.NewObjectID(),
+		Email:           email,
+		Address_Details: &domain.Address{City: "Jakarta"},
+	})
+	suite.Require().NoError(err)
+
+	if len(items) == 0 {
+		return email
+	}
+
+	err = repository.NewCartRepository(suite.Client).CreateCart(ctx, &domain.Cart{
+		ID:    primitive.NewObjectID(),
+		Email: email,
+		Items: items,
+	})
+	suite.Require().NoError(err)
+
+	return email
+}
+
+// cartItem is a selected cart item of quantity units of the product at 10000.
+func cartItem(storeID, productID string, quantity float64) domain.CartItem {
+	return domain.CartItem{
+		Product_Id: productID,
+		StoreID:    storeID,
+		Quantity:   quantity,
+		Selected:   true,
+		Price:      10000,
+	}
+}
+
+// orderItem is an order item of quantity units of the product at 10000.
+func orderItem(storeID, productID string, quantity float64, status domain.OrderStatus) domain.OrderItem {
+	return domain.OrderItem{
+		Product_Id:   productID,
+		StoreID:      storeID,
+		Order_Status: string(status),
+		Quantity:     quantity,
+		Price:        10000,
+	}
+}
+
+// seedOrderItem creates an order of 1 unit of a new product in status and
+// returns the order id, the email of the seller and the product id.
+func (suite *ServiceTestSuite) seedOrderItem(ctx context.Context, status domain.OrderStatus) (orderID, seller, productID string) {
+	storeID := primitive.NewObjectID().Hex()
+	productID = primitive.NewObjectID().Hex()
+
+	orderID = suite.seedOrder(ctx, domain.Orders{Items: []domain.OrderItem{orderItem(storeID, productID, 1, status)}})
+
+	return orderID, sellerEmail(storeID), productID
+}
+
+// seedOrder creates order with a seller order for every store of its items,
+// sold by sellerEmail of the store, and returns the order id. By default the
+// order is placed now by buyerEmail and costs the price of its items.
+func (suite *ServiceTestSuite) seedOrder(ctx context.Context, order domain.Orders) string {
+	order.ID = primitive.NewObjectID()
+	if order.Order_id == "" {
+		order.Order_id = primitive.NewObjectID().Hex()
+	}
+	if order.Email == "" {
+		order.Email = buyerEmail
+	}
+	if order.Order_Date.IsZero() {
+		order.Order_Date = time.Now()
+	}
+	order.Updated_At = order.Order_Date
+	if order.Payment == nil {
+		order.Payment = &domain.PaymentOrder{}
+	}
+
+	var stores []string
+	sellerOrders := map[string]*domain.SellerOrder{}
+	for _, item := range order.Items {
+		sellerOrder, ok := sellerOrders[item.StoreID]
+		if !ok {
+			sellerOrder = &domain.SellerOrder{
+				ID:         primitive.NewObjectID(),
+				Order_id:   order.Order_id,
+				Email:      sellerEmail(item.StoreID),
+				Ordered_At: order.Order_Date,
+				Updated_At: order.Order_Date,
+			}
+			sellerOrders[item.StoreID] = sellerOrder
+			stores = append(stores, item.StoreID)
+		}
+
+		sellerOrder.Total_Price += item.Price * item.Quantity
+		sellerOrder.Items = append(sellerOrder.Items, domain.SellerOrderItem{
+			User_Email:     order.Email,
+			Product_Id:     item.Product_Id,
+			Variant_Id:     item.Variant_Id,
+			Quantity:       item.Quantity,
+			Unit:           item.Unit,
+			Price:          item.Price,
+			Status:         item.Order_Status,
+			Status_History: item.Status_History,
+		})
+	}
+
+	if order.Total_Price == 0 {
+		for _, store := range stores {
+			order.Total_Price += sellerOrders[store].Total_Price
+		}
+	}
+
+	_, err := repository.NewOrderRepository(suite.Client).CreateOrder(ctx, order)
+	suite.Require().NoError(err)
+
+	for _, store := range stores {
+		_, err := repository.NewSellerOrderRepository(suite.Client).CreateOrderSeller(ctx, *sellerOrders[store])
+		suite.Require().NoError(err)
+	}
+
+	return order.Order_id
+}
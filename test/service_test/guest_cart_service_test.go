@@ -0,0 +1,228 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type GuestCartServiceTestSuite struct {
+	ServiceTestSuite
+	svc         domain.GuestCartService
+	repo        domain.GuestCartRepository
+	cartRepo    domain.CartRepository
+	productRepo domain.ProductRepository
+	storeRepo   domain.StoreRepository
+	storeID     string
+}
+
+func (suite *GuestCartServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	repo, err := repository.NewGuestCartRepository(context.Background(), suite.Client)
+	suite.Require().NoError(err)
+
+	suite.repo = repo
+	suite.cartRepo = repository.NewCartRepository(suite.Client)
+	suite.productRepo = repository.NewProductRepository(suite.Client)
+	suite.storeRepo = repository.NewStoreRepository(suite.Client)
+	suite.svc = suite.newService(time.Hour)
+}
+
+func (suite *GuestCartServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *GuestCartServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+
+	suite.storeID = suite.seedStore(context.Background(), domain.Store{Name: "Green Store"})
+}
+
+func (suite *GuestCartServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+func (suite *GuestCartServiceTestSuite) newService(ttl time.Duration) domain.GuestCartService {
+	return service.NewGuestCartService(suite.repo, suite.cartRepo, suite.productRepo, suite.storeRepo,
+		"test-secret-key", ttl)
+}
+
+func (suite *GuestCartServiceTestSuite) TestAddToCart() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	productID := suite.seedProduct(ctx, domain.Products{Store_id: suite.storeID, Price: 10000, Stock: 5})
+
+	token, err := suite.svc.AddToCart(ctx, "", productID, &dto.AddCartReq{Quantity: 2})
+	suite.Require().NoError(err)
+	suite.Require().NotEmpty(token)
+
+	// adding the product again adds to its item in the same cart
+	again, err := suite.svc.AddToCart(ctx, token, productID, &dto.AddCartReq{Quantity: 1})
+	suite.Require().NoError(err)
+	suite.Require().Equal(token, again)
+
+	items, err := suite.svc.GetAllCartItem(ctx, token)
+	suite.Require().NoError(err)
+	suite.Require().Len(*items, 1)
+	suite.Require().Equal(float64(3), (*items)[0].Quantity)
+	suite.Require().Equal("Green Store", (*items)[0].Store_Name)
+
+	_, err = suite.svc.AddToCart(ctx, token, productID, &dto.AddCartReq{Quantity: 3})
+	suite.Require().Error(err)
+
+	err = suite.svc.UpdateCartItemById(ctx, token, productID, "", &dto.CartItemEditReq{Quantity: 0})
+	suite.Require().NoError(err)
+	items, err = suite.svc.GetAllCartItem(ctx, token)
+	suite.Require().NoError(err)
+	suite.Require().Empty(*items)
+}
+
+func (suite *GuestCartServiceTestSuite) TestTokenIsSigned() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	productID := suite.seedProduct(ctx, domain.Products{Store_id: suite.storeID, Price: 10000, Stock: 5})
+	token, err := suite.svc.AddToCart(ctx, "", productID, &dto.AddCartReq{Quantity: 1})
+	suite.Require().NoError(err)
+
+	cartID, signature, _ := strings.Cut(token, ".")
+	for _, forged := range []string{cartID, cartID + ".", token + "x", primitive.NewObjectID().Hex() + "." + signature} {
+		err := suite.svc.RemoveCartItemById(ctx, forged, productID, "")
+		suite.Require().ErrorIs(err, domain.ErrInvalidCartToken, forged)
+	}
+
+	// a token signed with another secret is not accepted either
+	other := service.NewGuestCartService(suite.repo, suite.cartRepo, suite.productRepo, suite.storeRepo,
+		"another-secret", time.Hour)
+	_, err = other.Merge(ctx, token, "user@test.com")
+	suite.Require().ErrorIs(err, domain.ErrInvalidCartToken)
+}
+
+func (suite *GuestCartServiceTestSuite) TestMergeResolvesConflicts() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	email := "user@test.com"
+	moved := suite.seedProduct(ctx, domain.Products{Store_id: suite.storeID, Price: 10000, Stock: 10})
+	combined := suite.seedProduct(ctx, domain.Products{Store_id: suite.storeID, Price: 5000, Stock: 10})
+	capped := suite.seedProduct(ctx, domain.Products{Store_id: suite.storeID, Price: 2000, Stock: 6})
+	soldOut := suite.seedProduct(ctx, domain.Products{Store_id: suite.storeID, Price: 3000, Stock: 2})
+	removed := suite.seedProduct(ctx, domain.Products{Store_id: suite.storeID, Price: 4000, Stock: 2})
+
+	token := ""
+	for _, item := range []struct {
+		productID string
+		quantity  float64
+	}{{moved, 2}, {combined, 3}, {capped, 4}, {soldOut, 2}, {removed, 1}} {
+		var err error
+		token, err = suite.svc.AddToCart(ctx, token, item.productID, &dto.AddCartReq{Quantity: item.quantity})
+		suite.Require().NoError(err)
+	}
+
+	err := suite.cartRepo.CreateCart(ctx, &domain.Cart{
+		ID:         primitive.NewObjectID(),
+		Email:      email,
+		TotalPrice: 2*5000 + 3*2000,
+		Items: []domain.CartItem{
+			{Product_Id: combined, StoreID: suite.storeID, Quantity: 2, Selected: true, Price: 5000},
+			{Product_Id: capped, StoreID: suite.storeID, Quantity: 3, Selected: true, Price: 2000},
+		},
+	})
+	suite.Require().NoError(err)
+
+	// the products changed while the items waited in the guest cart
+	_, err = suite.productRepo.UpdateProduct(ctx, suite.storeID, moved, bson.D{{Key: "price", Value: 12000}})
+	suite.Require().NoError(err)
+	_, err = suite.productRepo.UpdateStockProduct(ctx, suite.storeID, soldOut, "", -2, time.Now())
+	suite.Require().NoError(err)
+	_, err = suite.productRepo.DeleteProductById(ctx, suite.storeID, removed)
+	suite.Require().NoError(err)
+
+	res, err := suite.svc.Merge(ctx, token, email)
+	suite.Require().NoError(err)
+
+	results := make(map[string]dto.CartMergeItem)
+	for _, item := range res.Items {
+		results[item.Product_Id] = item
+	}
+	suite.Require().Len(results, 5)
+	suite.Require().Equal(domain.MergeMoved, results[moved].Result)
+	suite.Require().Equal(domain.MergeCombined, results[combined].Result)
+	suite.Require().Equal(float64(5), results[combined].Quantity)
+	suite.Require().Equal(domain.MergeCapped, results[capped].Result)
+	suite.Require().Equal(float64(4), results[capped].Requested)
+	suite.Require().Equal(float64(6), results[capped].Quantity)
+	suite.Require().Equal(domain.MergeOutOfStock, results[soldOut].Result)
+	suite.Require().Equal(domain.MergeUnavailable, results[removed].Result)
+
+	cart, err := suite.cartRepo.GetUserCart(ctx, email)
+	suite.Require().NoError(err)
+	suite.Require().Len(cart.Items, 3)
+	quantities := make(map[string]float64)
+	for _, item := range cart.Items {
+		quantities[item.Product_Id] = item.Quantity
+	}
+	suite.Require().Equal(map[string]float64{moved: 2, combined: 5, capped: 6}, quantities)
+	// the moved item is at the new price
+	suite.Require().Equal(float64(2*12000+5*5000+6*2000), cart.TotalPrice)
+
+	// the guest cart is gone after the merge
+	_, err = suite.svc.Merge(ctx, token, email)
+	suite.Require().ErrorIs(err, domain.ErrGuestCartNotFound)
+}
+
+func (suite *GuestCartServiceTestSuite) TestMergeCreatesUserCart() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// users signing in with oauth for the first time have no cart
+	email := "oauth@test.com"
+	productID := suite.seedProduct(ctx, domain.Products{Store_id: suite.storeID, Price: 10000, Stock: 5})
+	token, err := suite.svc.AddToCart(ctx, "", productID, &dto.AddCartReq{Quantity: 1})
+	suite.Require().NoError(err)
+
+	_, err = suite.svc.Merge(ctx, token, email)
+	suite.Require().NoError(err)
+
+	cart, err := suite.cartRepo.GetUserCart(ctx, email)
+	suite.Require().NoError(err)
+	suite.Require().Len(cart.Items, 1)
+	suite.Require().Equal(float64(10000), cart.TotalPrice)
+}
+
+func (suite *GuestCartServiceTestSuite) TestExpiredCart() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	expired := suite.newService(-time.Minute)
+	productID := suite.seedProduct(ctx, domain.Products{Store_id: suite.storeID, Price: 10000, Stock: 5})
+	token, err := expired.AddToCart(ctx, "", productID, &dto.AddCartReq{Quantity: 1})
+	suite.Require().NoError(err)
+
+	items, err := expired.GetAllCartItem(ctx, token)
+	suite.Require().NoError(err)
+	suite.Require().Empty(*items)
+
+	_, err = expired.Merge(ctx, token, "user@test.com")
+	suite.Require().ErrorIs(err, domain.ErrGuestCartNotFound)
+
+	// adding to an expired cart starts a new one
+	fresh, err := suite.svc.AddToCart(ctx, token, productID, &dto.AddCartReq{Quantity: 1})
+	suite.Require().NoError(err)
+	suite.Require().NotEqual(token, fresh)
+}
+
+func TestGuestCartServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(GuestCartServiceTestSuite))
+}
@@ -0,0 +1,289 @@
+package service_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the recorded payloads in test/testdata/midtrans are signed with this server key
+const testServerKey = "SB-Mid-server-greenbasket-test"
+
+const recordedOrderID = "664b1f0e8f1a2c3d4e5f6071"
+
+type MidtransServiceTestSuite struct {
+	ServiceTestSuite
+	svc             domain.PaymentNotificationService
+	paymentRepo     domain.PaymentRepository
+	orderRepo       domain.OrderRepository
+	sellerOrderRepo domain.SellerOrderRepository
+	productRepo     domain.ProductRepository
+	eventRepo       domain.PaymentEventRepository
+	reservationSvc  domain.ReservationService
+}
+
+func (suite *MidtransServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	cnf := &config.Config{Midtrans: config.Midtrans{Key: testServerKey}}
+	suite.paymentRepo = repository.NewPaymentRepository(suite.Client)
+	suite.orderRepo = repository.NewOrderRepository(suite.Client)
+	suite.sellerOrderRepo = repository.NewSellerOrderRepository(suite.Client)
+	suite.productRepo = repository.NewProductRepository(suite.Client)
+	suite.eventRepo = repository.NewPaymentEventRepository(suite.Client)
+	ledgerSvc := service.NewStockLedgerService(repository.NewStockMovementRepository(suite.Client), suite.productRepo,
+		repository.NewStoreRepository(suite.Client), repository.NewTransactor(suite.Client, repository.TxModeAuto))
+	batchSvc := service.NewBatchService(repository.NewBatchRepository(suite.Client), suite.productRepo,
+		repository.NewStoreRepository(suite.Client), repository.NewSellerRepository(suite.Client), ledgerSvc, nil,
+		repository.NewTransactor(suite.Client, repository.TxModeAuto), config.Inventory{})
+	suite.reservationSvc = service.NewReservationService(repository.NewReservationRepository(suite.Client), suite.productRepo, batchSvc, ledgerSvc,
+		repository.NewTransactor(suite.Client, repository.TxModeAuto))
+
+	hub := &dto.Hub{NotificationChannel: map[string]chan dto.NotificationRes{}}
+	notifSvc := service.NewNotificationService(repository.NewNotificationRepository(suite.Client), repository.NewTemplateRepository(suite.Client), hub)
+	transactor := repository.NewTransactor(suite.Client, repository.TxModeAuto)
+
+	orderStatusSvc := service.NewOrderStatusService(suite.orderRepo, suite.sellerOrderRepo)
+	suite.svc = service.NewPaymentNotificationService(service.NewMidtransGateway(cnf), suite.paymentRepo, suite.orderRepo,
+		suite.sellerOrderRepo, suite.eventRepo, suite.reservationSvc, orderStatusSvc, notifSvc, transactor)
+}
+
+func (suite *MidtransServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *MidtransServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+	suite.cleanRecordedOrder()
+}
+
+func (suite *MidtransServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+	suite.cleanRecordedOrder()
+}
+
+// cleanRecordedOrder removes everything the recorded payloads point at, their
+// order id is fixed so they would otherwise collide between runs.
+func (suite *MidtransServiceTestSuite) cleanRecordedOrder() {
+	ctx := context.Background()
+	for _, name := range []string{"Payments", "Orders", "Seller_Orders", "Reservations", "PaymentEvents"} {
+		_, err := db.OpenCollection(suite.Client, name).DeleteMany(ctx, bson.M{"order_id": recordedOrderID})
+		suite.Require().NoError(err)
+	}
+}
+
+// placeOrder creates the pending order of the recorded payloads, holding a
+// reservation of 3 units out of a stock of 5 and a payment of amount.
+func (suite *MidtransServiceTestSuite) placeOrder(ctx context.Context, amount float64) (storeID, productID string) {
+	storeID = primitive.NewObjectID().Hex()
+	productID = suite.seedProduct(ctx, domain.Products{Store_id: storeID, Stock: 5})
+
+	items := []domain.OrderItem{orderItem(storeID, productID, 3, domain.OrderPending)}
+	suite.seedOrder(ctx, domain.Orders{Order_id: recordedOrderID, Items: items})
+
+	err := suite.paymentRepo.Insert(ctx, &domain.Payment{
+		ID:        primitive.NewObjectID(),
+		OrderID:   recordedOrderID,
+		UserID:    buyerEmail,
+		CreatedAt: time.Now(),
+		UpdateAt:  time.Now(),
+		Amount:    amount,
+		Status:    "PENDING",
+	})
+	suite.Require().NoError(err)
+
+	err = suite.reservationSvc.ReserveStock(ctx, recordedOrderID, items)
+	suite.Require().NoError(err)
+
+	return storeID, productID
+}
+
+func (suite *MidtransServiceTestSuite) notify(ctx context.Context, name string) (bool, error) {
+	payload, err := os.ReadFile("../testdata/midtrans/" + name + ".json")
+	suite.Require().NoError(err)
+
+	return suite.svc.HandleNotification(ctx, payload)
+}
+
+func (suite *MidtransServiceTestSuite) TestSettlementSuccess() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID, productID := suite.placeOrder(ctx, 30000)
+
+	success, err := suite.notify(ctx, "settlement")
+	suite.Require().NoError(err)
+	suite.Require().True(success)
+
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, recordedOrderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal("SUCCESS", payment.Status)
+
+	product, err := suite.productRepo.GetProductById(ctx, productID, storeID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(2), product.Stock, "A settled payment keeps the reserved stock")
+}
+
+func (suite *MidtransServiceTestSuite) TestDuplicateSettlementIsNoop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	suite.placeOrder(ctx, 30000)
+
+	success, err := suite.notify(ctx, "settlement")
+	suite.Require().NoError(err)
+	suite.Require().True(success)
+
+	success, err = suite.notify(ctx, "settlement")
+	suite.Require().NoError(err, "A duplicate notification must still be acknowledged")
+	suite.Require().False(success, "A duplicate notification must not be processed again")
+
+	events, err := suite.eventRepo.FindByTransactionId(ctx, "9aed5972-5b6a-401e-894b-a32c91ed1a3a")
+	suite.Require().NoError(err)
+	suite.Require().Len(*events, 1, "A duplicate notification must not be stored twice")
+	suite.Require().True((*events)[0].Processed)
+}
+
+func (suite *MidtransServiceTestSuite) TestOutOfOrderPendingIsRejected() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	suite.placeOrder(ctx, 30000)
+
+	_, err := suite.notify(ctx, "settlement")
+	suite.Require().NoError(err)
+
+	success, err := suite.notify(ctx, "pending")
+	suite.Require().NoError(err)
+	suite.Require().False(success)
+
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, recordedOrderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal("SUCCESS", payment.Status, "A late pending notification must not undo the settlement")
+
+	event, err := suite.eventRepo.FindById(ctx, "9aed5972-5b6a-401e-894b-a32c91ed1a3a:pending")
+	suite.Require().NoError(err)
+	suite.Require().Equal("REJECTED", event.Result)
+}
+
+func (suite *MidtransServiceTestSuite) TestExpireReleasesStock() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID, productID := suite.placeOrder(ctx, 30000)
+
+	success, err := suite.notify(ctx, "expire")
+	suite.Require().NoError(err)
+	suite.Require().False(success)
+
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, recordedOrderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal("EXPIRED", payment.Status)
+
+	product, err := suite.productRepo.GetProductById(ctx, productID, storeID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(5), product.Stock, "An expired payment gives the reserved stock back")
+}
+
+func (suite *MidtransServiceTestSuite) TestDenyReleasesStock() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID, productID := suite.placeOrder(ctx, 30000)
+
+	success, err := suite.notify(ctx, "deny")
+	suite.Require().NoError(err)
+	suite.Require().False(success)
+
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, recordedOrderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentDenied), payment.Status)
+
+	order, err := suite.orderRepo.GetOrder(ctx, recordedOrderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentDenied), order.Payment.Status, "The user order must follow the payment")
+
+	product, err := suite.productRepo.GetProductById(ctx, productID, storeID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(5), product.Stock, "A denied payment gives the reserved stock back")
+}
+
+func (suite *MidtransServiceTestSuite) TestExpireAfterSettlementIsRejected() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID, productID := suite.placeOrder(ctx, 30000)
+
+	_, err := suite.notify(ctx, "settlement")
+	suite.Require().NoError(err)
+
+	success, err := suite.notify(ctx, "expire")
+	suite.Require().NoError(err)
+	suite.Require().False(success)
+
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, recordedOrderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentSuccess), payment.Status, "A settled payment cannot expire")
+
+	product, err := suite.productRepo.GetProductById(ctx, productID, storeID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(2), product.Stock, "The committed stock must stay taken")
+}
+
+func (suite *MidtransServiceTestSuite) TestSettlementOfAnotherAmountIsFlagged() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID, productID := suite.placeOrder(ctx, 25000)
+
+	success, err := suite.notify(ctx, "settlement")
+	suite.Require().NoError(err)
+	suite.Require().False(success)
+
+	events, err := suite.eventRepo.FindByTransactionId(ctx, "9aed5972-5b6a-401e-894b-a32c91ed1a3a")
+	suite.Require().NoError(err)
+	suite.Require().Len(*events, 1)
+	suite.Require().Equal("AMOUNT_MISMATCH", (*events)[0].Result)
+
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, recordedOrderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal("PENDING", payment.Status)
+
+	product, err := suite.productRepo.GetProductById(ctx, productID, storeID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(2), product.Stock, "The stock stays reserved until the payment is reviewed")
+}
+
+func (suite *MidtransServiceTestSuite) TestInvalidSignatureRejected() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	suite.placeOrder(ctx, 30000)
+
+	success, err := suite.notify(ctx, "settlement_tampered")
+	suite.Require().ErrorIs(err, domain.ErrInvalidSignature)
+	suite.Require().False(success)
+
+	events, err := suite.eventRepo.FindByTransactionId(ctx, "9aed5972-5b6a-401e-894b-a32c91ed1a3a")
+	suite.Require().NoError(err)
+	suite.Require().Empty(*events, "A notification with a bad signature must not be stored")
+
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, recordedOrderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal("PENDING", payment.Status)
+}
+
+func TestMidtransServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(MidtransServiceTestSuite))
+}
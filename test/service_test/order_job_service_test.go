@@ -0,0 +1,322 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/scheduler"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/IndraSty/GreenBasket/test"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OrderJobServiceTestSuite struct {
+	ServiceTestSuite
+	svc             domain.OrderJobService
+	gateway         domain.FakePaymentGateway
+	paymentSvc      domain.PaymentService
+	paymentRepo     domain.PaymentRepository
+	paymentNotifSvc domain.PaymentNotificationService
+	orderRepo       domain.OrderRepository
+	sellerOrderRepo domain.SellerOrderRepository
+	productRepo     domain.ProductRepository
+	reservationSvc  domain.ReservationService
+}
+
+func (suite *OrderJobServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	cnf := &config.Config{Server: config.Server{Host: "localhost", Port: "8080"}}
+	cacheRepo := test.NewMemoryCache()
+	suite.gateway = service.NewFakeGateway(cnf)
+	suite.paymentRepo = repository.NewPaymentRepository(suite.Client)
+	suite.orderRepo = repository.NewOrderRepository(suite.Client)
+	suite.sellerOrderRepo = repository.NewSellerOrderRepository(suite.Client)
+	suite.productRepo = repository.NewProductRepository(suite.Client)
+	ledgerSvc := service.NewStockLedgerService(repository.NewStockMovementRepository(suite.Client), suite.productRepo,
+		repository.NewStoreRepository(suite.Client), repository.NewTransactor(suite.Client, repository.TxModeAuto))
+	batchSvc := service.NewBatchService(repository.NewBatchRepository(suite.Client), suite.productRepo,
+		repository.NewStoreRepository(suite.Client), repository.NewSellerRepository(suite.Client), ledgerSvc, nil,
+		repository.NewTransactor(suite.Client, repository.TxModeAuto), config.Inventory{})
+	suite.reservationSvc = service.NewReservationService(repository.NewReservationRepository(suite.Client), suite.productRepo, batchSvc, ledgerSvc,
+		repository.NewTransactor(suite.Client, repository.TxModeAuto))
+	sellerRepo := repository.NewSellerRepository(suite.Client)
+	transactor := repository.NewTransactor(suite.Client, repository.TxModeAuto)
+	orderStatusSvc := service.NewOrderStatusService(suite.orderRepo, suite.sellerOrderRepo)
+
+	hub := &dto.Hub{NotificationChannel: map[string]chan dto.NotificationRes{}}
+	notificationSvc := service.NewNotificationService(repository.NewNotificationRepository(suite.Client), repository.NewTemplateRepository(suite.Client), hub)
+	salesReportSvc := service.NewSalesRepository(repository.NewSalesReportRepository(suite.Client), suite.sellerOrderRepo,
+		repository.NewStoreRepository(suite.Client), suite.productRepo, repository.NewReviewRepository(suite.Client), cacheRepo)
+
+	suite.paymentSvc = service.NewPaymentService(notificationSvc, suite.paymentRepo, repository.NewUserRepository(suite.Client), suite.orderRepo, suite.gateway)
+	suite.paymentNotifSvc = service.NewPaymentNotificationService(suite.gateway, suite.paymentRepo, suite.orderRepo, suite.sellerOrderRepo,
+		repository.NewPaymentEventRepository(suite.Client), suite.reservationSvc, orderStatusSvc, notificationSvc, transactor)
+	suite.svc = service.NewOrderJobService(suite.orderRepo, suite.sellerOrderRepo, sellerRepo, suite.paymentRepo,
+		orderStatusSvc, suite.reservationSvc, suite.paymentNotifSvc, salesReportSvc, transactor)
+}
+
+func (suite *OrderJobServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *OrderJobServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+}
+
+func (suite *OrderJobServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+// placeOrder creates an order placed at orderedAt with a single item of 2 units
+// out of a stock of 5. A PENDING item holds a reservation, a SHIPPED item was
+// shipped at orderedAt.
+func (suite *OrderJobServiceTestSuite) placeOrder(ctx context.Context, status domain.OrderStatus, orderedAt time.Time) (orderID, productID string) {
+	storeID := primitive.NewObjectID().Hex()
+	productID = suite.seedProduct(ctx, domain.Products{Store_id: storeID, Stock: 5})
+
+	item := orderItem(storeID, productID, 2, status)
+	if status == domain.OrderShipped {
+		item.Status_History = []domain.OrderStatusHistory{{
+			Actor:      domain.ActorSeller,
+			From:       domain.OrderProcessed,
+			To:         domain.OrderShipped,
+			Changed_At: orderedAt,
+		}}
+	}
+
+	orderID = suite.seedOrder(ctx, domain.Orders{Order_Date: orderedAt, Items: []domain.OrderItem{item}})
+
+	if status == domain.OrderPending {
+		err := suite.reservationSvc.ReserveStock(ctx, orderID, []domain.OrderItem{item})
+		suite.Require().NoError(err)
+	}
+
+	return orderID, productID
+}
+
+func (suite *OrderJobServiceTestSuite) itemStatus(ctx context.Context, orderID string) string {
+	order, err := suite.orderRepo.GetOrder(ctx, orderID)
+	suite.Require().NoError(err)
+	return order.Items[0].Order_Status
+}
+
+func (suite *OrderJobServiceTestSuite) TestFinishShippedItems() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	oldOrderID, _ := suite.placeOrder(ctx, domain.OrderShipped, time.Now().Add(-8*24*time.Hour))
+	newOrderID, _ := suite.placeOrder(ctx, domain.OrderShipped, time.Now().Add(-24*time.Hour))
+
+	finished, err := suite.svc.FinishShippedItems(ctx, time.Now().Add(-7*24*time.Hour))
+	suite.Require().NoError(err)
+	suite.Require().GreaterOrEqual(finished, 1)
+
+	suite.Require().Equal(string(domain.OrderFinished), suite.itemStatus(ctx, oldOrderID))
+	suite.Require().Equal(string(domain.OrderShipped), suite.itemStatus(ctx, newOrderID))
+
+	order, err := suite.orderRepo.GetOrder(ctx, oldOrderID)
+	suite.Require().NoError(err)
+	last := order.Items[0].Status_History[len(order.Items[0].Status_History)-1]
+	suite.Require().Equal(domain.ActorSystem, last.Actor)
+
+	// a second run has nothing left to finish for this order
+	_, err = suite.svc.FinishShippedItems(ctx, time.Now().Add(-7*24*time.Hour))
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.OrderFinished), suite.itemStatus(ctx, oldOrderID))
+}
+
+func (suite *OrderJobServiceTestSuite) TestExpireOrderWithoutPayment() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, productID := suite.placeOrder(ctx, domain.OrderPending, time.Now().Add(-48*time.Hour))
+
+	_, err := suite.svc.ExpireUnpaidOrders(ctx, time.Now().Add(-24*time.Hour))
+	suite.Require().NoError(err)
+
+	suite.Require().Equal(string(domain.OrderCancelled), suite.itemStatus(ctx, orderID))
+
+	order, err := suite.orderRepo.GetOrder(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentExpired), order.Payment.Status)
+
+	product, err := suite.productRepo.GetProductById(ctx, productID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(5), product.Stock)
+}
+
+func (suite *OrderJobServiceTestSuite) TestExpirePendingPayment() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, productID := suite.placeOrder(ctx, domain.OrderPending, time.Now().Add(-48*time.Hour))
+	recentID, _ := suite.placeOrder(ctx, domain.OrderPending, time.Now())
+
+	for _, id := range []string{orderID, recentID} {
+		_, err := suite.paymentSvc.InitializePayment(ctx, &dto.PaymentReq{OrderID: id, UserID: "testemail@gmail.com"})
+		suite.Require().NoError(err)
+	}
+
+	_, err := suite.svc.ExpireUnpaidOrders(ctx, time.Now().Add(-24*time.Hour))
+	suite.Require().NoError(err)
+
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentExpired), payment.Status)
+	suite.Require().Equal(string(domain.OrderCancelled), suite.itemStatus(ctx, orderID))
+
+	product, err := suite.productRepo.GetProductById(ctx, productID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(5), product.Stock)
+
+	// the payment window of this order is still open
+	payment, err = suite.paymentRepo.FindByOrderId(ctx, recentID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentPending), payment.Status)
+}
+
+func (suite *OrderJobServiceTestSuite) TestExpireKeepsPaymentSettledAtGateway() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, productID := suite.placeOrder(ctx, domain.OrderPending, time.Now().Add(-48*time.Hour))
+
+	_, err := suite.paymentSvc.InitializePayment(ctx, &dto.PaymentReq{OrderID: orderID, UserID: "testemail@gmail.com"})
+	suite.Require().NoError(err)
+
+	// the buyer paid but the notification never arrived
+	_, err = suite.gateway.Simulate(ctx, orderID, "settlement")
+	suite.Require().NoError(err)
+
+	_, err = suite.svc.ExpireUnpaidOrders(ctx, time.Now().Add(-24*time.Hour))
+	suite.Require().NoError(err)
+
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentSuccess), payment.Status)
+	suite.Require().Equal(string(domain.OrderProcessed), suite.itemStatus(ctx, orderID))
+
+	product, err := suite.productRepo.GetProductById(ctx, productID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(3), product.Stock)
+}
+
+func (suite *OrderJobServiceTestSuite) TestExpireClosesChargeAndRefundsLatePayment() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, _ := suite.placeOrder(ctx, domain.OrderPending, time.Now().Add(-48*time.Hour))
+
+	_, err := suite.paymentSvc.InitializePayment(ctx, &dto.PaymentReq{OrderID: orderID, UserID: "testemail@gmail.com"})
+	suite.Require().NoError(err)
+
+	_, err = suite.svc.ExpireUnpaidOrders(ctx, time.Now().Add(-24*time.Hour))
+	suite.Require().NoError(err)
+
+	charge, err := suite.gateway.GetCharge(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal("expire", charge.TransactionStatus)
+
+	// the buyer still got the payment through, twice delivered
+	payload, err := suite.gateway.Simulate(ctx, orderID, "settlement")
+	suite.Require().NoError(err)
+	for i := 0; i < 2; i++ {
+		_, err = suite.paymentNotifSvc.HandleNotification(ctx, payload)
+		suite.Require().NoError(err)
+	}
+
+	charge, err = suite.gateway.GetCharge(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal("refund", charge.TransactionStatus)
+	suite.Require().Equal(charge.Amount, charge.Refunded)
+
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentExpired), payment.Status)
+	suite.Require().Equal(string(domain.OrderCancelled), suite.itemStatus(ctx, orderID))
+}
+
+func TestOrderJobServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(OrderJobServiceTestSuite))
+}
+
+func TestSchedulerRunsOncePerInterval(t *testing.T) {
+	cacheRepo := test.NewMemoryCache()
+	replicaA := scheduler.NewScheduler(cacheRepo)
+	replicaB := scheduler.NewScheduler(cacheRepo)
+
+	var runs int
+	job := scheduler.Job{
+		Name:     "count",
+		Interval: 50 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			runs++
+			return nil
+		},
+	}
+
+	ran, err := replicaA.RunOnce(context.Background(), job)
+	if err != nil || !ran {
+		t.Fatalf("first run: ran=%v err=%v", ran, err)
+	}
+
+	ran, err = replicaB.RunOnce(context.Background(), job)
+	if err != nil || ran {
+		t.Fatalf("second replica in the same interval: ran=%v err=%v", ran, err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	ran, err = replicaB.RunOnce(context.Background(), job)
+	if err != nil || !ran {
+		t.Fatalf("second replica in the next interval: ran=%v err=%v", ran, err)
+	}
+
+	if runs != 2 {
+		t.Fatalf("expected 2 runs, got %d", runs)
+	}
+}
+
+func TestSchedulerHoldsLockWhileJobRuns(t *testing.T) {
+	cacheRepo := test.NewMemoryCache()
+	replicaA := scheduler.NewScheduler(cacheRepo)
+	replicaB := scheduler.NewScheduler(cacheRepo)
+
+	other := scheduler.Job{Name: "slow", Interval: 30 * time.Millisecond, Run: func(ctx context.Context) error { return nil }}
+	job := scheduler.Job{
+		Name:     "slow",
+		Interval: 30 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			// well past the interval the lock is still held
+			time.Sleep(70 * time.Millisecond)
+			ran, err := replicaB.RunOnce(context.Background(), other)
+			if err != nil || ran {
+				t.Errorf("second replica during a long run: ran=%v err=%v", ran, err)
+			}
+
+			// a lock is only released by the run holding it
+			if released, _ := cacheRepo.ExpireIfEqual("scheduler-lock:slow", []byte("another run"), 0); released {
+				t.Errorf("lock released by another run")
+			}
+			return ctx.Err()
+		},
+	}
+
+	ran, err := replicaA.RunOnce(context.Background(), job)
+	if err != nil || !ran {
+		t.Fatalf("long run: ran=%v err=%v", ran, err)
+	}
+
+	// the run outlived its interval, so the lock is released right away
+	ran, err = replicaB.RunOnce(context.Background(), other)
+	if err != nil || !ran {
+		t.Fatalf("second replica after a long run: ran=%v err=%v", ran, err)
+	}
+}
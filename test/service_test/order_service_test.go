@@ -0,0 +1,468 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/db"
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/IndraSty/GreenBasket/test"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type OrderServiceTestSuite struct {
+	ServiceTestSuite
+	svc         domain.OrderService
+	userRepo    domain.UserRepository
+	cartRepo    domain.CartRepository
+	sellerRepo  domain.SellerRepository
+	storeRepo   domain.StoreRepository
+	productRepo domain.ProductRepository
+	orderRepo   domain.OrderRepository
+	rateRepo    domain.ShippingRateRepository
+}
+
+func (suite *OrderServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	suite.userRepo = repository.NewUserRepository(suite.Client)
+	suite.cartRepo = repository.NewCartRepository(suite.Client)
+	suite.sellerRepo = repository.NewSellerRepository(suite.Client)
+	suite.storeRepo = repository.NewStoreRepository(suite.Client)
+	suite.productRepo = repository.NewProductRepository(suite.Client)
+	suite.orderRepo = repository.NewOrderRepository(suite.Client)
+	suite.rateRepo = repository.NewShippingRateRepository(suite.Client)
+
+	suite.svc = suite.newOrderService(suite.productRepo)
+}
+
+// newOrderService builds the order service, reserving the stock of an order
+// through productRepo.
+func (suite *OrderServiceTestSuite) newOrderService(productRepo domain.ProductRepository) domain.OrderService {
+	cacheRepo := test.NewMemoryCache()
+	sellerOrderRepo := repository.NewSellerOrderRepository(suite.Client)
+	reservationRepo := repository.NewReservationRepository(suite.Client)
+
+	hub := &dto.Hub{NotificationChannel: map[string]chan dto.NotificationRes{}}
+	notifSvc := service.NewNotificationService(repository.NewNotificationRepository(suite.Client), repository.NewTemplateRepository(suite.Client), hub)
+	salesReportSvc := service.NewSalesRepository(repository.NewSalesReportRepository(suite.Client), sellerOrderRepo, suite.storeRepo,
+		suite.productRepo, repository.NewReviewRepository(suite.Client), cacheRepo)
+	ledgerSvc := service.NewStockLedgerService(repository.NewStockMovementRepository(suite.Client), suite.productRepo,
+		repository.NewStoreRepository(suite.Client), repository.NewTransactor(suite.Client, repository.TxModeAuto))
+	batchSvc := service.NewBatchService(repository.NewBatchRepository(suite.Client), suite.productRepo,
+		repository.NewStoreRepository(suite.Client), repository.NewSellerRepository(suite.Client), ledgerSvc, nil,
+		repository.NewTransactor(suite.Client, repository.TxModeAuto), config.Inventory{})
+	reservationSvc := service.NewReservationService(reservationRepo, productRepo, batchSvc, ledgerSvc,
+		repository.NewTransactor(suite.Client, repository.TxModeAuto))
+	transactor := repository.NewTransactor(suite.Client, repository.TxModeAuto)
+	shippingSvc := service.NewShippingService(service.NewTableRateProvider(suite.rateRepo), suite.productRepo,
+		suite.storeRepo, suite.userRepo, suite.cartRepo)
+
+	return service.NewOrderService(suite.orderRepo, suite.userRepo, suite.cartRepo, suite.sellerRepo, suite.storeRepo,
+		notifSvc, sellerOrderRepo, salesReportSvc, reservationSvc, service.NewOrderStatusService(suite.orderRepo, sellerOrderRepo),
+		shippingSvc, transactor, cacheRepo)
+}
+
+func (suite *OrderServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *OrderServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+}
+
+func (suite *OrderServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+func (suite *OrderServiceTestSuite) TestCreateOrderReservesStock() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID, productID := suite.seedShop(ctx, domain.Products{Stock: 5, Weight: 500})
+	email := suite.seedBuyer(ctx, cartItem(storeID, productID, 3))
+
+	res, err := suite.svc.CreateOrder(ctx, email)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(res)
+
+	product, err := suite.productRepo.GetProductById(ctx, productID, storeID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(2), product.Stock, "The ordered quantity must be reserved at checkout")
+}
+
+func (suite *OrderServiceTestSuite) TestCreateOrderInsufficientStock() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID, productID := suite.seedShop(ctx, domain.Products{Stock: 1, Weight: 500})
+	email := suite.seedBuyer(ctx, cartItem(storeID, productID, 2))
+
+	res, err := suite.svc.CreateOrder(ctx, email)
+	suite.Require().Error(err, "An error should occur because the stock is less than the quantity")
+	suite.Require().Nil(res)
+
+	product, err := suite.productRepo.GetProductById(ctx, productID, storeID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(1), product.Stock, "A failed checkout must not touch the stock")
+}
+
+func (suite *OrderServiceTestSuite) TestCreateOrderReservesVariantStock() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID, productID := suite.seedShop(ctx, domain.Products{Stock: 20, Weight: 500})
+	small, large := suite.seedVariants(ctx, storeID, productID)
+	email := suite.seedBuyer(ctx, cartItem(storeID, productID, 3))
+
+	// an item without a variant can not take the stock of the variants
+	_, err := suite.svc.CreateOrder(ctx, email)
+	suite.Require().Error(err)
+
+	product, err := suite.productRepo.GetProductById(ctx, productID, storeID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(20), product.Stock)
+
+	buyer := suite.seedBuyer(ctx, cartItem(storeID, productID, 6))
+	_, err = suite.cartRepo.RemoveCartItemById(ctx, buyer, productID, "", time.Now())
+	suite.Require().NoError(err)
+	_, err = suite.cartRepo.AddToCart(ctx, buyer, &domain.CartItem{Product_Id: productID, Variant_Id: small, StoreID: storeID, Quantity: 6, Selected: true, Price: 10000})
+	suite.Require().NoError(err)
+
+	// only 5 small packs are left
+	_, err = suite.svc.CreateOrder(ctx, buyer)
+	suite.Require().Error(err)
+
+	_, err = suite.cartRepo.UpdateCartItemById(ctx, buyer, productID, small, &dto.CartItemEditRepo{Quantity: 4, Selected: true}, time.Now())
+	suite.Require().NoError(err)
+
+	res, err := suite.svc.CreateOrder(ctx, buyer)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(res)
+
+	product, err = suite.productRepo.GetProductById(ctx, productID, storeID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(16), product.Stock)
+
+	variant, err := domain.FindVariant(product.Variants, small)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(1), variant.Stock)
+
+	variant, err = domain.FindVariant(product.Variants, large)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(15), variant.Stock)
+}
+
+func (suite *OrderServiceTestSuite) TestCreateOrderTakesTwoVariantsOfAProduct() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID, productID := suite.seedShop(ctx, domain.Products{Stock: 20, Weight: 500})
+	small, large := suite.seedVariants(ctx, storeID, productID)
+	email := suite.seedBuyer(ctx, cartItem(storeID, productID, 1))
+
+	_, err := suite.cartRepo.RemoveCartItemById(ctx, email, productID, "", time.Now())
+	suite.Require().NoError(err)
+	for variantID, quantity := range map[string]float64{small: 1, large: 2} {
+		_, err = suite.cartRepo.AddToCart(ctx, email, &domain.CartItem{Product_Id: productID, Variant_Id: variantID, StoreID: storeID, Quantity: quantity, Selected: true, Price: 10000})
+		suite.Require().NoError(err)
+	}
+
+	_, err = suite.svc.CreateOrder(ctx, email)
+	suite.Require().NoError(err)
+
+	variantStock := func(variantID string) float64 {
+		product, err := suite.productRepo.GetProductById(ctx, productID, storeID)
+		suite.Require().NoError(err)
+		variant, err := domain.FindVariant(product.Variants, variantID)
+		suite.Require().NoError(err)
+		return variant.Stock
+	}
+	suite.Require().Equal(float64(4), variantStock(small))
+	suite.Require().Equal(float64(13), variantStock(large))
+
+	orders, err := suite.svc.GetAllOrders(ctx, email, domain.OrderPages.Offset(1))
+	suite.Require().NoError(err)
+	suite.Require().Len(orders.Items, 1)
+	order := orders.Items[0]
+	suite.Require().Len(order.Items, 2)
+
+	// cancelling one variant leaves the other one and its reservation alone
+	err = suite.svc.CancelOrder(ctx, email, order.Order_id, productID, small)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(5), variantStock(small))
+	suite.Require().Equal(float64(13), variantStock(large))
+
+	updated, err := suite.orderRepo.GetOrder(ctx, order.Order_id)
+	suite.Require().NoError(err)
+	suite.Require().Len(updated.Items, 2, "A cancelled item stays on the order with its history")
+	for _, item := range updated.Items {
+		if item.Is(productID, small) {
+			suite.Require().Equal(string(domain.OrderCancelled), item.Order_Status)
+			suite.Require().Len(item.Status_History, 1)
+			suite.Require().Equal(domain.ActorUser, item.Status_History[0].Actor)
+			suite.Require().Equal(order.Total_Price-item.Price*item.Quantity, updated.Total_Price)
+		} else {
+			suite.Require().Equal(string(domain.OrderPending), item.Order_Status)
+		}
+	}
+}
+
+func (suite *OrderServiceTestSuite) TestCreateOrderRefusesHiddenProduct() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID, productID := suite.seedShop(ctx, domain.Products{Stock: 5, Weight: 500})
+	email := suite.seedBuyer(ctx, cartItem(storeID, productID, 1))
+
+	// the product was unpublished after it went into the cart
+	_, err := suite.productRepo.UpdateProduct(ctx, storeID, productID, bson.D{{Key: "unpublished", Value: true}})
+	suite.Require().NoError(err)
+
+	_, err = suite.svc.CreateOrder(ctx, email)
+	suite.Require().Error(err)
+
+	product, err := suite.productRepo.GetProductById(ctx, productID, storeID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(5), product.Stock)
+}
+
+func (suite *OrderServiceTestSuite) TestCreateOrderConcurrentNoOversell() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	const stock = 3
+	const buyers = 20
+
+	storeID, productID := suite.seedShop(ctx, domain.Products{Stock: stock, Weight: 500})
+
+	var emails []string
+	for i := 0; i < buyers; i++ {
+		emails = append(emails, suite.seedBuyer(ctx, cartItem(storeID, productID, 1)))
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	success := 0
+
+	for _, email := range emails {
+		wg.Add(1)
+		go func(email string) {
+			defer wg.Done()
+			_, err := suite.svc.CreateOrder(ctx, email)
+			if err == nil {
+				mu.Lock()
+				success++
+				mu.Unlock()
+			}
+		}(email)
+	}
+	wg.Wait()
+
+	suite.Require().LessOrEqual(success, stock, "No more orders than there are units in stock may succeed")
+
+	product, err := suite.productRepo.GetProductById(ctx, productID, storeID)
+	suite.Require().NoError(err)
+	suite.Require().GreaterOrEqual(product.Stock, float64(0), "The stock must never go below zero")
+	suite.Require().Equal(float64(stock-success), product.Stock, "Every successful order must hold exactly its reserved units")
+}
+
+func (suite *OrderServiceTestSuite) TestCreateOrderClearsSelectedCartItems() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID, productID := suite.seedShop(ctx, domain.Products{Stock: 5, Weight: 500})
+	email := suite.seedBuyer(ctx, cartItem(storeID, productID, 1))
+
+	_, err := suite.svc.CreateOrder(ctx, email)
+	suite.Require().NoError(err)
+
+	cart, err := suite.cartRepo.GetUserCart(ctx, email)
+	suite.Require().NoError(err)
+	suite.Require().Empty(cart.Items, "The ordered items must be removed from the cart")
+}
+
+func (suite *OrderServiceTestSuite) TestCreateOrderRollbackOnFailure() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID, productID := suite.seedShop(ctx, domain.Products{Stock: 5, Weight: 500})
+	email := suite.seedBuyer(ctx, cartItem(storeID, productID, 2))
+
+	// the second item belongs to a store without a seller, so its stock can be
+	// reserved but the seller order cannot be created
+	orphanStoreID := suite.seedStore(ctx, domain.Store{Address_Details: &domain.Address{City: "orphan city"}})
+	suite.seedRate(ctx, "orphan city", "Jakarta", 100000, 9000)
+	orphanProductID := suite.seedProduct(ctx, domain.Products{Store_id: orphanStoreID, Stock: 5, Weight: 500})
+
+	item := cartItem(orphanStoreID, orphanProductID, 1)
+	_, err := suite.cartRepo.AddToCart(ctx, email, &item)
+	suite.Require().NoError(err)
+
+	res, err := suite.svc.CreateOrder(ctx, email)
+	suite.Require().Error(err)
+	suite.Require().Nil(res)
+
+	product, err := suite.productRepo.GetProductById(ctx, productID, storeID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(5), product.Stock, "The reserved stock must be given back")
+
+	product, err = suite.productRepo.GetProductById(ctx, orphanProductID, orphanStoreID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(5), product.Stock, "The reserved stock must be given back")
+
+	orders, err := suite.orderRepo.GetAllOrders(ctx, email)
+	suite.Require().NoError(err)
+	suite.Require().Empty(*orders, "No user order may be left behind")
+
+	cart, err := suite.cartRepo.GetUserCart(ctx, email)
+	suite.Require().NoError(err)
+	suite.Require().Len(cart.Items, 2, "The cart must be untouched")
+}
+
+// conflictingProductRepo writes the product in another transaction right
+// before the first reservation, so that reservation hits a write conflict.
+type conflictingProductRepo struct {
+	domain.ProductRepository
+	client    *mongo.Client
+	conflicts int
+	calls     int
+}
+
+func (repo *conflictingProductRepo) ReserveStock(ctx context.Context, storeID, productID, variantID string, quantity float64, updatedAt time.Time) (*mongo.UpdateResult, error) {
+	repo.calls++
+	if repo.conflicts > 0 {
+		return repo.ProductRepository.ReserveStock(ctx, storeID, productID, variantID, quantity, updatedAt)
+	}
+	repo.conflicts++
+
+	session, err := repo.client.StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	err = session.StartTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer session.AbortTransaction(context.Background())
+
+	err = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
+		_, err := db.OpenCollection(repo.client, "Products").UpdateOne(sc, bson.M{"product_id": productID},
+			bson.M{"$set": bson.M{"updated_at": time.Now()}})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.ProductRepository.ReserveStock(ctx, storeID, productID, variantID, quantity, updatedAt)
+}
+
+func (suite *OrderServiceTestSuite) TestCreateOrderRetriesWriteConflict() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	var hello struct {
+		SetName string `bson:"setName"`
+	}
+	err := suite.Client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	suite.Require().NoError(err)
+	if hello.SetName == "" {
+		suite.T().Skip("write conflicts need transactions, which need a replica set")
+	}
+
+	storeID, productID := suite.seedShop(ctx, domain.Products{Stock: 5, Weight: 500})
+	email := suite.seedBuyer(ctx, cartItem(storeID, productID, 3))
+
+	productRepo := &conflictingProductRepo{ProductRepository: suite.productRepo, client: suite.Client}
+	res, err := suite.newOrderService(productRepo).CreateOrder(ctx, email)
+	suite.Require().NoError(err, "A write conflict must retry the transaction instead of failing the order")
+	suite.Require().NotNil(res)
+	suite.Require().Equal(2, productRepo.calls)
+
+	product, err := suite.productRepo.GetProductById(ctx, productID, storeID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(2), product.Stock, "The stock must be reserved once")
+}
+
+func (suite *OrderServiceTestSuite) TestCreateOrderAddsShippingPerSeller() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID, productID := suite.seedShop(ctx, domain.Products{Stock: 5, Weight: 500})
+	email := suite.seedBuyer(ctx, cartItem(storeID, productID, 2))
+
+	otherStoreID, otherProductID := suite.seedShop(ctx, domain.Products{Stock: 5, Weight: 500})
+	item := cartItem(otherStoreID, otherProductID, 1)
+	_, err := suite.cartRepo.AddToCart(ctx, email, &item)
+	suite.Require().NoError(err)
+
+	_, err = suite.svc.CreateOrder(ctx, email)
+	suite.Require().NoError(err)
+
+	orders, err := suite.orderRepo.GetAllOrders(ctx, email)
+	suite.Require().NoError(err)
+	suite.Require().Len(*orders, 1)
+
+	order := (*orders)[0]
+	suite.Require().Equal(float64(18000), order.Shipping_Fee, "Every seller ships its own parcel")
+	suite.Require().Equal(float64(30000+18000), order.Total_Price)
+
+	sellerOrder, err := repository.NewSellerOrderRepository(suite.Client).GetSellerOrderByEmailAndId(ctx, storeID+"@seller.com", order.Order_id)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(9000), sellerOrder.Shipping_Fee)
+	suite.Require().Equal(float64(20000+9000), sellerOrder.Total_Price)
+}
+
+func (suite *OrderServiceTestSuite) TestCancelLastItemOfStoreDropsItsShipping() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID, productID := suite.seedShop(ctx, domain.Products{Stock: 5, Weight: 500})
+	email := suite.seedBuyer(ctx, cartItem(storeID, productID, 2))
+
+	otherStoreID, otherProductID := suite.seedShop(ctx, domain.Products{Stock: 5, Weight: 500})
+	item := cartItem(otherStoreID, otherProductID, 1)
+	_, err := suite.cartRepo.AddToCart(ctx, email, &item)
+	suite.Require().NoError(err)
+
+	_, err = suite.svc.CreateOrder(ctx, email)
+	suite.Require().NoError(err)
+
+	orders, err := suite.orderRepo.GetAllOrders(ctx, email)
+	suite.Require().NoError(err)
+	orderID := (*orders)[0].Order_id
+
+	err = suite.svc.CancelOrder(ctx, email, orderID, otherProductID, "")
+	suite.Require().NoError(err)
+
+	order, err := suite.orderRepo.GetOrder(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(9000), order.Shipping_Fee, "Only the store with items left ships a parcel")
+	suite.Require().Equal(float64(20000+9000), order.Total_Price)
+
+	sellerOrderRepo := repository.NewSellerOrderRepository(suite.Client)
+	sellerOrder, err := sellerOrderRepo.GetSellerOrderByEmailAndId(ctx, sellerEmail(otherStoreID), orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(0), sellerOrder.Shipping_Fee)
+	suite.Require().Equal(float64(0), sellerOrder.Total_Price)
+
+	sellerOrder, err = sellerOrderRepo.GetSellerOrderByEmailAndId(ctx, sellerEmail(storeID), orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(9000), sellerOrder.Shipping_Fee)
+	suite.Require().Equal(float64(20000+9000), sellerOrder.Total_Price)
+}
+
+func TestOrderServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(OrderServiceTestSuite))
+}
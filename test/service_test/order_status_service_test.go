@@ -0,0 +1,95 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/stretchr/testify/suite"
+)
+
+type OrderStatusServiceTestSuite struct {
+	ServiceTestSuite
+	svc             domain.OrderStatusService
+	orderRepo       domain.OrderRepository
+	sellerOrderRepo domain.SellerOrderRepository
+}
+
+func (suite *OrderStatusServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	suite.orderRepo = repository.NewOrderRepository(suite.Client)
+	suite.sellerOrderRepo = repository.NewSellerOrderRepository(suite.Client)
+	suite.svc = service.NewOrderStatusService(suite.orderRepo, suite.sellerOrderRepo)
+}
+
+func (suite *OrderStatusServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *OrderStatusServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+}
+
+func (suite *OrderStatusServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+func (suite *OrderStatusServiceTestSuite) TestTransitionRecordsHistory() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, sellerEmail, productID := suite.seedOrderItem(ctx, domain.OrderProcessed)
+
+	err := suite.svc.Transition(ctx, orderID, productID, "", domain.OrderShipped, domain.ActorSeller)
+	suite.Require().NoError(err)
+
+	err = suite.svc.Transition(ctx, orderID, productID, "", domain.OrderFinished, domain.ActorUser)
+	suite.Require().NoError(err)
+
+	order, err := suite.orderRepo.GetOrder(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.OrderFinished), order.Items[0].Order_Status)
+	suite.Require().Len(order.Items[0].Status_History, 2)
+	suite.Require().Equal(domain.ActorSeller, order.Items[0].Status_History[0].Actor)
+	suite.Require().Equal(domain.OrderProcessed, order.Items[0].Status_History[0].From)
+	suite.Require().Equal(domain.OrderShipped, order.Items[0].Status_History[0].To)
+	suite.Require().Equal(domain.ActorUser, order.Items[0].Status_History[1].Actor)
+
+	sellerOrder, err := suite.sellerOrderRepo.GetSellerOrderByEmailAndId(ctx, sellerEmail, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.OrderFinished), sellerOrder.Items[0].Status)
+	suite.Require().Len(sellerOrder.Items[0].Status_History, 2)
+}
+
+func (suite *OrderStatusServiceTestSuite) TestIllegalTransition() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, _, productID := suite.seedOrderItem(ctx, domain.OrderProcessed)
+
+	// a seller can not skip shipping, nor can a buyer ship their own order
+	var transitionErr *domain.OrderTransitionError
+	err := suite.svc.Transition(ctx, orderID, productID, "", domain.OrderFinished, domain.ActorSeller)
+	suite.Require().ErrorAs(err, &transitionErr)
+	suite.Require().Equal(domain.OrderProcessed, transitionErr.From)
+
+	err = suite.svc.Transition(ctx, orderID, productID, "", domain.OrderShipped, domain.ActorUser)
+	suite.Require().ErrorAs(err, &transitionErr)
+
+	// an item never goes back to PENDING
+	err = suite.svc.Transition(ctx, orderID, productID, "", domain.OrderPending, domain.ActorSeller)
+	suite.Require().ErrorAs(err, &transitionErr)
+
+	order, err := suite.orderRepo.GetOrder(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.OrderProcessed), order.Items[0].Order_Status)
+	suite.Require().Empty(order.Items[0].Status_History)
+}
+
+func TestOrderStatusServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(OrderStatusServiceTestSuite))
+}
@@ -0,0 +1,162 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type ProductImportServiceTestSuite struct {
+	ServiceTestSuite
+	svc         domain.ProductImportService
+	categorySvc domain.CategoryService
+	storeRepo   domain.StoreRepository
+	sellerRepo  domain.SellerRepository
+	productRepo domain.ProductRepository
+}
+
+func (suite *ProductImportServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	suite.productRepo = repository.NewProductRepository(suite.Client)
+	suite.storeRepo = repository.NewStoreRepository(suite.Client)
+	suite.sellerRepo = repository.NewSellerRepository(suite.Client)
+	transactor := repository.NewTransactor(suite.Client, repository.TxModeAuto)
+
+	suite.categorySvc = service.NewCategoryService(repository.NewCategoryRepository(suite.Client), suite.productRepo,
+		repository.NewAuditLogRepository(suite.Client))
+	ledgerSvc := service.NewStockLedgerService(repository.NewStockMovementRepository(suite.Client), suite.productRepo,
+		suite.storeRepo, transactor)
+	productSvc := service.NewProductService(suite.productRepo, suite.storeRepo, suite.sellerRepo, repository.NewSalesReportRepository(suite.Client),
+		nil, suite.categorySvc, ledgerSvc, nil, service.NewProductSearchService(repository.NewMemorySearchIndex(), suite.productRepo, suite.storeRepo), transactor)
+	suite.svc = service.NewProductImportService(repository.NewImportJobRepository(suite.Client), productSvc, suite.productRepo, suite.storeRepo)
+}
+
+func (suite *ProductImportServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *ProductImportServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+}
+
+func (suite *ProductImportServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+// seedCategory creates the vegetables category.
+func (suite *ProductImportServiceTestSuite) seedCategory(ctx context.Context) {
+	_, err := suite.categorySvc.CreateCategory(ctx, adminEmail, &dto.CategoryReq{
+		Names: map[string]string{"en": "Vegetables"},
+	})
+	suite.Require().NoError(err)
+}
+
+func (suite *ProductImportServiceTestSuite) waitForJob(ctx context.Context, seller, storeID, jobID string) *domain.ImportJob {
+	for {
+		job, err := suite.svc.GetImportJob(ctx, seller, storeID, jobID)
+		suite.Require().NoError(err)
+
+		if job.Status == domain.ImportDone {
+			return job
+		}
+
+		select {
+		case <-ctx.Done():
+			suite.FailNow("import job did not finish")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func (suite *ProductImportServiceTestSuite) TestImportCSVReportsBadRows() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID := suite.seedStore(ctx, domain.Store{})
+	_, seller := suite.seedSeller(ctx, storeID)
+	suite.seedCategory(ctx)
+
+	file := "name,price,stock,category,description,images\n" +
+		"Carrot,12000,10,vegetables,Fresh carrot,https://img/carrot.jpg|https://img/carrot-2.jpg\n" +
+		"Spinach,abc,5,vegetables,Fresh spinach,https://img/spinach.jpg\n" +
+		"Mango,20000,5,fruits,Sweet mango,https://img/mango.jpg\n"
+
+	res, err := suite.svc.Import(ctx, seller, storeID, domain.ImportFormatCSV, strings.NewReader(file))
+	suite.Require().NoError(err)
+	suite.Require().Equal(3, res.Total_Rows)
+
+	job := suite.waitForJob(ctx, seller, storeID, res.Job_Id)
+	suite.Require().Equal(1, job.Created)
+	suite.Require().Len(job.Errors, 2)
+	suite.Require().Equal(3, job.Errors[0].Row)
+	suite.Require().Equal("Spinach", job.Errors[0].Name)
+	suite.Require().Equal(4, job.Errors[1].Row)
+
+	var export bytes.Buffer
+	err = suite.svc.Export(ctx, seller, storeID, &export)
+	suite.Require().NoError(err)
+
+	records, err := csv.NewReader(&export).ReadAll()
+	suite.Require().NoError(err)
+	suite.Require().Len(records, 2)
+	suite.Require().Equal("Carrot", records[1][0])
+	suite.Require().Equal("https://img/carrot.jpg|https://img/carrot-2.jpg", records[1][9])
+}
+
+func (suite *ProductImportServiceTestSuite) TestImportRefusesUnreadableFile() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID := suite.seedStore(ctx, domain.Store{})
+	_, seller := suite.seedSeller(ctx, storeID)
+	suite.seedCategory(ctx)
+
+	_, err := suite.svc.Import(ctx, seller, storeID, domain.ImportFormatCSV, strings.NewReader("name,price\nCarrot,12000\n"))
+	suite.Require().Error(err)
+
+	_, err = suite.svc.Import(ctx, "other@seller.com", storeID, domain.ImportFormatJSONL, strings.NewReader(`{"name":"Carrot"}`))
+	suite.Require().Error(err)
+}
+
+func (suite *ProductImportServiceTestSuite) TestImportHonoursSuspension() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	suite.seedCategory(ctx)
+	file := "name,price,stock,category,description,images\nCarrot,12000,10,vegetables,Fresh carrot,https://img/carrot.jpg\n"
+
+	suspended := suite.seedStore(ctx, domain.Store{Suspended: true})
+	_, seller := suite.seedSeller(ctx, suspended)
+	_, err := suite.svc.Import(ctx, seller, suspended, domain.ImportFormatCSV, strings.NewReader(file))
+	suite.Require().ErrorIs(err, domain.ErrStoreSuspended)
+
+	// the products of a suspended seller are hidden from the start
+	storeID := suite.seedStore(ctx, domain.Store{})
+	_, seller = suite.seedSeller(ctx, storeID)
+	_, err = suite.sellerRepo.UpdateSeller(ctx, seller, bson.D{{Key: "suspended", Value: true}})
+	suite.Require().NoError(err)
+
+	res, err := suite.svc.Import(ctx, seller, storeID, domain.ImportFormatCSV, strings.NewReader(file))
+	suite.Require().NoError(err)
+	suite.Require().Equal(1, suite.waitForJob(ctx, seller, storeID, res.Job_Id).Created)
+
+	products, err := suite.productRepo.GetAllProductWithNoPage(ctx, storeID)
+	suite.Require().NoError(err)
+	suite.Require().Len(*products, 1)
+	suite.Require().True((*products)[0].Store_Suspended)
+}
+
+func TestProductImportServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ProductImportServiceTestSuite))
+}
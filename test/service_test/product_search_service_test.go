@@ -0,0 +1,178 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type ProductSearchServiceTestSuite struct {
+	ServiceTestSuite
+	productRepo domain.ProductRepository
+	storeRepo   domain.StoreRepository
+}
+
+func (suite *ProductSearchServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	suite.productRepo = repository.NewProductRepository(suite.Client)
+	suite.storeRepo = repository.NewStoreRepository(suite.Client)
+}
+
+func (suite *ProductSearchServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *ProductSearchServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+}
+
+func (suite *ProductSearchServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+// engines are a search service on each index, filled with the seeded products.
+func (suite *ProductSearchServiceTestSuite) engines(ctx context.Context) map[string]domain.ProductSearchService {
+	mongoIndex, err := repository.NewMongoSearchIndex(ctx, suite.Client)
+	suite.Require().NoError(err)
+
+	engines := map[string]domain.ProductSearchService{
+		"mongo":  service.NewProductSearchService(mongoIndex, suite.productRepo, suite.storeRepo),
+		"memory": service.NewProductSearchService(repository.NewMemorySearchIndex(), suite.productRepo, suite.storeRepo),
+	}
+
+	for _, engine := range engines {
+		_, err := engine.Reindex(ctx)
+		suite.Require().NoError(err)
+	}
+
+	return engines
+}
+
+func (suite *ProductSearchServiceTestSuite) TestSearchRanksByField() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	farm := suite.seedStore(ctx, domain.Store{Name: "Mango Farm"})
+	market := suite.seedStore(ctx, domain.Store{Name: "Green Market"})
+	inName := suite.seedProduct(ctx, domain.Products{Store_id: market, Name: "Sweet Mango", Category: "fruits", Description: "Ripe and juicy"})
+	inDescription := suite.seedProduct(ctx, domain.Products{Store_id: market, Name: "Fruit Salad", Category: "fruits", Description: "Apple, melon and mango pieces"})
+	inStoreName := suite.seedProduct(ctx, domain.Products{Store_id: farm, Name: "Papaya", Category: "fruits", Description: "Ripe papaya"})
+	suite.seedProduct(ctx, domain.Products{Store_id: market, Name: "Carrot", Category: "vegetables", Description: "Fresh carrot"})
+
+	for name, engine := range suite.engines(ctx) {
+		productIDs, err := engine.Search(ctx, "mango", "")
+		suite.Require().NoError(err, name)
+		suite.Require().Equal([]string{inName, inStoreName, inDescription}, productIDs, name)
+
+		productIDs, err = engine.Search(ctx, "mango", market)
+		suite.Require().NoError(err, name)
+		suite.Require().Equal([]string{inName, inDescription}, productIDs, name)
+	}
+}
+
+func (suite *ProductSearchServiceTestSuite) TestSyncProducts() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID := suite.seedStore(ctx, domain.Store{Name: "Green Market"})
+	productID := suite.seedProduct(ctx, domain.Products{Store_id: storeID, Name: "Carrot", Category: "vegetables", Description: "Fresh carrot"})
+
+	for name, engine := range suite.engines(ctx) {
+		_, err := suite.productRepo.UpdateProduct(ctx, storeID, productID, bson.D{{Key: "name", Value: "Spinach"}})
+		suite.Require().NoError(err)
+		suite.Require().NoError(engine.SyncProducts(ctx, productID), name)
+
+		productIDs, err := engine.Search(ctx, "spinach", "")
+		suite.Require().NoError(err, name)
+		suite.Require().Equal([]string{productID}, productIDs, name)
+
+		_, err = suite.productRepo.DeleteProductById(ctx, storeID, productID)
+		suite.Require().NoError(err)
+		suite.Require().NoError(engine.SyncProducts(ctx, productID), name)
+
+		productIDs, err = engine.Search(ctx, "spinach", "")
+		suite.Require().NoError(err, name)
+		suite.Require().Empty(productIDs, name)
+
+		productID = suite.seedProduct(ctx, domain.Products{Store_id: storeID, Name: "Carrot", Category: "vegetables", Description: "Fresh carrot"})
+	}
+}
+
+func (suite *ProductSearchServiceTestSuite) TestSuggest() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID := suite.seedStore(ctx, domain.Store{Name: "Green Market"})
+	otherID := suite.seedStore(ctx, domain.Store{Name: "Corner Shop"})
+	suite.seedProduct(ctx, domain.Products{Store_id: storeID, Name: "Cabbage", Category: "vegetables", Description: "Green cabbage"})
+	suite.seedProduct(ctx, domain.Products{Store_id: otherID, Name: "Cabbage", Category: "vegetables", Description: "Red cabbage"})
+	suite.seedProduct(ctx, domain.Products{Store_id: storeID, Name: "Carrot", Category: "vegetables", Description: "Fresh carrot"})
+
+	for name, engine := range suite.engines(ctx) {
+		names, err := engine.Suggest(ctx, "cab")
+		suite.Require().NoError(err, name)
+		suite.Require().Equal([]string{"Cabbage"}, names, name)
+	}
+}
+
+func (suite *ProductSearchServiceTestSuite) TestHiddenProductsAreNotFound() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID := suite.seedStore(ctx, domain.Store{Name: "Green Market"})
+	unpublished := suite.seedProduct(ctx, domain.Products{Store_id: storeID, Name: "Cabbage", Category: "vegetables", Description: "Green cabbage"})
+	suspended := suite.seedProduct(ctx, domain.Products{Store_id: storeID, Name: "Carrot", Category: "vegetables", Description: "Fresh carrot"})
+	visible := suite.seedProduct(ctx, domain.Products{Store_id: storeID, Name: "Cauliflower", Category: "vegetables", Description: "White cauliflower"})
+
+	engines := suite.engines(ctx)
+
+	_, err := suite.productRepo.UpdateProduct(ctx, storeID, unpublished, bson.D{{Key: "unpublished", Value: true}})
+	suite.Require().NoError(err)
+	_, err = suite.productRepo.UpdateProduct(ctx, storeID, suspended, bson.D{{Key: "store_suspended", Value: true}})
+	suite.Require().NoError(err)
+
+	for name, engine := range engines {
+		// a sync takes the hidden products out, and so does a reindex
+		suite.Require().NoError(engine.SyncProducts(ctx, unpublished), name)
+		_, err := engine.Reindex(ctx)
+		suite.Require().NoError(err, name)
+
+		productIDs, err := engine.Search(ctx, "vegetables", "")
+		suite.Require().NoError(err, name)
+		suite.Require().Equal([]string{visible}, productIDs, name)
+
+		names, err := engine.Suggest(ctx, "ca")
+		suite.Require().NoError(err, name)
+		suite.Require().Equal([]string{"Cauliflower"}, names, name)
+	}
+}
+
+func (suite *ProductSearchServiceTestSuite) TestMemoryIndexMatchesTypos() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID := suite.seedStore(ctx, domain.Store{Name: "Green Market"})
+	broccoli := suite.seedProduct(ctx, domain.Products{Store_id: storeID, Name: "Broccoli", Category: "vegetables", Description: "Fresh broccoli"})
+	suite.seedProduct(ctx, domain.Products{Store_id: storeID, Name: "Carrot", Category: "vegetables", Description: "Fresh carrot"})
+
+	engine := suite.engines(ctx)["memory"]
+
+	productIDs, err := engine.Search(ctx, "brocoli", "")
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{broccoli}, productIDs)
+
+	names, err := engine.Suggest(ctx, "broc")
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{"Broccoli"}, names)
+}
+
+func TestProductSearchServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ProductSearchServiceTestSuite))
+}
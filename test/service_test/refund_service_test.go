@@ -0,0 +1,333 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/IndraSty/GreenBasket/test"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RefundServiceTestSuite struct {
+	ServiceTestSuite
+	svc             domain.RefundService
+	gateway         domain.FakePaymentGateway
+	paymentSvc      domain.PaymentService
+	notifSvc        domain.PaymentNotificationService
+	paymentRepo     domain.PaymentRepository
+	orderRepo       domain.OrderRepository
+	sellerOrderRepo domain.SellerOrderRepository
+	sellerRepo      domain.SellerRepository
+	productRepo     domain.ProductRepository
+	reservationSvc  domain.ReservationService
+}
+
+func (suite *RefundServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	cnf := &config.Config{Server: config.Server{Host: "localhost", Port: "8080"}}
+	cacheRepo := test.NewMemoryCache()
+	suite.gateway = service.NewFakeGateway(cnf)
+	suite.paymentRepo = repository.NewPaymentRepository(suite.Client)
+	suite.orderRepo = repository.NewOrderRepository(suite.Client)
+	suite.sellerOrderRepo = repository.NewSellerOrderRepository(suite.Client)
+	suite.sellerRepo = repository.NewSellerRepository(suite.Client)
+	suite.productRepo = repository.NewProductRepository(suite.Client)
+	ledgerSvc := service.NewStockLedgerService(repository.NewStockMovementRepository(suite.Client), suite.productRepo,
+		repository.NewStoreRepository(suite.Client), repository.NewTransactor(suite.Client, repository.TxModeAuto))
+	batchSvc := service.NewBatchService(repository.NewBatchRepository(suite.Client), suite.productRepo,
+		repository.NewStoreRepository(suite.Client), repository.NewSellerRepository(suite.Client), ledgerSvc, nil,
+		repository.NewTransactor(suite.Client, repository.TxModeAuto), config.Inventory{})
+	suite.reservationSvc = service.NewReservationService(repository.NewReservationRepository(suite.Client), suite.productRepo, batchSvc, ledgerSvc,
+		repository.NewTransactor(suite.Client, repository.TxModeAuto))
+	storeRepo := repository.NewStoreRepository(suite.Client)
+	transactor := repository.NewTransactor(suite.Client, repository.TxModeAuto)
+
+	hub := &dto.Hub{NotificationChannel: map[string]chan dto.NotificationRes{}}
+	notificationSvc := service.NewNotificationService(repository.NewNotificationRepository(suite.Client), repository.NewTemplateRepository(suite.Client), hub)
+	salesReportSvc := service.NewSalesRepository(repository.NewSalesReportRepository(suite.Client), suite.sellerOrderRepo, storeRepo,
+		suite.productRepo, repository.NewReviewRepository(suite.Client), cacheRepo)
+
+	orderStatusSvc := service.NewOrderStatusService(suite.orderRepo, suite.sellerOrderRepo)
+
+	suite.paymentSvc = service.NewPaymentService(notificationSvc, suite.paymentRepo, repository.NewUserRepository(suite.Client), suite.orderRepo, suite.gateway)
+	suite.notifSvc = service.NewPaymentNotificationService(suite.gateway, suite.paymentRepo, suite.orderRepo, suite.sellerOrderRepo,
+		repository.NewPaymentEventRepository(suite.Client), suite.reservationSvc, orderStatusSvc, notificationSvc, transactor)
+	suite.svc = service.NewRefundService(repository.NewRefundRepository(suite.Client), suite.orderRepo, suite.sellerOrderRepo,
+		suite.sellerRepo, suite.paymentRepo, suite.productRepo, ledgerSvc, orderStatusSvc, suite.gateway, salesReportSvc, notificationSvc, transactor)
+}
+
+func (suite *RefundServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *RefundServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+}
+
+func (suite *RefundServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+// paidOrder creates an order of two products from one store, 2 units of the
+// first and 1 unit of the second out of a stock of 5 each, shipped for
+// shippingFee, and settles its payment at the fake gateway.
+func (suite *RefundServiceTestSuite) paidOrder(ctx context.Context, shippingFee float64) (orderID, buyer, seller string, productIDs []string) {
+	storeID := primitive.NewObjectID().Hex()
+	_, seller = suite.seedSeller(ctx, storeID)
+	buyer = suite.seedBuyer(ctx)
+
+	var items []domain.OrderItem
+	for _, quantity := range []float64{2, 1} {
+		productID := suite.seedProduct(ctx, domain.Products{Store_id: storeID, Stock: 5})
+		productIDs = append(productIDs, productID)
+		items = append(items, orderItem(storeID, productID, quantity, domain.OrderPending))
+	}
+
+	orderID = suite.seedOrder(ctx, domain.Orders{Email: buyer, Items: items, Shipping_Fee: shippingFee, Total_Price: 30000 + shippingFee})
+
+	err := suite.reservationSvc.ReserveStock(ctx, orderID, items)
+	suite.Require().NoError(err)
+
+	_, err = suite.paymentSvc.InitializePayment(ctx, &dto.PaymentReq{
+		OrderID: orderID,
+		UserID:  buyer,
+	})
+	suite.Require().NoError(err)
+
+	payload, err := suite.gateway.Simulate(ctx, orderID, "settlement")
+	suite.Require().NoError(err)
+
+	success, err := suite.notifSvc.HandleNotification(ctx, payload)
+	suite.Require().NoError(err)
+	suite.Require().True(success)
+
+	return orderID, buyer, seller, productIDs
+}
+
+func (suite *RefundServiceTestSuite) TestApprovePartialRefund() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, buyer, seller, productIDs := suite.paidOrder(ctx, 0)
+
+	res, err := suite.svc.RequestRefund(ctx, buyer, orderID, productIDs[0], "", &dto.RefundReq{Reason: "damaged"})
+	suite.Require().NoError(err)
+	suite.Require().Equal(20000.0, res.Amount)
+	suite.Require().Equal(domain.RefundRequested, res.Status)
+
+	err = suite.svc.ApproveRefund(ctx, seller, res.Refund_Id)
+	suite.Require().NoError(err)
+
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentPartialRefunded), payment.Status)
+
+	order, err := suite.orderRepo.GetOrder(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(10000.0, order.Total_Price)
+	suite.Require().Equal(string(domain.OrderRefunded), order.Items[0].Order_Status)
+	suite.Require().Equal(string(domain.OrderProcessed), order.Items[1].Order_Status)
+
+	sellerOrder, err := suite.sellerOrderRepo.GetSellerOrderByEmailAndId(ctx, seller, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(10000.0, sellerOrder.Total_Price)
+	suite.Require().Equal(string(domain.PaymentPartialRefunded), sellerOrder.Payment_Status)
+
+	// the item had not been shipped yet, so it goes back on sale
+	product, err := suite.productRepo.GetProductById(ctx, productIDs[0])
+	suite.Require().NoError(err)
+	suite.Require().Equal(float64(5), product.Stock)
+
+	charge, err := suite.gateway.GetCharge(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(20000.0, charge.Refunded)
+
+	refunds, err := suite.svc.GetUserRefunds(ctx, buyer, domain.RefundPages.Offset(1))
+	suite.Require().NoError(err)
+	suite.Require().Len(refunds.Items, 1)
+	suite.Require().Equal(domain.RefundRefunded, refunds.Items[0].Status)
+}
+
+func (suite *RefundServiceTestSuite) TestRefundEveryItem() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, buyer, seller, productIDs := suite.paidOrder(ctx, 0)
+
+	for _, productID := range productIDs {
+		res, err := suite.svc.RequestRefund(ctx, buyer, orderID, productID, "", &dto.RefundReq{Reason: "late"})
+		suite.Require().NoError(err)
+
+		err = suite.svc.ApproveRefund(ctx, seller, res.Refund_Id)
+		suite.Require().NoError(err)
+	}
+
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentRefunded), payment.Status)
+
+	order, err := suite.orderRepo.GetOrder(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(0.0, order.Total_Price)
+}
+
+func (suite *RefundServiceTestSuite) TestRefundEveryItemOfShippedOrder() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, buyer, seller, productIDs := suite.paidOrder(ctx, 9000)
+
+	for _, productID := range productIDs {
+		res, err := suite.svc.RequestRefund(ctx, buyer, orderID, productID, "", &dto.RefundReq{Reason: "late"})
+		suite.Require().NoError(err)
+
+		err = suite.svc.ApproveRefund(ctx, seller, res.Refund_Id)
+		suite.Require().NoError(err)
+	}
+
+	// every item is refunded, the shipping was paid for
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentRefunded), payment.Status)
+
+	charge, err := suite.gateway.GetCharge(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(30000.0, charge.Refunded)
+}
+
+func (suite *RefundServiceTestSuite) TestRetryApprovedRefund() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, buyer, _, productIDs := suite.paidOrder(ctx, 0)
+
+	res, err := suite.svc.RequestRefund(ctx, buyer, orderID, productIDs[0], "", &dto.RefundReq{Reason: "damaged"})
+	suite.Require().NoError(err)
+
+	// the approval reached the gateway but never got to the orders
+	refundRepo := repository.NewRefundRepository(suite.Client)
+	_, err = refundRepo.UpdateStatus(ctx, res.Refund_Id, domain.RefundRequested, domain.RefundApproved, time.Now())
+	suite.Require().NoError(err)
+	err = suite.gateway.Refund(ctx, orderID, res.Refund_Id, res.Amount, "damaged")
+	suite.Require().NoError(err)
+
+	finished, err := suite.svc.RetryApprovedRefunds(ctx, time.Now().Add(-time.Minute))
+	suite.Require().NoError(err)
+	suite.Require().Equal(0, finished)
+
+	finished, err = suite.svc.RetryApprovedRefunds(ctx, time.Now().Add(time.Minute))
+	suite.Require().NoError(err)
+	suite.Require().Equal(1, finished)
+
+	refund, err := refundRepo.FindById(ctx, res.Refund_Id)
+	suite.Require().NoError(err)
+	suite.Require().Equal(domain.RefundRefunded, refund.Status)
+
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentPartialRefunded), payment.Status)
+
+	charge, err := suite.gateway.GetCharge(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(20000.0, charge.Refunded)
+}
+
+func (suite *RefundServiceTestSuite) TestApproveTwiceRefundsOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, buyer, seller, productIDs := suite.paidOrder(ctx, 0)
+
+	res, err := suite.svc.RequestRefund(ctx, buyer, orderID, productIDs[0], "", &dto.RefundReq{Reason: "damaged"})
+	suite.Require().NoError(err)
+
+	err = suite.svc.ApproveRefund(ctx, seller, res.Refund_Id)
+	suite.Require().NoError(err)
+
+	err = suite.svc.ApproveRefund(ctx, seller, res.Refund_Id)
+	suite.Require().Error(err)
+
+	_, err = suite.svc.RequestRefund(ctx, buyer, orderID, productIDs[0], "", &dto.RefundReq{Reason: "damaged"})
+	suite.Require().Error(err)
+
+	charge, err := suite.gateway.GetCharge(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(20000.0, charge.Refunded)
+}
+
+func (suite *RefundServiceTestSuite) TestRejectRefund() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, buyer, seller, productIDs := suite.paidOrder(ctx, 0)
+
+	res, err := suite.svc.RequestRefund(ctx, buyer, orderID, productIDs[0], "", &dto.RefundReq{Reason: "changed my mind"})
+	suite.Require().NoError(err)
+
+	// only the seller of the item may decide on the refund
+	err = suite.svc.RejectRefund(ctx, buyer, res.Refund_Id)
+	suite.Require().Error(err)
+
+	err = suite.svc.RejectRefund(ctx, seller, res.Refund_Id)
+	suite.Require().NoError(err)
+
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentSuccess), payment.Status)
+
+	order, err := suite.orderRepo.GetOrder(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(30000.0, order.Total_Price)
+}
+
+func (suite *RefundServiceTestSuite) TestRequestRefundUnpaidOrder() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID := primitive.NewObjectID().Hex()
+	buyer := primitive.NewObjectID().Hex() + "@buyer.com"
+	productID := primitive.NewObjectID().Hex()
+
+	_, err := suite.orderRepo.CreateOrder(ctx, domain.Orders{
+		ID:          primitive.NewObjectID(),
+		Order_id:    orderID,
+		Email:       buyer,
+		Order_Date:  time.Now(),
+		Updated_At:  time.Now(),
+		Total_Price: 10000,
+		Payment:     &domain.PaymentOrder{},
+		Items: []domain.OrderItem{{
+			Product_Id:   productID,
+			StoreID:      primitive.NewObjectID().Hex(),
+			Order_Status: "PENDING",
+			Quantity:     1,
+			Price:        10000,
+		}},
+	})
+	suite.Require().NoError(err)
+
+	err = suite.paymentRepo.Insert(ctx, &domain.Payment{
+		ID:      primitive.NewObjectID(),
+		OrderID: orderID,
+		UserID:  buyer,
+		Amount:  10000,
+		Status:  string(domain.PaymentPending),
+	})
+	suite.Require().NoError(err)
+
+	res, err := suite.svc.RequestRefund(ctx, buyer, orderID, productID, "", &dto.RefundReq{Reason: "damaged"})
+	suite.Require().Error(err)
+	suite.Require().Nil(res)
+}
+
+func TestRefundServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(RefundServiceTestSuite))
+}
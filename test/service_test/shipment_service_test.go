@@ -0,0 +1,161 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/IndraSty/GreenBasket/test"
+	"github.com/stretchr/testify/suite"
+)
+
+type ShipmentServiceTestSuite struct {
+	ServiceTestSuite
+	svc             domain.ShipmentService
+	tracker         domain.FakeCarrierTracker
+	orderRepo       domain.OrderRepository
+	sellerOrderRepo domain.SellerOrderRepository
+}
+
+func (suite *ShipmentServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	suite.orderRepo = repository.NewOrderRepository(suite.Client)
+	suite.sellerOrderRepo = repository.NewSellerOrderRepository(suite.Client)
+	suite.tracker = service.NewFakeTracker()
+	orderStatusSvc := service.NewOrderStatusService(suite.orderRepo, suite.sellerOrderRepo)
+
+	hub := &dto.Hub{NotificationChannel: map[string]chan dto.NotificationRes{}}
+	notificationSvc := service.NewNotificationService(repository.NewNotificationRepository(suite.Client), repository.NewTemplateRepository(suite.Client), hub)
+
+	suite.svc = service.NewShipmentService(repository.NewShipmentRepository(suite.Client), suite.orderRepo, suite.sellerOrderRepo,
+		orderStatusSvc, suite.tracker, notificationSvc, test.NewMemoryCache())
+}
+
+func (suite *ShipmentServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *ShipmentServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+}
+
+func (suite *ShipmentServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+func (suite *ShipmentServiceTestSuite) TestAddShipmentShipsItem() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, sellerEmail, productID := suite.seedOrderItem(ctx, domain.OrderProcessed)
+	req := &dto.ShipmentReq{Carrier: "JNE", Tracking_Number: "JNE-" + orderID}
+
+	err := suite.svc.AddShipment(ctx, sellerEmail, orderID, productID, "", req)
+	suite.Require().NoError(err)
+
+	sellerOrder, err := suite.sellerOrderRepo.GetSellerOrderByEmailAndId(ctx, sellerEmail, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.OrderShipped), sellerOrder.Items[0].Status)
+	suite.Require().NotNil(sellerOrder.Items[0].Shipment)
+	suite.Require().Equal("JNE", sellerOrder.Items[0].Shipment.Carrier)
+
+	// the tracking of an item is added once, after that it is updated
+	err = suite.svc.AddShipment(ctx, sellerEmail, orderID, productID, "", req)
+	suite.Require().Error(err)
+
+	shipments, err := suite.svc.GetOrderShipments(ctx, "testemail@gmail.com", orderID)
+	suite.Require().NoError(err)
+	suite.Require().Len(*shipments, 1)
+	suite.Require().Equal(string(domain.OrderShipped), (*shipments)[0].Status)
+	suite.Require().Equal(req.Tracking_Number, (*shipments)[0].Shipment.Tracking_Number)
+}
+
+func (suite *ShipmentServiceTestSuite) TestAddShipmentToUnpaidItem() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, sellerEmail, productID := suite.seedOrderItem(ctx, domain.OrderPending)
+
+	var transitionErr *domain.OrderTransitionError
+	err := suite.svc.AddShipment(ctx, sellerEmail, orderID, productID, "", &dto.ShipmentReq{Carrier: "JNE", Tracking_Number: "JNE-" + orderID})
+	suite.Require().ErrorAs(err, &transitionErr)
+
+	sellerOrder, err := suite.sellerOrderRepo.GetSellerOrderByEmailAndId(ctx, sellerEmail, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Nil(sellerOrder.Items[0].Shipment)
+}
+
+func (suite *ShipmentServiceTestSuite) TestRefreshTracking() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, sellerEmail, productID := suite.seedOrderItem(ctx, domain.OrderProcessed)
+	trackingNumber := "SICEPAT-" + orderID
+
+	err := suite.svc.AddShipment(ctx, sellerEmail, orderID, productID, "", &dto.ShipmentReq{Carrier: "SICEPAT", Tracking_Number: trackingNumber})
+	suite.Require().NoError(err)
+
+	err = suite.tracker.Push(ctx, "SICEPAT", trackingNumber, domain.TrackingEvent{Status: domain.TrackingInTransit, Location: "Jakarta"})
+	suite.Require().NoError(err)
+
+	_, err = suite.svc.RefreshTracking(ctx)
+	suite.Require().NoError(err)
+
+	shipments, err := suite.svc.GetOrderShipments(ctx, "testemail@gmail.com", orderID)
+	suite.Require().NoError(err)
+	shipment := (*shipments)[0].Shipment
+	suite.Require().Len(shipment.Events, 1)
+	suite.Require().Nil(shipment.Delivered_At)
+	suite.Require().False(shipment.Estimated_Delivery.IsZero())
+
+	err = suite.tracker.Push(ctx, "SICEPAT", trackingNumber, domain.TrackingEvent{Status: domain.TrackingDelivered, Location: "Bandung"})
+	suite.Require().NoError(err)
+
+	_, err = suite.svc.RefreshTracking(ctx)
+	suite.Require().NoError(err)
+
+	shipments, err = suite.svc.GetOrderShipments(ctx, "testemail@gmail.com", orderID)
+	suite.Require().NoError(err)
+	shipment = (*shipments)[0].Shipment
+	suite.Require().Len(shipment.Events, 2)
+	suite.Require().NotNil(shipment.Delivered_At)
+
+	// a delivered shipment can not be changed anymore
+	err = suite.svc.UpdateShipment(ctx, sellerEmail, orderID, productID, "", &dto.ShipmentReq{Tracking_Number: "other"})
+	suite.Require().Error(err)
+}
+
+func (suite *ShipmentServiceTestSuite) TestUpdateShipmentResetsTimeline() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, sellerEmail, productID := suite.seedOrderItem(ctx, domain.OrderProcessed)
+	trackingNumber := "JNE-" + orderID
+
+	err := suite.svc.AddShipment(ctx, sellerEmail, orderID, productID, "", &dto.ShipmentReq{Carrier: "JNE", Tracking_Number: trackingNumber})
+	suite.Require().NoError(err)
+
+	err = suite.tracker.Push(ctx, "JNE", trackingNumber, domain.TrackingEvent{Status: domain.TrackingInTransit})
+	suite.Require().NoError(err)
+
+	_, err = suite.svc.RefreshTracking(ctx)
+	suite.Require().NoError(err)
+
+	err = suite.svc.UpdateShipment(ctx, sellerEmail, orderID, productID, "", &dto.ShipmentReq{Tracking_Number: "JNE-fixed-" + orderID})
+	suite.Require().NoError(err)
+
+	sellerOrder, err := suite.sellerOrderRepo.GetSellerOrderByEmailAndId(ctx, sellerEmail, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal("JNE", sellerOrder.Items[0].Shipment.Carrier)
+	suite.Require().Equal("JNE-fixed-"+orderID, sellerOrder.Items[0].Shipment.Tracking_Number)
+	suite.Require().Empty(sellerOrder.Items[0].Shipment.Events)
+}
+
+func TestShipmentServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ShipmentServiceTestSuite))
+}
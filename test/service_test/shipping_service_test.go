@@ -0,0 +1,102 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ShippingServiceTestSuite struct {
+	ServiceTestSuite
+	svc         domain.ShippingService
+	storeRepo   domain.StoreRepository
+	productRepo domain.ProductRepository
+	rateRepo    domain.ShippingRateRepository
+}
+
+func (suite *ShippingServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	suite.storeRepo = repository.NewStoreRepository(suite.Client)
+	suite.productRepo = repository.NewProductRepository(suite.Client)
+	suite.rateRepo = repository.NewShippingRateRepository(suite.Client)
+	suite.svc = service.NewShippingService(service.NewTableRateProvider(suite.rateRepo), suite.productRepo, suite.storeRepo,
+		repository.NewUserRepository(suite.Client), repository.NewCartRepository(suite.Client))
+}
+
+func (suite *ShippingServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *ShippingServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+}
+
+func (suite *ShippingServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+// seedItem creates a store in its own city selling one product of weight grams,
+// with a 1 kg and a 5 kg rate from that city to destination.
+func (suite *ShippingServiceTestSuite) seedItem(ctx context.Context, destination string, weight int) domain.OrderItem {
+	storeID := primitive.NewObjectID().Hex()
+	city := "city " + storeID
+
+	suite.seedStore(ctx, domain.Store{Store_Id: storeID, Address_Details: &domain.Address{City: city}})
+	suite.seedRate(ctx, city, destination, 1000, 9000)
+	suite.seedRate(ctx, city, destination, 5000, 20000)
+	productID := suite.seedProduct(ctx, domain.Products{Store_id: storeID, Stock: 10, Weight: weight})
+
+	return domain.OrderItem{Product_Id: productID, StoreID: storeID, Price: 10000}
+}
+
+func (suite *ShippingServiceTestSuite) TestQuoteByWeight() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	light := suite.seedItem(ctx, "Jakarta", 400)
+	light.Quantity = 2
+	heavy := suite.seedItem(ctx, "Jakarta", 1500)
+	heavy.Quantity = 2
+
+	// the destination city matches whatever its case
+	quote, err := suite.svc.Quote(ctx, domain.Address{City: "jakarta"}, []domain.OrderItem{light, heavy})
+	suite.Require().NoError(err)
+	suite.Require().Len(quote.Stores, 2)
+
+	suite.Require().Equal(800, quote.Stores[0].Weight)
+	suite.Require().Equal(float64(9000), quote.Stores[0].Fee)
+	suite.Require().Equal(3000, quote.Stores[1].Weight)
+	suite.Require().Equal(float64(20000), quote.Stores[1].Fee)
+
+	suite.Require().Equal(float64(40000), quote.Subtotal)
+	suite.Require().Equal(float64(29000), quote.Shipping_Fee)
+	suite.Require().Equal(float64(69000), quote.Total)
+}
+
+func (suite *ShippingServiceTestSuite) TestQuoteWithoutRate() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	item := suite.seedItem(ctx, "Jakarta", 4000)
+	item.Quantity = 2
+
+	// heavier than the largest weight bracket
+	_, err := suite.svc.Quote(ctx, domain.Address{City: "Jakarta"}, []domain.OrderItem{item})
+	suite.Require().ErrorIs(err, domain.ErrNoShippingRate)
+
+	// a city the store does not ship to
+	item.Quantity = 1
+	_, err = suite.svc.Quote(ctx, domain.Address{City: "Surabaya"}, []domain.OrderItem{item})
+	suite.Require().ErrorIs(err, domain.ErrNoShippingRate)
+}
+
+func TestShippingServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ShippingServiceTestSuite))
+}
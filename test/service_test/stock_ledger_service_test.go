@@ -0,0 +1,124 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type StockLedgerServiceTestSuite struct {
+	ServiceTestSuite
+	svc            domain.StockLedgerService
+	reservationSvc domain.ReservationService
+	productRepo    domain.ProductRepository
+	storeRepo      domain.StoreRepository
+}
+
+func (suite *StockLedgerServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	suite.productRepo = repository.NewProductRepository(suite.Client)
+	suite.storeRepo = repository.NewStoreRepository(suite.Client)
+	transactor := repository.NewTransactor(suite.Client, repository.TxModeAuto)
+
+	suite.svc = service.NewStockLedgerService(repository.NewStockMovementRepository(suite.Client), suite.productRepo,
+		suite.storeRepo, transactor)
+	batchSvc := service.NewBatchService(repository.NewBatchRepository(suite.Client), suite.productRepo, suite.storeRepo,
+		repository.NewSellerRepository(suite.Client), suite.svc, nil, transactor, config.Inventory{})
+	suite.reservationSvc = service.NewReservationService(repository.NewReservationRepository(suite.Client), suite.productRepo,
+		batchSvc, suite.svc, transactor)
+}
+
+func (suite *StockLedgerServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *StockLedgerServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+}
+
+func (suite *StockLedgerServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+func (suite *StockLedgerServiceTestSuite) TestMovementsFollowTheStock() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID := suite.seedStore(ctx, domain.Store{})
+	productID := suite.seedProduct(ctx, domain.Products{Store_id: storeID, Stock: 5})
+	seller := sellerEmail(storeID)
+
+	drifts, err := suite.svc.Reconcile(ctx, true, false)
+	suite.Require().NoError(err)
+	suite.Require().Empty(*drifts)
+
+	err = suite.svc.AdjustStock(ctx, seller, storeID, productID, &dto.StockAdjustmentReq{Quantity: 3, Reason: "recount"})
+	suite.Require().NoError(err)
+
+	err = suite.svc.AdjustStock(ctx, seller, storeID, productID, &dto.StockAdjustmentReq{Quantity: -9, Reason: "damaged"})
+	suite.Require().Error(err)
+
+	orderID := primitive.NewObjectID().Hex()
+	err = suite.reservationSvc.ReserveStock(ctx, orderID, []domain.OrderItem{{
+		Product_Id: productID,
+		StoreID:    storeID,
+		Quantity:   2,
+	}})
+	suite.Require().NoError(err)
+
+	err = suite.reservationSvc.ReleaseStock(ctx, orderID)
+	suite.Require().NoError(err)
+
+	movements, err := suite.svc.GetMovements(ctx, seller, storeID, productID, domain.MovementPages.Offset(1))
+	suite.Require().NoError(err)
+	suite.Require().Len(movements.Items, 4)
+
+	var types []domain.StockMovementType
+	for _, movement := range movements.Items {
+		types = append(types, movement.Type)
+	}
+	suite.Require().ElementsMatch([]domain.StockMovementType{domain.MovementAdjustment, domain.MovementAdjustment,
+		domain.MovementReservation, domain.MovementRelease}, types)
+
+	drifts, err = suite.svc.Reconcile(ctx, false, false)
+	suite.Require().NoError(err)
+	suite.Require().Empty(*drifts)
+}
+
+func (suite *StockLedgerServiceTestSuite) TestReconcileFixesDrift() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID := suite.seedStore(ctx, domain.Store{})
+	productID := suite.seedProduct(ctx, domain.Products{Store_id: storeID, Stock: 5})
+
+	_, err := suite.svc.Reconcile(ctx, true, false)
+	suite.Require().NoError(err)
+
+	// a change that went around the ledger
+	_, err = suite.productRepo.UpdateStockProduct(ctx, storeID, productID, "", 4, time.Now())
+	suite.Require().NoError(err)
+
+	drifts, err := suite.svc.Reconcile(ctx, false, true)
+	suite.Require().NoError(err)
+	suite.Require().Len(*drifts, 1)
+	suite.Require().Equal(9.0, (*drifts)[0].Stock)
+	suite.Require().Equal(5.0, (*drifts)[0].Ledger)
+
+	product, err := suite.productRepo.GetProductById(ctx, productID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(5.0, product.Stock)
+}
+
+func TestStockLedgerServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(StockLedgerServiceTestSuite))
+}
@@ -0,0 +1,155 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UploadServiceTestSuite struct {
+	ServiceTestSuite
+	svc         domain.UploadService
+	productSvc  domain.ProductService
+	productRepo domain.ProductRepository
+	storeRepo   domain.StoreRepository
+	dir         string
+}
+
+func (suite *UploadServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	suite.dir = suite.T().TempDir()
+	cnf := &config.Config{
+		Server:  config.Server{Host: "localhost", Port: "8080"},
+		Storage: config.Storage{Driver: "local", LocalDir: suite.dir},
+	}
+
+	suite.productRepo = repository.NewProductRepository(suite.Client)
+	suite.storeRepo = repository.NewStoreRepository(suite.Client)
+	suite.svc = service.NewUploadService(repository.NewUploadRepository(suite.Client), suite.productRepo, suite.storeRepo,
+		service.NewLocalStorage(cnf), 1<<20, 320)
+	suite.productSvc = service.NewProductService(suite.productRepo, suite.storeRepo, repository.NewSellerRepository(suite.Client), repository.NewSalesReportRepository(suite.Client),
+		nil, nil, nil, suite.svc, service.NewProductSearchService(repository.NewMemorySearchIndex(), suite.productRepo, suite.storeRepo),
+		repository.NewTransactor(suite.Client, repository.TxModeAuto))
+}
+
+func (suite *UploadServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *UploadServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+}
+
+func (suite *UploadServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+func (suite *UploadServiceTestSuite) pngImage(width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, 0, color.RGBA{R: 200, G: 100, A: 255})
+	}
+
+	var buf bytes.Buffer
+	suite.Require().NoError(png.Encode(&buf, img))
+
+	return buf.Bytes()
+}
+
+func (suite *UploadServiceTestSuite) blobExists(url string) bool {
+	key := strings.TrimPrefix(url, "http://localhost:8080/uploads/")
+	_, err := os.Stat(filepath.Join(suite.dir, filepath.FromSlash(key)))
+	return err == nil
+}
+
+func (suite *UploadServiceTestSuite) TestUploadImageMakesThumbnail() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	res, err := suite.svc.UploadImage(ctx, "seller@seller.com", bytes.NewReader(suite.pngImage(800, 400)))
+	suite.Require().NoError(err)
+	suite.Require().Equal("image/png", res.Content_Type)
+	suite.Require().Equal(800, res.Width)
+	suite.Require().True(suite.blobExists(res.Url))
+
+	key := strings.TrimPrefix(res.Thumbnail_Url, "http://localhost:8080/uploads/")
+	file, err := os.Open(filepath.Join(suite.dir, filepath.FromSlash(key)))
+	suite.Require().NoError(err)
+	defer file.Close()
+
+	thumbnail, _, err := image.DecodeConfig(file)
+	suite.Require().NoError(err)
+	suite.Require().Equal(320, thumbnail.Width)
+	suite.Require().Equal(160, thumbnail.Height)
+}
+
+func (suite *UploadServiceTestSuite) TestUploadImageRefusesOtherFiles() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	_, err := suite.svc.UploadImage(ctx, "seller@seller.com", strings.NewReader("<html>not an image</html>"))
+	suite.Require().ErrorIs(err, domain.ErrUnsupportedImage)
+
+	_, err = suite.svc.UploadImage(ctx, "seller@seller.com", bytes.NewReader(make([]byte, 2<<20)))
+	suite.Require().ErrorIs(err, domain.ErrImageTooLarge)
+}
+
+func (suite *UploadServiceTestSuite) TestDeleteProductReleasesUnusedImages() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	storeID := primitive.NewObjectID().Hex()
+	seller := storeID + "@seller.com"
+	_, err := suite.storeRepo.CreateStore(ctx, domain.Store{
+		ID:       primitive.NewObjectID(),
+		Name:     "store " + storeID,
+		Email:    seller,
+		Store_Id: storeID,
+	})
+	suite.Require().NoError(err)
+
+	own, err := suite.svc.UploadImage(ctx, seller, bytes.NewReader(suite.pngImage(100, 100)))
+	suite.Require().NoError(err)
+	shared, err := suite.svc.UploadImage(ctx, seller, bytes.NewReader(suite.pngImage(100, 100)))
+	suite.Require().NoError(err)
+
+	var productIDs []string
+	for _, images := range [][]string{{own.Url, shared.Url}, {shared.Url}} {
+		productID := primitive.NewObjectID().Hex()
+		_, err := suite.productRepo.CreateProduct(ctx, domain.Products{
+			ID:         primitive.NewObjectID(),
+			Name:       "product " + productID,
+			Product_id: productID,
+			Store_id:   storeID,
+			Images:     images,
+		})
+		suite.Require().NoError(err)
+		productIDs = append(productIDs, productID)
+	}
+
+	_, err = suite.productSvc.DeleteProductById(ctx, storeID, seller, productIDs[0])
+	suite.Require().NoError(err)
+
+	suite.Require().False(suite.blobExists(own.Url))
+	suite.Require().False(suite.blobExists(own.Thumbnail_Url))
+	suite.Require().True(suite.blobExists(shared.Url))
+}
+
+func TestUploadServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(UploadServiceTestSuite))
+}
@@ -0,0 +1,312 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IndraSty/GreenBasket/domain"
+	"github.com/IndraSty/GreenBasket/dto"
+	"github.com/IndraSty/GreenBasket/internal/config"
+	"github.com/IndraSty/GreenBasket/internal/repository"
+	"github.com/IndraSty/GreenBasket/internal/service"
+	"github.com/IndraSty/GreenBasket/test"
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WeightAdjustmentServiceTestSuite struct {
+	ServiceTestSuite
+	svc             domain.WeightAdjustmentService
+	refundSvc       domain.RefundService
+	sellerOrderSvc  domain.SellerOrderService
+	gateway         domain.FakePaymentGateway
+	paymentSvc      domain.PaymentService
+	notifSvc        domain.PaymentNotificationService
+	paymentRepo     domain.PaymentRepository
+	orderRepo       domain.OrderRepository
+	sellerOrderRepo domain.SellerOrderRepository
+	sellerRepo      domain.SellerRepository
+	productRepo     domain.ProductRepository
+	reservationRepo domain.ReservationRepository
+	batchRepo       domain.BatchRepository
+	reservationSvc  domain.ReservationService
+}
+
+func (suite *WeightAdjustmentServiceTestSuite) SetupSuite() {
+	suite.MongoTestSuite.SetupSuite()
+
+	cnf := &config.Config{Server: config.Server{Host: "localhost", Port: "8080"}}
+	cacheRepo := test.NewMemoryCache()
+	suite.gateway = service.NewFakeGateway(cnf)
+	suite.paymentRepo = repository.NewPaymentRepository(suite.Client)
+	suite.orderRepo = repository.NewOrderRepository(suite.Client)
+	suite.sellerOrderRepo = repository.NewSellerOrderRepository(suite.Client)
+	suite.sellerRepo = repository.NewSellerRepository(suite.Client)
+	suite.productRepo = repository.NewProductRepository(suite.Client)
+	ledgerSvc := service.NewStockLedgerService(repository.NewStockMovementRepository(suite.Client), suite.productRepo,
+		repository.NewStoreRepository(suite.Client), repository.NewTransactor(suite.Client, repository.TxModeAuto))
+	suite.batchRepo = repository.NewBatchRepository(suite.Client)
+	batchSvc := service.NewBatchService(suite.batchRepo, suite.productRepo,
+		repository.NewStoreRepository(suite.Client), repository.NewSellerRepository(suite.Client), ledgerSvc, nil,
+		repository.NewTransactor(suite.Client, repository.TxModeAuto), config.Inventory{})
+	suite.reservationRepo = repository.NewReservationRepository(suite.Client)
+	suite.reservationSvc = service.NewReservationService(suite.reservationRepo, suite.productRepo, batchSvc, ledgerSvc,
+		repository.NewTransactor(suite.Client, repository.TxModeAuto))
+	refundRepo := repository.NewRefundRepository(suite.Client)
+	storeRepo := repository.NewStoreRepository(suite.Client)
+	transactor := repository.NewTransactor(suite.Client, repository.TxModeAuto)
+
+	hub := &dto.Hub{NotificationChannel: map[string]chan dto.NotificationRes{}}
+	notificationSvc := service.NewNotificationService(repository.NewNotificationRepository(suite.Client), repository.NewTemplateRepository(suite.Client), hub)
+	salesReportSvc := service.NewSalesRepository(repository.NewSalesReportRepository(suite.Client), suite.sellerOrderRepo, storeRepo,
+		suite.productRepo, repository.NewReviewRepository(suite.Client), cacheRepo)
+
+	orderStatusSvc := service.NewOrderStatusService(suite.orderRepo, suite.sellerOrderRepo)
+
+	suite.paymentSvc = service.NewPaymentService(notificationSvc, suite.paymentRepo, repository.NewUserRepository(suite.Client), suite.orderRepo, suite.gateway)
+	suite.notifSvc = service.NewPaymentNotificationService(suite.gateway, suite.paymentRepo, suite.orderRepo, suite.sellerOrderRepo,
+		repository.NewPaymentEventRepository(suite.Client), suite.reservationSvc, orderStatusSvc, notificationSvc, transactor)
+	suite.refundSvc = service.NewRefundService(refundRepo, suite.orderRepo, suite.sellerOrderRepo,
+		suite.sellerRepo, suite.paymentRepo, suite.productRepo, ledgerSvc, orderStatusSvc, suite.gateway, salesReportSvc, notificationSvc, transactor)
+	suite.svc = service.NewWeightAdjustmentService(suite.orderRepo, suite.sellerOrderRepo, suite.productRepo, suite.reservationRepo, refundRepo,
+		suite.refundSvc, suite.paymentSvc, batchSvc, ledgerSvc, notificationSvc, cacheRepo, transactor)
+	suite.sellerOrderSvc = service.NewSellerOrderService(suite.sellerOrderRepo, suite.sellerRepo, suite.orderRepo, suite.productRepo,
+		suite.paymentRepo, suite.reservationSvc, orderStatusSvc, notificationSvc, cacheRepo, transactor)
+}
+
+func (suite *WeightAdjustmentServiceTestSuite) TearDownSuite() {
+	suite.MongoTestSuite.TearDownSuite()
+}
+
+func (suite *WeightAdjustmentServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.MongoTestSuite.BeforeTest(suiteName, testName)
+}
+
+func (suite *WeightAdjustmentServiceTestSuite) AfterTest(suiteName, testName string) {
+	suite.MongoTestSuite.AfterTest(suiteName, testName)
+}
+
+// paidOrder creates a paid order of 2 units of a product sold in unit, priced
+// 30000 a unit out of a stock of 10.
+func (suite *WeightAdjustmentServiceTestSuite) paidOrder(ctx context.Context, unit domain.Unit) (orderID, buyer, seller, productID string) {
+	storeID := primitive.NewObjectID().Hex()
+	_, seller = suite.seedSeller(ctx, storeID)
+	buyer = suite.seedBuyer(ctx)
+	productID = suite.seedProduct(ctx, domain.Products{
+		Price:         30000,
+		Stock:         10,
+		Unit:          string(unit),
+		Quantity_Step: 0.25,
+		Store_id:      storeID,
+	})
+
+	// the whole stock is one batch, the reservation takes the order from it
+	_, err := suite.batchRepo.Insert(ctx, domain.Batch{
+		ID:          primitive.NewObjectID(),
+		Batch_Id:    primitive.NewObjectID().Hex(),
+		Product_Id:  productID,
+		Store_Id:    storeID,
+		Quantity:    10,
+		Received_At: time.Now(),
+		Expires_At:  time.Now().Add(30 * 24 * time.Hour),
+		Status:      domain.BatchActive,
+		Created_At:  time.Now(),
+		Updated_At:  time.Now(),
+	})
+	suite.Require().NoError(err)
+
+	item := orderItem(storeID, productID, 2, domain.OrderPending)
+	item.Unit = string(unit)
+	item.Price = 30000
+	items := []domain.OrderItem{item}
+	orderID = suite.seedOrder(ctx, domain.Orders{Email: buyer, Items: items})
+
+	err = suite.reservationSvc.ReserveStock(ctx, orderID, items)
+	suite.Require().NoError(err)
+
+	_, err = suite.paymentSvc.InitializePayment(ctx, &dto.PaymentReq{
+		OrderID: orderID,
+		UserID:  buyer,
+	})
+	suite.Require().NoError(err)
+
+	suite.settle(ctx, orderID)
+
+	return orderID, buyer, seller, productID
+}
+
+func (suite *WeightAdjustmentServiceTestSuite) settle(ctx context.Context, orderID string) {
+	payload, err := suite.gateway.Simulate(ctx, orderID, "settlement")
+	suite.Require().NoError(err)
+
+	success, err := suite.notifSvc.HandleNotification(ctx, payload)
+	suite.Require().NoError(err)
+	suite.Require().True(success)
+}
+
+// requireBatches checks that the reservation of the item holds the packed
+// quantity from the batch of the product, and what is left of the batch.
+func (suite *WeightAdjustmentServiceTestSuite) requireBatches(ctx context.Context, orderID, productID string, packed, left float64) {
+	reservations, err := suite.reservationRepo.FindByOrderId(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Len(*reservations, 1)
+	suite.Require().Equal(packed, (*reservations)[0].Quantity)
+
+	var allocated float64
+	for _, allocation := range (*reservations)[0].Batches {
+		allocated += allocation.Quantity
+	}
+	suite.Require().Equal(packed, allocated)
+
+	batches, err := suite.batchRepo.FindAvailable(ctx, productID, "")
+	suite.Require().NoError(err)
+	suite.Require().Len(*batches, 1)
+	suite.Require().Equal(left, (*batches)[0].Quantity)
+}
+
+func (suite *WeightAdjustmentServiceTestSuite) TestLighterPackIsRefunded() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, buyer, seller, productID := suite.paidOrder(ctx, domain.UnitKilogram)
+
+	res, err := suite.svc.AdjustPackedQuantity(ctx, seller, orderID, productID, "", &dto.PackedQuantityReq{Quantity: 1.75})
+	suite.Require().NoError(err)
+	suite.Require().Equal(-7500.0, res.Amount)
+	suite.Require().NotEmpty(res.Refund_Id)
+
+	order, err := suite.orderRepo.GetOrder(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(52500.0, order.Total_Price)
+	suite.Require().Equal(1.75, order.Items[0].Quantity)
+	suite.Require().Equal(2.0, order.Items[0].Ordered_Quantity)
+	suite.Require().Equal(string(domain.OrderProcessed), order.Items[0].Order_Status)
+
+	sellerOrder, err := suite.sellerOrderRepo.GetSellerOrderByEmailAndId(ctx, seller, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(52500.0, sellerOrder.Total_Price)
+	suite.Require().Equal(1.75, sellerOrder.Items[0].Quantity)
+
+	// the quarter kilogram that was not packed goes back on sale
+	product, err := suite.productRepo.GetProductById(ctx, productID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(8.25, product.Stock)
+	suite.requireBatches(ctx, orderID, productID, 1.75, 8.25)
+
+	charge, err := suite.gateway.GetCharge(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(7500.0, charge.Refunded)
+
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentPartialRefunded), payment.Status)
+
+	refunds, err := suite.refundSvc.GetUserRefunds(ctx, buyer, domain.RefundPages.Offset(1))
+	suite.Require().NoError(err)
+	suite.Require().Len(refunds.Items, 1)
+	suite.Require().True(refunds.Items[0].Adjustment)
+	suite.Require().Equal(domain.RefundRefunded, refunds.Items[0].Status)
+
+	_, err = suite.svc.AdjustPackedQuantity(ctx, seller, orderID, productID, "", &dto.PackedQuantityReq{Quantity: 1.5})
+	suite.Require().Error(err)
+
+	// the item itself can still be refunded at its packed price
+	refund, err := suite.refundSvc.RequestRefund(ctx, buyer, orderID, productID, "", &dto.RefundReq{Reason: "damaged"})
+	suite.Require().NoError(err)
+	suite.Require().Equal(52500.0, refund.Amount)
+
+	err = suite.refundSvc.ApproveRefund(ctx, seller, refund.Refund_Id)
+	suite.Require().NoError(err)
+
+	payment, err = suite.paymentRepo.FindByOrderId(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentRefunded), payment.Status)
+}
+
+func (suite *WeightAdjustmentServiceTestSuite) TestHeavierPackIsCharged() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, _, seller, productID := suite.paidOrder(ctx, domain.UnitKilogram)
+
+	res, err := suite.svc.AdjustPackedQuantity(ctx, seller, orderID, productID, "", &dto.PackedQuantityReq{Quantity: 2.5})
+	suite.Require().NoError(err)
+	suite.Require().Equal(15000.0, res.Amount)
+	suite.Require().NotEmpty(res.Snap_Url)
+
+	order, err := suite.orderRepo.GetOrder(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(75000.0, order.Total_Price)
+
+	product, err := suite.productRepo.GetProductById(ctx, productID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(7.5, product.Stock)
+	suite.requireBatches(ctx, orderID, productID, 2.5, 7.5)
+
+	charge, err := suite.paymentRepo.FindByOrderId(ctx, res.Charge_Id)
+	suite.Require().NoError(err)
+	suite.Require().Equal(orderID, charge.Parent_Order_Id)
+	suite.Require().Equal(15000.0, charge.Amount)
+	suite.Require().Equal(string(domain.PaymentPending), charge.Status)
+
+	suite.settle(ctx, res.Charge_Id)
+
+	charge, err = suite.paymentRepo.FindByOrderId(ctx, res.Charge_Id)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentSuccess), charge.Status)
+
+	// the order keeps the status of its own payment
+	payment, err := suite.paymentRepo.FindByOrderId(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.PaymentSuccess), payment.Status)
+
+	order, err = suite.orderRepo.GetOrder(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(string(domain.OrderProcessed), order.Items[0].Order_Status)
+
+	err = suite.sellerOrderSvc.UpdateSellerAndUserOrderStatus(ctx, seller, orderID, productID, "",
+		&dto.OrderStatusUpdateReq{Status: string(domain.OrderShipped)})
+	suite.Require().NoError(err)
+}
+
+func (suite *WeightAdjustmentServiceTestSuite) TestHeavierPackWaitsForTheCharge() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, _, seller, productID := suite.paidOrder(ctx, domain.UnitKilogram)
+	shipped := &dto.OrderStatusUpdateReq{Status: string(domain.OrderShipped)}
+
+	// the item is not weighed yet
+	err := suite.sellerOrderSvc.UpdateSellerAndUserOrderStatus(ctx, seller, orderID, productID, "", shipped)
+	suite.Require().Error(err)
+
+	res, err := suite.svc.AdjustPackedQuantity(ctx, seller, orderID, productID, "", &dto.PackedQuantityReq{Quantity: 2.5})
+	suite.Require().NoError(err)
+
+	err = suite.sellerOrderSvc.UpdateSellerAndUserOrderStatus(ctx, seller, orderID, productID, "", shipped)
+	suite.Require().Error(err)
+
+	sellerOrder, err := suite.sellerOrderRepo.GetSellerOrderByEmailAndId(ctx, seller, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(res.Charge_Id, sellerOrder.Items[0].Charge_Id)
+	suite.Require().Equal(string(domain.OrderProcessed), sellerOrder.Items[0].Status)
+}
+
+func (suite *WeightAdjustmentServiceTestSuite) TestPieceHasNoPackedQuantity() {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	orderID, _, seller, productID := suite.paidOrder(ctx, domain.UnitPiece)
+
+	_, err := suite.svc.AdjustPackedQuantity(ctx, seller, orderID, productID, "", &dto.PackedQuantityReq{Quantity: 3})
+	suite.Require().Error(err)
+
+	order, err := suite.orderRepo.GetOrder(ctx, orderID)
+	suite.Require().NoError(err)
+	suite.Require().Equal(60000.0, order.Total_Price)
+}
+
+func TestWeightAdjustmentServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(WeightAdjustmentServiceTestSuite))
+}
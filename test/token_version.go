@@ -0,0 +1,36 @@
+package test
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryTokenVersions is an in-memory domain.TokenVersionRepository so the
+// tests can run without the user and seller collections.
+type MemoryTokenVersions struct {
+	mu       sync.Mutex
+	versions map[string]int
+}
+
+func NewMemoryTokenVersions() *MemoryTokenVersions {
+	return &MemoryTokenVersions{
+		versions: map[string]int{},
+	}
+}
+
+func (v *MemoryTokenVersions) TokenVersion(ctx context.Context, account string, uid string) (int, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.versions[account+":"+uid], nil
+}
+
+func (v *MemoryTokenVersions) SaveTokenVersion(ctx context.Context, account string, uid string, version int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if version > v.versions[account+":"+uid] {
+		v.versions[account+":"+uid] = version
+	}
+	return nil
+}